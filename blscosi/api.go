@@ -21,10 +21,27 @@ func NewClient() *Client {
 // SignatureRequest sends a CoSi sign request to the Cothority defined by the given
 // Roster
 func (c *Client) SignatureRequest(r *onet.Roster, msg []byte) (*SignatureResponse, error) {
-	serviceReq := &SignatureRequest{
+	return c.signatureRequest(&SignatureRequest{
 		Roster:  r,
 		Message: msg,
-	}
+	})
+}
+
+// SignatureRequestParticipants is like SignatureRequest, but only the nodes
+// of the roster whose bit is set in the participants mask are asked to sign.
+// The mask has one bit per node of the roster, the lowest bit of the first
+// byte being the first node, which must be a participant. The signature is
+// verified with BlsSignature.VerifyParticipants.
+func (c *Client) SignatureRequestParticipants(r *onet.Roster, msg, participants []byte) (*SignatureResponse, error) {
+	return c.signatureRequest(&SignatureRequest{
+		Roster:       r,
+		Message:      msg,
+		Participants: participants,
+	})
+}
+
+func (c *Client) signatureRequest(serviceReq *SignatureRequest) (*SignatureResponse, error) {
+	r := serviceReq.Roster
 	if len(r.List) == 0 {
 		return nil, errors.New("Got an empty roster-list")
 	}
@@ -35,3 +52,24 @@ func (c *Client) SignatureRequest(r *onet.Roster, msg []byte) (*SignatureRespons
 
 	return reply, err
 }
+
+// BatchSignatureRequest asks the first node of the roster to sign the hash
+// together with the hashes of other clients. The proof in the response
+// shows that the hash is part of the signed root.
+func (c *Client) BatchSignatureRequest(r *onet.Roster, hash []byte) (*BatchSignatureResponse, error) {
+	if len(r.List) == 0 {
+		return nil, errors.New("Got an empty roster-list")
+	}
+	reply := &BatchSignatureResponse{}
+	err := c.SendProtobuf(r.List[0], &BatchSignatureRequest{
+		Hash:   hash,
+		Roster: r,
+	}, reply)
+	if err != nil {
+		return nil, err
+	}
+	if err := reply.Proof.Verify(reply.Root, hash); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
@@ -31,4 +31,11 @@ func TestClient_SignatureRequest(t *testing.T) {
 		// verify the response still
 		require.Nil(t, reply.Signature.Verify(testSuite, msg, publics))
 	}
+
+	participants := []byte{0xff, 0x01} // all but the last node
+	reply, err := client.SignatureRequestParticipants(roster, msg, participants)
+	require.NoError(t, err)
+	publics := roster.ServicePublics(ServiceName)
+	require.NoError(t, reply.Signature.VerifyParticipants(testSuite, msg,
+		publics, participants))
 }
@@ -0,0 +1,169 @@
+package blscosi
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"go.dedis.ch/cothority/v3/blscosi/protocol"
+	"go.dedis.ch/cothority/v3/merkle"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+)
+
+/*
+This file holds the batching of signature requests. Clients send a small
+hash, and the root collects all hashes it receives for the same roster during
+BatchWindow. At the end of the window, a merkle tree is built over all hashes
+and one collective signature is created on its root. Every client gets back
+the signature, the root and a proof that its hash is included in the root.
+*/
+
+// maxBatchHash is the maximum size of a hash in a batch.
+const maxBatchHash = 64
+
+// maxBatchSize is the maximum number of hashes in one batch. A full batch
+// is signed right away.
+const maxBatchSize = 1 << 16
+
+// defaultBatchWindow is how long the root waits for more hashes before
+// signing a batch.
+const defaultBatchWindow = 500 * time.Millisecond
+
+func init() {
+	network.RegisterMessages(&BatchSignatureRequest{}, &BatchSignatureResponse{})
+}
+
+// BatchSignatureRequest asks for the hash to be signed in the next batch.
+type BatchSignatureRequest struct {
+	Hash   []byte
+	Roster *onet.Roster
+}
+
+// BatchSignatureResponse holds the signature on the root of the merkle tree
+// of the batch, and the proof that the hash is part of this tree.
+type BatchSignatureResponse struct {
+	Root      []byte
+	Proof     BatchProof
+	Signature protocol.BlsSignature
+}
+
+// Verify checks that the hash is part of the batch and that the root is
+// signed by the given public keys with the default threshold.
+func (r *BatchSignatureResponse) Verify(suite pairing.Suite, hash []byte, publics []kyber.Point) error {
+	if err := r.Proof.Verify(r.Root, hash); err != nil {
+		return err
+	}
+	return r.Signature.Verify(suite, r.Root, publics)
+}
+
+// BatchProof proves that a hash is part of the merkle tree of a batch.
+type BatchProof struct {
+	// Index of the hash in the batch.
+	Index int
+	// Count is the number of hashes in the batch.
+	Count int
+	// Path holds the siblings from the leaf up to the root.
+	Path [][]byte
+}
+
+// Verify returns nil if the hash is part of the merkle tree with the given
+// root.
+func (bp BatchProof) Verify(root, hash []byte) error {
+	return merkle.Proof(bp).Verify(root, merkle.LeafHash(hash))
+}
+
+// batch collects the hashes of one window for one roster.
+type batch struct {
+	roster *onet.Roster
+	leaves [][]byte
+	full   chan struct{}
+	done   chan struct{}
+	// Only valid once done is closed.
+	tree      *merkle.Tree
+	signature protocol.BlsSignature
+	err       error
+}
+
+type batches struct {
+	sync.Mutex
+	byRoster map[onet.RosterID]*batch
+}
+
+// BatchSignatureRequest adds the hash to the batch of the current window
+// and returns once the batch has been signed.
+func (s *Service) BatchSignatureRequest(req *BatchSignatureRequest) (*BatchSignatureResponse, error) {
+	if len(req.Hash) == 0 || len(req.Hash) > maxBatchHash {
+		return nil, errors.New("hash must be between 1 and 64 bytes")
+	}
+	if req.Roster == nil {
+		return nil, errors.New("missing roster")
+	}
+	if i, _ := req.Roster.Search(s.ServerIdentity().ID); i < 0 {
+		return nil, errors.New("we're not in the roster")
+	}
+
+	s.batches.Lock()
+	if s.batches.byRoster == nil {
+		s.batches.byRoster = make(map[onet.RosterID]*batch)
+	}
+	b, ok := s.batches.byRoster[req.Roster.ID]
+	if !ok {
+		b = &batch{
+			roster: req.Roster,
+			full:   make(chan struct{}),
+			done:   make(chan struct{}),
+		}
+		s.batches.byRoster[req.Roster.ID] = b
+		go s.signBatch(b)
+	}
+	index := len(b.leaves)
+	b.leaves = append(b.leaves, merkle.LeafHash(req.Hash))
+	if len(b.leaves) == maxBatchSize {
+		delete(s.batches.byRoster, req.Roster.ID)
+		close(b.full)
+	}
+	s.batches.Unlock()
+
+	<-b.done
+	if b.err != nil {
+		return nil, b.err
+	}
+	return &BatchSignatureResponse{
+		Root:      b.tree.Root(),
+		Proof:     BatchProof(b.tree.Proof(index)),
+		Signature: b.signature,
+	}, nil
+}
+
+// signBatch waits for the end of the window or for the batch to be full,
+// and signs the root of the merkle tree of all hashes.
+func (s *Service) signBatch(b *batch) {
+	defer close(b.done)
+
+	select {
+	case <-time.After(s.BatchWindow):
+		s.batches.Lock()
+		if s.batches.byRoster[b.roster.ID] == b {
+			delete(s.batches.byRoster, b.roster.ID)
+		}
+		s.batches.Unlock()
+	case <-b.full:
+	}
+
+	b.tree = merkle.NewTree(b.leaves)
+	reply, err := s.SignatureRequest(&SignatureRequest{
+		Message: b.tree.Root(),
+		Roster:  b.roster,
+	})
+	if err != nil {
+		b.err = errors.New("couldn't sign batch: " + err.Error())
+		return
+	}
+	log.Lvlf2("%s: signed a batch of %d hashes", s.ServerIdentity(),
+		len(b.leaves))
+	b.signature = reply.(*SignatureResponse).Signature
+}
@@ -0,0 +1,94 @@
+package blscosi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3/merkle"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestBatchProof(t *testing.T) {
+	for n := 1; n < 10; n++ {
+		var hashes, leaves [][]byte
+		for i := 0; i < n; i++ {
+			hashes = append(hashes, []byte(fmt.Sprintf("hash %d", i)))
+			leaves = append(leaves, merkle.LeafHash(hashes[i]))
+		}
+		tree := merkle.NewTree(leaves)
+		for i := range hashes {
+			proof := BatchProof(tree.Proof(i))
+			require.NoError(t, proof.Verify(tree.Root(), hashes[i]))
+			require.Error(t, proof.Verify(tree.Root(), []byte("other")))
+		}
+	}
+}
+
+func TestService_BatchSignatureRequest(t *testing.T) {
+	local := onet.NewTCPTest(testSuite)
+	hosts, roster, _ := local.GenTree(4, false)
+	defer local.CloseAll()
+	service := hosts[0].Service(ServiceName).(*Service)
+
+	_, err := service.BatchSignatureRequest(&BatchSignatureRequest{
+		Roster: roster,
+	})
+	require.Error(t, err)
+	_, err = service.BatchSignatureRequest(&BatchSignatureRequest{
+		Hash:   make([]byte, maxBatchHash+1),
+		Roster: roster,
+	})
+	require.Error(t, err)
+	_, err = service.BatchSignatureRequest(&BatchSignatureRequest{
+		Hash:   []byte("hash"),
+		Roster: onet.NewRoster(roster.List[1:]),
+	})
+	require.Error(t, err)
+
+	n := 10
+	replies := make(chan *BatchSignatureResponse, n)
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			reply, err := NewClient().BatchSignatureRequest(roster,
+				[]byte(fmt.Sprintf("hash %d", i)))
+			if err != nil {
+				errs <- err
+				return
+			}
+			replies <- reply
+		}(i)
+	}
+
+	publics := roster.ServicePublics(ServiceName)
+	var root []byte
+	indexes := map[int]bool{}
+	for i := 0; i < n; i++ {
+		select {
+		case err := <-errs:
+			require.NoError(t, err)
+		case reply := <-replies:
+			if root == nil {
+				root = reply.Root
+			}
+			require.Equal(t, root, reply.Root)
+			require.Error(t, reply.Verify(testSuite, []byte("other"), publics))
+			// The reply proves the inclusion of exactly one hash.
+			found := 0
+			for j := 0; j < n; j++ {
+				hash := []byte(fmt.Sprintf("hash %d", j))
+				if reply.Verify(testSuite, hash, publics) == nil {
+					found++
+				}
+			}
+			require.Equal(t, 1, found)
+			indexes[reply.Proof.Index] = true
+		}
+	}
+	require.Equal(t, n, len(indexes))
+	// All hashes are signed in one round.
+	service.metricsLock.Lock()
+	require.Equal(t, 1, service.rounds)
+	service.metricsLock.Unlock()
+}
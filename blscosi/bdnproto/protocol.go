@@ -5,6 +5,7 @@ package bdnproto
 
 import (
 	"go.dedis.ch/cothority/v3/blscosi/protocol"
+	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/pairing"
 	"go.dedis.ch/kyber/v3/sign"
 	"go.dedis.ch/kyber/v3/sign/bdn"
@@ -40,6 +41,7 @@ func NewBdnCosi(n *onet.TreeNodeInstance, vf protocol.VerificationFn, subProtoco
 	mbc.Sign = bdn.Sign
 	mbc.Verify = bdn.Verify
 	mbc.Aggregate = aggregate
+	mbc.VerifyFinal = verifyFinal
 
 	return mbc, nil
 }
@@ -67,6 +69,11 @@ func NewSubBdnCosi(n *onet.TreeNodeInstance, vf protocol.VerificationFn, suite *
 	return subCosi, nil
 }
 
+// verifyFinal checks the final signature with the robust scheme.
+func verifyFinal(suite pairing.Suite, msg []byte, publics []kyber.Point, sig []byte, policy sign.Policy) error {
+	return BdnSignature(sig).VerifyWithPolicy(suite, msg, publics, policy)
+}
+
 // aggregate uses the robust aggregate algorithm to aggregate the signatures.
 func aggregate(suite pairing.Suite, mask *sign.Mask, sigs [][]byte) ([]byte, error) {
 	sig, err := bdn.AggregateSignatures(suite, sigs, mask)
@@ -102,6 +102,39 @@ func createApp() *cli.App {
 				}),
 		},
 
+		{
+			Name:  "vectors",
+			Usage: "Generate or verify test vectors of collective signatures",
+			Subcommands: []cli.Command{
+				{
+					Name:      "generate",
+					Aliases:   []string{"g"},
+					Usage:     "Write test vectors to 'file'; they are written to STDOUT by default",
+					ArgsUsage: "[file]",
+					Action:    generateVectorsFile,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "seed",
+							Value: "cothority",
+							Usage: "Seed of the keys",
+						},
+						cli.IntFlag{
+							Name:  "keys, n",
+							Value: 7,
+							Usage: "Number of keys in the roster",
+						},
+					},
+				},
+				{
+					Name:      "verify",
+					Aliases:   []string{"v"},
+					Usage:     "Verify the test vectors of 'file'; they are read from STDIN by default",
+					ArgsUsage: "[file]",
+					Action:    verifyVectorsFile,
+				},
+			},
+		},
+
 		// CLIENT END ----------
 		// BEGIN SERVER --------
 		{
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	cli "github.com/urfave/cli"
+	"go.dedis.ch/cothority/v3/blscosi/bdnproto"
+	"go.dedis.ch/cothority/v3/blscosi/protocol"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
+	kybersign "go.dedis.ch/kyber/v3/sign"
+	"go.dedis.ch/kyber/v3/sign/bdn"
+	"go.dedis.ch/kyber/v3/sign/bls"
+	"go.dedis.ch/kyber/v3/xof/blake2xb"
+)
+
+/*
+This file holds the generation and the verification of test vectors for the
+collective signatures, so that implementations in other languages can check
+their verifiers against this one. The format of the vectors is described in
+the README.
+*/
+
+const (
+	schemeBLS = "bls"
+	schemeBDN = "bdn"
+)
+
+// testVectors is the JSON file holding the test vectors.
+type testVectors struct {
+	// Suite is the name of the pairing suite of the keys and signatures.
+	Suite   string
+	Vectors []testVector
+}
+
+// testVector is a collective signature and whether it must be accepted. All
+// binary values are hex-encoded.
+type testVector struct {
+	Description string
+	// Scheme is either "bls" or "bdn".
+	Scheme  string
+	Message string
+	// Publics are the marshalled public keys of the roster, in order.
+	Publics []string
+	// Mask has one bit per public key, the lowest bit of the first byte
+	// being the first key.
+	Mask string
+	// Threshold is the minimum number of bits set in the mask.
+	Threshold int
+	// Signature is the marshalled aggregate signature, without the mask.
+	Signature string
+	Valid     bool
+}
+
+// vectorCase describes one test vector: the signers create the signature on
+// msg, while claimed are the bits set in the mask.
+type vectorCase struct {
+	description string
+	signers     []int
+	claimed     []int
+	msg         []byte
+	valid       bool
+}
+
+// generateVectors returns the test vectors of both schemes for a roster of n
+// keys created from the seed.
+func generateVectors(seed []byte, n int) (*testVectors, error) {
+	if n < 4 {
+		return nil, errors.New("need at least 4 keys")
+	}
+	suite := pairing.NewSuiteBn256()
+	stream := blake2xb.New(seed)
+	privs := make([]kyber.Scalar, n)
+	publics := make([]kyber.Point, n)
+	for i := range privs {
+		privs[i], publics[i] = bls.NewKeyPair(suite, stream)
+	}
+	pubStrs := make([]string, n)
+	for i, p := range publics {
+		buf, err := p.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		pubStrs[i] = hex.EncodeToString(buf)
+	}
+
+	msg := []byte("cothority collective signature test vector")
+	threshold := protocol.DefaultThreshold(n)
+	all := make([]int, n)
+	for i := range all {
+		all[i] = i
+	}
+	cases := []vectorCase{
+		{description: "all keys signed", signers: all, valid: true},
+		{description: "threshold of keys signed", signers: all[n-threshold:],
+			valid: true},
+		{description: "less than threshold keys signed",
+			signers: all[n-threshold+1:]},
+		{description: "signature on another message", signers: all,
+			msg: []byte("another message")},
+		{description: "mask with a key that didn't sign",
+			signers: all[1:], claimed: all},
+		{description: "mask without a key that signed",
+			signers: all, claimed: all[1:]},
+	}
+
+	tv := &testVectors{Suite: suite.String()}
+	for _, scheme := range []string{schemeBLS, schemeBDN} {
+		for _, vc := range cases {
+			sig, mask, err := signVector(suite, scheme, privs, publics, msg, vc)
+			if err != nil {
+				return nil, err
+			}
+			v := testVector{
+				Description: vc.description,
+				Scheme:      scheme,
+				Message:     hex.EncodeToString(msg),
+				Publics:     pubStrs,
+				Mask:        hex.EncodeToString(mask),
+				Threshold:   threshold,
+				Signature:   hex.EncodeToString(sig),
+				Valid:       vc.valid,
+			}
+			if err := verifyVector(suite, v); (err == nil) != v.Valid {
+				return nil, fmt.Errorf("vector %s of scheme %s: got %v",
+					vc.description, scheme, err)
+			}
+			tv.Vectors = append(tv.Vectors, v)
+		}
+	}
+	return tv, nil
+}
+
+// signVector returns the aggregate signature and the mask of the case.
+func signVector(suite *pairing.SuiteBn256, scheme string, privs []kyber.Scalar,
+	publics []kyber.Point, msg []byte, vc vectorCase) ([]byte, []byte, error) {
+	if vc.msg != nil {
+		msg = vc.msg
+	}
+	if vc.claimed == nil {
+		vc.claimed = vc.signers
+	}
+	newMask := func(bits []int) (*kybersign.Mask, error) {
+		mask, err := kybersign.NewMask(suite, publics, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range bits {
+			if err := mask.SetBit(i, true); err != nil {
+				return nil, err
+			}
+		}
+		return mask, nil
+	}
+
+	var sigs [][]byte
+	for _, i := range vc.signers {
+		var sig []byte
+		var err error
+		if scheme == schemeBDN {
+			sig, err = bdn.Sign(suite, privs[i], msg)
+		} else {
+			sig, err = bls.Sign(suite, privs[i], msg)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+	var agg []byte
+	if scheme == schemeBDN {
+		mask, err := newMask(vc.signers)
+		if err != nil {
+			return nil, nil, err
+		}
+		point, err := bdn.AggregateSignatures(suite, sigs, mask)
+		if err != nil {
+			return nil, nil, err
+		}
+		if agg, err = point.MarshalBinary(); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		var err error
+		if agg, err = bls.AggregateSignatures(suite, sigs...); err != nil {
+			return nil, nil, err
+		}
+	}
+	claimed, err := newMask(vc.claimed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return agg, claimed.Mask(), nil
+}
+
+// verifyVector verifies the signature of the vector with the Go
+// implementation, and returns an error if it is refused.
+func verifyVector(suite *pairing.SuiteBn256, v testVector) error {
+	msg, err := hex.DecodeString(v.Message)
+	if err != nil {
+		return fmt.Errorf("invalid message: %v", err)
+	}
+	publics := make([]kyber.Point, len(v.Publics))
+	for i, p := range v.Publics {
+		buf, err := hex.DecodeString(p)
+		if err != nil {
+			return fmt.Errorf("invalid public key %d: %v", i, err)
+		}
+		publics[i] = suite.G2().Point()
+		if err := publics[i].UnmarshalBinary(buf); err != nil {
+			return fmt.Errorf("invalid public key %d: %v", i, err)
+		}
+	}
+	sig, err := hex.DecodeString(v.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+	mask, err := hex.DecodeString(v.Mask)
+	if err != nil {
+		return fmt.Errorf("invalid mask: %v", err)
+	}
+	// The signatures of the protocols hold the mask after the aggregate.
+	final := append(append([]byte{}, sig...), mask...)
+	policy := kybersign.NewThresholdPolicy(v.Threshold)
+	switch v.Scheme {
+	case schemeBLS:
+		return protocol.BlsSignature(final).VerifyWithPolicy(suite, msg,
+			publics, policy)
+	case schemeBDN:
+		return bdnproto.BdnSignature(final).VerifyWithPolicy(suite, msg,
+			publics, policy)
+	}
+	return fmt.Errorf("unknown scheme %s", v.Scheme)
+}
+
+// generateVectorsFile writes the test vectors to the file given in the
+// arguments, or to STDOUT.
+func generateVectorsFile(c *cli.Context) error {
+	tv, err := generateVectors([]byte(c.String("seed")), c.Int("keys"))
+	if err != nil {
+		return fmt.Errorf("Couldn't generate test vectors: %s", err.Error())
+	}
+	buf, err := json.MarshalIndent(tv, "", "\t")
+	if err != nil {
+		return fmt.Errorf("Couldn't encode test vectors: %s", err.Error())
+	}
+	buf = append(buf, '\n')
+	if c.Args().First() == "" {
+		_, err = c.App.Writer.Write(buf)
+		return err
+	}
+	return ioutil.WriteFile(c.Args().First(), buf, 0644)
+}
+
+// verifyVectorsFile checks that the Go implementation accepts exactly the
+// valid test vectors of the file given in the arguments, or of STDIN.
+func verifyVectorsFile(c *cli.Context) error {
+	var buf []byte
+	var err error
+	if c.Args().First() == "" {
+		buf, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		buf, err = ioutil.ReadFile(c.Args().First())
+	}
+	if err != nil {
+		return fmt.Errorf("Couldn't read test vectors: %s", err.Error())
+	}
+	tv := &testVectors{}
+	if err := json.Unmarshal(buf, tv); err != nil {
+		return fmt.Errorf("Couldn't decode test vectors: %s", err.Error())
+	}
+	suite := pairing.NewSuiteBn256()
+	if tv.Suite != suite.String() {
+		return fmt.Errorf("Unsupported suite %s", tv.Suite)
+	}
+
+	var failed int
+	for i, v := range tv.Vectors {
+		err := verifyVector(suite, v)
+		if (err == nil) != v.Valid {
+			failed++
+			fmt.Fprintf(c.App.Writer, "[-] vector %d (%s, %s): valid=%v, got %v\n",
+				i, v.Scheme, v.Description, v.Valid, err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test vectors failed", failed, len(tv.Vectors))
+	}
+	fmt.Fprintf(c.App.Writer, "[+] OK: %d test vectors verified.\n", len(tv.Vectors))
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestMain_Vectors checks that the generated test vectors are verified and
+// that wrong expectations are detected.
+func TestMain_Vectors(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmp)
+	vectorsFile := path.Join(tmp, "vectors.json")
+
+	cliApp := createApp()
+	var out bytes.Buffer
+	cliApp.Writer = &out
+	err = cliApp.Run([]string{"", "vectors", "generate", "-n", "5", vectorsFile})
+	require.NoError(t, err)
+	err = cliApp.Run([]string{"", "vectors", "verify", vectorsFile})
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "12 test vectors verified")
+
+	// The same seed gives the same vectors.
+	buf, err := ioutil.ReadFile(vectorsFile)
+	require.NoError(t, err)
+	tv, err := generateVectors([]byte("cothority"), 5)
+	require.NoError(t, err)
+	buf2, err := json.MarshalIndent(tv, "", "\t")
+	require.NoError(t, err)
+	require.Equal(t, string(buf2)+"\n", string(buf))
+	var valid int
+	for _, v := range tv.Vectors {
+		if v.Valid {
+			valid++
+		}
+	}
+	require.Equal(t, 4, valid)
+
+	tv.Vectors[0].Valid = false
+	tv.Vectors[len(tv.Vectors)-1].Valid = true
+	buf, err = json.Marshal(tv)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(vectorsFile, buf, 0644))
+	out.Reset()
+	err = cliApp.Run([]string{"", "vectors", "verify", vectorsFile})
+	require.EqualError(t, err, "2 of 12 test vectors failed")
+	require.Contains(t, out.String(), "vector 0 (bls, all keys signed)")
+
+	_, err = generateVectors([]byte("cothority"), 3)
+	require.Error(t, err)
+}
@@ -1,6 +1,8 @@
 package protocol
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 
@@ -16,6 +18,52 @@ func NewBlsProtocolTree(tree *onet.Tree, nSubTrees int) (BlsProtocolTree, error)
 	return genTrees(tree, nSubTrees)
 }
 
+// NewBlsProtocolTreeFromSeed creates the subtrees like NewBlsProtocolTree,
+// but the nodes are distributed in an order derived from the roster, the
+// message and the seed. Anybody knowing these three values can reconstruct
+// the same subtrees.
+func NewBlsProtocolTreeFromSeed(tree *onet.Tree, nSubTrees int, msg, seed []byte) (BlsProtocolTree, error) {
+	if tree.Roster == nil {
+		return nil, errors.New("the roster is nil")
+	}
+	return genTreesOrdered(tree, nSubTrees, seededOrder(tree, msg, seed))
+}
+
+// seededOrder returns the indexes of the roster without the root, shuffled
+// with a Fisher-Yates shuffle. The random source for the i-th step is
+// SHA-256(SHA-256(rosterID | SHA-256(msg) | seed) | i), with i as a 32-bit
+// big-endian integer.
+func seededOrder(tree *onet.Tree, msg, seed []byte) []int {
+	hMsg := sha256.Sum256(msg)
+	h := sha256.New()
+	h.Write(tree.Roster.ID[:])
+	h.Write(hMsg[:])
+	h.Write(seed)
+	base := h.Sum(nil)
+
+	order := defaultOrder(tree)
+	for i := len(order) - 1; i > 0; i-- {
+		step := make([]byte, 4)
+		binary.BigEndian.PutUint32(step, uint32(i))
+		r := sha256.Sum256(append(append([]byte{}, base...), step...))
+		j := int(binary.BigEndian.Uint64(r[:8]) % uint64(i+1))
+		order[i], order[j] = order[j], order[i]
+	}
+	return order
+}
+
+// defaultOrder returns the indexes of the roster without the root, starting
+// after the root.
+func defaultOrder(tree *onet.Tree) []int {
+	nNodes := len(tree.Roster.List)
+	root := tree.Root.RosterIndex
+	var order []int
+	for i := 1; i < nNodes; i++ {
+		order = append(order, (root+i)%nNodes)
+	}
+	return order
+}
+
 // GetLeaves returns the server identities of the leaves
 func (pt BlsProtocolTree) GetLeaves() []*network.ServerIdentity {
 	si := []*network.ServerIdentity{}
@@ -48,18 +96,30 @@ func (pt BlsProtocolTree) GetSubLeaders() []*network.ServerIdentity {
 // TODO: we may be able to simplify the code here to make sure the existing onet
 // tree generation functions.
 func genTrees(tree *onet.Tree, nSubtrees int) ([]*onet.Tree, error) {
-	roster := tree.Roster
-	nNodes := len(roster.List)
-	root := tree.Root.RosterIndex
-
 	// parameter verification
-	if roster == nil {
+	if tree.Roster == nil {
 		return nil, errors.New("the roster is nil")
 	}
-	if nNodes < 1 {
+
+	// the sub-trees are generated starting after the root index as an
+	// optimization to the dead leader situation. In a normal situation,
+	// the root index is 0 and then this doesn't change anything but in
+	// the case of a leader change, the index 0 should be avoided because
+	// it is very likely that the node is not alive
+	return genTreesOrdered(tree, nSubtrees, defaultOrder(tree))
+}
+
+// genTreesOrdered works like genTrees, but fills the subtrees with the
+// nodes in the given order, which holds the indexes of the nodes other than
+// the root. Nodes missing from the order are left out of the subtrees.
+func genTreesOrdered(tree *onet.Tree, nSubtrees int, order []int) ([]*onet.Tree, error) {
+	roster := tree.Roster
+	root := tree.Root.RosterIndex
+	if len(roster.List) < 1 {
 		return nil, fmt.Errorf("the number of nodes in the trees "+
-			"cannot be less than one, but is %d", nNodes)
+			"cannot be less than one, but is %d", len(roster.List))
 	}
+	nNodes := len(order) + 1
 	if nSubtrees < 1 {
 		return nil, fmt.Errorf("the number of subtrees"+
 			"cannot be less than one, but is %d", nSubtrees)
@@ -81,12 +141,7 @@ func genTrees(tree *onet.Tree, nSubtrees int) ([]*onet.Tree, error) {
 	nodesPerSubtree := (nNodes - 1) / nSubtrees
 	surplusNodes := (nNodes - 1) % nSubtrees
 
-	// the sub-trees are generated starting after the root index as an
-	// optimization to the dead leader situation. In a normal situation,
-	// the root index is 0 and then this doesn't change anything but in
-	// the case of a leader change, the index 0 should be avoided because
-	// it is very likely that the node is not alive
-	pointer := (root + 1) % len(tree.Roster.List)
+	pointer := 0
 	for i := 0; i < nSubtrees; i++ {
 		length := nodesPerSubtree + 1
 		if i < surplusNodes { // to handle surplus nodes
@@ -99,8 +154,8 @@ func genTrees(tree *onet.Tree, nSubtrees int) ([]*onet.Tree, error) {
 			if j == 0 {
 				nodes[j] = root
 			} else {
-				nodes[j] = pointer
-				pointer = (pointer + 1) % len(tree.Roster.List)
+				nodes[j] = order[pointer]
+				pointer++
 			}
 		}
 
@@ -7,6 +7,7 @@ protocol, as in Test Driven Development.
 */
 
 import (
+	"reflect"
 	"testing"
 
 	"go.dedis.ch/onet/v3"
@@ -360,3 +361,69 @@ func TestGenSubtreeErrors(t *testing.T) {
 		local.CloseAll()
 	}
 }
+
+// tests that the seeded subtrees are reproducible and depend on the seed
+func TestGenTreesFromSeed(t *testing.T) {
+	local := onet.NewLocalTest(testSuite)
+	defer local.CloseAll()
+	_, _, tree := local.GenTree(20, false)
+	msg := []byte("message")
+
+	trees1, err := NewBlsProtocolTreeFromSeed(tree, 4, msg, []byte("seed"))
+	if err != nil {
+		t.Fatal("Error in tree generation:", err)
+	}
+	trees2, err := NewBlsProtocolTreeFromSeed(tree, 4, msg, []byte("seed"))
+	if err != nil {
+		t.Fatal("Error in tree generation:", err)
+	}
+	trees3, err := NewBlsProtocolTreeFromSeed(tree, 4, msg, []byte("other"))
+	if err != nil {
+		t.Fatal("Error in tree generation:", err)
+	}
+	trees4, err := NewBlsProtocolTreeFromSeed(tree, 4, []byte("other"), []byte("seed"))
+	if err != nil {
+		t.Fatal("Error in tree generation:", err)
+	}
+
+	l1 := subtreesLayout(trees1)
+	if !reflect.DeepEqual(l1, subtreesLayout(trees2)) {
+		t.Fatal("the same seed should give the same subtrees")
+	}
+	if reflect.DeepEqual(l1, subtreesLayout(trees3)) {
+		t.Fatal("a different seed should give different subtrees")
+	}
+	if reflect.DeepEqual(l1, subtreesLayout(trees4)) {
+		t.Fatal("a different message should give different subtrees")
+	}
+
+	// every server is used exactly once, plus the root in every subtree
+	serverSet := make(map[network.ServerIdentityID]int)
+	for _, id := range l1 {
+		serverSet[id]++
+	}
+	if len(serverSet) != 20 {
+		t.Fatal("the subtrees should use the whole roster")
+	}
+	if serverSet[tree.Root.ServerIdentity.ID] != 4 {
+		t.Fatal("the root should be in every subtree")
+	}
+}
+
+// subtreesLayout returns the servers of the subtrees in the order they are
+// placed: for each subtree, the root, the subleader and then the leaves.
+func subtreesLayout(trees BlsProtocolTree) []network.ServerIdentityID {
+	var ids []network.ServerIdentityID
+	for _, tree := range trees {
+		ids = append(ids, tree.Root.ServerIdentity.ID)
+		if len(tree.Root.Children) == 0 {
+			continue
+		}
+		subleader := tree.Root.Children[0]
+		ids = append(ids, subleader.ServerIdentity.ID)
+		for _, c := range subleader.Children {
+			ids = append(ids, c.ServerIdentity.ID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,110 @@
+package protocol
+
+import (
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/pairing"
+	"go.dedis.ch/kyber/v3/sign"
+	"golang.org/x/xerrors"
+)
+
+// isParticipant returns true if the bit of the index is set in the mask.
+func isParticipant(participants []byte, index int) bool {
+	return index/8 < len(participants) &&
+		participants[index/8]&(1<<uint(index&7)) != 0
+}
+
+// countParticipants returns the number of bits set in the mask for a roster
+// of n nodes.
+func countParticipants(participants []byte, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if isParticipant(participants, i) {
+			count++
+		}
+	}
+	return count
+}
+
+// participantsOrder removes the nodes that are not participants from the
+// order.
+func participantsOrder(order []int, participants []byte) []int {
+	var filtered []int
+	for _, i := range order {
+		if isParticipant(participants, i) {
+			filtered = append(filtered, i)
+		}
+	}
+	return filtered
+}
+
+// SetParticipants restricts the signers to the nodes of the roster whose bit
+// is set in the mask, which uses the same format as the mask of the
+// signature. The root must be one of them. The threshold is set to the
+// default threshold for the number of participants. It must be called
+// before SetNbrSubTree.
+func (p *BlsCosi) SetParticipants(participants []byte) error {
+	n := len(p.Roster().List)
+	if len(participants) != (n+7)/8 {
+		return xerrors.Errorf("mask must be %d bytes long", (n+7)/8)
+	}
+	for i := n; i < len(participants)*8; i++ {
+		if isParticipant(participants, i) {
+			return xerrors.New("mask has bits outside of the roster")
+		}
+	}
+	if !isParticipant(participants, p.Tree().Root.RosterIndex) {
+		return xerrors.New("the root must be a participant")
+	}
+	p.Participants = participants
+	p.Threshold = DefaultThreshold(countParticipants(participants, n))
+	return nil
+}
+
+// nbrParticipants returns the number of nodes asked to sign.
+func (p *BlsCosi) nbrParticipants() int {
+	n := len(p.Roster().List)
+	if p.Participants == nil {
+		return n
+	}
+	return countParticipants(p.Participants, n)
+}
+
+// participantsPolicy accepts masks with enough signers, all of them being
+// participants.
+type participantsPolicy struct {
+	participants []byte
+	threshold    int
+}
+
+// NewParticipantsPolicy returns a policy accepting signatures of at least
+// threshold nodes, all of them in the participants mask.
+func NewParticipantsPolicy(participants []byte, threshold int) sign.Policy {
+	return participantsPolicy{participants: participants, threshold: threshold}
+}
+
+// Check implements sign.Policy.
+func (pp participantsPolicy) Check(m sign.ParticipationMask) bool {
+	mask, ok := m.(*sign.Mask)
+	if !ok {
+		return false
+	}
+	bits := mask.Mask()
+	for i := 0; i < len(bits)*8; i++ {
+		if isParticipant(bits, i) && !isParticipant(pp.participants, i) {
+			return false
+		}
+	}
+	return m.CountEnabled() >= pp.threshold
+}
+
+// VerifyParticipants checks the signature of a round restricted to the
+// participants: at least the default threshold of the participants signed,
+// and no other node did.
+func (sig BlsSignature) VerifyParticipants(ps pairing.Suite, msg []byte, publics []kyber.Point, participants []byte) error {
+	count := countParticipants(participants, len(publics))
+	if count == 0 {
+		return xerrors.New("no participant in the mask")
+	}
+	policy := NewParticipantsPolicy(participants, DefaultThreshold(count))
+	return sig.VerifyWithPolicy(ps, msg, publics, policy)
+}
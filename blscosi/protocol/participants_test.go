@@ -0,0 +1,62 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/kyber/v3/sign"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestParticipants_Order(t *testing.T) {
+	participants := []byte{0x2d, 0x01} // 0, 2, 3, 5, 8
+	require.Equal(t, 5, countParticipants(participants, 9))
+	require.Equal(t, 4, countParticipants(participants, 8))
+	require.Equal(t, []int{2, 3, 5, 8, 0},
+		participantsOrder([]int{1, 2, 3, 4, 5, 6, 7, 8, 0}, participants))
+}
+
+func TestProtocol_Participants(t *testing.T) {
+	local := onet.NewLocalTest(testSuite)
+	defer local.CloseAll()
+	servers, _, tree := local.GenTree(7, false)
+
+	services := local.GetServices(servers, testServiceID)
+	rootService := services[0].(*testService)
+	pi, err := rootService.CreateProtocol(DefaultProtocolName, tree)
+	require.NoError(t, err)
+
+	cosiProtocol := pi.(*BlsCosi)
+	cosiProtocol.CreateProtocol = rootService.CreateProtocol
+	cosiProtocol.Msg = []byte{0xFF}
+	cosiProtocol.Timeout = testTimeout
+
+	require.Error(t, cosiProtocol.SetParticipants([]byte{0x2f, 0x00}))
+	require.Error(t, cosiProtocol.SetParticipants([]byte{0xaf}))
+	require.Error(t, cosiProtocol.SetParticipants([]byte{0x2e}))
+	participants := []byte{0x2f} // 0, 1, 2, 3, 5
+	require.NoError(t, cosiProtocol.SetParticipants(participants))
+	require.Equal(t, 4, cosiProtocol.Threshold)
+	require.NoError(t, cosiProtocol.SetNbrSubTree(2))
+	for _, tree := range cosiProtocol.subTrees {
+		for _, tn := range tree.List() {
+			require.True(t, isParticipant(participants, tn.RosterIndex))
+		}
+	}
+
+	// The nodes that are not participants are never contacted.
+	servers[4].Pause()
+	servers[6].Pause()
+	defer servers[4].Unpause()
+	defer servers[6].Unpause()
+	require.NoError(t, cosiProtocol.Start())
+
+	sig, err := getAndVerifySignature(cosiProtocol, cosiProtocol.Msg,
+		NewParticipantsPolicy(participants, 4))
+	require.NoError(t, err)
+	publics := cosiProtocol.Roster().ServicePublics(testServiceName)
+	require.Error(t, sig.VerifyWithPolicy(testSuite, cosiProtocol.Msg, publics,
+		NewParticipantsPolicy([]byte{0x0f}, 4)))
+	require.Error(t, sig.VerifyWithPolicy(testSuite, cosiProtocol.Msg, publics,
+		sign.NewThresholdPolicy(6)))
+}
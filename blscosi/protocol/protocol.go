@@ -34,6 +34,10 @@ func init() {
 const defaultTimeout = 10 * time.Second
 const defaultSubleaderFailures = 2
 
+// defaultFlatRosterSize is the biggest number of participants that are
+// contacted directly by the root.
+const defaultFlatRosterSize = 4
+
 // VerificationFn is called on every node. Where msg is the message that is
 // co-signed and the data is additional data for verification.
 type VerificationFn func(msg, data []byte) bool
@@ -48,6 +52,10 @@ type SignFn func(suite pairing.Suite, secret kyber.Scalar, msg []byte) ([]byte,
 // mask of the peer's participation
 type AggregateFn func(suite pairing.Suite, mask *sign.Mask, sigs [][]byte) ([]byte, error)
 
+// VerifyFinalFn is called on the root to verify the final signature, made of
+// the aggregated signature and the mask, against the policy
+type VerifyFinalFn func(suite pairing.Suite, msg []byte, publics []kyber.Point, sig []byte, policy sign.Policy) error
+
 // BlsCosi holds the parameters of the protocol.
 // It also defines a channel that will receive the final signature.
 // This protocol should only exist on the root node.
@@ -59,14 +67,35 @@ type BlsCosi struct {
 	Verify         VerifyFn
 	Sign           SignFn
 	Aggregate      AggregateFn
+	VerifyFinal    VerifyFinalFn
 	// Timeout is not a global timeout for the protocol, but a timeout used
 	// for waiting for responses for sub protocols.
 	Timeout           time.Duration
 	SubleaderFailures int
 	Threshold         int
-	FinalSignature    chan []byte // final signature that is sent back to client
+	// Seed, if set, is used with the roster and the message to build the
+	// subtrees in a reproducible order. It must be set together with Msg
+	// before calling SetNbrSubTree.
+	Seed []byte
+	// Participants, if set, is a mask over the roster of the nodes asked to
+	// sign. It is set with SetParticipants.
+	Participants []byte
+	// Client, if set, identifies the client that asked for the signature.
+	// It is sent to the other nodes, so that they can limit the signatures
+	// of every client.
+	Client string
+	// FlatRosterSize is the biggest number of participants for which the
+	// root contacts every node directly, without subleaders. It is ignored
+	// when the Threshold is lower than the default one, as the verifiers of
+	// the signature might still expect the default policy. Set it to 0 to
+	// always use the given number of subtrees.
+	FlatRosterSize int
+	FinalSignature chan []byte // final signature that is sent back to client
 
 	stoppedOnce      sync.Once
+	metrics          RoundMetrics
+	metricsLock      sync.Mutex
+	startTime        time.Time
 	subProtocolsLock sync.Mutex
 	subProtocols     []*SubBlsCosi
 	subProtocolName  string
@@ -115,10 +144,12 @@ func NewBlsCosi(n *onet.TreeNodeInstance, vf VerificationFn, subProtocolName str
 		FinalSignature:    make(chan []byte, 1),
 		Timeout:           defaultTimeout,
 		SubleaderFailures: defaultSubleaderFailures,
+		FlatRosterSize:    defaultFlatRosterSize,
 		Threshold:         DefaultThreshold(nNodes),
 		Sign:              bls.Sign,
 		Verify:            bls.Verify,
 		Aggregate:         aggregate,
+		VerifyFinal:       verifyFinal,
 		verificationFn:    vf,
 		subProtocolName:   subProtocolName,
 		suite:             suite,
@@ -141,8 +172,26 @@ func (p *BlsCosi) SetNbrSubTree(nbr int) error {
 		return nil
 	}
 
+	if p.Tree().Roster == nil {
+		return xerrors.New("the roster is nil")
+	}
+	var order []int
+	if len(p.Seed) > 0 {
+		order = seededOrder(p.Tree(), p.Msg, p.Seed)
+	} else {
+		order = defaultOrder(p.Tree())
+	}
+	if p.Participants != nil {
+		order = participantsOrder(order, p.Participants)
+	}
+	if len(order) > 0 && len(order) < p.FlatRosterSize &&
+		p.Threshold >= DefaultThreshold(len(order)+1) {
+		// Every node is the subleader of its own subtree, so the root
+		// contacts all of them.
+		nbr = len(order)
+	}
 	var err error
-	p.subTrees, err = NewBlsProtocolTree(p.Tree(), nbr)
+	p.subTrees, err = genTreesOrdered(p.Tree(), nbr, order)
 	if err != nil {
 		return xerrors.Errorf("error in tree generation: %v", err)
 	}
@@ -184,8 +233,8 @@ func (p *BlsCosi) Start() error {
 	if p.subTrees == nil {
 		// the default number of subtrees is the square root of the number of
 		// nodes to distribute the nodes evenly
-		if err := p.SetNbrSubTree(int(math.Sqrt(float64(len(p.Roster().
-			List))))); err != nil {
+		if err := p.SetNbrSubTree(int(math.Sqrt(float64(
+			p.nbrParticipants())))); err != nil {
 			p.Done()
 			return xerrors.Errorf("couldn't set subtrees: %v", err)
 		}
@@ -204,15 +253,40 @@ func (p *BlsCosi) Start() error {
 	return nil
 }
 
+// Metrics returns the measurements of the round. It can be called while the
+// protocol is running, but the values are only complete once the final
+// signature has been sent.
+func (p *BlsCosi) Metrics() RoundMetrics {
+	p.metricsLock.Lock()
+	defer p.metricsLock.Unlock()
+	m := p.metrics
+	m.SubtreeLatencies = append([]time.Duration{}, p.metrics.SubtreeLatencies...)
+	return m
+}
+
+func (p *BlsCosi) updateMetrics(f func(m *RoundMetrics)) {
+	p.metricsLock.Lock()
+	f(&p.metrics)
+	p.metricsLock.Unlock()
+}
+
 func (p *BlsCosi) runSubProtocols() {
 	defer p.Done()
 
+	p.startTime = time.Now()
+	p.updateMetrics(func(m *RoundMetrics) {
+		m.SubtreeLatencies = make([]time.Duration, len(p.subTrees))
+	})
+
 	// Verification of the data is done before contacting the children
 	if ok := p.verificationFn(p.Msg, p.Data); !ok {
 		// root should not fail the verification otherwise it would not have started the protocol
 		log.Errorf("verification failed on root node")
 		return
 	}
+	p.updateMetrics(func(m *RoundMetrics) {
+		m.VerificationLatency = time.Since(p.startTime)
+	})
 
 	// start all subprotocols
 	p.subProtocolsLock.Lock()
@@ -246,6 +320,16 @@ func (p *BlsCosi) runSubProtocols() {
 		return
 	}
 
+	// a wrong aggregate of a subleader would only be noticed by the client
+	if err := p.checkFinalSignature(sig); err != nil {
+		log.Error(err)
+		return
+	}
+
+	p.updateMetrics(func(m *RoundMetrics) {
+		m.Latency = time.Since(p.startTime)
+	})
+
 	p.FinalSignature <- sig
 }
 
@@ -267,8 +351,8 @@ func (p *BlsCosi) checkIntegrity() error {
 	if p.Timeout < 500*time.Microsecond {
 		return fmt.Errorf("unrealistic timeout")
 	}
-	if p.Threshold > p.Tree().Size() {
-		return fmt.Errorf("threshold (%d) bigger than number of nodes (%d)", p.Threshold, p.Tree().Size())
+	if p.Threshold > p.nbrParticipants() {
+		return fmt.Errorf("threshold (%d) bigger than number of nodes (%d)", p.Threshold, p.nbrParticipants())
 	}
 	if p.Threshold < 1 {
 		return fmt.Errorf("threshold of %d smaller than one node", p.Threshold)
@@ -280,7 +364,7 @@ func (p *BlsCosi) checkIntegrity() error {
 // checkFailureThreshold returns true when the number of failures
 // is above the threshold
 func (p *BlsCosi) checkFailureThreshold(numFailure int) bool {
-	return numFailure > len(p.Roster().List)-p.Threshold
+	return numFailure > p.nbrParticipants()-p.Threshold
 }
 
 // startSubProtocol creates, parametrize and starts a subprotocol on a given tree
@@ -293,6 +377,8 @@ func (p *BlsCosi) startSubProtocol(tree *onet.Tree) (*SubBlsCosi, error) {
 	cosiSubProtocol := pi.(*SubBlsCosi)
 	cosiSubProtocol.Msg = p.Msg
 	cosiSubProtocol.Data = p.Data
+	cosiSubProtocol.Seed = p.Seed
+	cosiSubProtocol.Client = p.Client
 	// Fail fast enough if the subleader is failing to try
 	// at least three leaves as new subleader
 	cosiSubProtocol.Timeout = p.Timeout / time.Duration(p.SubleaderFailures+1)
@@ -368,7 +454,11 @@ func (p *BlsCosi) collectSignatures() (ResponseMap, error) {
 					p.subProtocolsLock.Lock()
 					p.subProtocols[i] = subProtocol
 					p.subProtocolsLock.Unlock()
+					p.updateMetrics(func(m *RoundMetrics) { m.Restarts++ })
 				case response := <-subProtocol.subResponse:
+					p.updateMetrics(func(m *RoundMetrics) {
+						m.SubtreeLatencies[i] = time.Since(p.startTime)
+					})
 					responsesChan <- response
 					return
 				}
@@ -381,6 +471,7 @@ func (p *BlsCosi) collectSignatures() (ResponseMap, error) {
 	responseMap := make(ResponseMap)
 	numSignature := 0
 	numFailure := 0
+	numQuota := 0
 	timeout := time.After(p.Timeout)
 	for numSubProtocols > 0 && numSignature < p.Threshold-1 && !p.checkFailureThreshold(numFailure) {
 		select {
@@ -401,6 +492,7 @@ func (p *BlsCosi) collectSignatures() (ResponseMap, error) {
 					count := mask.CountEnabled()
 					numSignature += count
 					numFailure += res.SubtreeCount() + 1 - count
+					numQuota += p.countQuotaRefusals(publics, mask, res.Quotas)
 
 					responseMap[index] = &res.Response
 				}
@@ -413,10 +505,15 @@ func (p *BlsCosi) collectSignatures() (ResponseMap, error) {
 			// more than Timeout + root computation time
 			return nil, fmt.Errorf("not enough replies from nodes at timeout %v "+
 				"for Threshold %d, got %d responses for %d requests", p.Timeout,
-				p.Threshold, numSignature, len(p.Roster().List)-1)
+				p.Threshold, numSignature, p.nbrParticipants()-1)
 		}
 	}
 
+	p.updateMetrics(func(m *RoundMetrics) {
+		m.Refusals = numFailure
+		m.QuotaRefusals = numQuota
+	})
+
 	if p.checkFailureThreshold(numFailure) {
 		return nil, fmt.Errorf("too many signature-refusals (got %d), "+
 			"the threshold of %d cannot be achieved",
@@ -426,6 +523,31 @@ func (p *BlsCosi) collectSignatures() (ResponseMap, error) {
 	return responseMap, nil
 }
 
+// countQuotaRefusals returns the number of valid quota refusals. A refusal
+// is ignored if its signature is wrong or if the node is in the mask of the
+// signers.
+func (p *BlsCosi) countQuotaRefusals(publics []kyber.Point, mask *sign.Mask, quotas []QuotaRefusal) int {
+	bits := mask.Mask()
+	count := 0
+	seen := make(map[int]bool)
+	for _, q := range quotas {
+		if q.Index < 0 || q.Index >= len(publics) || seen[q.Index] {
+			continue
+		}
+		if bits[q.Index/8]&(byte(1)<<uint(q.Index&7)) != 0 {
+			continue
+		}
+		err := p.Verify(p.suite, publics[q.Index], quotaRefusalMsg(q.Nonce), q.Signature)
+		if err != nil {
+			log.Warnf("Invalid quota refusal from node %d: %v", q.Index, err)
+			continue
+		}
+		seen[q.Index] = true
+		count++
+	}
+	return count
+}
+
 // Sign the message with this node and aggregates with all child signatures (in structResponses)
 // Also aggregates the child bitmasks
 func (p *BlsCosi) generateSignature(responses ResponseMap) (BlsSignature, error) {
@@ -511,10 +633,32 @@ func (p *BlsCosi) makeAggregateResponse(suite pairing.Suite, publics []kyber.Poi
 	}
 
 	log.Lvlf3("%v is done aggregating signatures with total of %d signatures", p.ServerIdentity(), finalMask.CountEnabled())
+	p.updateMetrics(func(m *RoundMetrics) {
+		m.MaskWeight = finalMask.CountEnabled()
+	})
 
 	return append(sig, finalMask.Mask()...), nil
 }
 
+// checkFinalSignature verifies the final signature with the public keys of
+// the mask, and checks that the mask fulfills the threshold and the
+// participants of the protocol.
+func (p *BlsCosi) checkFinalSignature(sig BlsSignature) error {
+	var policy sign.Policy = sign.NewThresholdPolicy(p.Threshold)
+	if p.Participants != nil {
+		policy = NewParticipantsPolicy(p.Participants, p.Threshold)
+	}
+	err := p.VerifyFinal(p.suite, p.Msg, p.Publics(), sig, policy)
+	if err != nil {
+		return fmt.Errorf("final signature doesn't verify: %v", err)
+	}
+	return nil
+}
+
+func verifyFinal(suite pairing.Suite, msg []byte, publics []kyber.Point, sig []byte, policy sign.Policy) error {
+	return BlsSignature(sig).VerifyWithPolicy(suite, msg, publics, policy)
+}
+
 func aggregate(suite pairing.Suite, mask *sign.Mask, sigs [][]byte) ([]byte, error) {
 	return bls.AggregateSignatures(suite, sigs...)
 }
@@ -10,8 +10,10 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/pairing"
 	"go.dedis.ch/kyber/v3/sign"
+	"go.dedis.ch/kyber/v3/sign/bls"
 	"go.dedis.ch/onet/v3"
 	"go.dedis.ch/onet/v3/log"
 )
@@ -295,9 +297,159 @@ func runProtocolFailingSubLeader(nbrNodes, nbrTrees int) error {
 		return err
 	}
 
+	if cosiProtocol.Metrics().Restarts == 0 {
+		return errors.New("restart of the subtree is missing in the metrics")
+	}
+
 	return nil
 }
 
+func TestProtocol_Metrics(t *testing.T) {
+	local := onet.NewLocalTest(testSuite)
+	defer local.CloseAll()
+	servers, _, tree := local.GenTree(7, false)
+
+	services := local.GetServices(servers, testServiceID)
+	rootService := services[0].(*testService)
+	pi, err := rootService.CreateProtocol(DefaultProtocolName, tree)
+	require.NoError(t, err)
+
+	cosiProtocol := pi.(*BlsCosi)
+	cosiProtocol.CreateProtocol = rootService.CreateProtocol
+	cosiProtocol.Msg = []byte{0xFF}
+	cosiProtocol.Timeout = testTimeout
+	cosiProtocol.Threshold = 7
+	require.NoError(t, cosiProtocol.SetNbrSubTree(2))
+	require.NoError(t, cosiProtocol.Start())
+
+	_, err = getAndVerifySignature(cosiProtocol, cosiProtocol.Msg,
+		sign.NewThresholdPolicy(7))
+	require.NoError(t, err)
+
+	m := cosiProtocol.Metrics()
+	require.Equal(t, 0, m.Restarts)
+	require.Equal(t, 0, m.Refusals)
+	require.Equal(t, 7, m.MaskWeight)
+	require.Equal(t, 2, len(m.SubtreeLatencies))
+	for _, l := range m.SubtreeLatencies {
+		require.True(t, l > 0)
+		require.True(t, l <= m.Latency)
+	}
+	require.True(t, m.VerificationLatency <= m.Latency)
+}
+
+func TestProtocol_Flat(t *testing.T) {
+	local := onet.NewLocalTest(testSuite)
+	defer local.CloseAll()
+	servers, _, tree := local.GenTree(4, false)
+
+	services := local.GetServices(servers, testServiceID)
+	rootService := services[0].(*testService)
+	pi, err := rootService.CreateProtocol(DefaultProtocolName, tree)
+	require.NoError(t, err)
+
+	cosiProtocol := pi.(*BlsCosi)
+	cosiProtocol.CreateProtocol = rootService.CreateProtocol
+	cosiProtocol.Msg = []byte{0xFF}
+	cosiProtocol.Timeout = testTimeout
+
+	// Above the flat size, the number of subtrees is kept.
+	cosiProtocol.FlatRosterSize = 3
+	require.NoError(t, cosiProtocol.SetNbrSubTree(1))
+	require.Equal(t, 1, len(cosiProtocol.subTrees))
+
+	cosiProtocol.FlatRosterSize = defaultFlatRosterSize
+	require.NoError(t, cosiProtocol.SetNbrSubTree(1))
+	require.Equal(t, 3, len(cosiProtocol.subTrees))
+	require.Equal(t, 0, len(cosiProtocol.subTrees.GetLeaves()))
+
+	// A failing node doesn't prevent the others from signing.
+	servers[2].Pause()
+	defer servers[2].Unpause()
+	require.NoError(t, cosiProtocol.Start())
+	_, err = getAndVerifySignature(cosiProtocol, cosiProtocol.Msg,
+		sign.NewThresholdPolicy(3))
+	require.NoError(t, err)
+	require.Equal(t, 0, cosiProtocol.Metrics().Restarts)
+}
+
+func TestProtocol_Seed(t *testing.T) {
+	local := onet.NewLocalTest(testSuite)
+	defer local.CloseAll()
+	servers, _, tree := local.GenTree(7, false)
+
+	services := local.GetServices(servers, testServiceID)
+	rootService := services[0].(*testService)
+	pi, err := rootService.CreateProtocol(DefaultProtocolName, tree)
+	require.NoError(t, err)
+
+	cosiProtocol := pi.(*BlsCosi)
+	cosiProtocol.CreateProtocol = rootService.CreateProtocol
+	cosiProtocol.Msg = []byte{0xFF}
+	cosiProtocol.Seed = []byte("seed")
+	cosiProtocol.Timeout = testTimeout
+	cosiProtocol.Threshold = 7
+	require.NoError(t, cosiProtocol.SetNbrSubTree(2))
+
+	expected, err := NewBlsProtocolTreeFromSeed(tree, 2, cosiProtocol.Msg,
+		cosiProtocol.Seed)
+	require.NoError(t, err)
+	require.Equal(t, subtreesLayout(expected),
+		subtreesLayout(cosiProtocol.subTrees))
+
+	require.NoError(t, cosiProtocol.Start())
+	_, err = getAndVerifySignature(cosiProtocol, cosiProtocol.Msg,
+		sign.NewThresholdPolicy(7))
+	require.NoError(t, err)
+}
+
+func TestProtocol_FinalSignatureCheck(t *testing.T) {
+	local := onet.NewLocalTest(testSuite)
+	defer local.CloseAll()
+	servers, _, tree := local.GenTree(5, false)
+
+	services := local.GetServices(servers, testServiceID)
+	rootService := services[0].(*testService)
+	pi, err := rootService.CreateProtocol(DefaultProtocolName, tree)
+	require.NoError(t, err)
+
+	cosiProtocol := pi.(*BlsCosi)
+	cosiProtocol.CreateProtocol = rootService.CreateProtocol
+	cosiProtocol.Msg = []byte{0xFF}
+	cosiProtocol.Timeout = testTimeout
+	// The root signs another message, so the aggregate doesn't verify.
+	cosiProtocol.Sign = func(s pairing.Suite, x kyber.Scalar, msg []byte) ([]byte, error) {
+		return bls.Sign(s, x, []byte{0xFE})
+	}
+	require.NoError(t, cosiProtocol.Start())
+
+	sig, err := getAndVerifySignature(cosiProtocol, cosiProtocol.Msg,
+		sign.NewThresholdPolicy(4))
+	require.Error(t, err)
+	require.Nil(t, sig)
+
+	// A signature with too few signers is refused.
+	publics := cosiProtocol.Publics()
+	mask, err := sign.NewMask(testSuite, publics, nil)
+	require.NoError(t, err)
+	var sigs [][]byte
+	for i := 0; i < 3; i++ {
+		require.NoError(t, mask.SetBit(i, true))
+		s, err := bls.Sign(testSuite, servers[i].ServerIdentity.
+			ServicePrivate(testServiceName), cosiProtocol.Msg)
+		require.NoError(t, err)
+		sigs = append(sigs, s)
+	}
+	agg, err := bls.AggregateSignatures(testSuite, sigs...)
+	require.NoError(t, err)
+	final := BlsSignature(append(agg, mask.Mask()...))
+	require.Error(t, cosiProtocol.checkFinalSignature(final))
+	cosiProtocol.Threshold = 3
+	require.NoError(t, cosiProtocol.checkFinalSignature(final))
+	cosiProtocol.Participants = []byte{0x1e}
+	require.Error(t, cosiProtocol.checkFinalSignature(final))
+}
+
 // Tests that the protocol throws errors with invalid configurations
 func TestProtocol_IntegrityCheck(t *testing.T) {
 	local := onet.NewLocalTest(testSuite)
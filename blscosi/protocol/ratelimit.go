@@ -0,0 +1,117 @@
+package protocol
+
+import (
+	"sync"
+	"time"
+)
+
+// maxIdleClients is the number of client buckets kept before the full ones
+// are dropped. A full bucket behaves the same as a missing one.
+const maxIdleClients = 1024
+
+// quotaRefusalPrefix is prepended to the nonce signed by a node refusing to
+// sign because it is over its quota, so that the refusal cannot be mistaken
+// for, or replayed as, a verification refusal.
+var quotaRefusalPrefix = []byte("blscosi-over-quota")
+
+// Rate defines a token bucket: PerSecond tokens are added every second, up
+// to Burst tokens. A zero PerSecond means no limit.
+type Rate struct {
+	PerSecond float64
+	Burst     int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// refill adds the tokens gained since the last call and returns true if at
+// least one token is available.
+func (b *bucket) refill(r Rate, now time.Time) bool {
+	if now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * r.PerSecond
+		if b.tokens > float64(r.Burst) {
+			b.tokens = float64(r.Burst)
+		}
+		b.last = now
+	}
+	return b.tokens >= 1
+}
+
+// RateLimiter limits the number of signatures a node is willing to produce,
+// both globally and for each client. It is safe for concurrent use and a nil
+// RateLimiter never refuses.
+type RateLimiter struct {
+	sync.Mutex
+	global    Rate
+	perClient Rate
+	all       bucket
+	clients   map[string]*bucket
+	now       func() time.Time
+}
+
+// NewRateLimiter returns a limiter allowing the global rate over all clients
+// and the perClient rate for every single client.
+func NewRateLimiter(global, perClient Rate) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		global:    global,
+		perClient: perClient,
+		all:       bucket{tokens: float64(global.Burst), last: now},
+		clients:   make(map[string]*bucket),
+		now:       time.Now,
+	}
+}
+
+// Allow returns true and takes a token if both the global and the client's
+// bucket have one left. Else it returns false without taking any token.
+func (rl *RateLimiter) Allow(client string) bool {
+	if rl == nil {
+		return true
+	}
+	rl.Lock()
+	defer rl.Unlock()
+
+	now := rl.now()
+	if rl.global.PerSecond > 0 && !rl.all.refill(rl.global, now) {
+		return false
+	}
+	var cb *bucket
+	if rl.perClient.PerSecond > 0 {
+		cb = rl.clients[client]
+		if cb == nil {
+			rl.pruneClients(now)
+			cb = &bucket{tokens: float64(rl.perClient.Burst), last: now}
+			rl.clients[client] = cb
+		}
+		if !cb.refill(rl.perClient, now) {
+			return false
+		}
+		cb.tokens--
+	}
+	if rl.global.PerSecond > 0 {
+		rl.all.tokens--
+	}
+	return true
+}
+
+// pruneClients removes the buckets that are full again once there are too
+// many of them.
+func (rl *RateLimiter) pruneClients(now time.Time) {
+	if len(rl.clients) < maxIdleClients {
+		return
+	}
+	for id, b := range rl.clients {
+		b.refill(rl.perClient, now)
+		if b.tokens >= float64(rl.perClient.Burst) {
+			delete(rl.clients, id)
+		}
+	}
+}
+
+// quotaRefusalMsg returns the message signed for a refusal because of the
+// quota.
+func quotaRefusalMsg(nonce []byte) []byte {
+	return append(append([]byte{}, quotaRefusalPrefix...), nonce...)
+}
@@ -0,0 +1,54 @@
+package protocol
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+	var nilLimiter *RateLimiter
+	require.True(t, nilLimiter.Allow("a"))
+
+	rl := NewRateLimiter(Rate{PerSecond: 2, Burst: 3}, Rate{PerSecond: 1, Burst: 2})
+	now := rl.all.last
+	rl.now = func() time.Time { return now }
+
+	// Per-client burst
+	require.True(t, rl.Allow("a"))
+	require.True(t, rl.Allow("a"))
+	require.False(t, rl.Allow("a"))
+
+	// Global burst, the refused client didn't take a global token
+	require.True(t, rl.Allow("b"))
+	require.False(t, rl.Allow("c"))
+
+	// One second later both buckets got new tokens
+	now = now.Add(time.Second)
+	require.True(t, rl.Allow("a"))
+	require.False(t, rl.Allow("a"))
+	require.True(t, rl.Allow("c"))
+	require.False(t, rl.Allow("c"))
+
+	// Unlimited
+	rl = NewRateLimiter(Rate{}, Rate{})
+	for i := 0; i < 100; i++ {
+		require.True(t, rl.Allow("a"))
+	}
+}
+
+func TestRateLimiter_Prune(t *testing.T) {
+	rl := NewRateLimiter(Rate{}, Rate{PerSecond: 1, Burst: 1})
+	now := rl.all.last
+	rl.now = func() time.Time { return now }
+
+	for i := 0; i < maxIdleClients; i++ {
+		require.True(t, rl.Allow(string(rune(i))))
+	}
+	require.Equal(t, maxIdleClients, len(rl.clients))
+
+	now = now.Add(time.Second)
+	require.True(t, rl.Allow("new"))
+	require.Equal(t, 1, len(rl.clients))
+}
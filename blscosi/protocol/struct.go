@@ -12,6 +12,7 @@ import (
 	"go.dedis.ch/onet/v3"
 	"go.dedis.ch/onet/v3/log"
 	"go.dedis.ch/onet/v3/network"
+	"go.dedis.ch/onet/v3/simul/monitor"
 )
 
 // DefaultProtocolName can be used from other packages to refer to this protocol.
@@ -115,6 +116,48 @@ func (sig BlsSignature) VerifyWithPolicy(ps pairing.Suite, msg []byte, publics [
 	return nil
 }
 
+// RoundMetrics holds the measurements of one round of the protocol as seen by
+// the root. All durations are measured from the start of the protocol.
+type RoundMetrics struct {
+	// Restarts is the number of subtrees that had to be restarted because
+	// the subleader didn't reply.
+	Restarts int
+	// Refusals is the number of nodes that refused to sign or that didn't
+	// reply in time.
+	Refusals int
+	// QuotaRefusals is the number of nodes that refused to sign because
+	// they reached their signing quota. They are also counted in Refusals.
+	QuotaRefusals int
+	// MaskWeight is the number of nodes present in the final signature.
+	MaskWeight int
+	// Latency is the time needed to get the final signature.
+	Latency time.Duration
+	// VerificationLatency is the time the root needed to verify the proposal.
+	// It is the latency of the first level of the tree.
+	VerificationLatency time.Duration
+	// SubtreeLatencies holds for each subtree the time needed to get the
+	// reply of its subleader, including the replies of the leaves. It is
+	// zero for subtrees that didn't reply. These are the latencies of the
+	// second level of the tree.
+	SubtreeLatencies []time.Duration
+}
+
+// Record sends the metrics to the monitor using the given prefix for the
+// names of the measures.
+func (m RoundMetrics) Record(prefix string) {
+	monitor.RecordSingleMeasure(prefix+"_restarts", float64(m.Restarts))
+	monitor.RecordSingleMeasure(prefix+"_refusals", float64(m.Refusals))
+	monitor.RecordSingleMeasure(prefix+"_quota_refusals", float64(m.QuotaRefusals))
+	monitor.RecordSingleMeasure(prefix+"_mask_weight", float64(m.MaskWeight))
+	monitor.RecordSingleMeasure(prefix+"_latency", m.Latency.Seconds())
+	monitor.RecordSingleMeasure(prefix+"_verification_latency",
+		m.VerificationLatency.Seconds())
+	for i, l := range m.SubtreeLatencies {
+		monitor.RecordSingleMeasure(fmt.Sprintf("%s_subtree_%d_latency",
+			prefix, i), l.Seconds())
+	}
+}
+
 // Announcement is the blscosi annoucement message.
 type Announcement struct {
 	Msg       []byte // statement to be signed
@@ -122,6 +165,10 @@ type Announcement struct {
 	Nonce     []byte
 	Timeout   time.Duration
 	Threshold int
+	// Seed used to build the subtrees, if any.
+	Seed []byte
+	// Client identifies the client that asked the root for the signature.
+	Client string
 }
 
 // StructAnnouncement just contains Announcement and the data necessary to identify and
@@ -135,6 +182,19 @@ type StructAnnouncement struct {
 type Response struct {
 	Signature BlsSignature
 	Mask      []byte
+	// Quotas holds the signed refusals of the nodes of the subtree that
+	// reached their signing quota.
+	Quotas []QuotaRefusal
+}
+
+// QuotaRefusal is the proof that a node refused to sign because it is over
+// its signing quota, and not because the verification failed.
+type QuotaRefusal struct {
+	// Index of the node in the roster.
+	Index int
+	// Nonce sent by the subleader.
+	Nonce     []byte
+	Signature []byte
 }
 
 // StructResponse just contains Response and the data necessary to identify and
@@ -147,6 +207,9 @@ type StructResponse struct {
 // Refusal is the signed refusal response from a given node.
 type Refusal struct {
 	Signature []byte
+	// Quota is true if the node refused because it reached its signing
+	// quota. The signature is then over the nonce prefixed with a marker.
+	Quota bool
 }
 
 // StructRefusal contains the refusal and the treenode that sent it.
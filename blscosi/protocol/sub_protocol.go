@@ -21,10 +21,13 @@ import (
 // SubBlsCosi holds the different channels used to receive the different protocol messages.
 type SubBlsCosi struct {
 	*onet.TreeNodeInstance
-	Msg            []byte
-	Data           []byte
-	Timeout        time.Duration
-	Threshold      int
+	Msg       []byte
+	Data      []byte
+	Timeout   time.Duration
+	Threshold int
+	Seed      []byte
+	// Client identifies the client that asked the root for the signature.
+	Client         string
 	stoppedOnce    sync.Once
 	verificationFn VerificationFn
 	suite          *pairing.SuiteBn256
@@ -45,6 +48,10 @@ type SubBlsCosi struct {
 	Sign      SignFn
 	Verify    VerifyFn
 	Aggregate AggregateFn
+
+	// Limiter, if set, is asked for a token before signing. Each client of
+	// each root is counted as a different client.
+	Limiter *RateLimiter
 }
 
 // NewDefaultSubProtocol is the default sub-protocol function used for registration
@@ -171,6 +178,8 @@ func (p *SubBlsCosi) waitAnnouncement(parent *onet.TreeNode) *Announcement {
 	p.Data = a.Data
 	p.Timeout = a.Timeout
 	p.Threshold = a.Threshold
+	p.Seed = a.Seed
+	p.Client = a.Client
 
 	return a
 }
@@ -200,6 +209,8 @@ func (p *SubBlsCosi) dispatchRoot() error {
 			Data:      p.Data,
 			Timeout:   p.Timeout,
 			Threshold: p.Threshold,
+			Seed:      p.Seed,
+			Client:    p.Client,
 		})
 	}()
 
@@ -266,12 +277,24 @@ func (p *SubBlsCosi) dispatchSubLeader() error {
 		}
 	}
 
-	own, err := p.makeResponse()
-	if ok := p.verificationFn(p.Msg, p.Data); ok {
+	var quotas []QuotaRefusal
+	_, ownIndex := searchPublicKey(p.TreeNodeInstance, p.ServerIdentity())
+	if !p.Limiter.Allow(p.clientKey()) {
+		log.Lvlf3("Subleader %v is over its signing quota", p.ServerIdentity())
+		r, err := p.makeQuotaRefusal(a.Nonce)
+		if err != nil {
+			return err
+		}
+		quotas = append(quotas, QuotaRefusal{Index: ownIndex, Nonce: a.Nonce,
+			Signature: r.Signature})
+	} else if ok := p.verificationFn(p.Msg, p.Data); ok {
 		log.Lvlf3("Subleader %v signed", p.ServerIdentity())
-		_, index := searchPublicKey(p.TreeNodeInstance, p.ServerIdentity())
-		if index != -1 {
-			responses[index] = own
+		own, err := p.makeResponse()
+		if err != nil {
+			return err
+		}
+		if ownIndex != -1 {
+			responses[ownIndex] = own
 		}
 	}
 
@@ -307,9 +330,17 @@ func (p *SubBlsCosi) dispatchSubLeader() error {
 			if !ok {
 				log.Warnf("Got a message from an unknown node %v", reply.ServerIdentity.ID)
 			} else if r == nil {
-				if err := p.Verify(p.suite, public, a.Nonce, reply.Signature); err == nil {
+				msg := a.Nonce
+				if reply.Quota {
+					msg = quotaRefusalMsg(a.Nonce)
+				}
+				if err := p.Verify(p.suite, public, msg, reply.Signature); err == nil {
 					// The child gives an empty signature as a mark of refusal
 					responses[pubIndex] = &Response{}
+					if reply.Quota {
+						quotas = append(quotas, QuotaRefusal{Index: pubIndex,
+							Nonce: a.Nonce, Signature: reply.Signature})
+					}
 					done++
 				} else {
 					log.Warnf("Tentative to send a unsigned refusal from %v", reply.ServerIdentity.ID)
@@ -331,6 +362,7 @@ func (p *SubBlsCosi) dispatchSubLeader() error {
 		log.Error(err)
 		return err
 	}
+	r.Quotas = quotas
 
 	log.Lvlf3("Subleader %v sent its reply with mask %b", p.ServerIdentity(), r.Mask)
 	return p.SendToParent(r)
@@ -343,6 +375,15 @@ func (p *SubBlsCosi) dispatchLeaf() error {
 		return nil
 	}
 
+	if !p.Limiter.Allow(p.clientKey()) {
+		log.Lvlf3("Leaf %v is over its signing quota", p.ServerIdentity())
+		r, err := p.makeQuotaRefusal(a.Nonce)
+		if err != nil {
+			return err
+		}
+		return p.SendToParent(r)
+	}
+
 	res := make(chan bool)
 	go p.makeVerification(res)
 
@@ -401,6 +442,21 @@ func (p *SubBlsCosi) makeRefusal(nonce []byte) (*Refusal, error) {
 	return &Refusal{Signature: sig}, err
 }
 
+// makeQuotaRefusal signs the nonce prefixed with the quota marker so that
+// the refusal can be told apart from a verification refusal.
+func (p *SubBlsCosi) makeQuotaRefusal(nonce []byte) (*Refusal, error) {
+	sig, err := p.Sign(p.suite, p.Private(), quotaRefusalMsg(nonce))
+
+	return &Refusal{Signature: sig, Quota: true}, err
+}
+
+// clientKey returns the key of the client asking for the signature. The
+// client announced by the root is prefixed with the key of the root, so that
+// a root can only spend the quota of its own clients.
+func (p *SubBlsCosi) clientKey() string {
+	return p.Root().ServerIdentity.Public.String() + "/" + p.Client
+}
+
 // makeVerification executes the verification function provided and
 // returns the result in the given channel
 func (p *SubBlsCosi) makeVerification(out chan bool) {
@@ -4,6 +4,10 @@ package blscosi
 
 import (
 	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"go.dedis.ch/cothority/v3/blscosi/protocol"
@@ -12,6 +16,8 @@ import (
 	"go.dedis.ch/onet/v3"
 	"go.dedis.ch/onet/v3/log"
 	"go.dedis.ch/onet/v3/network"
+	"go.dedis.ch/protobuf"
+	"golang.org/x/xerrors"
 )
 
 const protocolTimeout = 20 * time.Second
@@ -37,12 +43,29 @@ type Service struct {
 	Threshold int
 	NSubtrees int
 	Timeout   time.Duration
+	// BatchWindow is how long the root collects the hashes of
+	// BatchSignatureRequests before signing them.
+	BatchWindow time.Duration
+
+	metricsLock sync.Mutex
+	lastMetrics protocol.RoundMetrics
+	rounds      int
+	limiterLock sync.Mutex
+	limiter     *protocol.RateLimiter
+	batches     batches
 }
 
 // SignatureRequest is what the Cosi service is expected to receive from clients.
 type SignatureRequest struct {
 	Message []byte
 	Roster  *onet.Roster
+	// Seed is optional. If it is given, the subtrees are built from it, so
+	// that a round can be reproduced with the same topology.
+	Seed []byte `protobuf:"opt"`
+	// Participants is optional. If it is given, only the nodes of the roster
+	// whose bit is set in this mask are asked to sign. The signature is then
+	// verified with BlsSignature.VerifyParticipants.
+	Participants []byte `protobuf:"opt"`
 }
 
 // SignatureResponse is what the Cosi service will reply to clients.
@@ -51,8 +74,70 @@ type SignatureResponse struct {
 	Signature protocol.BlsSignature
 }
 
-// SignatureRequest treats external request to this service.
+// errOverQuota is returned to the clients that asked for more signatures
+// than allowed by the rate limits of the root.
+var errOverQuota = errors.New("over signing quota")
+
+// ProcessClientRequest implements onet.Service. We override the version
+// we normally get from embedding onet.ServiceProcessor in order to hook it
+// and limit the signatures asked by every client, which is identified by
+// its address.
+func (s *Service) ProcessClientRequest(req *http.Request, path string, buf []byte) ([]byte, *onet.StreamingTunnel, error) {
+	if path != "SignatureRequest" && path != "BatchSignatureRequest" {
+		return s.ServiceProcessor.ProcessClientRequest(req, path, buf)
+	}
+	client, err := clientAddress(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.getLimiter().Allow(client) {
+		return nil, nil, errOverQuota
+	}
+	if path == "BatchSignatureRequest" {
+		// The batch is signed once for all its clients.
+		return s.ServiceProcessor.ProcessClientRequest(req, path, buf)
+	}
+
+	sr := &SignatureRequest{}
+	err = protobuf.DecodeWithConstructors(buf, sr,
+		network.DefaultConstructors(s.Context.Suite()))
+	if err != nil {
+		return nil, nil, errors.New("couldn't decode request: " + err.Error())
+	}
+	reply, err := s.signatureRequest(sr, client)
+	if err != nil {
+		return nil, nil, err
+	}
+	buf, err = protobuf.Encode(reply)
+	if err != nil {
+		return nil, nil, errors.New("couldn't encode reply: " + err.Error())
+	}
+	return buf, nil, nil
+}
+
+// clientAddress returns the host of the client sending the request, without
+// the port, so that a client cannot get a new quota by reconnecting.
+func clientAddress(req *http.Request) (string, error) {
+	if req == nil {
+		return "", errors.New("missing client request")
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return "", errors.New("invalid client address: " + err.Error())
+	}
+	return host, nil
+}
+
+// SignatureRequest treats external request to this service. The requests
+// of the clients are limited in ProcessClientRequest, while the requests of
+// the other services of this node are only limited by the other nodes.
 func (s *Service) SignatureRequest(req *SignatureRequest) (network.Message, error) {
+	return s.signatureRequest(req, "")
+}
+
+// signatureRequest runs the signature of the request for the given client,
+// which is announced to the other nodes for their rate limits.
+func (s *Service) signatureRequest(req *SignatureRequest, client string) (network.Message, error) {
 	// generate the tree
 	nNodes := len(req.Roster.List)
 	rooted := req.Roster.NewRosterWithRoot(s.ServerIdentity())
@@ -73,6 +158,16 @@ func (s *Service) SignatureRequest(req *SignatureRequest) (network.Message, erro
 	p.CreateProtocol = s.CreateProtocol
 	p.Timeout = s.Timeout
 	p.Msg = req.Message
+	p.Seed = req.Seed
+	p.Client = client
+	if len(req.Participants) > 0 {
+		// The mask of the request is over the roster of the client.
+		participants := rosterMask(req.Participants, req.Roster, rooted)
+		if err := p.SetParticipants(participants); err != nil {
+			p.Done()
+			return nil, err
+		}
+	}
 
 	// Threshold before the subtrees so that we can optimize situation
 	// like a threshold of one
@@ -96,6 +191,16 @@ func (s *Service) SignatureRequest(req *SignatureRequest) (network.Message, erro
 
 	// wait for reply. This will always eventually return.
 	sig := <-p.FinalSignature
+	s.storeMetrics(p.Metrics())
+	if len(sig) == 0 {
+		return nil, xerrors.New("couldn't get a valid signature of the roster")
+	}
+	// The mask of the signature is over the roster with this node as the
+	// root, and the client expects it over its own roster.
+	if lenSig := s.suite.G1().PointLen(); len(sig) > lenSig {
+		mask := rosterMask(sig[lenSig:], rooted, req.Roster)
+		sig = append(sig[:lenSig:lenSig], mask...)
+	}
 
 	// The hash is the message blscosi actually signs, we recompute it the
 	// same way as blscosi and then return it.
@@ -104,6 +209,72 @@ func (s *Service) SignatureRequest(req *SignatureRequest) (network.Message, erro
 	return &SignatureResponse{h.Sum(nil), sig}, nil
 }
 
+// rosterMask returns the mask over the roster to with the bits of the nodes
+// that are set in the mask over the roster from. Both rosters must hold the
+// same nodes. The bits after the nodes are kept at the same place.
+func rosterMask(mask []byte, from, to *onet.Roster) []byte {
+	out := make([]byte, len(mask))
+	for i := 0; i < len(mask)*8; i++ {
+		if mask[i/8]&(1<<uint(i&7)) == 0 {
+			continue
+		}
+		j := i
+		if i < len(from.List) {
+			j, _ = to.Search(from.List[i].ID)
+		}
+		out[j/8] |= 1 << uint(j&7)
+	}
+	return out
+}
+
+// SetRateLimit limits the number of signatures this node produces, over all
+// clients with global and for every client with perClient. As the root, the
+// node refuses the requests of the clients over their quota. Else it sends a
+// signed refusal that is counted in the QuotaRefusals of the metrics. A zero
+// Rate disables the corresponding limit.
+func (s *Service) SetRateLimit(global, perClient protocol.Rate) {
+	s.limiterLock.Lock()
+	s.limiter = protocol.NewRateLimiter(global, perClient)
+	s.limiterLock.Unlock()
+}
+
+func (s *Service) getLimiter() *protocol.RateLimiter {
+	s.limiterLock.Lock()
+	defer s.limiterLock.Unlock()
+	return s.limiter
+}
+
+// LastMetrics returns the measurements of the latest round started by this
+// node.
+func (s *Service) LastMetrics() protocol.RoundMetrics {
+	s.metricsLock.Lock()
+	defer s.metricsLock.Unlock()
+	return s.lastMetrics
+}
+
+// GetStatus implements onet.StatusReporter and returns the metrics of the
+// latest round started by this node.
+func (s *Service) GetStatus() *onet.Status {
+	s.metricsLock.Lock()
+	defer s.metricsLock.Unlock()
+	m := s.lastMetrics
+	return &onet.Status{Field: map[string]string{
+		"Rounds":            strconv.Itoa(s.rounds),
+		"LastRestarts":      strconv.Itoa(m.Restarts),
+		"LastRefusals":      strconv.Itoa(m.Refusals),
+		"LastQuotaRefusals": strconv.Itoa(m.QuotaRefusals),
+		"LastMaskWeight":    strconv.Itoa(m.MaskWeight),
+		"LastLatency":       m.Latency.String(),
+	}}
+}
+
+func (s *Service) storeMetrics(m protocol.RoundMetrics) {
+	s.metricsLock.Lock()
+	s.lastMetrics = m
+	s.rounds++
+	s.metricsLock.Unlock()
+}
+
 // NewProtocol is called on all nodes of a Tree (except the root, since it is
 // the one starting the protocol) so it's the Service that will be called to
 // generate the PI on all others node.
@@ -113,7 +284,12 @@ func (s *Service) NewProtocol(tn *onet.TreeNodeInstance, conf *onet.GenericConfi
 	case protocol.DefaultProtocolName:
 		return protocol.NewDefaultProtocol(tn)
 	case protocol.DefaultSubProtocolName:
-		return protocol.NewDefaultSubProtocol(tn)
+		pi, err := protocol.NewDefaultSubProtocol(tn)
+		if err != nil {
+			return nil, err
+		}
+		pi.(*protocol.SubBlsCosi).Limiter = s.getLimiter()
+		return pi, nil
 	}
 	return nil, errors.New("no such protocol " + tn.ProtocolName())
 }
@@ -123,12 +299,14 @@ func newCoSiService(c *onet.Context) (onet.Service, error) {
 		ServiceProcessor: onet.NewServiceProcessor(c),
 		suite:            suite,
 		Timeout:          protocolTimeout,
+		BatchWindow:      defaultBatchWindow,
 	}
 
-	if err := s.RegisterHandler(s.SignatureRequest); err != nil {
+	if err := s.RegisterHandlers(s.SignatureRequest, s.BatchSignatureRequest); err != nil {
 		log.Error("couldn't register message:", err)
 		return nil, err
 	}
+	s.RegisterStatusReporter("BlsCosi", s)
 
 	return s, nil
 }
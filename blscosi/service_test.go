@@ -1,13 +1,18 @@
 package blscosi
 
 import (
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3/blscosi/protocol"
 	"go.dedis.ch/kyber/v3/pairing"
 	"go.dedis.ch/kyber/v3/sign"
 	"go.dedis.ch/onet/v3"
 	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"go.dedis.ch/protobuf"
 )
 
 var testSuite = pairing.NewSuiteBn256()
@@ -68,3 +73,138 @@ func TestService_SignatureRequest(t *testing.T) {
 	// verify the response still
 	require.Nil(t, res.Signature.VerifyWithPolicy(testSuite, msg, publics, sign.NewThresholdPolicy(1)))
 }
+
+func TestService_Participants(t *testing.T) {
+	local := onet.NewTCPTest(testSuite)
+	hosts, roster, _ := local.GenTree(5, false)
+	defer local.CloseAll()
+
+	// The request is sent to a node that is not the first of the roster,
+	// and the masks are over the roster of the request.
+	service := hosts[2].Service(ServiceName).(*Service)
+	service.NSubtrees = 1
+	participants := []byte{0x1e} // 1, 2, 3, 4
+	msg := []byte("participants")
+	reply, err := service.SignatureRequest(&SignatureRequest{
+		Roster:       roster,
+		Message:      msg,
+		Participants: participants,
+	})
+	require.NoError(t, err)
+	sig := reply.(*SignatureResponse).Signature
+
+	publics := roster.ServicePublics(ServiceName)
+	require.NoError(t, sig.VerifyParticipants(testSuite, msg, publics, participants))
+	mask, err := sig.GetMask(testSuite, publics)
+	require.NoError(t, err)
+	// The root signed, at its place in the roster of the request.
+	require.Equal(t, byte(0x04), mask.Mask()[0]&0x05)
+	require.Error(t, sig.VerifyParticipants(testSuite, msg, publics, []byte{0x1a}))
+	require.Error(t, sig.VerifyParticipants(testSuite, msg, publics, []byte{0}))
+
+	// Without participants, the mask is also over the roster of the request.
+	reply, err = service.SignatureRequest(&SignatureRequest{
+		Roster:  roster,
+		Message: msg,
+	})
+	require.NoError(t, err)
+	sig = reply.(*SignatureResponse).Signature
+	require.NoError(t, sig.Verify(testSuite, msg, publics))
+}
+
+func TestService_RateLimit(t *testing.T) {
+	local := onet.NewTCPTest(testSuite)
+	hosts, roster, _ := local.GenTree(5, false)
+	defer local.CloseAll()
+
+	services := make([]*Service, len(hosts))
+	for i, h := range hosts {
+		services[i] = h.Service(ServiceName).(*Service)
+	}
+	root := services[0]
+	root.NSubtrees = 1
+	root.Threshold = 3
+	// Two of the nodes can only sign once for each root.
+	for _, s := range services[1:3] {
+		s.SetRateLimit(protocol.Rate{}, protocol.Rate{PerSecond: 1e-3, Burst: 1})
+	}
+
+	req := &SignatureRequest{Roster: roster, Message: []byte("limited")}
+	_, err := root.SignatureRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, 0, root.LastMetrics().QuotaRefusals)
+	require.Equal(t, 5, root.LastMetrics().MaskWeight)
+
+	buf, err := root.SignatureRequest(req)
+	require.NoError(t, err)
+	m := root.LastMetrics()
+	require.Equal(t, 2, m.QuotaRefusals)
+	require.Equal(t, 2, m.Refusals)
+	require.Equal(t, 3, m.MaskWeight)
+	publics := roster.ServicePublics(ServiceName)
+	sig := buf.(*SignatureResponse).Signature
+	require.NoError(t, sig.VerifyWithPolicy(testSuite, req.Message, publics,
+		sign.NewThresholdPolicy(3)))
+
+	// The clients of the root are limited one by one.
+	reqBuf, err := protobuf.Encode(req)
+	require.NoError(t, err)
+	clientA := &http.Request{RemoteAddr: "10.0.0.1:2000"}
+	clientB := &http.Request{RemoteAddr: "10.0.0.2:2000"}
+	_, _, err = root.ProcessClientRequest(clientA, "SignatureRequest", reqBuf)
+	require.NoError(t, err)
+	require.Equal(t, 0, root.LastMetrics().QuotaRefusals)
+	_, _, err = root.ProcessClientRequest(clientA, "SignatureRequest", reqBuf)
+	require.NoError(t, err)
+	require.Equal(t, 2, root.LastMetrics().QuotaRefusals)
+	// Another port of the same host is the same client.
+	clientA.RemoteAddr = "10.0.0.1:2001"
+	_, _, err = root.ProcessClientRequest(clientA, "SignatureRequest", reqBuf)
+	require.NoError(t, err)
+	require.Equal(t, 2, root.LastMetrics().QuotaRefusals)
+	_, _, err = root.ProcessClientRequest(clientB, "SignatureRequest", reqBuf)
+	require.NoError(t, err)
+	require.Equal(t, 0, root.LastMetrics().QuotaRefusals)
+
+	// The root refuses requests over the quota of the client.
+	root.SetRateLimit(protocol.Rate{}, protocol.Rate{PerSecond: 1e-3, Burst: 1})
+	_, _, err = root.ProcessClientRequest(clientA, "SignatureRequest", reqBuf)
+	require.NoError(t, err)
+	_, _, err = root.ProcessClientRequest(clientA, "SignatureRequest", reqBuf)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "over signing quota")
+	_, _, err = root.ProcessClientRequest(clientB, "SignatureRequest", reqBuf)
+	require.NoError(t, err)
+}
+
+func TestService_InvalidSignature(t *testing.T) {
+	local := onet.NewTCPTest(testSuite)
+	hosts, roster, _ := local.GenTree(4, false)
+	defer local.CloseAll()
+
+	// The last node of the roster announces another key than its own, so
+	// the protocol cannot get a valid signature, and the service must return
+	// an error instead of an empty signature.
+	list := append([]*network.ServerIdentity{}, roster.List...)
+	si := *list[3]
+	si.ServiceIdentities = []network.ServiceIdentity{network.NewServiceIdentity(
+		ServiceName, testSuite, testSuite.G2().Point().Pick(testSuite.RandomStream()), nil)}
+	list[3] = &si
+	wrong := onet.NewRoster(list)
+
+	service := hosts[0].Service(ServiceName).(*Service)
+	service.Threshold = len(list)
+	service.Timeout = time.Second
+	_, err := service.SignatureRequest(&SignatureRequest{
+		Roster:  wrong,
+		Message: []byte("invalid"),
+	})
+	require.Error(t, err)
+
+	service.BatchWindow = 10 * time.Millisecond
+	_, err = service.BatchSignatureRequest(&BatchSignatureRequest{
+		Roster: wrong,
+		Hash:   []byte("invalid"),
+	})
+	require.Error(t, err)
+}
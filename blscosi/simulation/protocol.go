@@ -149,6 +149,7 @@ func (s *SimulationProtocol) Run(config *onet.SimulationConfig) error {
 
 		mask, err := serviceReply.Signature.GetMask(suite, publics)
 		monitor.RecordSingleMeasure("correct_nodes", float64(mask.CountEnabled()))
+		blscosiService.LastMetrics().Record("blscosi")
 
 		log.Lvl2("Signature correctly verified!")
 	}
@@ -142,12 +142,12 @@ func (p *Paginate) handlePages(ctx context.Context, numPages int, wire *wire,
 		}
 	}
 
-	if len(block.ForwardLink) == 0 {
+	if block.GetForwardLen() == 0 {
 		log.Lvl1("we're at the end of the chain")
 		return nil, nil
 	}
 
-	return block.ForwardLink[0].To, nil
+	return block.GetForward(0).To, nil
 }
 
 // checkError checks the paginate response for error. In case we reach the end
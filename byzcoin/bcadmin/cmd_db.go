@@ -64,9 +64,9 @@ func dbCatchup(c *cli.Context) error {
 	log.Info("Search for latest block in local db")
 	latestID := *fb.bcID
 	lastIndex := 0
-	for next := fb.db.GetByID(latestID); next != nil && len(next.ForwardLink) > 0; next = fb.db.GetByID(latestID) {
+	for next := fb.db.GetByID(latestID); next != nil && next.GetForwardLen() > 0; next = fb.db.GetByID(latestID) {
 		lastIndex = next.Index
-		latestID = next.ForwardLink[0].To
+		latestID = next.GetForward(0).To
 		if next.Index%1000 == 0 {
 			log.Info("Found block", next.Index)
 		}
@@ -82,7 +82,7 @@ func dbCatchup(c *cli.Context) error {
 		if sb == nil {
 			break
 		}
-		if len(sb.ForwardLink) == 0 {
+		if sb.GetForwardLen() == 0 {
 			if askAllNodes {
 				// If no further blocks exist,
 				// and no node is given on the command-line,
@@ -103,7 +103,7 @@ func dbCatchup(c *cli.Context) error {
 		} else {
 			fb.index = 0
 		}
-		latestID = sb.ForwardLink[0].To
+		latestID = sb.GetForward(0).To
 	}
 
 	log.Info("Downloaded all available blocks from the chain")
@@ -340,8 +340,8 @@ func dbMerge(c *cli.Context) error {
 			log.Infof("Stopping after applying %d blocks", copyBlocks)
 			break
 		}
-		if len(sb.ForwardLink) > 0 {
-			sb = dbBack.GetByID(sb.ForwardLink[0].To)
+		if sb.GetForwardLen() > 0 {
+			sb = dbBack.GetByID(sb.GetForward(0).To)
 		} else {
 			break
 		}
@@ -372,7 +372,7 @@ func dbReset(c *cli.Context) error {
 			}
 			sb = fb.db.GetByID(previous)
 			log.Info("Found dangling forward-link in block", sb.Index)
-			sb.ForwardLink = []*skipchain.ForwardLink{}
+			sb.RemoveForwardLinks(0)
 			err = fb.db.RemoveBlock(previous)
 			if err != nil {
 				return xerrors.Errorf("couldn't remove block: %v", err)
@@ -381,11 +381,11 @@ func dbReset(c *cli.Context) error {
 			log.Info("DB updated")
 			break
 		}
-		if len(sb.ForwardLink) == 0 {
+		if sb.GetForwardLen() == 0 {
 			return xerrors.New("no dangling forward-links")
 		}
 		previous = latest
-		latest = sb.ForwardLink[len(sb.ForwardLink)-1].To
+		latest = sb.GetForward(sb.GetForwardLen() - 1).To
 	}
 
 	return nil
@@ -452,7 +452,7 @@ func dbRemove(c *cli.Context) error {
 			if err := fb.db.RemoveBlock(bl); err != nil {
 				return fmt.Errorf("couldn't remove block: %v", err)
 			}
-			tmp.ForwardLink = tmp.ForwardLink[:i]
+			tmp.RemoveForwardLinks(i)
 			fb.db.Store(tmp)
 		}
 		latest = fb.db.GetByID(latest.BackLinkIDs[0])
@@ -478,10 +478,10 @@ func dbCheck(c *cli.Context) error {
 	sb := fb.db.GetByID(*fb.bcID)
 	start := c.Int("start")
 	for sb.Index < start {
-		if len(sb.ForwardLink) == 0 {
+		if sb.GetForwardLen() == 0 {
 			return xerrors.Errorf("cannot find block %d", start)
 		}
-		sb = fb.db.GetByID(sb.ForwardLink[0].To)
+		sb = fb.db.GetByID(sb.GetForward(0).To)
 	}
 
 	last, err := fb.db.GetLatestByID(sb.SkipChainID())
@@ -522,13 +522,13 @@ func dbCheck(c *cli.Context) error {
 						"%s pointing to unknown block", errStrBl)
 					continue
 				}
-				if len(previous.ForwardLink) <= i {
+				if previous.GetForwardLen() <= i {
 					continue
 				}
-				if previous.ForwardLink[i].IsEmpty() {
+				if previous.GetForward(i).IsEmpty() {
 					continue
 				}
-				if !previous.ForwardLink[i].To.Equal(sb.Hash) {
+				if !previous.GetForward(i).To.Equal(sb.Hash) {
 					log.Errorf(
 						"%s pointing backwards to a block that references"+
 							" another block, which indicates a fork", errStrBl)
@@ -546,13 +546,13 @@ func dbCheck(c *cli.Context) error {
 			}
 			errStrFl := fmt.Sprintf("%s forwardLink at height %d", errStr, i)
 
-			if i >= sb.GetForwardLen() || sb.ForwardLink[i].IsEmpty() {
+			if i >= sb.GetForwardLen() || sb.GetForward(i).IsEmpty() {
 				log.Warnf("%s missing: should point to %d", errStrFl,
 					index)
 				continue
 			}
 
-			fl := sb.ForwardLink[i]
+			fl := sb.GetForward(i)
 			if !fl.From.Equal(sb.Hash) {
 				log.Errorf(
 					"%s not originating from itself", errStrFl)
@@ -573,9 +573,9 @@ func dbCheck(c *cli.Context) error {
 			}
 
 			if !skipSig {
-				err := fl.VerifyWithScheme(pairing.NewSuiteBn256(),
+				err := fl.VerifyWithThreshold(pairing.NewSuiteBn256(),
 					sb.Roster.ServicePublics(skipchain.ServiceName),
-					sb.SignatureScheme)
+					sb.SignatureScheme, sb.SignatureThreshold)
 				if err != nil {
 					log.Errorf("%s fails signature verification: %+v",
 						errStrFl, err)
@@ -598,8 +598,8 @@ func dbCheck(c *cli.Context) error {
 		}
 
 		// Get lowest forwardLink available.
-		if len(sb.ForwardLink) > 0 {
-			for i, fl := range sb.ForwardLink {
+		if sb.GetForwardLen() > 0 {
+			for i, fl := range sb.GetForwardLinks() {
 				if !fl.IsEmpty() {
 					if i > 0 {
 						log.Errorf("%s has empty level-0 forward link, "+
@@ -847,7 +847,13 @@ func (fb *fetchBlocks) openDB(name string) (*skipchain.SkipBlockDB,
 	if err != nil {
 		return nil, nil, xerrors.Errorf("couldn't create bucket: %+v", err)
 	}
-	return skipchain.NewSkipBlockDB(db, bucketName), db, nil
+	sbdb := skipchain.NewSkipBlockDB(db, bucketName)
+	if err := sbdb.CheckLayout(); err != nil {
+		db.Close()
+		return nil, nil, xerrors.Errorf("%v: start the conode once to "+
+			"convert them", err)
+	}
+	return sbdb, db, nil
 }
 
 func (fb *fetchBlocks) setNode(i int) {
@@ -922,13 +928,13 @@ func (fb *fetchBlocks) gbSingle(blockID skipchain.SkipBlockID) (
 			if err != nil {
 				return nil, xerrors.Errorf("couldn't store blocks: %+v", err)
 			}
-			if len(sb.ForwardLink) == 0 {
+			if sb.GetForwardLen() == 0 {
 				return sb, nil
 			}
 			break
 		}
-		if sb != nil && !sb.ForwardLink[0].To.Equal(blockID) {
-			blockID = sb.ForwardLink[0].To
+		if sb != nil && !sb.GetForward(0).To.Equal(blockID) {
+			blockID = sb.GetForward(0).To
 		} else {
 			return sb, xerrors.New("couldn't fetch next block")
 		}
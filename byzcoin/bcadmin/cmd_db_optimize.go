@@ -92,7 +92,7 @@ func (dba *dbOptArgs) getNextBlock() error {
 	}
 
 	fb := dba.fb
-	nextSB := fb.db.GetByID(currentSB.ForwardLink[0].To)
+	nextSB := fb.db.GetByID(currentSB.GetForward(0).To)
 	if nextSB != nil {
 		dba.currentSB = nextSB
 		return nil
@@ -104,7 +104,7 @@ func (dba *dbOptArgs) getNextBlock() error {
 				"cannot skip missing block %d because no forward links"+
 					" available", currentSB.Index+1)
 		}
-		for _, fl := range currentSB.ForwardLink {
+		for _, fl := range currentSB.GetForwardLinks() {
 			if currentSB = fb.db.GetByID(fl.To); currentSB != nil {
 				dba.currentSB = currentSB
 				return nil
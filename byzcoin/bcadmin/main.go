@@ -1099,7 +1099,7 @@ func debugBlock(c *cli.Context) error {
 			blinks = append(blinks, fmt.Sprintf("\t\tTo: %x", l))
 		}
 		var flinks []string
-		for _, l := range sb.ForwardLink {
+		for _, l := range sb.GetForwardLinks() {
 			flinks = append(flinks, fmt.Sprintf("\t\tTo: %x - NewRoster: %t",
 				l.To, l.NewRoster != nil))
 		}
@@ -35,14 +35,14 @@ func NewProof(c ReadOnlyStateTrie, s *skipchain.SkipBlockDB, id skipchain.SkipBl
 		To:        id,
 		NewRoster: sb.Roster,
 	}}
-	for len(sb.ForwardLink) > 0 && sb.Index < c.GetIndex() {
+	for sb.GetForwardLen() > 0 && sb.Index < c.GetIndex() {
 		var link *skipchain.ForwardLink
 		// Corner-case when the database is downloading blocks and a proof is
 		// requested before all blocks are stored - then we need to make sure that
 		// we don't get the latest block, but the block corresponding to the
 		// StateTrie.Index
-		for height := len(sb.ForwardLink) - 1; height >= 0; height-- {
-			link = sb.ForwardLink[height]
+		for height := sb.GetForwardLen() - 1; height >= 0; height-- {
+			link = sb.GetForward(height)
 			sbTemp := s.GetByID(link.To)
 			if sbTemp == nil {
 				log.Warnf("Found block %d with invalid forward-link at level"+
@@ -137,7 +137,8 @@ func (p Proof) Verify(sbID skipchain.SkipBlockID) error {
 	publics := p.Links[0].NewRoster.ServicePublics(skipchain.ServiceName)
 
 	for _, l := range p.Links[1:] {
-		if err = l.VerifyWithScheme(pairing.NewSuiteBn256(), publics, p.Latest.SignatureScheme); err != nil {
+		if err = l.VerifyWithThreshold(pairing.NewSuiteBn256(), publics, p.Latest.SignatureScheme,
+			p.Latest.SignatureThreshold); err != nil {
 			return cothority.WrapError(ErrorVerifySkipchain)
 		}
 		if !l.From.Equal(sbID) {
@@ -73,6 +73,14 @@ var ByzCoinID onet.ServiceID
 // Verify is the verifier ID for ByzCoin skipchains.
 var Verify = skipchain.VerifierID(uuid.NewV5(uuid.NamespaceURL, "ByzCoin"))
 
+// genesisTemplate holds the parameters of the ByzCoin skipchains. The
+// verification functions have to be registered in the genesis block.
+var genesisTemplate = skipchain.ChainTemplate{
+	BaseHeight:    4,
+	MaximumHeight: 32,
+	VerifierIDs:   []skipchain.VerifierID{skipchain.VerifyBase, Verify},
+}
+
 func init() {
 	var err error
 	ByzCoinID, err = onet.RegisterNewServiceWithSuite(ServiceName, pairingSuite, newService)
@@ -179,6 +187,11 @@ var storageID = []byte("ByzCoin")
 // transaction is not set.
 const defaultInterval = 5 * time.Second
 
+// verifyTimeout is how long the verification of a block may take. Replaying
+// the transactions of a block takes longer than the default timeout of the
+// skipchain verifiers when the conode is loaded.
+const verifyTimeout = time.Minute
+
 // defaultMaxBlockSize is used when the config cannot be loaded.
 const defaultMaxBlockSize = 4 * 1e6
 
@@ -683,7 +696,7 @@ func (s *Service) GetUpdates(pr *GetUpdatesRequest) (*GetUpdatesReply, error) {
 		if sb == nil {
 			return nil, xerrors.New("cannot find skipblock while getting proof")
 		}
-		if len(sb.ForwardLink) > 0 {
+		if sb.GetForwardLen() > 0 {
 			return nil, xerrors.New("can only give proofs for latest block")
 		}
 		scID = sb.SkipChainID()
@@ -1081,11 +1094,7 @@ func (s *Service) createNewBlock(scID skipchain.SkipBlockID, r *onet.Roster, tx
 		if r == nil {
 			return nil, xerrors.New("need roster for genesis block")
 		}
-		sb = skipchain.NewSkipBlock()
-		sb.MaximumHeight = 32
-		sb.BaseHeight = 4
-		// We have to register the verification functions in the genesis block
-		sb.VerifierIDs = []skipchain.VerifierID{skipchain.VerifyBase, Verify}
+		sb = genesisTemplate.NewGenesis(r)
 
 		nonce, err := loadNonceFromTxs(tx)
 		if err != nil {
@@ -3014,8 +3023,8 @@ func (s *Service) repairStateTrie(from *skipchain.SkipBlock, st *stateTrie) erro
 	// Try to do the repair until we have no more forward links.
 	log.Warn(s.ServerIdentity(), "repairing state trie from a known state")
 	var cnt int
-	for len(from.ForwardLink) > 0 {
-		from = s.db().GetByID(from.ForwardLink[0].To)
+	for from.GetForwardLen() > 0 {
+		from = s.db().GetByID(from.GetForward(0).To)
 		if from == nil {
 			return xerrors.New("missing skipblocks")
 		}
@@ -3107,7 +3116,7 @@ func newService(c *onet.Context) (onet.Service, error) {
 	}
 	s.RegisterProcessorFunc(viewChangeMsgID, s.handleViewChangeReq)
 
-	if err := skipchain.RegisterVerification(c, Verify, s.verifySkipBlock); err != nil {
+	if err := skipchain.RegisterVerificationTimeout(c, Verify, s.verifySkipBlock, verifyTimeout); err != nil {
 		log.ErrFatal(err)
 	}
 
@@ -161,7 +161,7 @@ func (s *Service) ReplayState(id skipchain.SkipBlockID,
 			}
 
 			log.Lvl2("Checking links for block", sb.Index)
-			for j, fl := range sb.ForwardLink {
+			for j, fl := range sb.GetForwardLinks() {
 				var errStr string
 				if fl.From == nil || fl.To == nil ||
 					len(fl.From) == 0 || len(fl.To) == 0 {
@@ -174,14 +174,15 @@ func (s *Service) ReplayState(id skipchain.SkipBlockID,
 				}
 				if errStr != "" {
 					rlog.LogWarn(sb, fmt.Sprintf(
-						"bad forward-link %d/%d: %s", j, len(sb.ForwardLink),
+						"bad forward-link %d/%d: %s", j, sb.GetForwardLen(),
 						errStr), fmt.Sprintf("%+v", fl))
 					continue
 				}
 
 				if opt.VerifyFLSig {
 					pubs := sb.Roster.ServicePublics(skipchain.ServiceName)
-					err = fl.VerifyWithScheme(pairing.NewSuiteBn256(), pubs, sb.SignatureScheme)
+					err = fl.VerifyWithThreshold(pairing.NewSuiteBn256(), pubs, sb.SignatureScheme,
+						sb.SignatureThreshold)
 					if err != nil {
 						log.Errorf("Found error in forward-link: '%s' - #%d: %+v", err, j, fl)
 						return nil, xerrors.Errorf("invalid forward-link: %v", err)
@@ -196,12 +197,12 @@ func (s *Service) ReplayState(id skipchain.SkipBlockID,
 			rlog.LogAppliedBlock(sb, dHead, dBody)
 		}
 
-		if len(sb.ForwardLink) == 0 {
+		if sb.GetForwardLen() == 0 {
 			break
 		} else {
 			// The level 0 forward link must be used as we need to rebuild the global
 			// states for each block.
-			sb = s.db().GetByID(sb.ForwardLink[0].To)
+			sb = s.db().GetByID(sb.GetForward(0).To)
 			if sb == nil {
 				return nil, errors.New("replay failed to get the next block")
 			}
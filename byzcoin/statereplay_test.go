@@ -50,7 +50,18 @@ func forceStoreBlock(s *BCTest, sb *skipchain.SkipBlock) error {
 			return err
 		}
 		sb.Hash = sb.CalculateHash()
-		return tx.Bucket([]byte("Skipchain_skipblocks")).Put(sb.Hash, buf)
+		// The blocks are stored in one bucket per skipchain, with an index
+		// from the blocks to their skipchains.
+		chain, err := tx.Bucket([]byte("Skipchain_skipblocks")).
+			CreateBucketIfNotExists(sb.SkipChainID())
+		if err != nil {
+			return err
+		}
+		if err := chain.Put(sb.Hash, buf); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("Skipchain_skipblocks_index")).
+			Put(sb.Hash, sb.SkipChainID())
 	})
 }
 
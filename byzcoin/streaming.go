@@ -183,8 +183,8 @@ func (s *Service) PaginateBlocks(msg *PaginateRequest) (chan *PaginateResponse,
 					nextID = nil
 				}
 			} else {
-				if len(skipBlock.ForwardLink) != 0 {
-					nextID = skipBlock.ForwardLink[0].To
+				if skipBlock.GetForwardLen() != 0 {
+					nextID = skipBlock.GetForward(0).To
 				} else {
 					nextID = nil
 				}
@@ -222,8 +222,8 @@ func (s *Service) PaginateBlocks(msg *PaginateRequest) (chan *PaginateResponse,
 						nextID = nil
 					}
 				} else {
-					if len(skipBlock.ForwardLink) != 0 {
-						nextID = skipBlock.ForwardLink[0].To
+					if skipBlock.GetForwardLen() != 0 {
+						nextID = skipBlock.GetForward(0).To
 					} else {
 						nextID = nil
 					}
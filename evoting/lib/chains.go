@@ -15,19 +15,21 @@ import (
 	"go.dedis.ch/cothority/v3/skipchain"
 )
 
+// chainTemplate holds the parameters of the election and master skipchains.
+var chainTemplate = skipchain.ChainTemplate{
+	BaseHeight:    8,
+	MaximumHeight: 4,
+	VerifierIDs:   []skipchain.VerifierID{skipchain.VerifyBase, TransactionVerifierID},
+}
+
 // NewSkipchain creates a new skipchain for a given roster and verification function.
 func NewSkipchain(s *skipchain.Service, roster *onet.Roster, testMode bool) (
 	*skipchain.SkipBlock, error) {
-	block := skipchain.NewSkipBlock()
-	block.Roster = roster
-	block.BaseHeight = 8
-	block.MaximumHeight = 4
-	verifier := []skipchain.VerifierID{skipchain.VerifyBase, TransactionVerifierID}
+	ct := chainTemplate
 	if testMode {
-		verifier = skipchain.VerificationStandard
+		ct.VerifierIDs = skipchain.VerificationStandard
 	}
-	block.VerifierIDs = verifier
-	block.Data = []byte{}
+	block := ct.NewGenesis(roster)
 
 	reply, err := s.StoreSkipBlockInternal(&skipchain.StoreSkipBlock{
 		NewBlock: block,
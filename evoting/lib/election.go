@@ -58,7 +58,7 @@ func GetElection(s *skipchain.Service, id skipchain.SkipBlockID,
 		}
 
 		// Stop looping at the end of the chain.
-		if len(search.SkipBlock.ForwardLink) == 0 {
+		if search.SkipBlock.GetForwardLen() == 0 {
 			break
 		}
 		// otherwise try the next index.
@@ -90,10 +90,10 @@ func (e *Election) setVoted(s *skipchain.Service, user uint32) error {
 	for {
 		transaction := UnmarshalTransaction(block.Data)
 		if transaction == nil {
-			if len(block.ForwardLink) == 0 {
+			if block.GetForwardLen() == 0 {
 				break
 			}
-			block = db.GetByID(block.ForwardLink[0].To)
+			block = db.GetByID(block.GetForward(0).To)
 			continue
 		}
 		if transaction.Ballot != nil && transaction.Ballot.User == user {
@@ -102,10 +102,10 @@ func (e *Election) setVoted(s *skipchain.Service, user uint32) error {
 		if transaction.Mix != nil || transaction.Partial != nil {
 			break
 		}
-		if len(block.ForwardLink) == 0 {
+		if block.GetForwardLen() == 0 {
 			break
 		}
-		block = db.GetByID(block.ForwardLink[0].To)
+		block = db.GetByID(block.GetForward(0).To)
 	}
 	return nil
 }
@@ -156,12 +156,12 @@ func (e *Election) Box(s *skipchain.Service) (*Box, error) {
 			ballots = append(ballots, transaction.Ballot)
 		}
 
-		if len(block.ForwardLink) <= 0 {
+		if block.GetForwardLen() <= 0 {
 			break
 		}
 		block, _ = s.GetSingleBlock(
 			&skipchain.GetSingleBlock{
-				ID: block.ForwardLink[0].To,
+				ID: block.GetForward(0).To,
 			})
 	}
 
@@ -56,11 +56,11 @@ func (m *Master) Links(s *skipchain.Service) ([]*Link, error) {
 			links = append(links, transaction.Link)
 		}
 
-		if len(block.ForwardLink) <= 0 {
+		if block.GetForwardLen() <= 0 {
 			break
 		}
 		block, _ = s.GetSingleBlock(
-			&skipchain.GetSingleBlock{ID: block.ForwardLink[0].To},
+			&skipchain.GetSingleBlock{ID: block.GetForward(0).To},
 		)
 	}
 	return links, nil
@@ -1,6 +1,7 @@
 package ch.epfl.dedis.lib;
 
 import ch.epfl.dedis.lib.crypto.Point;
+import ch.epfl.dedis.lib.crypto.PointFactory;
 import ch.epfl.dedis.lib.exception.CothorityCryptoException;
 import ch.epfl.dedis.lib.exception.CothorityException;
 import ch.epfl.dedis.lib.network.Roster;
@@ -8,7 +9,10 @@ import ch.epfl.dedis.lib.proto.SkipchainProto;
 import ch.epfl.dedis.skipchain.ForwardLink;
 import ch.epfl.dedis.skipchain.SignatureScheme;
 import ch.epfl.dedis.skipchain.SkipchainRPC;
+import com.google.protobuf.ByteString;
+import com.google.protobuf.CodedInputStream;
 import com.google.protobuf.InvalidProtocolBufferException;
+import com.google.protobuf.UnknownFieldSet;
 
 import java.net.URISyntaxException;
 import java.nio.ByteBuffer;
@@ -24,6 +28,12 @@ import java.util.stream.Collectors;
  * storage.
  */
 public class SkipBlock {
+    // Protobuf field number of the signature threshold, which is not part of the generated class yet.
+    private static final int SIGNATURE_THRESHOLD_FIELD = 14;
+    // Same for the proposers and the signature of the proposal.
+    private static final int PROPOSERS_FIELD = 15;
+    private static final int PROPOSER_SIGNATURE_FIELD = 16;
+
     private SkipchainProto.SkipBlock skipBlock;
 
     /**
@@ -87,7 +97,21 @@ public class SkipBlock {
         if (getSignatureScheme() != SignatureScheme.BLS) {
             bb.putInt(getSignatureScheme().getValue());
             digest.update(bb.array());
+            bb.clear();
+        }
+        // Same for the signature threshold, which is only hashed when set.
+        if (getSignatureThreshold() > 0) {
+            bb.putInt(getSignatureThreshold());
+            digest.update(bb.array());
+        }
+        // And the proposers and the signature of the proposal, which are only set in chains restricting their
+        // proposers.
+        try {
+            getProposers().forEach(p -> digest.update(p.toBytes()));
+        } catch (CothorityCryptoException e) {
+            return null;
         }
+        digest.update(getProposerSignature());
 
         return digest.digest();
     }
@@ -158,6 +182,49 @@ public class SkipBlock {
         return SignatureScheme.fromValue(skipBlock.getSignatureScheme());
     }
 
+    /**
+     * @return the number of nodes that need to sign the forward-links, or 0 for the default threshold.
+     */
+    public int getSignatureThreshold() {
+        UnknownFieldSet.Field field = skipBlock.getUnknownFields().getField(SIGNATURE_THRESHOLD_FIELD);
+        List<Long> values = field.getVarintList();
+        if (values.isEmpty()) {
+            return 0;
+        }
+        // The field is a sint32, so it is zigzag-encoded.
+        return CodedInputStream.decodeZigZag32(values.get(values.size() - 1).intValue());
+    }
+
+    /**
+     * @return the keys allowed to propose new blocks, which are only set in the genesis block of chains restricting
+     * their proposers.
+     * @throws CothorityCryptoException if one of the keys cannot be decoded
+     */
+    public List<Point> getProposers() throws CothorityCryptoException {
+        List<Point> ret = new ArrayList<>();
+        for (ByteString buf : skipBlock.getUnknownFields().getField(PROPOSERS_FIELD).getLengthDelimitedList()) {
+            Point p = PointFactory.getInstance().fromProto(buf);
+            if (p == null) {
+                throw new CothorityCryptoException("unknown proposer key");
+            }
+            ret.add(p);
+        }
+        return ret;
+    }
+
+    /**
+     * @return the signature of the proposer of this block, or an empty array if the chain doesn't restrict its
+     * proposers.
+     */
+    public byte[] getProposerSignature() {
+        List<ByteString> values = skipBlock.getUnknownFields().getField(PROPOSER_SIGNATURE_FIELD)
+                .getLengthDelimitedList();
+        if (values.isEmpty()) {
+            return new byte[0];
+        }
+        return values.get(values.size() - 1).toByteArray();
+    }
+
     /**
      * @return the list of all forwardlinks contained in this block. There might be no forward link at all,
      * if this is the tip of the chain.
@@ -0,0 +1,102 @@
+package ch.epfl.dedis.skipchain;
+
+import com.google.protobuf.ByteString;
+import com.google.protobuf.CodedInputStream;
+import com.google.protobuf.WireFormat;
+
+import java.io.IOException;
+import java.security.MessageDigest;
+import java.security.NoSuchAlgorithmException;
+import java.util.ArrayList;
+import java.util.Arrays;
+import java.util.List;
+
+/**
+ * BatchProof proves that the hash of a forward-link is part of the merkle root signed for a batch of blocks. The
+ * generated protobuf classes don't have this message yet, so it is decoded from the unknown fields of the
+ * forward-link.
+ */
+public class BatchProof {
+    private int index;
+    private int count;
+    private List<byte[]> path = new ArrayList<>();
+
+    /**
+     * @param buf the protobuf representation of the proof.
+     * @throws IOException if the proof cannot be decoded
+     */
+    public BatchProof(ByteString buf) throws IOException {
+        CodedInputStream in = buf.newCodedInput();
+        for (int tag = in.readTag(); tag != 0; tag = in.readTag()) {
+            switch (WireFormat.getTagFieldNumber(tag)) {
+                case 1:
+                    index = in.readSInt32();
+                    break;
+                case 2:
+                    count = in.readSInt32();
+                    break;
+                case 3:
+                    path.add(in.readBytes().toByteArray());
+                    break;
+                default:
+                    in.skipField(tag);
+            }
+        }
+    }
+
+    /**
+     * @return the index of the forward-link in the batch.
+     */
+    public int getIndex() {
+        return index;
+    }
+
+    /**
+     * @return the number of blocks in the batch.
+     */
+    public int getCount() {
+        return count;
+    }
+
+    /**
+     * Verifies that the hash of a forward-link is a leaf of the merkle tree with the given root. The last node of a
+     * level with an odd number of nodes is promoted to the next level without being hashed.
+     *
+     * @param root the merkle root signed by the roster
+     * @param hash the hash of the forward-link
+     * @return true if the forward-link is part of the batch.
+     */
+    public boolean verify(byte[] root, byte[] hash) {
+        if (index < 0 || index >= count) {
+            return false;
+        }
+        byte[] h = merkleHash(0, hash);
+        int p = 0;
+        for (int i = index, n = count; n > 1; i /= 2, n = (n + 1) / 2) {
+            if (i == n - 1 && n % 2 == 1) {
+                continue;
+            }
+            if (p >= path.size()) {
+                return false;
+            }
+            h = i % 2 == 0 ? merkleHash(1, h, path.get(p)) : merkleHash(1, path.get(p), h);
+            p++;
+        }
+        return p == path.size() && Arrays.equals(h, root);
+    }
+
+    // Leaves and inner nodes are hashed with different prefixes, so that a leaf can never be interpreted as an inner
+    // node.
+    private static byte[] merkleHash(int prefix, byte[]... data) {
+        try {
+            MessageDigest digest = MessageDigest.getInstance("SHA-256");
+            digest.update((byte) prefix);
+            for (byte[] d : data) {
+                digest.update(d);
+            }
+            return digest.digest();
+        } catch (NoSuchAlgorithmException e) {
+            throw new RuntimeException(e);
+        }
+    }
+}
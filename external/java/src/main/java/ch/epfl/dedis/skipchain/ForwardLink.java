@@ -8,6 +8,7 @@ import ch.epfl.dedis.lib.proto.SkipchainProto;
 import com.google.protobuf.ByteString;
 import com.google.protobuf.InvalidProtocolBufferException;
 
+import java.io.IOException;
 import java.net.URISyntaxException;
 import java.security.MessageDigest;
 import java.security.NoSuchAlgorithmException;
@@ -19,6 +20,9 @@ import java.util.List;
  * the current block.
  */
 public class ForwardLink {
+    // Protobuf field number of the batch proof, which is not part of the generated class yet.
+    private static final int BATCH_FIELD = 5;
+
     private SkipchainProto.ForwardLink forwardLink;
 
     public ForwardLink(SkipchainProto.ForwardLink fl) {
@@ -67,6 +71,34 @@ public class ForwardLink {
         return new ByzcoinSig(forwardLink.getSignature());
     }
 
+    /**
+     * @return the proof that the hash of this link is part of the merkle root signed for a batch of blocks, or null
+     * if the signature is on the hash of this link.
+     * @throws IOException if the proof cannot be decoded
+     */
+    public BatchProof getBatch() throws IOException {
+        List<ByteString> values = forwardLink.getUnknownFields().getField(BATCH_FIELD).getLengthDelimitedList();
+        if (values.isEmpty()) {
+            return null;
+        }
+        return new BatchProof(values.get(values.size() - 1));
+    }
+
+    /**
+     * @return true if the signed message is the hash of this link, or the merkle root of a batch holding this link.
+     */
+    private boolean verifyMessage() {
+        try {
+            BatchProof batch = getBatch();
+            if (batch != null) {
+                return batch.verify(this.getByzcoinSig().getMsg(), this.hash());
+            }
+        } catch (IOException e) {
+            return false;
+        }
+        return Arrays.equals(this.getByzcoinSig().getMsg(), this.hash());
+    }
+
     /**
      * Verifies whether the signature is correctly signed by the given public keys.
      *
@@ -74,7 +106,7 @@ public class ForwardLink {
      * @return true if the signature is ok.
      */
     public boolean verify(List<Point> publics) {
-        return Arrays.equals(this.getByzcoinSig().getMsg(), this.hash()) && this.getByzcoinSig().verify(publics);
+        return verifyMessage() && this.getByzcoinSig().verify(publics);
     }
 
     /**
@@ -86,8 +118,7 @@ public class ForwardLink {
      * @return true if the signature is ok.
      */
     public boolean verifyWithScheme(List<Point> publics, SignatureScheme scheme) {
-        return Arrays.equals(this.getByzcoinSig().getMsg(), this.hash())
-                && this.getByzcoinSig().verifyWithScheme(publics, scheme);
+        return verifyMessage() && this.getByzcoinSig().verifyWithScheme(publics, scheme);
     }
 
     /**
@@ -22,4 +22,25 @@ public class SkipBlockTest {
         // expect a different hash because of the signature scheme
         assertNotEquals(expectedHash, sb2.getHash());
     }
+
+    @Test
+    void testHashSignatureThreshold() throws CothorityException {
+        // The same block as in testHash, with a signature threshold of 1.
+        byte[] canned = Hex.parseHexBinary("08001008180020003a004201314a94010a106bc1027de8ef542e8b09219c287b2fde12560a2865642e706f696e7400000000000000000000000000000000000000000000000000000000000000001a103809e37975a45b4a865899668d645d9522147463703a2f2f3132372e302e302e313a323030302a003a001a2865642e706f696e7400000000000000000000000000000000000000000000000000000000000000005220e88dd0eecc54e16b461a3953e17ba0d609bdeaadbe5cb6a013f92c3e208c350b620068007002");
+        SkipBlock sb = new SkipBlock(canned);
+        assertEquals(1, sb.getSignatureThreshold());
+        byte[] expectedHash = Hex.parseHexBinary("e88dd0eecc54e16b461a3953e17ba0d609bdeaadbe5cb6a013f92c3e208c350b");
+        assertArrayEquals(expectedHash, sb.getHash());
+    }
+
+    @Test
+    void testHashProposers() throws CothorityException {
+        // The same block as in testHash, with a proposer and a proposer signature of 0x040506.
+        byte[] canned = Hex.parseHexBinary("08001008180020003a004201314a94010a106bc1027de8ef542e8b09219c287b2fde12560a2865642e706f696e7400000000000000000000000000000000000000000000000000000000000000001a103809e37975a45b4a865899668d645d9522147463703a2f2f3132372e302e302e313a323030302a003a001a2865642e706f696e74000000000000000000000000000000000000000000000000000000000000000052201a040a9799f1d6a183a2b3fed45d34b516aac21a65942ca4eeef08d79eab916f6200680070007a2865642e706f696e745866666666666666666666666666666666666666666666666666666666666666820103040506");
+        SkipBlock sb = new SkipBlock(canned);
+        assertEquals(1, sb.getProposers().size());
+        assertArrayEquals(new byte[]{4, 5, 6}, sb.getProposerSignature());
+        byte[] expectedHash = Hex.parseHexBinary("1a040a9799f1d6a183a2b3fed45d34b516aac21a65942ca4eeef08d79eab916f");
+        assertArrayEquals(expectedHash, sb.getHash());
+    }
 }
@@ -0,0 +1,35 @@
+package ch.epfl.dedis.skipchain;
+
+import ch.epfl.dedis.lib.Hex;
+import org.junit.jupiter.api.Test;
+
+import static org.junit.jupiter.api.Assertions.*;
+
+class ForwardLinkTest {
+    // The root of a batch of three forward-links, see skipchain/batch.go for how it is signed.
+    private static final byte[] root = Hex.parseHexBinary("e16bb085d00c2dc7339c910c63d7fba9eea737817ace013ae54d711ed37a8c1c");
+
+    @Test
+    void batch() throws Exception {
+        // The second forward-link of the batch.
+        ForwardLink fl = new ForwardLink(Hex.parseHexBinary("0a010112010222240a20e16bb085d00c2dc7339c910c63d7fba9eea737817ace013ae54d711ed37a8c1c12002a48080210061a20921373811d8c21187b55e273c70065206fe391fbc947a629b3c0f1dc80a34d791a20a60b51fbc43878b15c6bfc96d2184f5b5746b9135da352d963e8437521d19571"));
+        BatchProof batch = fl.getBatch();
+        assertNotNull(batch);
+        assertEquals(1, batch.getIndex());
+        assertEquals(3, batch.getCount());
+        assertTrue(batch.verify(root, fl.hash()));
+
+        // The last forward-link is promoted and has a shorter path.
+        ForwardLink last = new ForwardLink(Hex.parseHexBinary("0a010212010322240a20e16bb085d00c2dc7339c910c63d7fba9eea737817ace013ae54d711ed37a8c1c12002a26080410061a20a3aef7d26eeb3b5ff8c9d795b306aacf4f9fc6d3bfdac7fe3c70cabb3a23929c"));
+        assertTrue(last.getBatch().verify(root, last.hash()));
+
+        assertFalse(last.getBatch().verify(root, fl.hash()));
+        assertFalse(batch.verify(fl.hash(), fl.hash()));
+    }
+
+    @Test
+    void noBatch() throws Exception {
+        ForwardLink fl = new ForwardLink(Hex.parseHexBinary("0a010112010222040a001200"));
+        assertNull(fl.getBatch());
+    }
+}
@@ -1,6 +1,6 @@
 import { BN256G1Point, BN256G2Point } from "@dedis/kyber/pairing/point";
 import { Roster, ServerIdentity } from "../../src/network/proto";
-import { BDN_INDEX, ByzcoinSignature, ForwardLink, SkipBlock } from "../../src/skipchain/skipblock";
+import { BatchProof, BDN_INDEX, ByzcoinSignature, ForwardLink, SkipBlock } from "../../src/skipchain/skipblock";
 
 describe("SkipBlock Tests", () => {
     it("should hash the block", () => {
@@ -19,6 +19,60 @@ describe("SkipBlock Tests", () => {
             .toBe("36a9ae78a58ea8a1dd7f851a7c0d163d7456f016eed30e9e41db1a80f017bcc0");
     });
 
+    it("should hash the block with a signature threshold", () => {
+        const sb = new SkipBlock({
+            backlinks: [Buffer.from([1, 2, 3])],
+            baseHeight: 4,
+            data: Buffer.from([1, 2, 3]),
+            genesis: Buffer.from([1, 2, 3]),
+            height: 32,
+            index: 0,
+            maxHeight: 32,
+            signatureThreshold: 2,
+            verifiers: [Buffer.from("a7f6cdb747f856b4aff5ece35a882489", "hex")],
+        });
+
+        expect(sb.computeHash().toString("hex"))
+            .toBe("5e26430a137884d71cca3eb2b65248c90515c26b3ccdde36eafeabe41fb28a9b");
+
+        const sb2 = new SkipBlock({
+            ...sb,
+            signatureScheme: 1,
+        });
+
+        expect(sb2.computeHash().toString("hex"))
+            .toBe("27f3283aa3446faaab6e1674ff10e807bc365c0cbcde860a55d6392892346412");
+    });
+
+    it("should hash the block with proposers", () => {
+        const sb = new SkipBlock({
+            backlinks: [Buffer.from([1, 2, 3])],
+            baseHeight: 4,
+            data: Buffer.from([1, 2, 3]),
+            genesis: Buffer.from([1, 2, 3]),
+            height: 32,
+            index: 0,
+            maxHeight: 32,
+            proposers: [Buffer.from(
+                "65642e706f696e745866666666666666666666666666666666666666666666666666666666666666",
+                "hex",
+            )],
+            verifiers: [Buffer.from("a7f6cdb747f856b4aff5ece35a882489", "hex")],
+        });
+
+        expect(sb.computeHash().toString("hex"))
+            .toBe("2b28b6580f7a8af4088edb8f4fc0af604913cb12c6c1a750014c1b275773f6f1");
+
+        const sb2 = new SkipBlock({
+            ...sb,
+            proposers: [],
+            proposersignature: Buffer.from([4, 5, 6]),
+        });
+
+        expect(sb2.computeHash().toString("hex"))
+            .toBe("d176b9cd8a2bfe467cba823a8e1a55ce682faff336930a5e892c9d43358e7212");
+    });
+
     it("should hash the block with a roster", () => {
         const ref = "bdbe534e525441980184bb53692da069a7ae9ecc5cafcc4f64cb54fc453ff02b";
         const roster = new Roster({
@@ -100,4 +154,42 @@ describe("SkipBlock Tests", () => {
         fl.signature.sig.fill(Buffer.concat([new BN256G1Point().null().marshalBinary(), Buffer.from([1])]));
         expect(fl.verify(publics).message).toBe("not enough signers");
     });
+
+    it("should verify the forward links of a batch", () => {
+        // See skipchain/batch.go for how these batches are signed.
+        const root = Buffer.from("e16bb085d00c2dc7339c910c63d7fba9eea737817ace013ae54d711ed37a8c1c", "hex");
+        const fl = new ForwardLink({
+            batch: new BatchProof({
+                count: 3,
+                index: 1,
+                path: [
+                    Buffer.from("921373811d8c21187b55e273c70065206fe391fbc947a629b3c0f1dc80a34d79", "hex"),
+                    Buffer.from("a60b51fbc43878b15c6bfc96d2184f5b5746b9135da352d963e8437521d19571", "hex"),
+                ],
+            }),
+            from: Buffer.from([1]),
+            signature: new ByzcoinSignature({ msg: root, sig: Buffer.allocUnsafe(65) }),
+            to: Buffer.from([2]),
+        });
+        expect(fl.batch.verify(root, fl.hash())).toBeNull();
+
+        const publics = [new BN256G2Point().pick(), new BN256G2Point().pick()];
+        fl.signature.sig.fill(Buffer.concat([new BN256G1Point().null().marshalBinary(), Buffer.from([3])]));
+        expect(fl.verify(publics).message).toBe("BLS signature not verified");
+
+        // The last forward-link is promoted and has a shorter path.
+        const last = new BatchProof({
+            count: 3,
+            index: 2,
+            path: [Buffer.from("a3aef7d26eeb3b5ff8c9d795b306aacf4f9fc6d3bfdac7fe3c70cabb3a23929c", "hex")],
+        });
+        const lastLink = new ForwardLink({ from: Buffer.from([2]), to: Buffer.from([3]) });
+        expect(last.verify(root, lastLink.hash())).toBeNull();
+
+        expect(last.verify(root, fl.hash()).message).toBe("forward-link is not part of the batch");
+        const wrong = new BatchProof({ ...last, index: 3 });
+        expect(wrong.verify(root, lastLink.hash()).message).toBe("index out of range");
+        const short = new BatchProof({ ...fl.batch, path: fl.batch.path.slice(1) });
+        expect(short.verify(root, fl.hash()).message).toBe("path is too short");
+    });
 });
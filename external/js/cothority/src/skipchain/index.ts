@@ -1,9 +1,10 @@
-import { ByzcoinSignature, ForwardLink, SkipBlock } from "./skipblock";
+import { BatchProof, ByzcoinSignature, ForwardLink, SkipBlock } from "./skipblock";
 import SkipchainRPC from "./skipchain-rpc";
 
 export {
     SkipBlock,
     ForwardLink,
+    BatchProof,
     ByzcoinSignature,
     SkipchainRPC,
 };
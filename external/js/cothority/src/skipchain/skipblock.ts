@@ -1,4 +1,4 @@
-import { Point, sign } from "@dedis/kyber";
+import { Point, PointFactory, sign } from "@dedis/kyber";
 import { BN256G1Point, BN256G2Point } from "@dedis/kyber/pairing/point";
 import { createHash } from "crypto-browserify";
 import { Message, Properties } from "protobufjs/light";
@@ -55,6 +55,9 @@ export class SkipBlock extends Message<SkipBlock> {
     readonly forward: ForwardLink[];
     readonly payload: Buffer;
     readonly signatureScheme: number;
+    readonly signatureThreshold: number;
+    readonly proposers: Buffer[];
+    readonly proposersignature: Buffer;
 
     constructor(props?: Properties<SkipBlock>) {
         super(props);
@@ -62,6 +65,8 @@ export class SkipBlock extends Message<SkipBlock> {
         this.backlinks = this.backlinks || [];
         this.verifiers = this.verifiers || [];
         this.forward = this.forward || [];
+        this.proposers = this.proposers || [];
+        this.proposersignature = Buffer.from(this.proposersignature || EMPTY_BUFFER);
         this.hash = Buffer.from(this.hash || EMPTY_BUFFER);
         this.data = Buffer.from(this.data || EMPTY_BUFFER);
         this.genesis = Buffer.from(this.genesis || EMPTY_BUFFER);
@@ -100,6 +105,16 @@ export class SkipBlock extends Message<SkipBlock> {
         if (this.signatureScheme > 0) {
             h.update(int2buf(this.signatureScheme));
         }
+        // Same for the signature threshold, which is only hashed when set.
+        if (this.signatureThreshold > 0) {
+            h.update(int2buf(this.signatureThreshold));
+        }
+        // And the proposers and the signature of the proposal, which are
+        // only set in chains restricting their proposers.
+        for (const p of this.proposers) {
+            h.update(PointFactory.fromProto(p).marshalBinary());
+        }
+        h.update(this.proposersignature);
 
         return h.digest();
     }
@@ -122,13 +137,14 @@ export class ForwardLink extends Message<ForwardLink> {
      * @see README#Message classes
      */
     static register() {
-        registerMessage("ForwardLink", ForwardLink, Roster, ByzcoinSignature);
+        registerMessage("ForwardLink", ForwardLink, Roster, ByzcoinSignature, BatchProof);
     }
 
     readonly from: Buffer;
     readonly to: Buffer;
     readonly newRoster: Roster;
     readonly signature: ByzcoinSignature;
+    readonly batch: BatchProof;
 
     constructor(props?: Properties<ForwardLink>) {
         super(props);
@@ -173,7 +189,14 @@ export class ForwardLink extends Message<ForwardLink> {
      * @returns an error if something is wrong, null otherwise
      */
     verifyWithScheme(publics: Point[], scheme: number): Error {
-        if (!this.hash().equals(this.signature.msg)) {
+        // The forward-links of a batch of blocks sign the merkle root over
+        // their hashes instead of their own hash.
+        if (this.batch) {
+            const err = this.batch.verify(this.signature.msg, this.hash());
+            if (err) {
+                return err;
+            }
+        } else if (!this.hash().equals(this.signature.msg)) {
             return new Error("recreated message does not match");
         }
 
@@ -214,6 +237,79 @@ export class ForwardLink extends Message<ForwardLink> {
     }
 }
 
+/**
+ * Hash the data of a merkle tree with the prefix of its kind, so that a leaf
+ * can never be interpreted as an inner node
+ *
+ * @param prefix    0 for a leaf, 1 for an inner node
+ * @param data      The data of the leaf or the children of the node
+ * @returns the hash
+ */
+function merkleHash(prefix: number, ...data: Buffer[]): Buffer {
+    const h = createHash("sha256");
+    h.update(Buffer.from([prefix]));
+    for (const d of data) {
+        h.update(d);
+    }
+
+    return h.digest();
+}
+
+export class BatchProof extends Message<BatchProof> {
+    /**
+     * @see README#Message classes
+     */
+    static register() {
+        registerMessage("BatchProof", BatchProof);
+    }
+
+    readonly index: number;
+    readonly count: number;
+    readonly path: Buffer[];
+
+    constructor(props?: Properties<BatchProof>) {
+        super(props);
+
+        this.path = this.path || [];
+    }
+
+    /**
+     * Verify that the hash of a forward-link is a leaf of the merkle tree
+     * with the given root. The last node of a level with an odd number of
+     * nodes is promoted to the next level without being hashed.
+     *
+     * @param root  The merkle root signed by the roster
+     * @param hash  The hash of the forward-link
+     * @returns an error if something is wrong, null otherwise
+     */
+    verify(root: Buffer, hash: Buffer): Error {
+        if (this.index < 0 || this.index >= this.count) {
+            return new Error("index out of range");
+        }
+
+        let h = merkleHash(0, hash);
+        let p = 0;
+        for (let index = this.index, n = this.count; n > 1; index = Math.floor(index / 2), n = Math.ceil(n / 2)) {
+            if (index === n - 1 && n % 2 === 1) {
+                continue;
+            }
+            if (p >= this.path.length) {
+                return new Error("path is too short");
+            }
+            h = index % 2 === 0 ? merkleHash(1, h, this.path[p]) : merkleHash(1, this.path[p], h);
+            p++;
+        }
+        if (p < this.path.length) {
+            return new Error("path is too long");
+        }
+        if (!h.equals(root)) {
+            return new Error("forward-link is not part of the batch");
+        }
+
+        return null;
+    }
+}
+
 export class ByzcoinSignature extends Message<ByzcoinSignature> {
     /**
      * @see README#Message classes
@@ -265,4 +361,5 @@ export class ByzcoinSignature extends Message<ByzcoinSignature> {
 
 SkipBlock.register();
 ForwardLink.register();
+BatchProof.register();
 ByzcoinSignature.register();
@@ -0,0 +1,128 @@
+// Package merkle implements the binary merkle trees used to include many
+// hashes in a single signed root, and the proofs of inclusion of one leaf.
+//
+// Leaves and inner nodes are hashed with different prefixes, so that a leaf
+// can never be interpreted as an inner node. If a level has an odd number of
+// nodes, the last node is promoted to the next level without being hashed,
+// so that no two different lists of leaves have the same root.
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+)
+
+const (
+	prefixLeaf = 0
+	prefixNode = 1
+)
+
+// LeafHash returns the hash of a leaf made of the concatenation of data.
+func LeafHash(data ...[]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{prefixLeaf})
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// NodeHash returns the hash of an inner node with the given children.
+func NodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{prefixNode})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// Tree holds all levels of a merkle tree, the first level being the leaves
+// and the last level the root.
+type Tree struct {
+	levels [][][]byte
+}
+
+// NewTree returns the merkle tree over the leaves, which must already be
+// hashed with LeafHash. There must be at least one leaf.
+func NewTree(leaves [][]byte) *Tree {
+	levels := [][][]byte{leaves}
+	for level := leaves; len(level) > 1; {
+		var next [][]byte
+		for i := 0; i+1 < len(level); i += 2 {
+			next = append(next, NodeHash(level[i], level[i+1]))
+		}
+		if len(level)%2 == 1 {
+			next = append(next, level[len(level)-1])
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return &Tree{levels: levels}
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() []byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Count returns the number of leaves of the tree.
+func (t *Tree) Count() int {
+	return len(t.levels[0])
+}
+
+// Proof returns the proof of inclusion of the leaf at the given index.
+func (t *Tree) Proof(index int) Proof {
+	p := Proof{Index: index, Count: t.Count()}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		if sibling := index ^ 1; sibling < len(level) {
+			p.Path = append(p.Path, level[sibling])
+		}
+		index /= 2
+	}
+	return p
+}
+
+// Proof proves that a leaf is part of a merkle tree.
+type Proof struct {
+	// Index of the leaf in the tree.
+	Index int
+	// Count is the number of leaves of the tree.
+	Count int
+	// Path holds the siblings from the leaf up to the root. Promoted nodes
+	// have no sibling.
+	Path [][]byte
+}
+
+// Verify returns nil if the leaf, hashed with LeafHash, is part of the
+// merkle tree with the given root. The caller has to make sure that Count
+// is the number of leaves of the tree with this root.
+func (p Proof) Verify(root, leaf []byte) error {
+	if p.Index < 0 || p.Index >= p.Count {
+		return errors.New("index out of range")
+	}
+	h := leaf
+	path := p.Path
+	for index, n := p.Index, p.Count; n > 1; index, n = index/2, (n+1)/2 {
+		if index == n-1 && n%2 == 1 {
+			// Promoted to the next level.
+			continue
+		}
+		if len(path) == 0 {
+			return errors.New("path is too short")
+		}
+		if index%2 == 0 {
+			h = NodeHash(h, path[0])
+		} else {
+			h = NodeHash(path[0], h)
+		}
+		path = path[1:]
+	}
+	if len(path) > 0 {
+		return errors.New("path is too long")
+	}
+	if !bytes.Equal(h, root) {
+		return errors.New("leaf is not part of the merkle tree")
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package merkle
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProof(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		var leaves [][]byte
+		for i := 0; i < n; i++ {
+			leaves = append(leaves, LeafHash([]byte{byte(i)}))
+		}
+		tree := NewTree(leaves)
+		require.Equal(t, n, tree.Count())
+		for i := 0; i < n; i++ {
+			p := tree.Proof(i)
+			require.NoError(t, p.Verify(tree.Root(), leaves[i]),
+				fmt.Sprintf("leaf %d of %d", i, n))
+			require.Error(t, p.Verify(tree.Root(), LeafHash([]byte{byte(n)})))
+
+			wrong := p
+			wrong.Index = n
+			require.Error(t, wrong.Verify(tree.Root(), leaves[i]))
+			wrong.Index = -1
+			require.Error(t, wrong.Verify(tree.Root(), leaves[i]))
+			wrong = p
+			wrong.Path = append(append([][]byte{}, p.Path...), leaves[i])
+			require.Error(t, wrong.Verify(tree.Root(), leaves[i]))
+		}
+	}
+}
+
+func TestNewTree_OddLevels(t *testing.T) {
+	// With the last node hashed with itself, [a b c] and [a b c c] would
+	// have the same root.
+	a, b, c := LeafHash([]byte("a")), LeafHash([]byte("b")), LeafHash([]byte("c"))
+	require.NotEqual(t, NewTree([][]byte{a, b, c}).Root(),
+		NewTree([][]byte{a, b, c, c}).Root())
+	require.Equal(t, NodeHash(NodeHash(a, b), c),
+		NewTree([][]byte{a, b, c}).Root())
+
+	// A leaf can't be presented as an inner node.
+	require.NotEqual(t, NodeHash(a, b), LeafHash(append(a, b...)))
+}
@@ -0,0 +1,141 @@
+package messaging
+
+import (
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+)
+
+/*
+This file holds the pacing of the propagation. Without pacing, a node sends
+the data to all its children at once, which saturates its uplink for big data
+and big rosters. A Pacer limits the number of concurrent sends and the
+bandwidth used to send the data, and records how long it took to send the data
+to all children.
+*/
+
+// PacingFunc returns the Pacer used to send the message to the children, or
+// nil if the message is sent without pacing. This allows to share a Pacer
+// between all propagations of, e.g., the same skipchain.
+type PacingFunc func(msg network.Message) *Pacer
+
+// PacingStats holds the fan-out times achieved with a Pacer.
+type PacingStats struct {
+	// FanOuts is the number of times data has been sent to all children,
+	// Total the time it took for all of them and Max the longest one.
+	FanOuts int
+	Total   time.Duration
+	Max     time.Duration
+	// Waited is the time sends have been delayed by the pacing.
+	Waited time.Duration
+}
+
+// Pacer limits the number of concurrent sends and the bandwidth used to send
+// data. It can be shared between propagations.
+type Pacer struct {
+	sync.Mutex
+	// sends holds one element per running send, it is nil if the number of
+	// concurrent sends is not limited.
+	sends chan struct{}
+	// rate is the maximum number of bytes per second, 0 if unlimited.
+	rate int
+	// next is the time the bandwidth allows the next send to start.
+	next  time.Time
+	stats PacingStats
+}
+
+// NewPacer returns a Pacer allowing at most maxSends concurrent sends and
+// bytesPerSecond bytes per second. A value smaller than 1 removes the
+// corresponding limit.
+func NewPacer(maxSends, bytesPerSecond int) *Pacer {
+	p := &Pacer{}
+	if maxSends > 0 {
+		p.sends = make(chan struct{}, maxSends)
+	}
+	if bytesPerSecond > 0 {
+		p.rate = bytesPerSecond
+	}
+	return p
+}
+
+// Stats returns the fan-out times achieved with this Pacer.
+func (p *Pacer) Stats() PacingStats {
+	p.Lock()
+	defer p.Unlock()
+	return p.stats
+}
+
+// reserve returns how long to wait before sending size bytes, and reserves
+// the bandwidth for them.
+func (p *Pacer) reserve(size int) time.Duration {
+	if p.rate == 0 {
+		return 0
+	}
+	p.Lock()
+	defer p.Unlock()
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	wait := p.next.Sub(now)
+	p.next = p.next.Add(time.Duration(size) * time.Second / time.Duration(p.rate))
+	return wait
+}
+
+// sendAll calls send for every child, starting a new send only if the pacing
+// allows it, and returns once all sends are done. It returns early if closing
+// is closed, without starting the remaining sends.
+func (p *Pacer) sendAll(children []*onet.TreeNode, size int, closing chan bool,
+	send func(*onet.TreeNode)) {
+	start := time.Now()
+	var waited time.Duration
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		p.Lock()
+		p.stats.Waited += waited
+		p.Unlock()
+	}()
+	for _, c := range children {
+		before := time.Now()
+		if p.sends != nil {
+			select {
+			case p.sends <- struct{}{}:
+			case <-closing:
+				return
+			}
+		}
+		if wait := p.reserve(size); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-closing:
+				p.release()
+				return
+			}
+		}
+		waited += time.Since(before)
+		wg.Add(1)
+		go func(tn *onet.TreeNode) {
+			defer wg.Done()
+			defer p.release()
+			send(tn)
+		}(c)
+	}
+	wg.Wait()
+	d := time.Since(start)
+	p.Lock()
+	p.stats.FanOuts++
+	p.stats.Total += d
+	if d > p.stats.Max {
+		p.stats.Max = d
+	}
+	p.Unlock()
+}
+
+func (p *Pacer) release() {
+	if p.sends != nil {
+		<-p.sends
+	}
+}
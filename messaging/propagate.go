@@ -27,6 +27,7 @@ type Propagate struct {
 	*onet.TreeNodeInstance
 	onData    PropagationStore
 	onDoneCb  func(int)
+	pace      PacingFunc
 	sd        *PropagateSendData
 	ChannelSD chan struct {
 		*onet.TreeNode
@@ -54,6 +55,9 @@ type PropagateSendData struct {
 // PropagateReply is sent from the children back to the root
 type PropagateReply struct {
 	Level int
+	// Count is the number of nodes of the subtree of the sender that got
+	// the data. It is only set by nodes that are not leaves.
+	Count int `protobuf:"opt"`
 }
 
 // PropagationFunc starts the propagation protocol and blocks until all children
@@ -77,7 +81,46 @@ type propagationContext interface {
 // The protocol will fail if more than thresh nodes per subtree fail to respond.
 // If thresh == -1, the threshold defaults to len(n.Roster().List-1)/3. Thus, for a roster of
 // 5, t = int(4/3) = 1, e.g. 1 node out of the 5 can fail.
+// The data is sent directly from the root to all nodes.
 func NewPropagationFunc(c propagationContext, name string, f PropagationStore, thresh int) (PropagationFunc, error) {
+	return NewPropagationFuncTree(c, name, f, thresh, StarTree)
+}
+
+// PropagationTree returns the tree used to propagate the data to all nodes
+// of the roster. The first node of the roster is the one starting the
+// propagation.
+type PropagationTree func(rooted *onet.Roster) *onet.Tree
+
+// StarTree returns a tree where the root sends the data to all nodes.
+func StarTree(rooted *onet.Roster) *onet.Tree {
+	return rooted.GenerateNaryTree(len(rooted.List))
+}
+
+// FanOutTree returns a PropagationTree where every node sends the data to at
+// most fanOut nodes, which reduces the bandwidth needed by the root for big
+// rosters. A fanOut smaller than 1 returns the StarTree.
+func FanOutTree(fanOut int) PropagationTree {
+	if fanOut < 1 {
+		return StarTree
+	}
+	return func(rooted *onet.Roster) *onet.Tree {
+		return rooted.GenerateNaryTree(fanOut)
+	}
+}
+
+// NewPropagationFuncTree works like NewPropagationFunc, but the data is sent
+// along the tree returned by gen. Nodes that are not leaves wait for the
+// replies of their subtree before replying to their parent, so the root
+// gets the number of nodes that stored the data.
+func NewPropagationFuncTree(c propagationContext, name string, f PropagationStore,
+	thresh int, gen PropagationTree) (PropagationFunc, error) {
+	return NewPropagationFuncPaced(c, name, f, thresh, gen, nil)
+}
+
+// NewPropagationFuncPaced works like NewPropagationFuncTree, but every node
+// sends the data to its children with the Pacer returned by pace, if any.
+func NewPropagationFuncPaced(c propagationContext, name string, f PropagationStore,
+	thresh int, gen PropagationTree, pace PacingFunc) (PropagationFunc, error) {
 	pid, err := c.ProtocolRegister(name, func(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
 		// Make a local copy in order to avoid a data race.
 		t := thresh
@@ -88,6 +131,7 @@ func NewPropagationFunc(c propagationContext, name string, f PropagationStore, t
 			sd:               &PropagateSendData{[]byte{}, initialWait},
 			TreeNodeInstance: n,
 			onData:           f,
+			pace:             pace,
 			allowedFailures:  t,
 			closing:          make(chan bool),
 		}
@@ -105,12 +149,11 @@ func NewPropagationFunc(c propagationContext, name string, f PropagationStore, t
 		if rooted == nil {
 			return 0, errors.New("we're not in the roster")
 		}
-		// Make a star (tree with height 1)
 		// TODO: it would be nice to search for a nice method to convert a
 		// list of nodes, a minimum branching-factor,
 		// and the maximum number of failing nodes into an optimal tree where
 		// most of the nodes appear more than once.
-		tree := rooted.GenerateNaryTree(len(el.List))
+		tree := gen(rooted)
 		if tree == nil {
 			return 0, errors.New("Didn't find root in tree")
 		}
@@ -170,9 +213,10 @@ func (p *Propagate) Dispatch() error {
 	}()
 
 	var gotSendData bool
-	var errs []error
 	subtreeCount := p.TreeNode().SubtreeCount()
-	errsChan := make(chan error, subtreeCount)
+	// missing is the number of nodes below us that we couldn't reach.
+	var missing int
+	missingChan := make(chan int, subtreeCount)
 
 	for process {
 		p.Lock()
@@ -189,61 +233,80 @@ func (p *Propagate) Dispatch() error {
 			log.Lvl3(p.ServerIdentity(), "Got data from",
 				msg.ServerIdentity, "and setting timeout to", msg.Timeout)
 			p.sd.Timeout = msg.Timeout
-			if p.onData != nil {
-				_, netMsg, err := network.Unmarshal(msg.Data, p.Suite())
+			var netMsg network.Message
+			if p.onData != nil || p.pace != nil {
+				var err error
+				_, netMsg, err = network.Unmarshal(msg.Data, p.Suite())
 				if err != nil {
 					log.Lvlf2("Unmarshal failed with %v", err)
-				} else {
+					netMsg = nil
+				} else if p.onData != nil {
 					err := p.onData(netMsg)
 					if err != nil {
 						log.Lvlf2("Propagation callback failed: %v", err)
 					}
 				}
 			}
-			if !p.IsRoot() || p.Tree().Size() == 1 {
+			if p.IsLeaf() {
 				log.Lvl3(p.ServerIdentity(), "Sending to parent")
 				if err := p.SendToParent(&PropagateReply{}); err != nil {
 					return err
 				}
 				process = false
+				continue
+			}
+			sd := msg.PropagateSendData
+			if !p.IsRoot() {
+				// Intermediate nodes need to reply to their parent before
+				// it times out, so they give less time to their children.
+				p.sd.Timeout = msg.Timeout / 2
+				sd.Timeout = p.sd.Timeout
 			}
 			log.Lvl3(p.ServerIdentity(), "Sending to children", p.Children())
 
 			// Just blindly send to the children - we don't care if they receive it or
 			// not. If they don't receive it, they will complain later.
-			for _, c := range p.Children() {
-				go func(tn *onet.TreeNode) {
-					err := p.SendTo(tn, &msg.PropagateSendData)
-					if err != nil {
-						log.Warnf("Error while sending to child %s: %v",
-							tn.Name(), err)
-						errsChan <- err
-					}
-				}(c)
+			send := func(tn *onet.TreeNode) {
+				err := p.SendTo(tn, &sd)
+				if err != nil {
+					log.Warnf("Error while sending to child %s: %v",
+						tn.Name(), err)
+					missingChan <- tn.SubtreeCount() + 1
+				}
+			}
+			var pacer *Pacer
+			if p.pace != nil && netMsg != nil {
+				pacer = p.pace(netMsg)
 			}
-		case <-p.ChannelReply:
+			if pacer != nil {
+				go pacer.sendAll(p.Children(), len(sd.Data), p.closing, send)
+			} else {
+				for _, c := range p.Children() {
+					go send(c)
+				}
+			}
+		case reply := <-p.ChannelReply:
 			if !gotSendData {
 				log.Error("got response before send")
 				continue
 			}
-			received++
-			log.Lvl4(p.ServerIdentity(), "received:", received, subtreeCount)
-			if !p.IsRoot() {
-				if err := p.SendToParent(&PropagateReply{}); err != nil {
-					return err
-				}
-			}
-			errsChan <- nil
-		case err := <-errsChan:
-			if err != nil {
-				errs = append(errs, err)
+			// Replies of leaves don't have a count.
+			if reply.Count > 0 {
+				received += reply.Count
+			} else {
+				received++
 			}
-			// Only wait for the number of children that successfully received our message.
-			if received == subtreeCount-len(errs) && received >= subtreeCount-p.allowedFailures {
+			log.Lvl4(p.ServerIdentity(), "received:", received, subtreeCount)
+			missingChan <- 0
+		case m := <-missingChan:
+			missing += m
+			// Only wait for the number of nodes that successfully received our message.
+			if received == subtreeCount-missing &&
+				(!p.IsRoot() || received >= subtreeCount-p.allowedFailures) {
 				process = false
 			}
 		case <-time.After(timeout):
-			if received+1 < subtreeCount-p.allowedFailures {
+			if p.IsRoot() && received+1 < subtreeCount-p.allowedFailures {
 				_, _, err := network.Unmarshal(p.sd.Data, p.Suite())
 				return fmt.Errorf("Timeout of %s reached, got %v but need %v, err: %v",
 					timeout, received, subtreeCount-p.allowedFailures, err)
@@ -254,6 +317,12 @@ func (p *Propagate) Dispatch() error {
 			p.onDoneCb = nil
 		}
 	}
+	if gotSendData && !p.IsRoot() && !p.IsLeaf() {
+		// Tell the parent how many nodes of our subtree got the data.
+		if err := p.SendToParent(&PropagateReply{Count: received + 1}); err != nil {
+			return err
+		}
+	}
 	log.Lvl3(p.ServerIdentity(), "done, isroot:", p.IsRoot())
 	return nil
 }
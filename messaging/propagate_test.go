@@ -26,11 +26,76 @@ func init() {
 func TestPropagation(t *testing.T) {
 	propagate(t,
 		[]int{3, 10, 14, 4, 8, 8},
-		[]int{0, 0, 0, 1, 3, 6})
+		[]int{0, 0, 0, 1, 3, 6}, StarTree)
+}
+
+func TestPropagation_FanOut(t *testing.T) {
+	propagate(t,
+		[]int{2, 13, 13, 20},
+		[]int{0, 0, 1, 2}, FanOutTree(3))
+}
+
+func TestPropagation_Paced(t *testing.T) {
+	local := onet.NewLocalTest(tSuite)
+	defer local.CloseAll()
+	servers, el, _ := local.GenTree(6, true)
+	msg := &propagateMsg{make([]byte, 1000)}
+	pacer := NewPacer(2, 20000)
+	var recvCount int
+	var iMut sync.Mutex
+	var root PropagationFunc
+	for i, server := range servers {
+		pc := &PC{server, local.Overlays[server.ServerIdentity.ID]}
+		f, err := NewPropagationFuncPaced(pc, "PropagatePaced",
+			func(m network.Message) error {
+				iMut.Lock()
+				recvCount++
+				iMut.Unlock()
+				return nil
+			}, 0, StarTree, func(network.Message) *Pacer { return pacer })
+		require.NoError(t, err)
+		if i == 0 {
+			root = f
+		}
+	}
+
+	// The five children need 5 * 1000 bytes, so the last send is delayed by
+	// at least 4 * 50ms.
+	replies, err := root(el, msg, 5*time.Second)
+	require.NoError(t, err)
+	require.Equal(t, 6, replies)
+	iMut.Lock()
+	require.Equal(t, 6, recvCount)
+	iMut.Unlock()
+	st := pacer.Stats()
+	require.Equal(t, 1, st.FanOuts)
+	require.True(t, st.Total >= 200*time.Millisecond, "fan-out took %s", st.Total)
+	require.True(t, st.Waited >= 200*time.Millisecond, "waited %s", st.Waited)
+}
+
+func TestPacer_Reserve(t *testing.T) {
+	p := NewPacer(0, 1000)
+	require.Equal(t, time.Duration(0), p.reserve(500))
+	wait := p.reserve(500)
+	require.True(t, wait > 400*time.Millisecond && wait <= 500*time.Millisecond)
+	require.Equal(t, time.Duration(0), NewPacer(1, 0).reserve(1e6))
+}
+
+func TestFanOutTree(t *testing.T) {
+	local := onet.NewLocalTest(tSuite)
+	defer local.CloseAll()
+	_, el, _ := local.GenTree(13, false)
+	tree := FanOutTree(3)(el)
+	require.Equal(t, 3, len(tree.Root.Children))
+	for _, c := range tree.Root.Children {
+		require.Equal(t, 3, len(c.Children))
+	}
+	tree = FanOutTree(0)(el)
+	require.Equal(t, 12, len(tree.Root.Children))
 }
 
 // Tests an n-node system
-func propagate(t *testing.T, nbrNodes, nbrFailures []int) {
+func propagate(t *testing.T, nbrNodes, nbrFailures []int, gen PropagationTree) {
 	for i, n := range nbrNodes {
 		local := onet.NewLocalTest(tSuite)
 		servers, el, _ := local.GenTree(n, true)
@@ -43,7 +108,7 @@ func propagate(t *testing.T, nbrNodes, nbrFailures []int) {
 		var err error
 		for n, server := range servers {
 			pc := &PC{server, local.Overlays[server.ServerIdentity.ID]}
-			propFuncs[n], err = NewPropagationFunc(pc,
+			propFuncs[n], err = NewPropagationFuncTree(pc,
 				"Propagate",
 				func(m network.Message) error {
 					if bytes.Equal(msg.Data, m.(*propagateMsg).Data) {
@@ -55,7 +120,7 @@ func propagate(t *testing.T, nbrNodes, nbrFailures []int) {
 
 					t.Error("Didn't receive correct data")
 					return errors.New("Didn't receive correct data")
-				}, nbrFailures[i])
+				}, nbrFailures[i], gen)
 			require.NoError(t, err)
 		}
 
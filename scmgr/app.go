@@ -326,7 +326,7 @@ func scUpdates(c *cli.Context) error {
 
 	for _, x := range r {
 		fmt.Fprintf(c.App.Writer, "index %v, hash %x\n", x.Index, x.Hash)
-		for li, fl := range x.ForwardLink {
+		for li, fl := range x.GetForwardLinks() {
 			if fl.NewRoster != nil {
 				fmt.Fprintf(c.App.Writer, "  forward link %v, newRoster %v\n", li, fmtRoster(fl.NewRoster))
 			} else {
@@ -431,7 +431,7 @@ func scPrint(c *cli.Context) error {
 	for i, bl := range sb.BackLinkIDs {
 		log.Infof("BackwardLink[%d] = %x", i, bl)
 	}
-	for i, fl := range sb.ForwardLink {
+	for i, fl := range sb.GetForwardLinks() {
 		log.Infof("ForwardLink[%d] = %x", i, fl.To)
 	}
 	log.Infof("Data: %#v", string(sb.Data))
@@ -845,6 +845,11 @@ func loadConfig(c *cli.Context) (*config, error) {
 		return nil, err
 	}
 	cfg.Db = skipchain.NewSkipBlockDB(db, bucketName)
+	if err := cfg.Db.CheckLayout(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %v: remove it to start again", cfgPath,
+			err)
+	}
 	err = cfg.Db.View(func(tx *bbolt.Tx) error {
 		b := tx.Bucket([]byte("config"))
 		v := b.Get([]byte("values"))
@@ -1,6 +1,9 @@
 package main
 
-import cli "github.com/urfave/cli"
+import (
+	cli "github.com/urfave/cli"
+	sccmd "go.dedis.ch/cothority/v3/skipchain/cmd"
+)
 
 func getCommands() cli.Commands {
 	groupsDef := "[group-definition]"
@@ -246,5 +249,11 @@ func getCommands() cli.Commands {
 				},
 			},
 		},
+
+		{
+			Name:        "db",
+			Usage:       "inspect the skipchains in the db of a conode",
+			Subcommands: sccmd.Commands(),
+		},
 	}
 }
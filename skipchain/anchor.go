@@ -0,0 +1,200 @@
+package skipchain
+
+import (
+	"sync"
+	"time"
+
+	"go.dedis.ch/cothority/v3/merkle"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the anchoring API. Clients send a 32-byte digest together with
+some metadata, and the leader of the skipchain collects all anchors received
+during one epoch. At the end of the epoch, a merkle tree is built over all
+anchors and its root is stored in a new block. Every client gets back the
+block, the forward-links proving the block from the genesis block, and a proof
+that its anchor is included in the root.
+*/
+
+// AnchorDigestSize is the size of the digests that can be anchored.
+const AnchorDigestSize = 32
+
+// maxAnchorMetadata is the maximum size of the metadata of one anchor.
+const maxAnchorMetadata = 1024
+
+// How long the leader waits for more anchors before creating a new block.
+var defaultAnchorEpoch = 2 * time.Second
+
+func init() {
+	network.RegisterMessages(&AnchorBatch{})
+}
+
+// AnchorBatch is stored in the data of the blocks created by AnchorData.
+type AnchorBatch struct {
+	// Root is the root of the merkle tree over all anchors of the block.
+	Root []byte
+	// Count is the number of anchors in the block.
+	Count int
+}
+
+// AnchorProof proves that an anchor is part of the merkle tree of an
+// AnchorBatch.
+type AnchorProof struct {
+	// Index of the anchor in the batch.
+	Index int
+	// Count is the number of anchors in the batch.
+	Count int
+	// Path holds the siblings from the leaf up to the root.
+	Path [][]byte
+}
+
+// Verify returns nil if the digest and the metadata are part of the merkle
+// tree of the batch.
+func (ap AnchorProof) Verify(batch *AnchorBatch, digest, metadata []byte) error {
+	if ap.Count != batch.Count {
+		return xerrors.New("proof is for another number of anchors")
+	}
+	err := merkle.Proof(ap).Verify(batch.Root, anchorLeaf(digest, metadata))
+	if err != nil {
+		return xerrors.Errorf("anchor is not part of the batch: %v", err)
+	}
+	return nil
+}
+
+// anchorLeaf hashes the digest and the metadata of an anchor. The digest
+// has a fixed size, so the concatenation is unambiguous.
+func anchorLeaf(digest, metadata []byte) []byte {
+	return merkle.LeafHash(digest, metadata)
+}
+
+// anchorBatch collects the anchors of one epoch for one skipchain.
+type anchorBatch struct {
+	leaves [][]byte
+	done   chan struct{}
+	// Only valid once done is closed.
+	tree  *merkle.Tree
+	block *SkipBlock
+	links []*ForwardLink
+	err   error
+}
+
+type anchorBatches struct {
+	sync.Mutex
+	batches map[string]*anchorBatch
+	epoch   time.Duration
+}
+
+// AnchorData adds the digest to the batch of the current epoch and returns
+// once the batch has been stored in a new block of the skipchain.
+func (s *Service) AnchorData(req *AnchorData) (*AnchorDataReply, error) {
+	if len(req.Digest) != AnchorDigestSize {
+		return nil, xerrors.Errorf("digest must be %d bytes", AnchorDigestSize)
+	}
+	if len(req.Metadata) > maxAnchorMetadata {
+		return nil, xerrors.Errorf("metadata must not be bigger than %d bytes",
+			maxAnchorMetadata)
+	}
+	latest, err := s.db.GetLatestByID(req.SkipChainID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't find skipchain: %v", err)
+	}
+	if !latest.SkipChainID().Equal(req.SkipChainID) {
+		return nil, xerrors.New("need the ID of the genesis block")
+	}
+	if !s.ServerIdentity().Equal(latest.Roster.Get(0)) {
+		return nil, xerrors.New("only the leader can anchor data")
+	}
+
+	key := string(req.SkipChainID)
+	s.anchors.Lock()
+	if s.anchors.batches == nil {
+		s.anchors.batches = make(map[string]*anchorBatch)
+	}
+	batch, ok := s.anchors.batches[key]
+	if !ok {
+		if err := s.incrementWorking(); err != nil {
+			s.anchors.Unlock()
+			return nil, err
+		}
+		batch = &anchorBatch{done: make(chan struct{})}
+		s.anchors.batches[key] = batch
+		go s.anchorEpoch(req.SkipChainID, batch, s.anchors.epoch)
+	}
+	index := len(batch.leaves)
+	batch.leaves = append(batch.leaves, anchorLeaf(req.Digest, req.Metadata))
+	s.anchors.Unlock()
+
+	<-batch.done
+	if batch.err != nil {
+		return nil, batch.err
+	}
+	return &AnchorDataReply{
+		Block: batch.block,
+		Links: batch.links,
+		Proof: AnchorProof(batch.tree.Proof(index)),
+	}, nil
+}
+
+// anchorEpoch waits for the end of the epoch and stores all anchors
+// received in the meantime in a new block.
+func (s *Service) anchorEpoch(scID SkipBlockID, batch *anchorBatch, epoch time.Duration) {
+	defer s.decrementWorking()
+	defer close(batch.done)
+
+	select {
+	case <-time.After(epoch):
+	case <-s.closing:
+		batch.err = xerrors.New("closing down")
+		return
+	}
+
+	s.anchors.Lock()
+	delete(s.anchors.batches, string(scID))
+	s.anchors.Unlock()
+
+	batch.tree = merkle.NewTree(batch.leaves)
+	data, err := network.Marshal(&AnchorBatch{Root: batch.tree.Root(),
+		Count: batch.tree.Count()})
+	if err != nil {
+		batch.err = xerrors.Errorf("couldn't marshal batch: %v", err)
+		return
+	}
+
+	latest, err := s.db.GetLatestByID(scID)
+	if err != nil {
+		batch.err = xerrors.Errorf("couldn't get latest block: %v", err)
+		return
+	}
+	sb := NewSkipBlock()
+	sb.Roster = latest.Roster
+	sb.Data = data
+	reply, err := s.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: scID,
+		NewBlock:          sb,
+	})
+	if err != nil {
+		batch.err = xerrors.Errorf("couldn't store anchors: %v", err)
+		return
+	}
+	log.Lvlf2("%s: anchored %d digests in block %d", s.ServerIdentity(),
+		len(batch.leaves), reply.Latest.Index)
+	// The clients verify the block from the genesis block they trust.
+	proof, err := s.GetProof(&GetProof{Genesis: scID, Target: reply.Latest.Hash})
+	if err != nil {
+		batch.err = xerrors.Errorf("couldn't get proof of block: %v", err)
+		return
+	}
+	batch.block = proof.Block
+	batch.links = proof.Links
+}
+
+// SetAnchorEpoch sets how long the leader collects anchors before storing
+// them in a new block. It applies to the epochs started afterwards.
+func (s *Service) SetAnchorEpoch(t time.Duration) {
+	s.anchors.Lock()
+	s.anchors.epoch = t
+	s.anchors.Unlock()
+}
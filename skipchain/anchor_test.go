@@ -0,0 +1,103 @@
+package skipchain
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/merkle"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+)
+
+func TestAnchorProof(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		var leaves [][]byte
+		for i := 0; i < n; i++ {
+			leaves = append(leaves, anchorLeaf(anchorDigest(i), []byte{byte(i)}))
+		}
+		tree := merkle.NewTree(leaves)
+		batch := &AnchorBatch{Root: tree.Root(), Count: n}
+		for i := 0; i < n; i++ {
+			ap := AnchorProof(tree.Proof(i))
+			require.NoError(t, ap.Verify(batch, anchorDigest(i), []byte{byte(i)}),
+				fmt.Sprintf("leaf %d of %d", i, n))
+			require.Error(t, ap.Verify(batch, anchorDigest(i), []byte{byte(i + 1)}))
+			require.Error(t, ap.Verify(batch, anchorDigest(i+1), []byte{byte(i)}))
+			require.Error(t, ap.Verify(&AnchorBatch{Root: batch.Root, Count: n + 1},
+				anchorDigest(i), []byte{byte(i)}))
+		}
+	}
+}
+
+func TestService_AnchorData(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	_, ro, genService := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := genService.(*Service)
+	service.SetAnchorEpoch(500 * time.Millisecond)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	_, err = service.AnchorData(&AnchorData{SkipChainID: genesis.Hash,
+		Digest: []byte{1, 2, 3}})
+	require.Error(t, err)
+	_, err = service.AnchorData(&AnchorData{SkipChainID: genesis.Hash,
+		Digest: anchorDigest(0), Metadata: make([]byte, maxAnchorMetadata+1)})
+	require.Error(t, err)
+	_, err = service.AnchorData(&AnchorData{SkipChainID: SkipBlockID{},
+		Digest: anchorDigest(0)})
+	require.Error(t, err)
+
+	// All anchors of one epoch need to end up in the same block.
+	anchors := 5
+	replies := make([]*AnchorDataReply, anchors)
+	errs := make(chan error, anchors)
+	for i := 0; i < anchors; i++ {
+		go func(i int) {
+			var err error
+			replies[i], err = service.AnchorData(&AnchorData{
+				SkipChainID: genesis.Hash,
+				Digest:      anchorDigest(i),
+				Metadata:    []byte(fmt.Sprintf("client %d", i)),
+			})
+			errs <- err
+		}(i)
+	}
+	for i := 0; i < anchors; i++ {
+		require.NoError(t, <-errs)
+	}
+	batch := anchorBatchFromBlock(t, replies[0].Block)
+	require.Equal(t, anchors, batch.Count)
+	for i, reply := range replies {
+		require.Equal(t, replies[0].Block.Hash, reply.Block.Hash)
+		require.NoError(t, VerifyProof(genesis, reply.Links, reply.Block))
+		require.NoError(t, reply.Proof.Verify(batch, anchorDigest(i),
+			[]byte(fmt.Sprintf("client %d", i))))
+	}
+
+	// A new epoch creates a new block.
+	reply, err := service.AnchorData(&AnchorData{SkipChainID: genesis.Hash,
+		Digest: anchorDigest(anchors)})
+	require.NoError(t, err)
+	require.Equal(t, replies[0].Block.Index+1, reply.Block.Index)
+	require.Equal(t, 1, anchorBatchFromBlock(t, reply.Block).Count)
+}
+
+func anchorDigest(i int) []byte {
+	h := sha256.Sum256([]byte{byte(i)})
+	return h[:]
+}
+
+func anchorBatchFromBlock(t *testing.T, sb *SkipBlock) *AnchorBatch {
+	_, msg, err := network.Unmarshal(sb.Data, cothority.Suite)
+	require.NoError(t, err)
+	batch, ok := msg.(*AnchorBatch)
+	require.True(t, ok)
+	return batch
+}
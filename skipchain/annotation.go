@@ -0,0 +1,95 @@
+package skipchain
+
+import (
+	bbolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the annotations of blocks. An annotation is a text stored
+locally by a conode for a block, for example to note which migration created
+it. Annotations are kept in their own bucket: they are not part of the hash
+of the block and are never sent to other conodes.
+*/
+
+// maxAnnotationLength is the maximum size of an annotation.
+const maxAnnotationLength = 1024
+
+// annotationBucket returns the name of the bucket of the annotations.
+func (db *SkipBlockDB) annotationBucket() []byte {
+	return append(append([]byte{}, db.bucketName...), []byte("_annotations")...)
+}
+
+// SetAnnotation stores the annotation of the block. An empty annotation
+// removes the existing one.
+func (db *SkipBlockDB) SetAnnotation(id SkipBlockID, annotation string) error {
+	if len(annotation) > maxAnnotationLength {
+		return xerrors.Errorf("annotation is longer than %d bytes",
+			maxAnnotationLength)
+	}
+	return db.Update(func(tx *bbolt.Tx) error {
+		if db.blockBucketTx(tx, id) == nil {
+			return xerrors.Errorf("unknown block %x", id)
+		}
+		if annotation == "" {
+			return db.deleteAnnotationTx(tx, id)
+		}
+		b, err := tx.CreateBucketIfNotExists(db.annotationBucket())
+		if err != nil {
+			return err
+		}
+		return b.Put(id, []byte(annotation))
+	})
+}
+
+// GetAnnotation returns the annotation of the block, or an empty string if
+// there is none.
+func (db *SkipBlockDB) GetAnnotation(id SkipBlockID) (string, error) {
+	var annotation string
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(db.annotationBucket())
+		if b == nil {
+			return nil
+		}
+		annotation = string(b.Get(id))
+		return nil
+	})
+	return annotation, err
+}
+
+// deleteAnnotationTx removes the annotation of the block, if any.
+func (db *SkipBlockDB) deleteAnnotationTx(tx *bbolt.Tx, id SkipBlockID) error {
+	b := tx.Bucket(db.annotationBucket())
+	if b == nil {
+		return nil
+	}
+	return b.Delete(id)
+}
+
+// SetAnnotation stores a local annotation for a block. The request must be
+// signed by a linked client or by this conode.
+func (s *Service) SetAnnotation(req *SetAnnotation) (*EmptyReply, error) {
+	if !s.verifyAdminSigs(setAnnotationMsg(req.BlockID, req.Annotation), req.Signature) {
+		return nil, xerrors.New("wrong signature of unknown signer")
+	}
+	if err := s.db.SetAnnotation(req.BlockID, req.Annotation); err != nil {
+		return nil, xerrors.Errorf("couldn't set annotation: %v", err)
+	}
+	return &EmptyReply{}, nil
+}
+
+// GetAnnotation returns the local annotation of a block.
+func (s *Service) GetAnnotation(req *GetAnnotation) (*GetAnnotationReply, error) {
+	annotation, err := s.db.GetAnnotation(req.BlockID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get annotation: %v", err)
+	}
+	return &GetAnnotationReply{Annotation: annotation}, nil
+}
+
+// setAnnotationMsg returns the message to be signed by a linked client to
+// set an annotation.
+func setAnnotationMsg(id SkipBlockID, annotation string) []byte {
+	msg := append([]byte("annotation:"), id...)
+	return append(msg, []byte(annotation)...)
+}
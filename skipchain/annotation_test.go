@@ -0,0 +1,101 @@
+package skipchain
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestSkipBlockDB_Annotation(t *testing.T) {
+	l := onet.NewLocalTest(suite)
+	_, roster, _ := l.GenTree(1, false)
+	defer l.CloseAll()
+
+	db, fname := setupSkipBlockDB(t)
+	defer db.Close()
+	defer os.Remove(fname)
+
+	sb := NewSkipBlock()
+	sb.Roster = roster
+	sb.BackLinkIDs = []SkipBlockID{{1, 2, 3}}
+	sb.updateHash()
+	hash := sb.Hash
+	db.Store(sb)
+
+	a, err := db.GetAnnotation(hash)
+	require.NoError(t, err)
+	require.Equal(t, "", a)
+
+	require.Error(t, db.SetAnnotation(SkipBlockID{1}, "unknown block"))
+	require.Error(t, db.SetAnnotation(hash, strings.Repeat("a", maxAnnotationLength+1)))
+	require.NoError(t, db.SetAnnotation(hash, "created by migration X"))
+	a, err = db.GetAnnotation(hash)
+	require.NoError(t, err)
+	require.Equal(t, "created by migration X", a)
+
+	// The annotation is not part of the block.
+	require.Equal(t, hash, db.GetByID(hash).CalculateHash())
+	require.Equal(t, 1, db.Length())
+
+	require.NoError(t, db.SetAnnotation(hash, ""))
+	a, err = db.GetAnnotation(hash)
+	require.NoError(t, err)
+	require.Equal(t, "", a)
+
+	require.NoError(t, db.SetAnnotation(hash, "removed with the block"))
+	require.NoError(t, db.RemoveBlock(hash))
+	a, err = db.GetAnnotation(hash)
+	require.NoError(t, err)
+	require.Equal(t, "", a)
+}
+
+func TestClient_Annotation(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, ro, gs := l.MakeSRS(cothority.Suite, 2, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	// Without linked clients, only the conode itself can set annotations.
+	other := key.NewKeyPair(cothority.Suite)
+	require.Error(t, c.SetAnnotation(ro.List[0], other.Private, genesis.Hash, "tag"))
+	_, err = service.SetAnnotation(&SetAnnotation{BlockID: genesis.Hash, Annotation: "tag"})
+	require.Error(t, err)
+	require.NoError(t, c.SetAnnotation(ro.List[0], l.GetPrivate(servers[0]),
+		genesis.Hash, "own"))
+	a, err := c.GetAnnotation(ro.List[0], genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, "own", a)
+
+	kp := key.NewKeyPair(cothority.Suite)
+	service.Storage.Clients = []kyber.Point{kp.Public}
+	require.Error(t, c.SetAnnotation(ro.List[0], other.Private, genesis.Hash, "tag"))
+	require.NoError(t, c.SetAnnotation(ro.List[0], kp.Private, genesis.Hash, "tag"))
+
+	a, err = c.GetAnnotation(ro.List[0], genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, "tag", a)
+	// Annotations are never sent to other nodes.
+	a, err = c.GetAnnotation(ro.List[1], genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, "", a)
+
+	sig, err := schnorr.Sign(cothority.Suite, kp.Private, setAnnotationMsg(genesis.Hash, ""))
+	require.NoError(t, err)
+	_, err = service.SetAnnotation(&SetAnnotation{BlockID: genesis.Hash, Signature: sig})
+	require.NoError(t, err)
+	a, err = c.GetAnnotation(ro.List[0], genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, "", a)
+}
@@ -4,6 +4,9 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
 	"go.dedis.ch/cothority/v3"
 	status "go.dedis.ch/cothority/v3/status/service"
@@ -12,6 +15,7 @@ import (
 	"go.dedis.ch/onet/v3"
 	"go.dedis.ch/onet/v3/log"
 	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
 )
 
 // Client is a structure to communicate with the Skipchain
@@ -60,6 +64,33 @@ func (c *Client) DontContact(si *network.ServerIdentity) {
 //  - priv is the private key that will be used to sign the skipblock. If priv
 //    is nil, the skipblock will not be signed.
 func (c *Client) StoreSkipBlockSignature(target *SkipBlock, ro *onet.Roster, d network.Message, priv kyber.Scalar) (reply *StoreSkipBlockReply, err error) {
+	return c.storeSkipBlock(target, ro, d, priv, nil, nil)
+}
+
+// StoreSkipBlockIdempotent works like StoreSkipBlock, but sends a key along
+// with the new block. If the leader already added a block with the same key
+// to the chain, it returns this block instead of adding a new one. This
+// allows to retry the call after a timeout without creating duplicate
+// blocks. The leader remembers the keys only for a limited time.
+func (c *Client) StoreSkipBlockIdempotent(target *SkipBlock, ro *onet.Roster, d network.Message, key []byte) (reply *StoreSkipBlockReply, err error) {
+	if len(key) == 0 {
+		return nil, errors.New("empty idempotency key")
+	}
+	return c.storeSkipBlock(target, ro, d, nil, key, nil)
+}
+
+// StoreSkipBlockProposal works like StoreSkipBlock for chains restricting
+// their Proposers. The new block is proposed to follow latest, which must be
+// the latest block of the chain, and proposerPriv must be the private key
+// of one of the Proposers stored in its genesis block.
+func (c *Client) StoreSkipBlockProposal(latest *SkipBlock, ro *onet.Roster, d network.Message, proposerPriv kyber.Scalar) (reply *StoreSkipBlockReply, err error) {
+	if d == nil {
+		d = []byte{}
+	}
+	return c.storeSkipBlock(latest, ro, d, nil, nil, proposerPriv)
+}
+
+func (c *Client) storeSkipBlock(target *SkipBlock, ro *onet.Roster, d network.Message, priv kyber.Scalar, key []byte, proposerPriv kyber.Scalar) (reply *StoreSkipBlockReply, err error) {
 	log.Lvlf3("%#v", target)
 	var newBlock *SkipBlock
 	var targetID SkipBlockID
@@ -95,8 +126,15 @@ func (c *Client) StoreSkipBlockSignature(target *SkipBlock, ro *onet.Roster, d n
 		}
 		sig = &signature
 	}
+	var proposal []byte
+	if proposerPriv != nil {
+		proposal, err = signProposal(target, newBlock.Data, proposerPriv)
+		if err != nil {
+			return nil, errors.New("couldn't sign proposal: " + err.Error())
+		}
+	}
 	err = c.SendProtobuf(host, &StoreSkipBlock{TargetSkipChainID: targetID, NewBlock: newBlock,
-		Signature: sig}, reply)
+		Signature: sig, IdempotencyKey: key, ProposerSignature: proposal}, reply)
 	if err != nil {
 		return nil, err
 	}
@@ -130,6 +168,35 @@ func (c *Client) StoreSkipBlock(target *SkipBlock, ro *onet.Roster, d network.Me
 	return c.StoreSkipBlockSignature(target, ro, d, nil)
 }
 
+// StoreSkipBlocks asks the leader of the target's roster to append one block
+// for every data to the skipchain of the target, in order and with a single
+// signature of the roster. The new blocks keep the roster of the latest
+// block. The forward-links of the new blocks are verified.
+func (c *Client) StoreSkipBlocks(target *SkipBlock, data [][]byte) (*StoreSkipBlocksReply, error) {
+	req := &StoreSkipBlocks{TargetSkipChainID: target.SkipChainID()}
+	for _, d := range data {
+		sb := NewSkipBlock()
+		sb.Data = d
+		req.NewBlocks = append(req.NewBlocks, sb)
+	}
+	reply := &StoreSkipBlocksReply{}
+	err := c.SendProtobuf(target.Roster.Get(0), req, reply)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Previous == nil || len(reply.Latest) != len(data) {
+		return nil, xerrors.New("incomplete reply")
+	}
+	if !reply.Previous.SkipChainID().Equal(target.SkipChainID()) {
+		return nil, xerrors.New("reply is for another skipchain")
+	}
+	chain := append([]*SkipBlock{reply.Previous}, reply.Latest...)
+	if err := Proof(chain).VerifyFromID(reply.Previous.Hash); err != nil {
+		return nil, xerrors.Errorf("invalid forward-links: %v", err)
+	}
+	return reply, nil
+}
+
 // CreateGenesisSignature is a convenience function to create a new SkipChain with the
 // given parameters.
 //  - ro is the responsible roster
@@ -144,11 +211,46 @@ func (c *Client) StoreSkipBlock(target *SkipBlock, ro *onet.Roster, d network.Me
 // This function returns the created skipblock or nil and an error.
 func (c *Client) CreateGenesisSignature(ro *onet.Roster, baseH, maxH int, ver []VerifierID,
 	data interface{}, priv kyber.Scalar) (*SkipBlock, error) {
-	genesis := NewSkipBlock()
-	genesis.Roster = ro
-	genesis.VerifierIDs = ver
-	genesis.MaximumHeight = maxH
-	genesis.BaseHeight = baseH
+	return c.createGenesis(ro, baseH, maxH, ver, data, priv, 0)
+}
+
+// CreateGenesisThreshold creates a new SkipChain like CreateGenesis, but the
+// forward-links of the new skipchain are accepted as soon as threshold nodes
+// of the roster signed them. The threshold is stored in the genesis block and
+// cannot be changed later on.
+func (c *Client) CreateGenesisThreshold(ro *onet.Roster, baseH, maxH int, ver []VerifierID,
+	data interface{}, threshold int) (*SkipBlock, error) {
+	if threshold <= 0 || threshold > len(ro.List) {
+		return nil, xerrors.Errorf("threshold must be between 1 and %d",
+			len(ro.List))
+	}
+	return c.createGenesis(ro, baseH, maxH, ver, data, nil, threshold)
+}
+
+// CreateChainFromTemplate creates a new skipchain with the parameters of the
+// template. The data is stored as-is if it is a []byte, else it is
+// marshalled. The template is verified before the roster is contacted.
+func (c *Client) CreateChainFromTemplate(ro *onet.Roster, ct ChainTemplate,
+	data interface{}) (*SkipBlock, error) {
+	if err := ct.Verify(ro); err != nil {
+		return nil, xerrors.Errorf("invalid template: %v", err)
+	}
+	return c.createFromTemplate(ro, ct, data, nil)
+}
+
+func (c *Client) createGenesis(ro *onet.Roster, baseH, maxH int, ver []VerifierID,
+	data interface{}, priv kyber.Scalar, threshold int) (*SkipBlock, error) {
+	return c.createFromTemplate(ro, ChainTemplate{
+		BaseHeight:         baseH,
+		MaximumHeight:      maxH,
+		VerifierIDs:        ver,
+		SignatureThreshold: threshold,
+	}, data, priv)
+}
+
+func (c *Client) createFromTemplate(ro *onet.Roster, ct ChainTemplate,
+	data interface{}, priv kyber.Scalar) (*SkipBlock, error) {
+	genesis := ct.NewGenesis(ro)
 	if data != nil {
 		var ok bool
 		genesis.Data, ok = data.([]byte)
@@ -209,6 +311,19 @@ func compareGenesisBlocks(prop *SkipBlock, ret *SkipBlock) error {
 		return errors.New("got a different base height")
 	}
 
+	if ret.SignatureThreshold != prop.SignatureThreshold {
+		return errors.New("got a different signature threshold")
+	}
+
+	if len(ret.Proposers) != len(prop.Proposers) {
+		return errors.New("got a different list of proposers")
+	}
+	for i, p := range prop.Proposers {
+		if !ret.Proposers[i].Equal(p) {
+			return errors.New("got a different list of proposers")
+		}
+	}
+
 	return nil
 }
 
@@ -344,7 +459,7 @@ func (c *Client) GetUpdateChainLevel(initRoster *onet.Roster,
 				if !b.BackLinkIDs[link-1].Equal(prevBlock.Hash) {
 					return nil, errors.New("corresponding backlink doesn't point to previous block")
 				}
-				if !prevBlock.ForwardLink[link-1].To.Equal(b.Hash) {
+				if fl := prevBlock.GetForward(link - 1); fl == nil || !fl.To.Equal(b.Hash) {
 					return nil, errors.New("corresponding forwardlink doesn't point to next block")
 				}
 			}
@@ -372,11 +487,11 @@ func (c *Client) GetUpdateChainLevel(initRoster *onet.Roster,
 		}
 
 		// Trust the block sent back and fetch the next block it points to
-		flHeight := len(last.ForwardLink)
+		flHeight := last.GetForwardLen()
 		if maxLevel > 0 && flHeight > maxLevel {
 			flHeight = maxLevel
 		}
-		highestFL := last.ForwardLink[flHeight-1]
+		highestFL := last.GetForward(flHeight - 1)
 		latest = highestFL.To
 		roster = highestFL.NewRoster
 		if roster == nil {
@@ -385,6 +500,215 @@ func (c *Client) GetUpdateChainLevel(initRoster *onet.Roster,
 	}
 }
 
+// VerifyUpdateChain checks a list of blocks as returned by GetUpdateChain and
+// returns the last block of the list if all checks pass. For every block the
+// hash and the signatures of the forward-links are verified. For every pair
+// of consecutive blocks it checks that:
+//   - both blocks belong to the same skipchain
+//   - the index of the next block corresponds to the height of the link
+//   - the previous block has a forward-link to the next block, signed by the
+//     roster of the previous block
+//   - the next block has the corresponding back-link to the previous block
+//   - a change of roster is announced in the forward-link
+func VerifyUpdateChain(blocks []*SkipBlock) (*SkipBlock, error) {
+	if len(blocks) == 0 {
+		return nil, xerrors.New("empty update chain")
+	}
+
+	for i, sb := range blocks {
+		if sb == nil {
+			return nil, xerrors.Errorf("block %d is nil", i)
+		}
+		if sb.SkipBlockFix == nil {
+			return nil, xerrors.Errorf("block %d has no header", i)
+		}
+		if err := sb.VerifyForwardSignatures(); err != nil {
+			return nil, xerrors.Errorf("block %d with index %d: %v", i,
+				sb.Index, err)
+		}
+		if i == 0 {
+			continue
+		}
+
+		prev := blocks[i-1]
+		if !prev.SkipChainID().Equal(sb.SkipChainID()) {
+			return nil, xerrors.Errorf("block %d is from a different skipchain", i)
+		}
+		if sb.Index <= prev.Index {
+			return nil, xerrors.Errorf("block %d has index %d which is not after %d",
+				i, sb.Index, prev.Index)
+		}
+
+		height := -1
+		for h, fl := range prev.forwardLinks() {
+			if !fl.IsEmpty() && fl.To.Equal(sb.Hash) {
+				height = h
+			}
+		}
+		if height < 0 {
+			return nil, xerrors.Errorf("block %d with index %d has no forward-link to index %d",
+				i-1, prev.Index, sb.Index)
+		}
+		if height >= len(sb.BackLinkIDs) {
+			return nil, xerrors.Errorf("forward-link of height %d points to block %d of height %d",
+				height, sb.Index, len(sb.BackLinkIDs))
+		}
+		if prev.BaseHeight > 1 {
+			dist := int(math.Pow(float64(prev.BaseHeight), float64(height)))
+			if sb.Index-prev.Index != dist {
+				return nil, xerrors.Errorf("forward-link of height %d from index %d cannot point to index %d",
+					height, prev.Index, sb.Index)
+			}
+		} else if sb.Index-prev.Index != 1 {
+			return nil, xerrors.Errorf("block %d has index %d but expected %d",
+				i, sb.Index, prev.Index+1)
+		}
+		if !sb.BackLinkIDs[height].Equal(prev.Hash) {
+			return nil, xerrors.Errorf("backlink of height %d of block %d doesn't point to block %d",
+				height, sb.Index, prev.Index)
+		}
+
+		fl := prev.GetForward(height)
+		if !fl.From.Equal(prev.Hash) {
+			return nil, xerrors.Errorf("forward-link of block %d doesn't start from it", prev.Index)
+		}
+		if sb.Roster == nil {
+			return nil, xerrors.Errorf("block %d has no roster", sb.Index)
+		}
+		if prev.Roster.ID.Equal(sb.Roster.ID) {
+			if fl.NewRoster != nil && !fl.NewRoster.ID.Equal(sb.Roster.ID) {
+				return nil, xerrors.Errorf("forward-link from %d to %d announces a different roster",
+					prev.Index, sb.Index)
+			}
+		} else {
+			if fl.NewRoster == nil {
+				return nil, xerrors.Errorf("roster changed from %d to %d without being announced",
+					prev.Index, sb.Index)
+			}
+			if !fl.NewRoster.ID.Equal(sb.Roster.ID) {
+				return nil, xerrors.Errorf("forward-link from %d to %d announces a different roster",
+					prev.Index, sb.Index)
+			}
+		}
+	}
+
+	return blocks[len(blocks)-1], nil
+}
+
+// AnchorData asks the leader of the skipchain to anchor the digest and the
+// metadata in a new block. It returns once the block is stored, and verifies
+// that the block is signed by the skipchain of the trusted genesis block and
+// that it includes the anchor.
+func (c *Client) AnchorData(roster *onet.Roster, genesis *SkipBlock, digest, metadata []byte) (*AnchorDataReply, error) {
+	reply := &AnchorDataReply{}
+	err := c.SendProtobuf(roster.Get(0), &AnchorData{
+		SkipChainID: genesis.Hash,
+		Digest:      digest,
+		Metadata:    metadata,
+	}, reply)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Block == nil {
+		return nil, xerrors.New("got no block in reply")
+	}
+	if err := VerifyProof(genesis, reply.Links, reply.Block); err != nil {
+		return nil, xerrors.Errorf("invalid proof of the block: %v", err)
+	}
+	_, msg, err := network.Unmarshal(reply.Block.Data, cothority.Suite)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't unmarshal block data: %v", err)
+	}
+	batch, ok := msg.(*AnchorBatch)
+	if !ok {
+		return nil, xerrors.New("block doesn't hold anchors")
+	}
+	if err := reply.Proof.Verify(batch, digest, metadata); err != nil {
+		return nil, xerrors.Errorf("invalid inclusion proof: %v", err)
+	}
+	return reply, nil
+}
+
+// RegisterName asks the leader of the naming registry to map the name to the
+// skipchain. Names are given on a first-come basis. If the leader has linked
+// clients, priv must be the private key of one of them, else it can be nil.
+func (c *Client) RegisterName(roster *onet.Roster, registry SkipBlockID, name string,
+	scID SkipBlockID, priv kyber.Scalar) (*RegisterNameReply, error) {
+	req := &RegisterName{Registry: registry, Name: name, SkipChainID: scID}
+	if priv != nil {
+		sig, err := schnorr.Sign(cothority.Suite, priv,
+			NameRegistrationHash(registry, name, scID))
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't sign name: %v", err)
+		}
+		req.Signature = &sig
+	}
+	reply := &RegisterNameReply{}
+	err := c.SendProtobuf(roster.Get(0), req, reply)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Block == nil {
+		return nil, xerrors.New("got no block in reply")
+	}
+	if !reply.Block.SkipChainID().Equal(registry) {
+		return nil, xerrors.New("got a block from another skipchain")
+	}
+	if !reply.Block.CalculateHash().Equal(reply.Block.Hash) {
+		return nil, xerrors.New("wrong hash of the returned block")
+	}
+	entry := nameEntryFromBlock(reply.Block)
+	if entry == nil || entry.Name != name || !entry.SkipChainID.Equal(scID) {
+		return nil, xerrors.New("block doesn't hold the name")
+	}
+	return reply, nil
+}
+
+// ResolveName returns the ID of the skipchain registered under the name in
+// the registry. The block holding the name is verified with the proof from
+// the genesis block of the registry.
+func (c *Client) ResolveName(roster *onet.Roster, registry SkipBlockID, name string) (SkipBlockID, error) {
+	reply := &ResolveNameReply{}
+	_, err := c.SendProtobufParallel(roster.List, &ResolveName{Registry: registry,
+		Name: name}, reply, c.options)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Verify(registry, name)
+}
+
+// ResolveChain returns the skipchain-ID for an argument given by a user,
+// which is either an hex-encoded ID or a name in the registry.
+func (c *Client) ResolveChain(roster *onet.Roster, registry SkipBlockID, nameOrID string) (SkipBlockID, error) {
+	if id := ParseSkipChainID(nameOrID); id != nil {
+		return id, nil
+	}
+	if err := checkName(nameOrID); err != nil {
+		return nil, xerrors.Errorf("neither an ID nor a name: %v", err)
+	}
+	return c.ResolveName(roster, registry, nameOrID)
+}
+
+// GetCheckpoint returns the latest checkpoint block of the skipchain and its
+// checkpoint. The block is verified with the proof from the genesis block.
+func (c *Client) GetCheckpoint(roster *onet.Roster, scID SkipBlockID) (*SkipBlock, *Checkpoint, error) {
+	reply := &GetCheckpointReply{}
+	_, err := c.SendProtobufParallel(roster.List, &GetCheckpoint{SkipChainID: scID},
+		reply, c.options)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, err := c.GetSingleBlockByIndex(roster, scID, reply.Index)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("couldn't get checkpoint block: %v", err)
+	}
+	cp := CheckpointFromBlock(block.SkipBlock)
+	if cp == nil {
+		return nil, nil, xerrors.New("block doesn't hold a checkpoint")
+	}
+	return block.SkipBlock, cp, nil
+}
+
 // GetAllSkipchains is deprecated and should no longer be used. See GetAllSkipChainIDs.
 func (c *Client) GetAllSkipchains(si *network.ServerIdentity) (reply *GetAllSkipchainsReply,
 	err error) {
@@ -454,6 +778,64 @@ func (c *Client) GetSingleBlockByIndex(roster *onet.Roster, genesis SkipBlockID,
 	return
 }
 
+// GetBlockRange returns a page of consecutive blocks of a chain. The hashes of
+// the blocks, their forward-link signatures and the backlinks between them
+// are verified. To get the next page, the request has to be sent again with
+// the NextCursor of the reply.
+func (c *Client) GetBlockRange(roster *onet.Roster, req *GetBlockRange) (*GetBlockRangeReply, error) {
+	reply := &GetBlockRangeReply{}
+	_, err := c.SendProtobufParallel(roster.List, req, reply, c.options)
+	if err != nil {
+		return nil, xerrors.Errorf("all nodes failed to return blocks: %v", err)
+	}
+	if len(reply.Blocks) == 0 {
+		return nil, xerrors.New("got an empty reply")
+	}
+	if len(req.Cursor) == 0 && !reply.Blocks[0].Hash.Equal(req.Start) {
+		return nil, xerrors.New("got the wrong first block")
+	}
+	for i, sb := range reply.Blocks {
+		if err := sb.VerifyForwardSignatures(); err != nil {
+			return nil, err
+		}
+		if i == 0 {
+			continue
+		}
+		older, newer := reply.Blocks[i-1], sb
+		if req.Direction == DirectionBackward {
+			older, newer = newer, older
+		}
+		if len(newer.BackLinkIDs) == 0 || !newer.BackLinkIDs[0].Equal(older.Hash) ||
+			!newer.SkipChainID().Equal(older.SkipChainID()) {
+			return nil, xerrors.Errorf("block %x is not linked to the previous one",
+				sb.Hash)
+		}
+	}
+	return reply, nil
+}
+
+// GetProof returns the forward-links from the genesis block to the target
+// block, or to the latest block if target is nil. The links are verified with
+// VerifyProof, so the genesis block must be trusted by the caller.
+func (c *Client) GetProof(roster *onet.Roster, genesis *SkipBlock, target SkipBlockID) (*GetProofReply, error) {
+	reply := &GetProofReply{}
+	_, err := c.SendProtobufParallel(roster.List, &GetProof{Genesis: genesis.Hash,
+		Target: target}, reply, c.options)
+	if err != nil {
+		return nil, xerrors.Errorf("all nodes failed to return a proof: %v", err)
+	}
+	if reply.Block == nil {
+		return nil, xerrors.New("got an empty reply")
+	}
+	if len(target) > 0 && !reply.Block.Hash.Equal(target) {
+		return nil, xerrors.New("got the wrong block in reply")
+	}
+	if err := VerifyProof(genesis, reply.Links, reply.Block); err != nil {
+		return nil, xerrors.Errorf("invalid proof: %v", err)
+	}
+	return reply, nil
+}
+
 // CreateLinkPrivate asks the conode to create a link by sending a public
 // key of the client, signed by the private key of the conode. The reasoning is
 // that an administrator should well be able to copy the private.toml-file from
@@ -551,6 +933,84 @@ func (c *Client) DelFollow(si *network.ServerIdentity, clientPriv kyber.Scalar,
 	return c.SendProtobuf(si, &DelFollow{SkipchainID: scid, Signature: sig}, nil)
 }
 
+// ReconcileDB asks the conode si to fetch all blocks it is missing from the
+// peer. This is used to repair a conode that has been offline, or whose
+// database has been restored from a partial backup. clientPriv must be the
+// private key of one of the linked clients of the conode, or the private key
+// of the conode itself.
+func (c *Client) ReconcileDB(si *network.ServerIdentity, clientPriv kyber.Scalar,
+	peer *network.ServerIdentity) (*ReconcileDBReply, error) {
+	sig, err := schnorr.Sign(cothority.Suite, clientPriv, reconcileDBMsg(peer))
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	reply := &ReconcileDBReply{}
+	err = c.SendProtobuf(si, &ReconcileDB{Peer: peer, Signature: sig}, reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// SetAnnotation stores an annotation for the block on the conode si. The
+// annotation is only kept locally and doesn't change the hash of the block.
+// An empty annotation removes the existing one. clientPriv must be the
+// private key of one of the linked clients of the conode, or the private key
+// of the conode itself.
+func (c *Client) SetAnnotation(si *network.ServerIdentity, clientPriv kyber.Scalar,
+	id SkipBlockID, annotation string) error {
+	sig, err := schnorr.Sign(cothority.Suite, clientPriv, setAnnotationMsg(id, annotation))
+	if err != nil {
+		return xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	return c.SendProtobuf(si, &SetAnnotation{BlockID: id, Annotation: annotation,
+		Signature: sig}, nil)
+}
+
+// GetAnnotation returns the annotation of the block stored on the conode si.
+func (c *Client) GetAnnotation(si *network.ServerIdentity, id SkipBlockID) (string, error) {
+	reply := &GetAnnotationReply{}
+	err := c.SendProtobuf(si, &GetAnnotation{BlockID: id}, reply)
+	if err != nil {
+		return "", err
+	}
+	return reply.Annotation, nil
+}
+
+// GetSignedHead asks the nodes of the roster for the latest block of the
+// skipchain, together with a statement that it is the head co-signed by the
+// roster of this block. The reply is verified from the genesis block, and
+// rejected if the statement is older than maxAge. A maxAge of 0 accepts
+// statements of any age.
+func (c *Client) GetSignedHead(roster *onet.Roster, scID SkipBlockID, maxAge time.Duration) (*GetSignedHeadReply, error) {
+	reply := &GetSignedHeadReply{}
+	_, err := c.SendProtobufParallel(roster.List, &GetSignedHead{SkipChainID: scID},
+		reply, c.options)
+	if err != nil {
+		return nil, err
+	}
+	if err := reply.Verify(scID, maxAge); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
+// GetPoF asks the nodes of the roster for the latest proof of freshness of
+// the skipchain. It is rejected if it doesn't verify from the genesis block
+// or if it is older than maxAge. A maxAge of 0 accepts proofs of any age.
+func (c *Client) GetPoF(roster *onet.Roster, scID SkipBlockID, maxAge time.Duration) (*PoF, error) {
+	reply := &GetPoFReply{}
+	_, err := c.SendProtobufParallel(roster.List, &GetPoF{SkipChainID: scID},
+		reply, c.options)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyPoF(reply.PoF, scID, maxAge); err != nil {
+		return nil, err
+	}
+	return reply.PoF, nil
+}
+
 // ListFollow returns the list of latest skipblock of all skipchains that are followed
 // for authentication purposes.
 func (c *Client) ListFollow(si *network.ServerIdentity, clientPriv kyber.Scalar) (*ListFollowReply, error) {
@@ -570,3 +1030,276 @@ func (c *Client) ListFollow(si *network.ServerIdentity, clientPriv kyber.Scalar)
 	}
 	return reply, nil
 }
+
+// Redact asks all nodes of the roster to delete the payload of a redactable
+// block. The hash and the links of the block are not changed. clientPriv must
+// be the private key of a client linked to the conodes. The returned error
+// holds the errors of all nodes that failed.
+func (c *Client) Redact(roster *onet.Roster, clientPriv kyber.Scalar,
+	id SkipBlockID, reason string) error {
+	sig, err := schnorr.Sign(cothority.Suite, clientPriv, redactMsg(id, reason))
+	if err != nil {
+		return xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	// All nodes are asked, even if some of them fail, so that the payload
+	// is deleted from as many nodes as possible.
+	var errs []string
+	for _, si := range roster.List {
+		err := c.SendProtobuf(si, &Redact{BlockID: id, Reason: reason,
+			Signature: sig}, nil)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", si, err))
+		}
+	}
+	if len(errs) > 0 {
+		return xerrors.Errorf("couldn't redact on %d of %d nodes: %s",
+			len(errs), len(roster.List), strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// GetRedaction returns the redaction of the block recorded by the conode
+// si, or nil if the block has not been redacted.
+func (c *Client) GetRedaction(si *network.ServerIdentity, id SkipBlockID) (*Redaction, error) {
+	reply := &GetRedactionReply{}
+	err := c.SendProtobuf(si, &GetRedaction{BlockID: id}, reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Redaction, nil
+}
+
+// DeleteChainLocal asks the conode si to remove all blocks of the skipchain
+// from its database and to refuse future blocks of this skipchain.
+// clientPriv must be the private key of one of the linked clients of the
+// conode, or the private key of the conode itself.
+func (c *Client) DeleteChainLocal(si *network.ServerIdentity, clientPriv kyber.Scalar,
+	scID SkipBlockID) error {
+	sig, err := schnorr.Sign(cothority.Suite, clientPriv, deleteChainMsg(scID))
+	if err != nil {
+		return xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	return c.SendProtobuf(si, &DeleteChainLocal{SkipChainID: scID,
+		Signature: sig}, nil)
+}
+
+// ArchiveExport returns the blocks of the skipchain stored by the conode si
+// with an index between from and to, both included.
+func (c *Client) ArchiveExport(si *network.ServerIdentity, scID SkipBlockID,
+	from, to int) (*Archive, error) {
+	reply := &ArchiveExportReply{}
+	err := c.SendProtobuf(si, &ArchiveExport{SkipChainID: scID, From: from,
+		To: to}, reply)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Archive == nil {
+		return nil, xerrors.New("got an empty archive")
+	}
+	for _, sb := range reply.Archive.Blocks {
+		if !sb.CalculateHash().Equal(sb.Hash) {
+			return nil, xerrors.Errorf("block %x doesn't match its hash", sb.Hash)
+		}
+	}
+	return reply.Archive, nil
+}
+
+// ArchiveImport asks the conode si to restore the pruned payloads of the
+// blocks of the archive and returns the number of restored blocks.
+// clientPriv must be the private key of one of the linked clients of the
+// conode, or the private key of the conode itself.
+func (c *Client) ArchiveImport(si *network.ServerIdentity, clientPriv kyber.Scalar,
+	a *Archive) (int, error) {
+	sig, err := schnorr.Sign(cothority.Suite, clientPriv, archiveImportMsg(a))
+	if err != nil {
+		return 0, xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	reply := &ArchiveImportReply{}
+	err = c.SendProtobuf(si, &ArchiveImport{Archive: a, Signature: sig}, reply)
+	if err != nil {
+		return 0, err
+	}
+	return reply.Restored, nil
+}
+
+// ChangeRoster asks the leader of the new roster to add a block with the
+// new roster to the chain. At most a third of the nodes of the current
+// roster can be added or removed at once, and the leader of the new roster
+// must be part of the current roster. The chain must use
+// VerifyRosterChange. clientPriv must be the private key of one of the
+// linked clients of the conode, or the private key of the conode itself.
+func (c *Client) ChangeRoster(scID SkipBlockID, newRoster *onet.Roster,
+	clientPriv kyber.Scalar) (*StoreSkipBlockReply, error) {
+	return c.changeRoster(&ChangeRoster{SkipChainID: scID, NewRoster: newRoster},
+		clientPriv)
+}
+
+// ChangeFrozenRoster works like ChangeRoster for a frozen chain, whose
+// latest block must be latest. adminPriv must be the private key of one of
+// the FreezeAdmins stored in its genesis block.
+func (c *Client) ChangeFrozenRoster(latest *SkipBlock, newRoster *onet.Roster,
+	clientPriv, adminPriv kyber.Scalar) (*StoreSkipBlockReply, error) {
+	sig, err := schnorr.Sign(cothority.Suite, adminPriv,
+		frozenRosterMsg(latest.SkipChainID(), latest.Index+1, newRoster))
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	return c.changeRoster(&ChangeRoster{SkipChainID: latest.SkipChainID(),
+		NewRoster: newRoster, AdminSignature: sig}, clientPriv)
+}
+
+// changeRoster signs the request with clientPriv and sends it to the leader
+// of the new roster.
+func (c *Client) changeRoster(req *ChangeRoster,
+	clientPriv kyber.Scalar) (*StoreSkipBlockReply, error) {
+	if req.NewRoster == nil {
+		return nil, xerrors.New("missing roster")
+	}
+	sig, err := schnorr.Sign(cothority.Suite, clientPriv,
+		changeRosterMsg(req.SkipChainID, req.NewRoster))
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	req.Signature = sig
+	reply := &StoreSkipBlockReply{}
+	err = c.SendProtobuf(req.NewRoster.Get(0), req, reply)
+	if err != nil {
+		return nil, err
+	}
+	if reply.Latest == nil {
+		return nil, xerrors.New("got an empty reply")
+	}
+	if err := reply.Latest.VerifyForwardSignatures(); err != nil {
+		return nil, err
+	}
+	if !reply.Latest.Roster.ID.Equal(req.NewRoster.ID) {
+		return nil, xerrors.New("got a block with another roster")
+	}
+	return reply, nil
+}
+
+// SetFrozen adds a control block freezing or unfreezing the chain after
+// latest, which must be the latest block of the chain. The chain must use
+// the VerifyFreezable verifier, and adminPriv must be the private key of one
+// of the FreezeAdmins stored in its genesis block.
+func (c *Client) SetFrozen(latest *SkipBlock, frozen bool,
+	adminPriv kyber.Scalar) (*StoreSkipBlockReply, error) {
+	fc, err := newFreezeControl(latest, frozen, adminPriv)
+	if err != nil {
+		return nil, err
+	}
+	return c.StoreSkipBlock(latest, nil, fc)
+}
+
+// GetForkEvidence returns the evidence of the fork of the chain detected by
+// the conode si, or of all chains if scID is nil.
+func (c *Client) GetForkEvidence(si *network.ServerIdentity, scID SkipBlockID) ([]ForkEvidence, error) {
+	reply := &GetForkEvidenceReply{}
+	err := c.SendProtobuf(si, &GetForkEvidence{SkipChainID: scID}, reply)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Forks, nil
+}
+
+// ResolveFork asks the conode si to remove the evidence of the fork of the
+// chain and to store its blocks again. clientPriv must be the private key of
+// one of the linked clients of the conode, or the private key of the conode
+// itself.
+func (c *Client) ResolveFork(si *network.ServerIdentity, clientPriv kyber.Scalar,
+	scID SkipBlockID) error {
+	sig, err := schnorr.Sign(cothority.Suite, clientPriv, resolveForkMsg(scID))
+	if err != nil {
+		return xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	return c.SendProtobuf(si, &ResolveFork{SkipChainID: scID, Signature: sig}, nil)
+}
+
+// StreamBlocks asks the conode si to send the new blocks of the skipchain
+// and calls handler for every one of them. It returns once the connection
+// fails or is closed with Close, after calling handler with the error. The
+// hash of every block and the target of its forward-link are checked, but
+// not the signature of the forward-link, as the roster of the previous block
+// might be unknown to the client.
+func (c *Client) StreamBlocks(si *network.ServerIdentity, scID SkipBlockID,
+	handler func(*StreamBlocksReply, error)) error {
+	return c.streamBlocks(si, []SkipBlockID{scID}, handler)
+}
+
+// streamBlocks works like StreamBlocks for the blocks of many skipchains
+// over one stream.
+func (c *Client) streamBlocks(si *network.ServerIdentity, scIDs []SkipBlockID,
+	handler func(*StreamBlocksReply, error)) error {
+	conn, err := c.openBlockStream(si, scIDs, nil)
+	if err != nil {
+		return err
+	}
+	chains := make(map[string]bool)
+	for _, scID := range scIDs {
+		chains[string(scID)] = true
+	}
+	readBlockStream(conn, func(scID SkipBlockID) bool {
+		return chains[string(scID)]
+	}, handler)
+	return nil
+}
+
+// openBlockStream asks the conode si to send the new blocks of the
+// skipchains. If id is set, the skipchains of the stream can be changed
+// with updateBlockStream.
+func (c *Client) openBlockStream(si *network.ServerIdentity,
+	scIDs []SkipBlockID, id []byte) (onet.StreamingConn, error) {
+	if len(scIDs) == 0 {
+		return onet.StreamingConn{}, xerrors.New("no skipchain to stream")
+	}
+	conn, err := c.Stream(si, &StreamBlocks{SkipChainID: scIDs[0],
+		Others: scIDs[1:], StreamID: id})
+	if err != nil {
+		return onet.StreamingConn{}, xerrors.Errorf("couldn't open stream: %v", err)
+	}
+	return conn, nil
+}
+
+// updateBlockStream adds and removes skipchains of the stream with the
+// given id, opened on the conode si.
+func (c *Client) updateBlockStream(si *network.ServerIdentity, id []byte,
+	add, remove []SkipBlockID) error {
+	return c.SendProtobuf(si, &UpdateStream{StreamID: id, Add: add,
+		Remove: remove}, &UpdateStreamReply{})
+}
+
+// readBlockStream calls handler for every block of the stream, until the
+// connection fails and handler is called with the error. Blocks of the
+// skipchains for which follows returns false are refused.
+func readBlockStream(conn onet.StreamingConn, follows func(SkipBlockID) bool,
+	handler func(*StreamBlocksReply, error)) {
+	for {
+		reply := &StreamBlocksReply{}
+		if err := conn.ReadMessage(reply); err != nil {
+			handler(nil, err)
+			return
+		}
+		sb := reply.Block
+		switch {
+		case sb == nil || !sb.CalculateHash().Equal(sb.Hash):
+			handler(nil, xerrors.New("got a corrupted block"))
+		case !follows(sb.SkipChainID()):
+			handler(nil, xerrors.New("got a block of another skipchain"))
+		case reply.Link != nil && !reply.Link.To.Equal(sb.Hash):
+			handler(nil, xerrors.New("forward-link doesn't point to the block"))
+		default:
+			handler(reply, nil)
+		}
+	}
+}
+
+// GetMetrics returns the metrics of the storage and the propagation of
+// blocks of the conode si.
+func (c *Client) GetMetrics(si *network.ServerIdentity) (*DBMetrics, error) {
+	reply := &GetMetricsReply{}
+	err := c.SendProtobuf(si, &GetMetrics{}, reply)
+	if err != nil {
+		return nil, err
+	}
+	return &reply.Metrics, nil
+}
@@ -2,6 +2,7 @@ package skipchain
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
 	"github.com/stretchr/testify/require"
 	"go.dedis.ch/cothority/v3"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func init() {
@@ -87,6 +89,63 @@ func TestClient_CreateGenesisCorrupted(t *testing.T) {
 	require.Equal(t, "got a different base height", err.Error())
 }
 
+func TestClient_CreateGenesisThreshold(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	_, roster, _ := l.GenTree(3, true)
+	defer l.CloseAll()
+	c := newTestClient(l)
+
+	_, err := c.CreateGenesisThreshold(roster, 1, 1, VerificationNone, nil, 4)
+	require.Error(t, err)
+	_, err = c.CreateGenesisThreshold(roster, 1, 1, VerificationNone, nil, 0)
+	require.Error(t, err)
+
+	genesis, err := c.CreateGenesisThreshold(roster, 1, 1, VerificationNone, nil, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, genesis.SignatureThreshold)
+
+	reply, err := c.StoreSkipBlock(genesis, nil, []byte{1})
+	require.NoError(t, err)
+	require.Equal(t, 2, reply.Latest.SignatureThreshold)
+	require.NoError(t, reply.Previous.VerifyForwardSignatures())
+
+	// A roster smaller than the threshold is refused.
+	_, err = c.StoreSkipBlock(genesis, onet.NewRoster(roster.List[:1]), nil)
+	require.Error(t, err)
+}
+
+func TestClient_CreateChainFromTemplate(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	_, roster, _ := l.GenTree(3, true)
+	defer l.CloseAll()
+	c := newTestClient(l)
+
+	for _, ct := range []ChainTemplate{
+		{BaseHeight: 0, MaximumHeight: 1},
+		{BaseHeight: 1, MaximumHeight: 0},
+		{BaseHeight: 1, MaximumHeight: 2},
+		{BaseHeight: 2, MaximumHeight: 2, SignatureThreshold: 4},
+	} {
+		require.Error(t, ct.Verify(roster))
+		_, err := c.CreateChainFromTemplate(roster, ct, nil)
+		require.Error(t, err)
+	}
+	require.Error(t, TemplateStandard.Verify(nil))
+
+	ct := TemplateStandard
+	ct.SignatureThreshold = 2
+	genesis, err := c.CreateChainFromTemplate(roster, ct, []byte{1})
+	require.NoError(t, err)
+	require.Equal(t, 4, genesis.BaseHeight)
+	require.Equal(t, 32, genesis.MaximumHeight)
+	require.Equal(t, 2, genesis.SignatureThreshold)
+	require.True(t, VerifierIDs(genesis.VerifierIDs).Equal(VerificationStandard))
+	require.Equal(t, []byte{1}, genesis.Data)
+	reply, err := c.StoreSkipBlock(genesis, nil, []byte{2})
+	require.NoError(t, err)
+	require.Equal(t, 1, reply.Latest.Index)
+}
+
 func TestClient_GetUpdateChain(t *testing.T) {
 	// Create a small chain and test whether we can get from one element
 	// of the chain to the last element with a valid slice of SkipBlocks
@@ -137,6 +196,9 @@ func TestClient_GetUpdateChain(t *testing.T) {
 			log.Lvl2(sbs[sbCount-1].Hash)
 			t.Fatal("Last Hash is not equal to last SkipBlock for", i)
 		}
+		head, err := VerifyUpdateChain(sbc.Update)
+		require.NoError(t, err)
+		require.True(t, head.Equal(sbs[sbCount-1]))
 		for up, sb1 := range sbc.Update {
 			log.ErrFatal(sb1.VerifyForwardSignatures())
 			if up < len(sbc.Update)-1 {
@@ -155,6 +217,72 @@ func TestClient_GetUpdateChain(t *testing.T) {
 	}
 }
 
+func TestVerifyUpdateChain(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+
+	_, roster, gs := local.MakeSRS(cothority.Suite, 4, skipchainSID)
+	s := gs.(*Service)
+
+	genesis, err := makeGenesisRosterArgs(s, onet.NewRoster(roster.List[0:3]),
+		nil, VerificationNone, 2, 3)
+	require.NoError(t, err)
+	latest := genesis
+	for i := 1; i < 6; i++ {
+		newSB := NewSkipBlock()
+		newSB.Roster = onet.NewRoster(roster.List[0:3])
+		if i == 3 {
+			newSB.Roster = onet.NewRoster(roster.List)
+		}
+		reply, err := s.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: genesis.Hash,
+			NewBlock: newSB})
+		require.NoError(t, err)
+		latest = reply.Latest
+	}
+
+	_, err = VerifyUpdateChain(nil)
+	require.Error(t, err)
+
+	for _, level := range []int{1, 0} {
+		guc, err := s.GetUpdateChain(&GetUpdateChain{LatestID: genesis.Hash,
+			MaxHeight: level})
+		require.NoError(t, err)
+		head, err := VerifyUpdateChain(guc.Update)
+		require.NoError(t, err)
+		require.True(t, head.Equal(latest))
+	}
+
+	guc, err := s.GetUpdateChain(&GetUpdateChain{LatestID: genesis.Hash,
+		MaxHeight: 1})
+	require.NoError(t, err)
+	blocks := guc.Update
+
+	// Missing block
+	_, err = VerifyUpdateChain(append([]*SkipBlock{blocks[0]}, blocks[3:]...))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "has no forward-link to index 3")
+
+	// Wrong order
+	_, err = VerifyUpdateChain([]*SkipBlock{blocks[1], blocks[0]})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "which is not after")
+
+	// Wrong hash
+	wrong := blocks[2].Copy()
+	wrong.Data = []byte{1}
+	_, err = VerifyUpdateChain([]*SkipBlock{blocks[1], wrong})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Calculated hash does not match")
+
+	// Wrong chain
+	other, err := makeGenesisRosterArgs(s, roster, nil, VerificationNone, 2, 3)
+	require.NoError(t, err)
+	_, err = VerifyUpdateChain([]*SkipBlock{other, blocks[1]})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "different skipchain")
+}
+
 func TestClient_StoreSkipBlock(t *testing.T) {
 	nbrHosts := 3
 	l := onet.NewTCPTest(cothority.Suite)
@@ -338,6 +466,72 @@ func TestClient_GetSingleBlock(t *testing.T) {
 	require.Equal(t, "Got the wrong block in return", err.Error())
 }
 
+func TestClient_AnchorData(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+	service.SetAnchorEpoch(100 * time.Millisecond)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	digest := make([]byte, AnchorDigestSize)
+	reply, err := c.AnchorData(ro, genesis, digest, []byte("metadata"))
+	require.NoError(t, err)
+	require.Equal(t, 1, reply.Block.Index)
+
+	// The block must be proven from the trusted genesis block.
+	other, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	other.Hash = genesis.Hash
+	_, err = c.AnchorData(ro, other, digest, nil)
+	require.Error(t, err)
+
+	_, err = c.AnchorData(ro, genesis, digest[1:], nil)
+	require.Error(t, err)
+	_, err = c.AnchorData(onet.NewRoster(ro.List[1:]), genesis, digest, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only the leader")
+}
+
+func TestClient_ResolveName(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	registry, err := makeGenesisRosterArgs(service, ro, nil,
+		VerificationNameRegistry, 1, 1)
+	require.NoError(t, err)
+	chain, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	_, err = c.RegisterName(ro, registry.Hash, "mychain", chain.Hash, nil)
+	require.NoError(t, err)
+	_, err = c.RegisterName(ro, registry.Hash, "mychain", registry.Hash, nil)
+	require.Error(t, err)
+
+	id, err := c.ResolveName(ro, registry.Hash, "mychain")
+	require.NoError(t, err)
+	require.Equal(t, chain.Hash, id)
+	_, err = c.ResolveName(ro, registry.Hash, "other")
+	require.Error(t, err)
+
+	id, err = c.ResolveChain(ro, registry.Hash, "mychain")
+	require.NoError(t, err)
+	require.Equal(t, chain.Hash, id)
+	id, err = c.ResolveChain(ro, registry.Hash, hex.EncodeToString(registry.Hash))
+	require.NoError(t, err)
+	require.Equal(t, registry.Hash, id)
+	_, err = c.ResolveChain(ro, registry.Hash, "Not a name")
+	require.Error(t, err)
+}
+
 func TestClient_GetSingleBlockByIndex(t *testing.T) {
 	nbrHosts := 3
 	l := onet.NewTCPTest(cothority.Suite)
@@ -0,0 +1,339 @@
+package skipchain
+
+import (
+	"bytes"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/byzcoinx"
+	"go.dedis.ch/cothority/v3/merkle"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the batched appends of blocks. Every block of a batch is
+linked to its predecessor by a level-0 forward-link, and the roster co-signs
+the merkle root over the hashes of all these forward-links in a single round,
+instead of one round per block. Every forward-link holds the proof that its
+hash is a leaf of the signed root, so that it can be verified on its own.
+
+The co-signers verify all blocks of a batch before the first one is stored.
+Verifiers other than VerifyBase check a new block against the stored state of
+the chain, so batches are only accepted for chains with the standard
+verification, and the roster can't change inside a batch.
+*/
+
+const bftNewBatch = "SkipchainBFTNewBatch"
+const bdnNewBatch = "SkipchainBDNNewBatch"
+
+// maxBatchSize is the maximum number of blocks in one batch.
+const maxBatchSize = 256
+
+// BatchProof proves that the hash of a forward-link is part of the merkle
+// root signed for a batch of blocks.
+type BatchProof struct {
+	// Index of the forward-link in the batch.
+	Index int
+	// Count is the number of blocks in the batch.
+	Count int
+	// Path holds the siblings from the leaf up to the root.
+	Path [][]byte
+}
+
+// verify returns an error if the hash of the forward-link is not part of the
+// root signed by the forward-link.
+func (bp *BatchProof) verify(fl *ForwardLink) error {
+	err := merkle.Proof(*bp).Verify(fl.Signature.Msg, batchLeaf(fl))
+	if err != nil {
+		return xerrors.Errorf("forward-link is not part of the batch: %v", err)
+	}
+	return nil
+}
+
+func (bp *BatchProof) copy() *BatchProof {
+	if bp == nil {
+		return nil
+	}
+	cp := &BatchProof{Index: bp.Index, Count: bp.Count}
+	for _, p := range bp.Path {
+		cp.Path = append(cp.Path, append([]byte{}, p...))
+	}
+	return cp
+}
+
+// batchLeaf returns the leaf of the forward-link in the merkle tree of a
+// batch.
+func batchLeaf(fl *ForwardLink) []byte {
+	return merkle.LeafHash(fl.Hash())
+}
+
+// batchProtocol returns the name of the byzcoinx protocol used to sign the
+// batches following the block.
+func batchProtocol(sb *SkipBlock) string {
+	switch sb.SignatureScheme {
+	case BlsSignatureSchemeIndex:
+		return bftNewBatch
+	case BdnSignatureSchemeIndex:
+		return bdnNewBatch
+	default:
+		return ""
+	}
+}
+
+// StoreSkipBlocks appends all blocks to the skipchain, in order, with a
+// single signature of the roster. Either all blocks are appended or none.
+// It must be sent to the leader of the latest block. The roster of the
+// blocks, if set, must be the one of the latest block.
+func (s *Service) StoreSkipBlocks(req *StoreSkipBlocks) (*StoreSkipBlocksReply, error) {
+	err := s.incrementWorking()
+	if err != nil {
+		return nil, err
+	}
+	defer s.decrementWorking()
+
+	if len(req.NewBlocks) == 0 {
+		return nil, xerrors.New("empty batch")
+	}
+	if len(req.NewBlocks) > maxBatchSize {
+		return nil, xerrors.Errorf("batch is bigger than %d blocks", maxBatchSize)
+	}
+	if req.TargetSkipChainID.IsNull() {
+		return nil, xerrors.New("batches can't create a new skipchain")
+	}
+	target := s.db.GetByID(req.TargetSkipChainID)
+	if target == nil {
+		return nil, xerrors.New("unknown skipchain")
+	}
+	scID := target.SkipChainID()
+
+	s.chains.lock(scID)
+	defer s.chains.unlock(scID)
+
+	prev, err := s.db.GetLatestByID(scID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get latest block: %v", err)
+	}
+	if !s.ServerIdentity().Equal(prev.Roster.Get(0)) {
+		return nil, xerrors.New("only leader is allowed to add blocks")
+	}
+	if prev.GetForwardLen() > 0 {
+		return nil, xerrors.New("the latest block already has a follower")
+	}
+	if err := s.checkBatchable(prev); err != nil {
+		return nil, err
+	}
+
+	blocks := make([]*SkipBlock, len(req.NewBlocks))
+	last := prev
+	for i, nb := range req.NewBlocks {
+		if nb == nil || nb.SkipBlockFix == nil {
+			return nil, xerrors.Errorf("block %d is empty", i)
+		}
+		prop := nb.Copy()
+		prop.ProposerSignature = nil
+		if prop.Roster == nil {
+			prop.Roster = prev.Roster
+		}
+		if !prop.Roster.ID.Equal(prev.Roster.ID) {
+			return nil, xerrors.New("the roster can't change inside a batch")
+		}
+		if err := s.newBlockHeader(scID, last, prop, blocks[:i]); err != nil {
+			return nil, err
+		}
+		if err := s.verifyBlock(prop); err != nil {
+			return nil, err
+		}
+		blocks[i] = prop
+		last = prop
+	}
+
+	if err := s.forwardLinkBatch(prev, blocks); err != nil {
+		return nil, xerrors.Errorf("couldn't get forward signature on batch: %v", err)
+	}
+	for i, sb := range blocks {
+		if i == 0 {
+			s.notifyHeadSubscribers(prev, sb)
+		} else {
+			s.notifyHeadSubscribers(blocks[i-1], sb)
+		}
+	}
+	if !s.disableForwardLink {
+		for _, sb := range blocks {
+			if err := s.requestForwardLinks(sb); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &StoreSkipBlocksReply{Previous: prev, Latest: blocks}, nil
+}
+
+// checkBatchable returns an error if the blocks following prev can't be
+// appended in batches.
+func (s *Service) checkBatchable(prev *SkipBlock) error {
+	for _, ver := range prev.VerifierIDs {
+		if !ver.Equal(VerifyBase) {
+			return xerrors.New("batches are only accepted for chains with " +
+				"the standard verification")
+		}
+	}
+	genesis := s.db.GetByID(prev.SkipChainID())
+	if genesis == nil {
+		return xerrors.New("unknown genesis block")
+	}
+	if len(genesis.Proposers) > 0 {
+		return xerrors.New("the chain only accepts signed proposals")
+	}
+	return nil
+}
+
+// forwardLinkBatch gets the level-0 forward-links of all blocks signed in
+// one round, and propagates the blocks to the roster.
+func (s *Service) forwardLinkBatch(prev *SkipBlock, blocks []*SkipBlock) error {
+	fls := make([]*ForwardLink, len(blocks))
+	leaves := make([][]byte, len(blocks))
+	from := prev
+	for i, sb := range blocks {
+		fls[i] = NewForwardLink(from, sb)
+		leaves[i] = batchLeaf(fls[i])
+		from = sb
+	}
+	tree := merkle.NewTree(leaves)
+
+	data, err := network.Marshal(&ForwardSignatureBatch{
+		Previous: prev.Hash,
+		Newest:   blocks,
+	})
+	if err != nil {
+		return xerrors.Errorf("couldn't marshal blocks: %v", err)
+	}
+	root := tree.Root()
+	sig, err := s.startBFT(batchProtocol(prev), prev.Roster, prev.Roster,
+		root, data, prev.SignatureThreshold)
+	verr, refused := s.verifierErrors.LoadAndDelete(sliceToArr(root))
+	if err != nil {
+		if refused {
+			err = xerrors.Errorf("%v: %v", err, verr)
+		}
+		return err
+	}
+
+	for i, fl := range fls {
+		fl.Signature = byzcoinx.FinalSignature{Msg: sig.Msg, Sig: sig.Sig}
+		bp := BatchProof(tree.Proof(i))
+		fl.Batch = &bp
+	}
+	if err := prev.AddForwardLink(fls[0], 0); err != nil {
+		return xerrors.Errorf("couldn't add forward-link: %v", err)
+	}
+	for i, sb := range blocks[:len(blocks)-1] {
+		if err := sb.AddForwardLink(fls[i+1], 0); err != nil {
+			return xerrors.Errorf("couldn't add forward-link: %v", err)
+		}
+	}
+	chain := append([]*SkipBlock{prev}, blocks...)
+	if err := Proof(chain).VerifyFromID(prev.Hash); err != nil {
+		return xerrors.Errorf("wrong BFT-signature: %v", err)
+	}
+
+	// The roster of the batch is the one of the previous block, so it
+	// already knows all blocks up to the first one of the batch.
+	return s.startPropagation(s.propagateHandover, prev.Roster,
+		&PropagateHandover{Blocks: chain})
+}
+
+// bftForwardLinkBatch makes sure that a signature-request for the
+// forward-links of a batch is valid.
+func (s *Service) bftForwardLinkBatch(msg, data []byte) bool {
+	var leader bool
+	err := func() error {
+		_, fsbInt, err := network.Unmarshal(data, cothority.Suite)
+		if err != nil {
+			return xerrors.Errorf("couldn't unmarshal ForwardSignatureBatch: %v", err)
+		}
+		fsb, ok := fsbInt.(*ForwardSignatureBatch)
+		if !ok {
+			return xerrors.Errorf("got unexpected type %T", fsbInt)
+		}
+		if len(fsb.Newest) == 0 || len(fsb.Newest) > maxBatchSize {
+			return xerrors.New("wrong size of batch")
+		}
+		for _, sb := range fsb.Newest {
+			if sb == nil || sb.SkipBlockFix == nil {
+				return xerrors.New("empty block in batch")
+			}
+		}
+		leader = s.ServerIdentity().Equal(fsb.Newest[0].Roster.Get(0))
+
+		scID := fsb.Newest[0].SkipChainID()
+		if !s.catchUps.wait(scID, s.propTimeout) {
+			return xerrors.New("still catching up")
+		}
+		prev := s.db.GetByID(fsb.Previous)
+		if prev == nil {
+			if err := s.SyncChain(fsb.Newest[0].Roster, fsb.Previous); err != nil {
+				return xerrors.Errorf("failed to sync skipchain: %v", err)
+			}
+			prev = s.db.GetByID(fsb.Previous)
+			if prev == nil {
+				return xerrors.New("didn't find src-skipblock")
+			}
+		}
+		if prev.GetForwardLen() > 0 {
+			return xerrors.New("previous block already has forward-link")
+		}
+		if err := s.checkBatchable(prev); err != nil {
+			return err
+		}
+
+		leaves := make([][]byte, len(fsb.Newest))
+		for i, sb := range fsb.Newest {
+			if !sb.CalculateHash().Equal(sb.Hash) {
+				return xerrors.Errorf("block %d doesn't match its hash", i)
+			}
+			if len(sb.BackLinkIDs) == 0 || !sb.BackLinkIDs[0].Equal(prev.Hash) {
+				return xerrors.Errorf("block %d doesn't point to the previous block", i)
+			}
+			if !VerifierIDs(sb.VerifierIDs).Equal(prev.VerifierIDs) {
+				return xerrors.Errorf("block %d has other verifiers", i)
+			}
+			if sb.Roster == nil || !sb.Roster.ID.Equal(prev.Roster.ID) {
+				return xerrors.Errorf("block %d has another roster", i)
+			}
+			if s.verifyBlock(sb) != nil || !verifyBase(prev, sb) {
+				return xerrors.Errorf("block %d refused by base verification", i)
+			}
+			if !s.BlockIsFriendly(sb) {
+				return xerrors.Errorf("block %d is not friendly", i)
+			}
+			leaves[i] = batchLeaf(NewForwardLink(prev, sb))
+			prev = sb
+		}
+		if !bytes.Equal(merkle.NewTree(leaves).Root(), msg) {
+			return xerrors.New("root of the batch is different from msg")
+		}
+		return nil
+	}()
+	if err != nil {
+		log.Lvlf2("%s: %v", s.ServerIdentity(), err)
+		if leader {
+			s.verifierErrors.Store(sliceToArr(msg), err)
+		}
+		return false
+	}
+
+	s.verifyNewBlockBuffer.Store(sliceToArr(msg), true)
+	return true
+}
+
+func (s *Service) bftForwardLinkBatchAck(msg, data []byte) bool {
+	arr := sliceToArr(msg)
+	_, ok := s.verifyNewBlockBuffer.Load(arr)
+	if ok {
+		s.verifyNewBlockBuffer.Delete(arr)
+	} else {
+		log.Errorf("%s refuses to acknowledge unknown batch %x",
+			s.ServerIdentity(), msg)
+	}
+	return ok
+}
@@ -0,0 +1,80 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_StoreSkipBlocks(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, ro, gs := l.MakeSRS(cothority.Suite, 4, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationStandard, 2, 3)
+	require.NoError(t, err)
+
+	data := [][]byte{{1}, {2}, {3}, {4}, {5}}
+	reply, err := c.StoreSkipBlocks(genesis, data)
+	require.NoError(t, err)
+	require.Equal(t, genesis.Hash, reply.Previous.Hash)
+	require.Len(t, reply.Latest, len(data))
+	for i, sb := range reply.Latest {
+		require.Equal(t, i+1, sb.Index)
+		require.Equal(t, data[i], sb.Data)
+	}
+	latest := reply.Latest[len(data)-1]
+
+	// All conodes store the batch, and the higher forward-links are added.
+	for _, srv := range servers {
+		s := l.Services[srv.ServerIdentity.ID][skipchainSID].(*Service)
+		require.Eventually(t, func() bool {
+			sb, err := s.db.GetLatestByID(genesis.Hash)
+			return err == nil && sb.Hash.Equal(latest.Hash)
+		}, 10*time.Second, 50*time.Millisecond)
+	}
+	require.Eventually(t, func() bool {
+		sb := service.db.GetByID(reply.Latest[1].Hash)
+		return sb != nil && sb.GetForwardLen() == 2
+	}, 10*time.Second, 50*time.Millisecond)
+	chain, err := c.GetUpdateChain(ro, genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, latest.Hash, chain.Update[len(chain.Update)-1].Hash)
+
+	// A forward-link of the batch can't be used for another leaf.
+	fl := reply.Latest[0].ForwardLink[0].Copy()
+	publics := ro.ServicePublics(ServiceName)
+	require.NoError(t, fl.VerifyWithScheme(suite, publics, genesis.SignatureScheme))
+	fl.Batch.Index++
+	require.Error(t, fl.VerifyWithScheme(suite, publics, genesis.SignatureScheme))
+	fl.Batch = nil
+	require.Error(t, fl.VerifyWithScheme(suite, publics, genesis.SignatureScheme))
+
+	// Single blocks can still be appended after a batch.
+	_, err = c.StoreSkipBlock(genesis, nil, []byte{6})
+	require.NoError(t, err)
+
+	_, err = c.StoreSkipBlocks(genesis, nil)
+	require.Error(t, err)
+	_, err = service.StoreSkipBlocks(&StoreSkipBlocks{
+		TargetSkipChainID: genesis.Hash,
+		NewBlocks: []*SkipBlock{
+			{SkipBlockFix: &SkipBlockFix{Roster: onet.NewRoster(ro.List[:3])}},
+		},
+	})
+	require.Error(t, err)
+
+	// Chains with verifiers depending on their state refuse batches.
+	registry, err := makeGenesisRosterArgs(service, ro, nil,
+		VerificationNameRegistry, 1, 1)
+	require.NoError(t, err)
+	_, err = c.StoreSkipBlocks(registry, data)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "standard verification")
+}
@@ -0,0 +1,88 @@
+package skipchain
+
+import (
+	"golang.org/x/xerrors"
+)
+
+// maxBlockRange is the maximum number of blocks returned by one GetBlockRange
+// request. It is also used if the request doesn't give a limit.
+const maxBlockRange = 100
+
+// Direction is the direction of the traversal of a chain.
+type Direction int
+
+const (
+	// DirectionForward follows the level-0 forward-links, from older to
+	// newer blocks.
+	DirectionForward Direction = iota
+	// DirectionBackward follows the level-0 backlinks, from newer to older
+	// blocks.
+	DirectionBackward
+)
+
+// GetBlockRange returns consecutive blocks of a chain, starting at Start or at
+// the block given by the Cursor of a previous reply. The reply holds at most
+// MaxBlocks blocks, and NextCursor is empty once the end of the chain in the
+// given direction has been reached.
+func (s *Service) GetBlockRange(req *GetBlockRange) (*GetBlockRangeReply, error) {
+	if req.Direction != DirectionForward && req.Direction != DirectionBackward {
+		return nil, xerrors.Errorf("unknown direction %d", req.Direction)
+	}
+	id := req.Start
+	if len(req.Cursor) > 0 {
+		var err error
+		id, err = parseCursor(req.Cursor, req.Direction)
+		if err != nil {
+			return nil, err
+		}
+	}
+	maxBlocks := req.MaxBlocks
+	if maxBlocks <= 0 || maxBlocks > maxBlockRange {
+		maxBlocks = maxBlockRange
+	}
+
+	reply := &GetBlockRangeReply{}
+	for id != nil {
+		if len(reply.Blocks) == maxBlocks {
+			reply.NextCursor = makeCursor(id, req.Direction)
+			break
+		}
+		sb := s.db.GetByID(id)
+		if sb == nil {
+			if len(reply.Blocks) == 0 {
+				return nil, xerrors.Errorf("unknown block %x", id)
+			}
+			// The rest of the chain might be known by another conode.
+			reply.NextCursor = makeCursor(id, req.Direction)
+			break
+		}
+		reply.Blocks = append(reply.Blocks, sb)
+
+		id = nil
+		switch req.Direction {
+		case DirectionForward:
+			if fl := sb.GetForward(0); fl != nil {
+				id = fl.To
+			}
+		case DirectionBackward:
+			if sb.Index > 0 && len(sb.BackLinkIDs) > 0 {
+				id = sb.BackLinkIDs[0]
+			}
+		}
+	}
+	return reply, nil
+}
+
+// makeCursor returns the cursor pointing to the block in the direction.
+func makeCursor(id SkipBlockID, dir Direction) []byte {
+	return append([]byte{byte(dir)}, id...)
+}
+
+// parseCursor returns the ID of the block of the cursor, or an error if the
+// cursor is for another direction.
+func parseCursor(cursor []byte, dir Direction) (SkipBlockID, error) {
+	if len(cursor) < 2 || cursor[0] != byte(dir) {
+		return nil, xerrors.New("invalid cursor for this direction")
+	}
+	return SkipBlockID(cursor[1:]), nil
+}
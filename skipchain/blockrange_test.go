@@ -0,0 +1,81 @@
+package skipchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_GetBlockRange(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 2, 3)
+	require.NoError(t, err)
+	ids := []SkipBlockID{genesis.Hash}
+	for i := 0; i < 6; i++ {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		reply, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+		ids = append(ids, reply.Latest.Hash)
+	}
+
+	// Forward, in pages of 3 blocks.
+	req := &GetBlockRange{Start: genesis.Hash, MaxBlocks: 3}
+	var got []SkipBlockID
+	for {
+		reply, err := c.GetBlockRange(ro, req)
+		require.NoError(t, err)
+		require.True(t, len(reply.Blocks) <= 3)
+		for _, sb := range reply.Blocks {
+			got = append(got, sb.Hash)
+		}
+		if len(reply.NextCursor) == 0 {
+			break
+		}
+		req.Cursor = reply.NextCursor
+	}
+	require.Equal(t, ids, got)
+
+	// Backward, from the latest block.
+	reply, err := c.GetBlockRange(ro, &GetBlockRange{Start: ids[6],
+		MaxBlocks: 4, Direction: DirectionBackward})
+	require.NoError(t, err)
+	require.Equal(t, 4, len(reply.Blocks))
+	require.Equal(t, 3, reply.Blocks[3].Index)
+	reply, err = c.GetBlockRange(ro, &GetBlockRange{Cursor: reply.NextCursor,
+		Direction: DirectionBackward})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(reply.Blocks))
+	require.Equal(t, 0, reply.Blocks[2].Index)
+	require.Equal(t, 0, len(reply.NextCursor))
+
+	// The cursor is bound to its direction.
+	reply, err = service.GetBlockRange(&GetBlockRange{Start: genesis.Hash,
+		MaxBlocks: 1})
+	require.NoError(t, err)
+	_, err = service.GetBlockRange(&GetBlockRange{Cursor: reply.NextCursor,
+		Direction: DirectionBackward})
+	require.Error(t, err)
+	_, err = service.GetBlockRange(&GetBlockRange{Start: genesis.Hash,
+		Direction: 2})
+	require.Error(t, err)
+	_, err = service.GetBlockRange(&GetBlockRange{Start: SkipBlockID{1}})
+	require.Error(t, err)
+
+	// The conode limits the size of the replies.
+	reply, err = service.GetBlockRange(&GetBlockRange{Start: genesis.Hash,
+		MaxBlocks: maxBlockRange + 1})
+	require.NoError(t, err)
+	require.Equal(t, 7, len(reply.Blocks))
+}
@@ -0,0 +1,131 @@
+package skipchain
+
+import (
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the catch-up of a conode that joins the roster of a chain.
+When joining, the conode only gets the shortest path to the latest block, so
+it fetches all the other blocks of the chain from the previous roster and
+verifies them batch by batch. Signature requests for new blocks of the chain
+wait for the catch-up to finish, so that the conode only takes part in the
+signatures once it holds the whole chain.
+*/
+
+// catchUps holds the chains this conode is currently catching up with. The
+// channel of a chain is closed once its catch-up is done.
+type catchUps struct {
+	sync.Mutex
+	chains map[string]chan bool
+}
+
+// start marks the chain as catching up and returns false if it already is.
+func (cu *catchUps) start(scID SkipBlockID) bool {
+	cu.Lock()
+	defer cu.Unlock()
+	if cu.chains == nil {
+		cu.chains = make(map[string]chan bool)
+	}
+	if _, ok := cu.chains[string(scID)]; ok {
+		return false
+	}
+	cu.chains[string(scID)] = make(chan bool)
+	return true
+}
+
+func (cu *catchUps) stop(scID SkipBlockID) {
+	cu.Lock()
+	defer cu.Unlock()
+	if done, ok := cu.chains[string(scID)]; ok {
+		close(done)
+		delete(cu.chains, string(scID))
+	}
+}
+
+func (cu *catchUps) running(scID SkipBlockID) bool {
+	cu.Lock()
+	defer cu.Unlock()
+	_, ok := cu.chains[string(scID)]
+	return ok
+}
+
+// wait returns once the catch-up of the chain is done, or false if it is
+// still running after the timeout.
+func (cu *catchUps) wait(scID SkipBlockID, timeout time.Duration) bool {
+	cu.Lock()
+	done, ok := cu.chains[string(scID)]
+	cu.Unlock()
+	if !ok {
+		return true
+	}
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// catchUp fetches all blocks of the chain from the conodes of the roster,
+// starting at the first block missing locally.
+func (s *Service) catchUp(roster *onet.Roster, scID SkipBlockID) error {
+	if !s.catchUps.start(scID) {
+		return xerrors.Errorf("already catching up with %x", scID)
+	}
+	defer s.catchUps.stop(scID)
+	return s.fetchChain(roster, scID)
+}
+
+// catchUpIfJoined starts a catch-up in the background if the proof shows
+// that this conode joined the roster of the chain with the latest block,
+// and some blocks are missing locally.
+func (s *Service) catchUpIfJoined(proof Proof) {
+	if len(proof) < 2 {
+		return
+	}
+	prev, latest := proof[len(proof)-2], proof[len(proof)-1]
+	if i, _ := latest.Roster.Search(s.ServerIdentity().ID); i < 0 {
+		return
+	}
+	if i, _ := prev.Roster.Search(s.ServerIdentity().ID); i >= 0 {
+		return
+	}
+	scID := latest.SkipChainID()
+	if s.firstMissing(scID).Equal(latest.Hash) {
+		return
+	}
+	if !s.catchUps.start(scID) {
+		return
+	}
+	if err := s.incrementWorking(); err != nil {
+		s.catchUps.stop(scID)
+		return
+	}
+	go func() {
+		defer s.decrementWorking()
+		defer s.catchUps.stop(scID)
+		if err := s.fetchChain(prev.Roster, scID); err != nil {
+			log.Warnf("%s: %v", s.ServerIdentity(), err)
+		}
+	}()
+}
+
+// fetchChain gets the missing blocks of the chain. Every batch of blocks is
+// verified from the last known block before it is stored.
+func (s *Service) fetchChain(roster *onet.Roster, scID SkipBlockID) error {
+	log.Lvlf2("%s: catching up with chain %x", s.ServerIdentity(), scID)
+	before := s.db.Length()
+	// This conode is in the roster of the latest block.
+	if err := s.reconcileChain(roster, scID, true); err != nil {
+		return xerrors.Errorf("couldn't catch up with %x: %v", scID, err)
+	}
+	log.Lvlf2("%s: got %d blocks of chain %x", s.ServerIdentity(),
+		s.db.Length()-before, scID)
+	return nil
+}
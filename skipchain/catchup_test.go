@@ -0,0 +1,58 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestService_CatchUp(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, all, gs := l.MakeSRS(cothority.Suite, 4, skipchainSID)
+	service := gs.(*Service)
+	services := l.GetServices(servers, skipchainSID)
+
+	ro := onet.NewRoster(all.List[:3])
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationStandard, 2, 3)
+	require.NoError(t, err)
+	store := func(roster *onet.Roster) {
+		sb := NewSkipBlock()
+		sb.Roster = roster
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 5; i++ {
+		store(ro)
+	}
+
+	// The new conode gets all blocks, not only the shortest path.
+	joined := services[3].(*Service)
+	store(all)
+	require.Eventually(t, func() bool {
+		summary, err := joined.db.summary()
+		return err == nil && len(summary) == 1 && summary[0].Blocks == 7
+	}, 5*time.Second, 10*time.Millisecond)
+	require.True(t, joined.catchUps.wait(genesis.Hash, time.Second))
+
+	// It takes part in the signature of the next block.
+	store(all)
+	require.Eventually(t, func() bool {
+		latest, err := joined.db.GetLatestByID(genesis.Hash)
+		return err == nil && latest.Index == 7
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Only one catch-up runs at a time.
+	require.True(t, joined.catchUps.start(genesis.Hash))
+	require.Error(t, joined.catchUp(all, genesis.Hash))
+	require.False(t, joined.catchUps.wait(genesis.Hash, 10*time.Millisecond))
+	joined.catchUps.stop(genesis.Hash)
+	require.False(t, joined.catchUps.running(genesis.Hash))
+}
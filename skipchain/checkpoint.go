@@ -0,0 +1,154 @@
+package skipchain
+
+import (
+	"sync"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the checkpoints. For chains used as append-only logs, the
+leader can add a checkpoint block after every given number of blocks. The
+checkpoint holds a digest of the state of the application up to the previous
+block, computed by a callback registered by the application. A new follower
+can start from the latest checkpoint instead of replaying all blocks from
+the genesis block.
+*/
+
+func init() {
+	network.RegisterMessages(&Checkpoint{})
+}
+
+// Checkpoint is the data of a checkpoint block.
+type Checkpoint struct {
+	// Index is the index of the last block included in the digest.
+	Index int
+	// Digest of the state of the application.
+	Digest []byte
+}
+
+// CheckpointFunc returns the digest of the state of the application after
+// the given block.
+type CheckpointFunc func(sb *SkipBlock) ([]byte, error)
+
+type checkpointer struct {
+	interval int
+	f        CheckpointFunc
+}
+
+type checkpoints struct {
+	sync.Mutex
+	chains map[string]checkpointer
+}
+
+// RegisterCheckpoint asks the leader of the skipchain to add a checkpoint
+// block after every interval blocks stored with StoreSkipBlock. A nil
+// function stops the checkpoints of the chain.
+func (s *Service) RegisterCheckpoint(scID SkipBlockID, interval int, f CheckpointFunc) error {
+	if f != nil && interval < 1 {
+		return xerrors.New("interval must be at least 1")
+	}
+	s.checkpoints.Lock()
+	defer s.checkpoints.Unlock()
+	if s.checkpoints.chains == nil {
+		s.checkpoints.chains = make(map[string]checkpointer)
+	}
+	if f == nil {
+		delete(s.checkpoints.chains, string(scID))
+		return nil
+	}
+	s.checkpoints.chains[string(scID)] = checkpointer{interval: interval, f: f}
+	return nil
+}
+
+// CheckpointFromBlock returns the checkpoint of the block, or nil if the
+// block is not a checkpoint block.
+func CheckpointFromBlock(sb *SkipBlock) *Checkpoint {
+	if sb.Index == 0 || len(sb.Data) == 0 {
+		return nil
+	}
+	_, msg, err := network.Unmarshal(sb.Data, cothority.Suite)
+	if err != nil {
+		return nil
+	}
+	cp, ok := msg.(*Checkpoint)
+	if !ok {
+		return nil
+	}
+	return cp
+}
+
+// blocksSinceCheckpoint returns the number of blocks after the latest
+// checkpoint or the genesis block, counting at most max blocks.
+func (s *Service) blocksSinceCheckpoint(sb *SkipBlock, max int) int {
+	n := 0
+	for n < max && sb.Index > 0 && CheckpointFromBlock(sb) == nil {
+		n++
+		sb = s.db.GetByID(sb.BackLinkIDs[0])
+		if sb == nil {
+			break
+		}
+	}
+	return n
+}
+
+// checkpointIfDue adds a checkpoint block after the latest block if a
+// checkpoint is registered for the chain and enough blocks have been added
+// since the previous one.
+func (s *Service) checkpointIfDue(latest *SkipBlock) error {
+	scID := latest.SkipChainID()
+	s.checkpoints.Lock()
+	cp, ok := s.checkpoints.chains[string(scID)]
+	s.checkpoints.Unlock()
+	if !ok {
+		return nil
+	}
+	if s.blocksSinceCheckpoint(latest, cp.interval) < cp.interval {
+		return nil
+	}
+
+	digest, err := cp.f(latest)
+	if err != nil {
+		return xerrors.Errorf("couldn't get digest: %v", err)
+	}
+	data, err := network.Marshal(&Checkpoint{Index: latest.Index, Digest: digest})
+	if err != nil {
+		return xerrors.Errorf("couldn't marshal checkpoint: %v", err)
+	}
+	sb := NewSkipBlock()
+	sb.Roster = latest.Roster
+	sb.Data = data
+	reply, err := s.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: scID,
+		NewBlock:          sb,
+	})
+	if err != nil {
+		return xerrors.Errorf("couldn't store checkpoint: %v", err)
+	}
+	log.Lvlf2("%s: added checkpoint of block %d in block %d",
+		s.ServerIdentity(), latest.Index, reply.Latest.Index)
+	return nil
+}
+
+// GetCheckpoint returns the index of the latest checkpoint block of the
+// skipchain.
+func (s *Service) GetCheckpoint(req *GetCheckpoint) (*GetCheckpointReply, error) {
+	sb, err := s.db.GetLatestByID(req.SkipChainID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't find skipchain: %v", err)
+	}
+	for sb.Index > 0 {
+		if CheckpointFromBlock(sb) != nil {
+			return &GetCheckpointReply{Index: sb.Index}, nil
+		}
+		prev := s.db.GetByID(sb.BackLinkIDs[0])
+		if prev == nil {
+			return nil, xerrors.Errorf("missing block %x", sb.BackLinkIDs[0])
+		}
+		sb = prev
+	}
+	return nil, xerrors.New("no checkpoint in this skipchain")
+}
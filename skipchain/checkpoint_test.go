@@ -0,0 +1,70 @@
+package skipchain
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_Checkpoint(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	_, _, err = c.GetCheckpoint(ro, genesis.Hash)
+	require.Error(t, err)
+
+	// The digest is the hash of the data of all blocks.
+	digest := func(sb *SkipBlock) ([]byte, error) {
+		h := sha256.New()
+		for sb.Index > 0 {
+			h.Write(sb.Data)
+			sb = service.db.GetByID(sb.BackLinkIDs[0])
+		}
+		return h.Sum(nil), nil
+	}
+	require.Error(t, service.RegisterCheckpoint(genesis.Hash, 0, digest))
+	require.NoError(t, service.RegisterCheckpoint(genesis.Hash, 2, digest))
+
+	store := func(data byte) *SkipBlock {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Data = []byte{data}
+		reply, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+		return reply.Latest
+	}
+	for i := byte(1); i <= 4; i++ {
+		store(i)
+	}
+	latest, err := service.db.GetLatestByID(genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 6, latest.Index)
+
+	sb, cp, err := c.GetCheckpoint(ro, genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 6, sb.Index)
+	require.Equal(t, 5, cp.Index)
+	expected, err := digest(service.db.GetByID(sb.BackLinkIDs[0]))
+	require.NoError(t, err)
+	require.Equal(t, expected, cp.Digest)
+
+	// Without a registered checkpoint, no more checkpoint blocks are added.
+	require.NoError(t, service.RegisterCheckpoint(genesis.Hash, 0, nil))
+	store(5)
+	store(6)
+	sb, _, err = c.GetCheckpoint(ro, genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 6, sb.Index)
+}
@@ -0,0 +1,293 @@
+// Package cmd holds the commands to inspect the skipchains stored in the
+// database of a conode. The database is opened read-only, so the commands
+// work on a copy of the database or on the database of a stopped conode.
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/urfave/cli"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+// bucketName is the name of the bucket the skipchain service stores its
+// blocks in.
+var bucketName = []byte(skipchain.ServiceName + "_skipblocks")
+
+// Commands returns the commands to inspect the database, so that they can be
+// added to the commands of a CLI.
+func Commands() cli.Commands {
+	dbDef := "conode.db"
+	return cli.Commands{
+		{
+			Name:      "list",
+			Usage:     "list the skipchains in the database",
+			Aliases:   []string{"ls"},
+			ArgsUsage: dbDef,
+			Action:    list,
+		},
+		{
+			Name:      "dump",
+			Usage:     "print a block as JSON",
+			Aliases:   []string{"d"},
+			ArgsUsage: dbDef + " skipblock-id",
+			Action:    dump,
+		},
+		{
+			Name:      "follow",
+			Usage:     "follow the forward-links from a block",
+			Aliases:   []string{"f"},
+			ArgsUsage: dbDef + " skipblock-id",
+			Action:    follow,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "level, l",
+					Usage: "highest level of the forward-links to follow",
+				},
+			},
+		},
+		{
+			Name:      "verify",
+			Usage:     "verify the hashes and links of all blocks of a skipchain",
+			Aliases:   []string{"v"},
+			ArgsUsage: dbDef + " skipchain-id",
+			Action:    verify,
+		},
+	}
+}
+
+// openDB opens the database read-only.
+func openDB(name string) (*skipchain.SkipBlockDB, error) {
+	db, err := bbolt.Open(name, 0600, &bbolt.Options{ReadOnly: true,
+		Timeout: time.Second})
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't open db: %v", err)
+	}
+	err = db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket(bucketName) == nil {
+			return errors.New("no skipchain blocks in this db")
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	sbdb := skipchain.NewSkipBlockDB(db, bucketName)
+	if err := sbdb.CheckLayout(); err != nil {
+		db.Close()
+		return nil, xerrors.Errorf("%v: start the conode once to convert "+
+			"them", err)
+	}
+	return sbdb, nil
+}
+
+// openDBAndBlock opens the database and searches the block given as second
+// argument. The ID of the block can be shortened.
+func openDBAndBlock(c *cli.Context) (*skipchain.SkipBlockDB, *skipchain.SkipBlock, error) {
+	if c.NArg() != 2 {
+		return nil, nil, errors.New("please give the db and the skipblock-id")
+	}
+	db, err := openDB(c.Args().First())
+	if err != nil {
+		return nil, nil, err
+	}
+	sb, err := db.GetFuzzy(c.Args().Get(1))
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	if sb == nil {
+		db.Close()
+		return nil, nil, errors.New("didn't find this skipblock")
+	}
+	return db, sb, nil
+}
+
+func list(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("please give the db")
+	}
+	db, err := openDB(c.Args().First())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	summaries, err := db.Summary()
+	if err != nil {
+		return xerrors.Errorf("couldn't read skipchains: %v", err)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return bytes.Compare(summaries[i].SkipChainID,
+			summaries[j].SkipChainID) < 0
+	})
+	for _, cs := range summaries {
+		fmt.Fprintf(c.App.Writer, "%x index=%d blocks=%d latest=%x\n",
+			cs.SkipChainID, cs.Index, cs.Blocks, cs.Latest)
+	}
+	return nil
+}
+
+// jsonBlock is the JSON representation of a block, with the IDs and the
+// binary data hex-encoded.
+type jsonBlock struct {
+	Index              int
+	Height             int
+	MaximumHeight      int
+	BaseHeight         int
+	Hash               string
+	SkipChainID        string
+	BackLinks          []string
+	ForwardLinks       []jsonForwardLink
+	Verifiers          []string
+	SignatureScheme    uint32
+	SignatureThreshold int
+	Roster             []jsonNode
+	Data               string
+	Payload            string
+}
+
+type jsonForwardLink struct {
+	From      string
+	To        string
+	NewRoster bool
+	Signature string
+}
+
+type jsonNode struct {
+	Address string
+	Public  string
+}
+
+func newJSONBlock(sb *skipchain.SkipBlock) jsonBlock {
+	jb := jsonBlock{
+		Index:              sb.Index,
+		Height:             sb.Height,
+		MaximumHeight:      sb.MaximumHeight,
+		BaseHeight:         sb.BaseHeight,
+		Hash:               hex.EncodeToString(sb.Hash),
+		SkipChainID:        hex.EncodeToString(sb.SkipChainID()),
+		SignatureScheme:    sb.SignatureScheme,
+		SignatureThreshold: sb.SignatureThreshold,
+		Data:               hex.EncodeToString(sb.Data),
+		Payload:            hex.EncodeToString(sb.Payload),
+	}
+	for _, bl := range sb.BackLinkIDs {
+		jb.BackLinks = append(jb.BackLinks, hex.EncodeToString(bl))
+	}
+	for _, fl := range sb.GetForwardLinks() {
+		jb.ForwardLinks = append(jb.ForwardLinks, jsonForwardLink{
+			From:      hex.EncodeToString(fl.From),
+			To:        hex.EncodeToString(fl.To),
+			NewRoster: fl.NewRoster != nil,
+			Signature: hex.EncodeToString(fl.Signature.Sig),
+		})
+	}
+	for _, v := range sb.VerifierIDs {
+		jb.Verifiers = append(jb.Verifiers, v.String())
+	}
+	if sb.Roster != nil {
+		for _, si := range sb.Roster.List {
+			jb.Roster = append(jb.Roster, jsonNode{
+				Address: si.Address.String(),
+				Public:  si.Public.String(),
+			})
+		}
+	}
+	return jb
+}
+
+func dump(c *cli.Context) error {
+	db, sb, err := openDBAndBlock(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	buf, err := json.MarshalIndent(newJSONBlock(sb), "", "  ")
+	if err != nil {
+		return xerrors.Errorf("couldn't encode block: %v", err)
+	}
+	fmt.Fprintln(c.App.Writer, string(buf))
+	return nil
+}
+
+func follow(c *cli.Context) error {
+	db, sb, err := openDBAndBlock(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	level := c.Int("level")
+	for {
+		fmt.Fprintf(c.App.Writer, "index %d, hash %x\n", sb.Index, sb.Hash)
+		var next *skipchain.ForwardLink
+		for h := sb.GetForwardLen() - 1; h >= 0; h-- {
+			if h <= level && !sb.GetForward(h).IsEmpty() {
+				next = sb.GetForward(h)
+				break
+			}
+		}
+		if next == nil {
+			return nil
+		}
+		nextSB := db.GetByID(next.To)
+		if nextSB == nil {
+			return fmt.Errorf("block %x is not in the db", next.To)
+		}
+		sb = nextSB
+	}
+}
+
+func verify(c *cli.Context) error {
+	db, sb, err := openDBAndBlock(c)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	scID := sb.SkipChainID()
+	sb = db.GetByID(scID)
+	if sb == nil {
+		return fmt.Errorf("genesis block %x is not in the db", scID)
+	}
+
+	var prev *skipchain.SkipBlock
+	for {
+		if err := sb.VerifyForwardSignatures(); err != nil {
+			return fmt.Errorf("block %d: %v", sb.Index, err)
+		}
+		if !sb.SkipChainID().Equal(scID) {
+			return fmt.Errorf("block %d: wrong skipchain-id", sb.Index)
+		}
+		if prev != nil {
+			if sb.Index != prev.Index+1 {
+				return fmt.Errorf("block %d follows block %d", sb.Index,
+					prev.Index)
+			}
+			if len(sb.BackLinkIDs) == 0 || !sb.BackLinkIDs[0].Equal(prev.Hash) {
+				return fmt.Errorf("block %d: wrong back-link", sb.Index)
+			}
+			if !prev.GetForward(0).From.Equal(prev.Hash) {
+				return fmt.Errorf("block %d: wrong forward-link", prev.Index)
+			}
+		}
+		if sb.GetForwardLen() == 0 || sb.GetForward(0).IsEmpty() {
+			break
+		}
+		next := db.GetByID(sb.GetForward(0).To)
+		if next == nil {
+			return fmt.Errorf("block %x is not in the db", sb.GetForward(0).To)
+		}
+		prev, sb = sb, next
+	}
+	fmt.Fprintf(c.App.Writer, "verified %d blocks of skipchain %x\n",
+		sb.Index+1, scID)
+	return nil
+}
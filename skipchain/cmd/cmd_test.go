@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/protobuf"
+	"go.etcd.io/bbolt"
+)
+
+func TestMain(m *testing.M) {
+	log.MainTest(m)
+}
+
+func run(args ...string) (string, error) {
+	app := cli.NewApp()
+	app.Commands = Commands()
+	var out bytes.Buffer
+	app.Writer = &out
+	err := app.Run(append([]string{"cmd"}, args...))
+	return out.String(), err
+}
+
+func TestCommands(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	servers, ro, _ := l.GenTree(3, true)
+	defer l.CloseAll()
+	c := skipchain.NewClient()
+
+	gen, err := c.CreateGenesis(ro, 2, 3, skipchain.VerificationStandard, nil)
+	require.NoError(t, err)
+	blocks := []*skipchain.SkipBlock{gen}
+	for i := 0; i < 4; i++ {
+		reply, err := c.StoreSkipBlock(blocks[i], nil, []byte{byte(i)})
+		require.NoError(t, err)
+		blocks = append(blocks, reply.Latest)
+	}
+	latest := blocks[4]
+	other, err := c.CreateGenesis(ro, 2, 3, skipchain.VerificationStandard, nil)
+	require.NoError(t, err)
+
+	// The higher forward-links are added after the blocks are returned.
+	db := servers[0].Service(skipchain.ServiceName).(*skipchain.Service).GetDB()
+	for i := 0; len(db.GetByID(gen.Hash).ForwardLink) < 3; i++ {
+		require.True(t, i < 50, "missing forward-link of level 2")
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	// The database of the conode is locked while it runs, so the commands
+	// work on a copy.
+	dir, err := ioutil.TempDir("", "skipchain-cmd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "conode.db")
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		return tx.CopyFile(name, 0600)
+	}))
+
+	out, err := run("list", name)
+	require.NoError(t, err)
+	require.Contains(t, out, fmt.Sprintf("%x index=4 blocks=5 latest=%x",
+		gen.Hash, latest.Hash))
+	require.Contains(t, out, fmt.Sprintf("%x index=0 blocks=1", other.Hash))
+
+	out, err = run("dump", name, hex.EncodeToString(latest.Hash[:4]))
+	require.NoError(t, err)
+	var jb jsonBlock
+	require.NoError(t, json.Unmarshal([]byte(out), &jb))
+	require.Equal(t, 4, jb.Index)
+	require.Equal(t, hex.EncodeToString(gen.Hash), jb.SkipChainID)
+	require.Equal(t, "03", jb.Data)
+	require.Equal(t, 3, len(jb.Roster))
+	_, err = run("dump", name, "ff00ff00ff")
+	require.Error(t, err)
+
+	out, err = run("follow", name, hex.EncodeToString(gen.Hash))
+	require.NoError(t, err)
+	require.Equal(t, 5, bytes.Count([]byte(out), []byte("\n")))
+	out, err = run("follow", "-level", "2", name, hex.EncodeToString(gen.Hash))
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("index 0, hash %x\nindex 4, hash %x\n",
+		gen.Hash, latest.Hash), out)
+
+	out, err = run("verify", name, hex.EncodeToString(latest.Hash))
+	require.NoError(t, err)
+	require.Contains(t, out, fmt.Sprintf("verified 5 blocks of skipchain %x",
+		gen.Hash))
+
+	_, err = run("list", filepath.Join(dir, "missing.db"))
+	require.Error(t, err)
+
+	// A missing block is detected.
+	bdb, err := bbolt.Open(name, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, skipchain.NewSkipBlockDB(bdb, bucketName).
+		RemoveBlock(blocks[2].Hash))
+	require.NoError(t, bdb.Close())
+	_, err = run("verify", name, hex.EncodeToString(gen.Hash))
+	require.Error(t, err)
+}
+
+func TestCommands_OldLayout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "skipchain-cmd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "conode.db")
+
+	// Blocks of older versions are stored directly in the bucket.
+	sb := skipchain.NewSkipBlock()
+	sb.Hash = sb.CalculateHash()
+	buf, err := protobuf.Encode(sb)
+	require.NoError(t, err)
+	db, err := bbolt.Open(name, 0600, nil)
+	require.NoError(t, err)
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucket(bucketName)
+		if err != nil {
+			return err
+		}
+		return b.Put(sb.Hash, buf)
+	}))
+	require.NoError(t, db.Close())
+
+	_, err = run("list", name)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "older version")
+}
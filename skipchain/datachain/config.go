@@ -0,0 +1,209 @@
+package datachain
+
+import (
+	"crypto/sha256"
+	"sort"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+	"go.dedis.ch/protobuf"
+	"golang.org/x/xerrors"
+)
+
+// configType is the type of the configuration chains in their header.
+const configType = "config"
+
+// ConfigChange is the operation of a block of a configuration chain. It
+// sets the value of the key, or deletes the key.
+type ConfigChange struct {
+	Key    string
+	Value  []byte
+	Delete bool
+}
+
+// ConfigEntry is a key of the configuration with its value.
+type ConfigEntry struct {
+	Key   string
+	Value []byte
+}
+
+// ConfigSnapshot holds all entries of the configuration, sorted by key, so
+// that its encoding is the same on every node.
+type ConfigSnapshot struct {
+	Entries []ConfigEntry
+}
+
+// Hash returns the sha256 of the protobuf encoding of the snapshot.
+func (cs *ConfigSnapshot) Hash() ([]byte, error) {
+	buf, err := protobuf.Encode(cs)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't encode snapshot: %v", err)
+	}
+	h := sha256.Sum256(buf)
+	return h[:], nil
+}
+
+// ConfigChain is a key/value configuration where every block changes one
+// key.
+type ConfigChain struct {
+	*chain
+	values map[string][]byte
+}
+
+// NewConfigChain creates a new, empty configuration chain on the roster.
+func NewConfigChain(roster *onet.Roster) (*ConfigChain, error) {
+	genesis, err := createChain(roster, configType)
+	if err != nil {
+		return nil, err
+	}
+	return OpenConfigChain(roster, genesis.Hash)
+}
+
+// OpenConfigChain returns the configuration chain with the given ID.
+func OpenConfigChain(roster *onet.Roster, id skipchain.SkipBlockID) (*ConfigChain, error) {
+	cc := &ConfigChain{values: make(map[string][]byte)}
+	c, err := openChain(roster, id, configType, cc.apply)
+	if err != nil {
+		return nil, err
+	}
+	cc.chain = c
+	return cc, nil
+}
+
+func (cc *ConfigChain) apply(_ *skipchain.SkipBlock, op interface{}) error {
+	ch, ok := op.(*ConfigChange)
+	if !ok {
+		return xerrors.New("not a configuration change")
+	}
+	if ch.Delete {
+		delete(cc.values, ch.Key)
+	} else {
+		cc.values[ch.Key] = ch.Value
+	}
+	return nil
+}
+
+// Set stores the value of the key.
+func (cc *ConfigChain) Set(key string, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+	_, err := cc.append(&ConfigChange{Key: key, Value: value})
+	return err
+}
+
+// Delete removes the key.
+func (cc *ConfigChain) Delete(key string) error {
+	_, err := cc.append(&ConfigChange{Key: key, Delete: true})
+	return err
+}
+
+// Get returns the latest value of the key, or an error if it is not set.
+func (cc *ConfigChain) Get(key string) ([]byte, error) {
+	cc.Lock()
+	defer cc.Unlock()
+	if err := cc.update(); err != nil {
+		return nil, err
+	}
+	value, ok := cc.values[key]
+	if !ok {
+		return nil, xerrors.Errorf("unknown key %q", key)
+	}
+	return value, nil
+}
+
+// Keys returns the sorted keys of the latest configuration.
+func (cc *ConfigChain) Keys() ([]string, error) {
+	cc.Lock()
+	defer cc.Unlock()
+	if err := cc.update(); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(cc.values))
+	for k := range cc.values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Snapshot returns all entries of the latest configuration.
+func (cc *ConfigChain) Snapshot() (*ConfigSnapshot, error) {
+	cc.Lock()
+	defer cc.Unlock()
+	if err := cc.update(); err != nil {
+		return nil, err
+	}
+	cs := &ConfigSnapshot{Entries: make([]ConfigEntry, 0, len(cc.values))}
+	for k, v := range cc.values {
+		cs.Entries = append(cs.Entries, ConfigEntry{Key: k, Value: v})
+	}
+	sort.Slice(cs.Entries, func(i, j int) bool {
+		return cs.Entries[i].Key < cs.Entries[j].Key
+	})
+	return cs, nil
+}
+
+// SetString stores the string as the value of the key.
+func (cc *ConfigChain) SetString(key, value string) error {
+	return cc.Set(key, []byte(value))
+}
+
+// GetString returns the value of the key as a string.
+func (cc *ConfigChain) GetString(key string) (string, error) {
+	value, err := cc.Get(key)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// SetPoint stores the binary encoding of the point as the value of the key.
+func (cc *ConfigChain) SetPoint(key string, point kyber.Point) error {
+	buf, err := point.MarshalBinary()
+	if err != nil {
+		return xerrors.Errorf("couldn't marshal point: %v", err)
+	}
+	return cc.Set(key, buf)
+}
+
+// GetPoint returns the value of the key as a point of the cothority suite.
+func (cc *ConfigChain) GetPoint(key string) (kyber.Point, error) {
+	value, err := cc.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	point := cothority.Suite.Point()
+	if err := point.UnmarshalBinary(value); err != nil {
+		return nil, xerrors.Errorf("value of %q is not a point: %v", key, err)
+	}
+	return point, nil
+}
+
+// SetStruct stores the protobuf encoding of the structure as the value of
+// the key.
+func (cc *ConfigChain) SetStruct(key string, value interface{}) error {
+	buf, err := protobuf.Encode(value)
+	if err != nil {
+		return xerrors.Errorf("couldn't encode value: %v", err)
+	}
+	return cc.Set(key, buf)
+}
+
+// GetStruct decodes the value of the key into the structure pointed to by
+// value.
+func (cc *ConfigChain) GetStruct(key string, value interface{}) error {
+	buf, err := cc.Get(key)
+	if err != nil {
+		return err
+	}
+	err = protobuf.DecodeWithConstructors(buf, value,
+		network.DefaultConstructors(cothority.Suite))
+	if err != nil {
+		return xerrors.Errorf("couldn't decode value of %q: %v", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package datachain
+
+import (
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/onet/v3"
+	"golang.org/x/xerrors"
+)
+
+// counterType is the type of the counter chains in their header.
+const counterType = "counter"
+
+// CounterAdd is the operation of a block of a counter chain. As blocks only
+// hold the difference, concurrent clients don't overwrite each other.
+type CounterAdd struct {
+	Delta int64
+}
+
+// CounterChain is a counter starting at 0.
+type CounterChain struct {
+	*chain
+	value int64
+}
+
+// NewCounterChain creates a new counter chain on the roster.
+func NewCounterChain(roster *onet.Roster) (*CounterChain, error) {
+	genesis, err := createChain(roster, counterType)
+	if err != nil {
+		return nil, err
+	}
+	return OpenCounterChain(roster, genesis.Hash)
+}
+
+// OpenCounterChain returns the counter chain with the given ID.
+func OpenCounterChain(roster *onet.Roster, id skipchain.SkipBlockID) (*CounterChain, error) {
+	cc := &CounterChain{}
+	c, err := openChain(roster, id, counterType, cc.apply)
+	if err != nil {
+		return nil, err
+	}
+	cc.chain = c
+	return cc, nil
+}
+
+func (cc *CounterChain) apply(_ *skipchain.SkipBlock, op interface{}) error {
+	a, ok := op.(*CounterAdd)
+	if !ok {
+		return xerrors.New("not a counter addition")
+	}
+	cc.value += a.Delta
+	return nil
+}
+
+// Add adds delta to the counter and returns the latest value of the
+// counter, which includes this addition.
+func (cc *CounterChain) Add(delta int64) (int64, error) {
+	if _, err := cc.append(&CounterAdd{Delta: delta}); err != nil {
+		return 0, err
+	}
+	cc.Lock()
+	defer cc.Unlock()
+	return cc.value, nil
+}
+
+// Value returns the latest value of the counter.
+func (cc *CounterChain) Value() (int64, error) {
+	cc.Lock()
+	defer cc.Unlock()
+	if err := cc.update(); err != nil {
+		return 0, err
+	}
+	return cc.value, nil
+}
@@ -0,0 +1,172 @@
+// Package datachain offers typed wrappers around skipchains for the most
+// common uses of the Data of the blocks: an append-only log, a key/value
+// configuration and a counter. Every block after the genesis block holds one
+// operation, and the state of the chain is the result of applying all
+// operations in order.
+//
+// The wrappers keep all blocks they have seen in memory. New blocks are
+// fetched page by page from the conodes, and their hashes, forward-link
+// signatures and backlinks are verified, as well as the type of their
+// operation.
+package datachain
+
+import (
+	"sync"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+// chainTemplate holds the parameters of the chains created by this package.
+var chainTemplate = skipchain.ChainTemplate{
+	BaseHeight:    4,
+	MaximumHeight: 4,
+	VerifierIDs:   skipchain.VerificationStandard,
+}
+
+func init() {
+	network.RegisterMessages(&Header{}, &LogEntry{}, &ConfigChange{},
+		&CounterAdd{})
+}
+
+// Header is stored in the genesis block and holds the type of the chain.
+type Header struct {
+	Type string
+}
+
+// chain holds the blocks of a skipchain and passes the operations of new
+// blocks to apply.
+type chain struct {
+	sync.Mutex
+	client *skipchain.Client
+	// blocks[i] is the block with index i.
+	blocks []*skipchain.SkipBlock
+	// apply is called with every new block and its operation, in order,
+	// and returns an error if the operation is of the wrong type.
+	apply func(sb *skipchain.SkipBlock, op interface{}) error
+}
+
+// createChain creates a new skipchain of the given type.
+func createChain(roster *onet.Roster, typ string) (*skipchain.SkipBlock, error) {
+	genesis, err := skipchain.NewClient().CreateChainFromTemplate(roster,
+		chainTemplate, &Header{Type: typ})
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't create genesis block: %v", err)
+	}
+	return genesis, nil
+}
+
+// openChain fetches the genesis block of the skipchain and checks its type.
+func openChain(roster *onet.Roster, id skipchain.SkipBlockID, typ string,
+	apply func(*skipchain.SkipBlock, interface{}) error) (*chain, error) {
+	c := &chain{client: skipchain.NewClient(), apply: apply}
+	genesis, err := c.client.GetSingleBlock(roster, id)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get genesis block: %v", err)
+	}
+	if genesis.Index != 0 {
+		return nil, xerrors.New("not the ID of a skipchain")
+	}
+	h, ok := decode(genesis).(*Header)
+	if !ok || h.Type != typ {
+		return nil, xerrors.Errorf("not a %s chain", typ)
+	}
+	c.blocks = []*skipchain.SkipBlock{genesis}
+	return c, nil
+}
+
+// ID returns the ID of the skipchain.
+func (c *chain) ID() skipchain.SkipBlockID {
+	return c.blocks[0].Hash
+}
+
+// latest returns the latest known block. The caller must hold the lock.
+func (c *chain) latest() *skipchain.SkipBlock {
+	return c.blocks[len(c.blocks)-1]
+}
+
+// append stores a new block with the operation and applies all new blocks.
+func (c *chain) append(op interface{}) (*skipchain.SkipBlock, error) {
+	c.Lock()
+	defer c.Unlock()
+	reply, err := c.client.StoreSkipBlock(c.blocks[0], nil, op)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't store block: %v", err)
+	}
+	if reply.Latest == nil {
+		return nil, xerrors.New("got an empty reply")
+	}
+	if err := c.update(); err != nil {
+		return nil, err
+	}
+	if reply.Latest.Index >= len(c.blocks) ||
+		!c.blocks[reply.Latest.Index].Hash.Equal(reply.Latest.Hash) {
+		return nil, xerrors.New("new block is not part of the chain")
+	}
+	return reply.Latest, nil
+}
+
+// update fetches the blocks following the latest known one and applies
+// them. The caller must hold the lock.
+func (c *chain) update() error {
+	latest := c.latest()
+	roster := latest.Roster
+	req := &skipchain.GetBlockRange{Start: latest.Hash}
+	for {
+		reply, err := c.client.GetBlockRange(roster, req)
+		if err != nil {
+			return xerrors.Errorf("couldn't get blocks: %v", err)
+		}
+		for _, sb := range reply.Blocks {
+			if sb.Index < len(c.blocks) {
+				// The known block, maybe with new forward-links.
+				if sb.Index != len(c.blocks)-1 ||
+					!sb.Hash.Equal(c.latest().Hash) {
+					return xerrors.New("got an inconsistent block")
+				}
+				c.blocks[sb.Index] = sb
+				continue
+			}
+			if sb.Index != len(c.blocks) {
+				return xerrors.Errorf("missing block %d", len(c.blocks))
+			}
+			op := decode(sb)
+			// Blocks added by the skipchain service itself, like roster
+			// changes, anchors or checkpoints, hold no operation.
+			if isOperation(op) {
+				if err := c.apply(sb, op); err != nil {
+					return xerrors.Errorf("invalid block %d: %v", sb.Index, err)
+				}
+			}
+			c.blocks = append(c.blocks, sb)
+		}
+		if len(reply.NextCursor) == 0 {
+			return nil
+		}
+		req.Cursor = reply.NextCursor
+	}
+}
+
+// decode returns the message stored in the data of the block, or nil if it
+// can't be decoded.
+// isOperation returns true if op is an operation of one of the chains of
+// this package.
+func isOperation(op interface{}) bool {
+	switch op.(type) {
+	case *LogEntry, *ConfigChange, *CounterAdd:
+		return true
+	default:
+		return false
+	}
+}
+
+func decode(sb *skipchain.SkipBlock) interface{} {
+	_, msg, err := network.Unmarshal(sb.Data, cothority.Suite)
+	if err != nil {
+		return nil
+	}
+	return msg
+}
@@ -0,0 +1,158 @@
+package datachain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+)
+
+func TestMain(m *testing.M) {
+	log.MainTest(m)
+}
+
+func TestLogChain(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer l.CloseAll()
+	_, ro, _ := l.GenTree(3, true)
+
+	lc, err := NewLogChain(ro)
+	require.NoError(t, err)
+	for i := 0; i < 6; i++ {
+		index, err := lc.Append([]byte{byte(i)})
+		require.NoError(t, err)
+		require.Equal(t, i, index)
+	}
+
+	// Another client sees all entries.
+	other, err := OpenLogChain(ro, lc.ID())
+	require.NoError(t, err)
+	n, err := other.Len()
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	entry, err := other.Get(5)
+	require.NoError(t, err)
+	require.Equal(t, []byte{5}, entry)
+	_, err = other.Get(6)
+	require.Error(t, err)
+
+	index, err := other.Append([]byte("new entry"))
+	require.NoError(t, err)
+	require.Equal(t, 6, index)
+	entry, err = lc.Get(6)
+	require.NoError(t, err)
+	require.Equal(t, []byte("new entry"), entry)
+
+	// Chains of other types are refused.
+	_, err = OpenCounterChain(ro, lc.ID())
+	require.Error(t, err)
+	genesis, err := skipchain.NewClient().CreateGenesis(ro, 1, 1,
+		skipchain.VerificationNone, nil)
+	require.NoError(t, err)
+	_, err = OpenLogChain(ro, genesis.Hash)
+	require.Error(t, err)
+}
+
+func TestConfigChain(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer l.CloseAll()
+	_, ro, _ := l.GenTree(3, true)
+
+	cc, err := NewConfigChain(ro)
+	require.NoError(t, err)
+	require.NoError(t, cc.Set("a", []byte("1")))
+	require.NoError(t, cc.Set("b", []byte("2")))
+	require.NoError(t, cc.Set("a", []byte("3")))
+	require.NoError(t, cc.Delete("b"))
+
+	other, err := OpenConfigChain(ro, cc.ID())
+	require.NoError(t, err)
+	value, err := other.Get("a")
+	require.NoError(t, err)
+	require.Equal(t, []byte("3"), value)
+	_, err = other.Get("b")
+	require.Error(t, err)
+	keys, err := other.Keys()
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, keys)
+}
+
+func TestConfigChain_Typed(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer l.CloseAll()
+	_, ro, _ := l.GenTree(3, true)
+
+	cc, err := NewConfigChain(ro)
+	require.NoError(t, err)
+	type entry struct {
+		Name  string
+		Count int
+		Key   kyber.Point
+	}
+	kp := key.NewKeyPair(cothority.Suite)
+	require.NoError(t, cc.SetString("name", "conode"))
+	require.NoError(t, cc.SetPoint("key", kp.Public))
+	require.NoError(t, cc.SetStruct("entry", &entry{"a", 2, kp.Public}))
+
+	other, err := OpenConfigChain(ro, cc.ID())
+	require.NoError(t, err)
+	name, err := other.GetString("name")
+	require.NoError(t, err)
+	require.Equal(t, "conode", name)
+	point, err := other.GetPoint("key")
+	require.NoError(t, err)
+	require.True(t, point.Equal(kp.Public))
+	_, err = other.GetPoint("name")
+	require.Error(t, err)
+	var e entry
+	require.NoError(t, other.GetStruct("entry", &e))
+	require.Equal(t, "a", e.Name)
+	require.Equal(t, 2, e.Count)
+	require.True(t, e.Key.Equal(kp.Public))
+
+	// The snapshot is the same on both sides, whatever the order of the
+	// map of the values.
+	s1, err := cc.Snapshot()
+	require.NoError(t, err)
+	s2, err := other.Snapshot()
+	require.NoError(t, err)
+	require.Equal(t, []string{"entry", "key", "name"},
+		[]string{s1.Entries[0].Key, s1.Entries[1].Key, s1.Entries[2].Key})
+	h1, err := s1.Hash()
+	require.NoError(t, err)
+	h2, err := s2.Hash()
+	require.NoError(t, err)
+	require.Equal(t, h1, h2)
+	require.NoError(t, cc.SetString("name", "other"))
+	s1, err = cc.Snapshot()
+	require.NoError(t, err)
+	h1, err = s1.Hash()
+	require.NoError(t, err)
+	require.NotEqual(t, h1, h2)
+}
+
+func TestCounterChain(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer l.CloseAll()
+	_, ro, _ := l.GenTree(3, true)
+
+	cc, err := NewCounterChain(ro)
+	require.NoError(t, err)
+	other, err := OpenCounterChain(ro, cc.ID())
+	require.NoError(t, err)
+
+	value, err := cc.Add(5)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), value)
+	value, err = other.Add(-2)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), value)
+	value, err = cc.Value()
+	require.NoError(t, err)
+	require.Equal(t, int64(3), value)
+}
@@ -0,0 +1,90 @@
+package datachain
+
+import (
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/onet/v3"
+	"golang.org/x/xerrors"
+)
+
+// logType is the type of the log chains in their header.
+const logType = "log"
+
+// LogEntry is the operation of a block of a log chain.
+type LogEntry struct {
+	Data []byte
+}
+
+// LogChain is an append-only log of entries, numbered from 0 in the order
+// of the blocks.
+type LogChain struct {
+	*chain
+	entries [][]byte
+	// indexes maps the index of a block to the index of its entry.
+	indexes map[int]int
+}
+
+// NewLogChain creates a new log chain on the roster.
+func NewLogChain(roster *onet.Roster) (*LogChain, error) {
+	genesis, err := createChain(roster, logType)
+	if err != nil {
+		return nil, err
+	}
+	return OpenLogChain(roster, genesis.Hash)
+}
+
+// OpenLogChain returns the log chain with the given ID.
+func OpenLogChain(roster *onet.Roster, id skipchain.SkipBlockID) (*LogChain, error) {
+	lc := &LogChain{indexes: make(map[int]int)}
+	c, err := openChain(roster, id, logType, lc.apply)
+	if err != nil {
+		return nil, err
+	}
+	lc.chain = c
+	return lc, nil
+}
+
+func (lc *LogChain) apply(sb *skipchain.SkipBlock, op interface{}) error {
+	e, ok := op.(*LogEntry)
+	if !ok {
+		return xerrors.New("not a log entry")
+	}
+	lc.indexes[sb.Index] = len(lc.entries)
+	lc.entries = append(lc.entries, e.Data)
+	return nil
+}
+
+// Append adds the entry to the log and returns its index.
+func (lc *LogChain) Append(entry []byte) (int, error) {
+	sb, err := lc.append(&LogEntry{Data: entry})
+	if err != nil {
+		return 0, err
+	}
+	lc.Lock()
+	defer lc.Unlock()
+	return lc.indexes[sb.Index], nil
+}
+
+// Get returns the entry with the given index.
+func (lc *LogChain) Get(index int) ([]byte, error) {
+	lc.Lock()
+	defer lc.Unlock()
+	if index >= len(lc.entries) {
+		if err := lc.update(); err != nil {
+			return nil, err
+		}
+	}
+	if index < 0 || index >= len(lc.entries) {
+		return nil, xerrors.Errorf("unknown entry %d", index)
+	}
+	return lc.entries[index], nil
+}
+
+// Len returns the number of entries of the log.
+func (lc *LogChain) Len() (int, error) {
+	lc.Lock()
+	defer lc.Unlock()
+	if err := lc.update(); err != nil {
+		return 0, err
+	}
+	return len(lc.entries), nil
+}
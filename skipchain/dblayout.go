@@ -0,0 +1,303 @@
+package skipchain
+
+import (
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/protobuf"
+	bbolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the layout of the blocks in the database. The bucket of the
+SkipBlockDB holds one nested bucket per skipchain, named after the ID of the
+skipchain, with the blocks of this skipchain stored by their hash. The index
+bucket maps the hash of every block to the ID of its skipchain. So removing a
+skipchain drops a single bucket, and going through the blocks of a skipchain
+doesn't read the blocks of other skipchains.
+
+Databases where all blocks are stored directly in the bucket of the
+SkipBlockDB are converted when the skipchain service starts. Tools opening
+the database don't change it, and refuse to work on it with CheckLayout, as
+they would not see the blocks that are not converted. If a block cannot be converted, the service refuses to start, and
+the blocks that are not converted yet stay where they are, so the conversion
+can be run again.
+*/
+
+// ErrorOldLayout is returned by CheckLayout if the database holds blocks in
+// the layout of older versions.
+var ErrorOldLayout = xerrors.New("the database holds blocks in the layout " +
+	"of an older version")
+
+// migrationBatch is the number of blocks converted in one transaction.
+const migrationBatch = 1000
+
+// indexBucket returns the name of the bucket mapping the hash of every block
+// to the ID of its skipchain.
+func (db *SkipBlockDB) indexBucket() []byte {
+	return append(append([]byte{}, db.bucketName...), []byte("_index")...)
+}
+
+// chainBucketTx returns the bucket of the blocks of the skipchain, or nil if
+// no block of the skipchain is stored.
+func (db *SkipBlockDB) chainBucketTx(tx *bbolt.Tx, scID SkipBlockID) *bbolt.Bucket {
+	b := tx.Bucket(db.bucketName)
+	if b == nil || len(scID) == 0 {
+		return nil
+	}
+	return b.Bucket(scID)
+}
+
+// blockBucketTx returns the bucket of the skipchain of the block, or nil if
+// the block is unknown.
+func (db *SkipBlockDB) blockBucketTx(tx *bbolt.Tx, id SkipBlockID) *bbolt.Bucket {
+	idx := tx.Bucket(db.indexBucket())
+	if idx == nil {
+		return nil
+	}
+	return db.chainBucketTx(tx, idx.Get(id))
+}
+
+// forEachChainTx calls f with the ID and the bucket of every skipchain.
+func (db *SkipBlockDB) forEachChainTx(tx *bbolt.Tx,
+	f func(scID SkipBlockID, b *bbolt.Bucket) error) error {
+	b := tx.Bucket(db.bucketName)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error {
+		if v != nil {
+			// Not converted yet.
+			return nil
+		}
+		return f(k, b.Bucket(k))
+	})
+}
+
+// forEachBlockTx calls f with the hash and the encoded block of every block
+// of every skipchain.
+func (db *SkipBlockDB) forEachBlockTx(tx *bbolt.Tx, f func(k, v []byte) error) error {
+	return db.forEachChainTx(tx, func(_ SkipBlockID, b *bbolt.Bucket) error {
+		return b.ForEach(f)
+	})
+}
+
+// forEachChainBlockTx calls f with the hash and the encoded block of every
+// block of the skipchain.
+func (db *SkipBlockDB) forEachChainBlockTx(tx *bbolt.Tx, scID SkipBlockID,
+	f func(k, v []byte) error) error {
+	b := db.chainBucketTx(tx, scID)
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(f)
+}
+
+// putTx stores the encoded block in the bucket of its skipchain and in the
+// index.
+func (db *SkipBlockDB) putTx(tx *bbolt.Tx, scID, id SkipBlockID, val []byte) error {
+	if len(scID) == 0 {
+		return xerrors.Errorf("block %x has no skipchain-id", id)
+	}
+	b, err := tx.CreateBucketIfNotExists(db.bucketName)
+	if err != nil {
+		return err
+	}
+	chain, err := b.CreateBucketIfNotExists(scID)
+	if err != nil {
+		return xerrors.Errorf("couldn't create bucket of skipchain %x: %v",
+			scID, err)
+	}
+	if err := chain.Put(id, val); err != nil {
+		return err
+	}
+	idx, err := tx.CreateBucketIfNotExists(db.indexBucket())
+	if err != nil {
+		return err
+	}
+	return idx.Put(id, scID)
+}
+
+// deleteBlockTx removes the block from the bucket of its skipchain and from
+// the index. The bucket of the skipchain is removed with its last block.
+func (db *SkipBlockDB) deleteBlockTx(tx *bbolt.Tx, id SkipBlockID) error {
+	idx := tx.Bucket(db.indexBucket())
+	if idx == nil {
+		return nil
+	}
+	scID := idx.Get(id)
+	if scID == nil {
+		return nil
+	}
+	scID = append(SkipBlockID{}, scID...)
+	if err := idx.Delete(id); err != nil {
+		return err
+	}
+	chain := db.chainBucketTx(tx, scID)
+	if chain == nil {
+		return nil
+	}
+	if err := chain.Delete(id); err != nil {
+		return err
+	}
+	if k, _ := chain.Cursor().First(); k == nil {
+		return tx.Bucket(db.bucketName).DeleteBucket(scID)
+	}
+	return nil
+}
+
+// deleteChainTx removes the bucket of the skipchain and all its blocks from
+// the index, and returns the hashes of the removed blocks.
+func (db *SkipBlockDB) deleteChainTx(tx *bbolt.Tx, scID SkipBlockID) ([]SkipBlockID, error) {
+	chain := db.chainBucketTx(tx, scID)
+	if chain == nil {
+		return nil, nil
+	}
+	var ids []SkipBlockID
+	err := chain.ForEach(func(k, v []byte) error {
+		ids = append(ids, append(SkipBlockID{}, k...))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if idx := tx.Bucket(db.indexBucket()); idx != nil {
+		for _, id := range ids {
+			if err := idx.Delete(id); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ids, tx.Bucket(db.bucketName).DeleteBucket(scID)
+}
+
+// sizeTx returns the number of stored blocks and the number of bytes they
+// use in the database.
+func (db *SkipBlockDB) sizeTx(tx *bbolt.Tx) (blocks, bytes int) {
+	if idx := tx.Bucket(db.indexBucket()); idx != nil {
+		blocks = idx.Stats().KeyN
+	}
+	if b := tx.Bucket(db.bucketName); b != nil {
+		s := b.Stats()
+		bytes = s.BranchInuse + s.LeafInuse
+	}
+	return
+}
+
+// ChainSize returns the number of blocks of the skipchain and the number of
+// bytes they use in the database.
+func (db *SkipBlockDB) ChainSize(scID SkipBlockID) (blocks, bytes int, err error) {
+	err = db.View(func(tx *bbolt.Tx) error {
+		b := db.chainBucketTx(tx, scID)
+		if b == nil {
+			return nil
+		}
+		s := b.Stats()
+		blocks = s.KeyN
+		bytes = s.BranchInuse + s.LeafInuse
+		return nil
+	})
+	return
+}
+
+// CheckLayout returns ErrorOldLayout if some blocks are stored in the layout
+// of older versions, and are not seen by the methods of the SkipBlockDB.
+func (db *SkipBlockDB) CheckLayout() error {
+	flat, err := db.flatLayout()
+	if err != nil {
+		return xerrors.Errorf("couldn't check the layout: %v", err)
+	}
+	if flat {
+		return ErrorOldLayout
+	}
+	return nil
+}
+
+// flatLayout returns true if some blocks are stored directly in the bucket
+// of the SkipBlockDB.
+func (db *SkipBlockDB) flatLayout() (flat bool, err error) {
+	err = db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(db.bucketName)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil && !flat; k, v = c.Next() {
+			flat = v != nil
+		}
+		return nil
+	})
+	return
+}
+
+// migrate moves the blocks stored directly in the bucket of the SkipBlockDB
+// to the buckets of their skipchains.
+func (db *SkipBlockDB) migrate() error {
+	flat, err := db.flatLayout()
+	if err != nil || !flat {
+		return err
+	}
+
+	total := 0
+	for {
+		moved := 0
+		err := db.Update(func(tx *bbolt.Tx) error {
+			b := tx.Bucket(db.bucketName)
+			var keys [][]byte
+			c := b.Cursor()
+			for k, v := c.First(); k != nil && len(keys) < migrationBatch; k, v = c.Next() {
+				if v != nil {
+					keys = append(keys, append([]byte{}, k...))
+				}
+			}
+			for _, k := range keys {
+				v := b.Get(k)
+				if v == nil {
+					// Already moved as the genesis of an earlier block.
+					continue
+				}
+				if err := db.migrateBlockTx(tx, k, v); err != nil {
+					return xerrors.Errorf("couldn't convert block %x: %v", k, err)
+				}
+				moved++
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if moved == 0 {
+			break
+		}
+		total += moved
+	}
+	log.Lvlf1("moved %d blocks to the buckets of their skipchains", total)
+	return nil
+}
+
+// migrateBlockTx moves one block to the bucket of its skipchain. As the key
+// of the genesis block is also the name of the bucket of its skipchain, the
+// genesis block is moved first.
+func (db *SkipBlockDB) migrateBlockTx(tx *bbolt.Tx, k, v []byte) error {
+	val := append([]byte{}, v...)
+	if len(val) < 16 {
+		return xerrors.New("value is too short")
+	}
+	var sbs skipBlockShort
+	if err := protobuf.Decode(val[16:], &sbs); err != nil {
+		return err
+	}
+	scID := SkipBlockID(sbs.GenesisID)
+	if sbs.Index == 0 || len(scID) == 0 {
+		scID = k
+	}
+	b := tx.Bucket(db.bucketName)
+	if gen := b.Get(scID); gen != nil && !scID.Equal(k) {
+		if err := db.migrateBlockTx(tx, scID, gen); err != nil {
+			return err
+		}
+	}
+	if err := b.Delete(k); err != nil {
+		return err
+	}
+	return db.putTx(tx, append(SkipBlockID{}, scID...), k, val)
+}
@@ -0,0 +1,134 @@
+package skipchain
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/onet/v3/network"
+	bbolt "go.etcd.io/bbolt"
+)
+
+// newLayoutChain returns a skipchain of n blocks with the given data in the
+// genesis block. The links are not signed.
+func newLayoutChain(n int, data byte) []*SkipBlock {
+	gen := NewSkipBlock()
+	gen.Data = []byte{data}
+	gen.Hash = gen.CalculateHash()
+	blocks := []*SkipBlock{gen}
+	for i := 1; i < n; i++ {
+		sb := NewSkipBlock()
+		sb.Index = i
+		sb.GenesisID = gen.Hash
+		sb.BackLinkIDs = []SkipBlockID{blocks[i-1].Hash}
+		sb.Hash = sb.CalculateHash()
+		blocks = append(blocks, sb)
+	}
+	return blocks
+}
+
+func TestSkipBlockDB_Migrate(t *testing.T) {
+	db, fname := setupSkipBlockDB(t)
+	defer os.Remove(fname)
+	chain1 := newLayoutChain(5, 1)
+	chain2 := newLayoutChain(3, 2)
+
+	// Store the blocks like older versions did, directly in the bucket.
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		for _, sb := range append(chain1, chain2...) {
+			buf, err := network.Marshal(sb)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(db.bucketName).Put(sb.Hash, buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// Opening the database doesn't change it, only the service converts it.
+	db = NewSkipBlockDB(db.DB, db.bucketName)
+	defer db.Close()
+	require.Equal(t, ErrorOldLayout, db.CheckLayout())
+	require.NoError(t, db.migrate())
+	require.NoError(t, db.CheckLayout())
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(db.bucketName).ForEach(func(k, v []byte) error {
+			require.Nil(t, v, "block %x has not been moved", k)
+			return nil
+		})
+	}))
+	require.Equal(t, 8, db.Length())
+	for _, sb := range append(chain1, chain2...) {
+		require.NotNil(t, db.GetByID(sb.Hash))
+	}
+	summaries, err := db.Summary()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(summaries))
+
+	blocks, _, err := db.ChainSize(chain1[0].Hash)
+	require.NoError(t, err)
+	require.Equal(t, 5, blocks)
+	blocks, _, err = db.ChainSize(chain2[0].Hash)
+	require.NoError(t, err)
+	require.Equal(t, 3, blocks)
+
+	sb, err := db.GetFuzzy(chain2[1].Hash.Short()[:8])
+	require.NoError(t, err)
+	require.True(t, sb.Hash.Equal(chain2[1].Hash))
+
+	// Removing a skipchain doesn't touch the other one, and removing an
+	// unknown skipchain does nothing.
+	require.NoError(t, db.RemoveSkipchain(chain1[0].Hash))
+	require.NoError(t, db.RemoveSkipchain(chain1[0].Hash))
+	require.Equal(t, 3, db.Length())
+	require.Nil(t, db.GetByID(chain1[2].Hash))
+	blocks, _, err = db.ChainSize(chain1[0].Hash)
+	require.NoError(t, err)
+	require.Equal(t, 0, blocks)
+	require.NotNil(t, db.GetByID(chain2[2].Hash))
+
+	// The bucket of a skipchain is removed with its last block.
+	for _, sb := range chain2 {
+		require.NoError(t, db.RemoveBlock(sb.Hash))
+	}
+	require.Equal(t, 0, db.Length())
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		require.Nil(t, db.chainBucketTx(tx, chain2[0].Hash))
+		return nil
+	}))
+}
+
+func TestSkipBlockDB_MigrateError(t *testing.T) {
+	db, fname := setupSkipBlockDB(t)
+	defer os.Remove(fname)
+	defer db.Close()
+	chain := newLayoutChain(3, 1)
+
+	require.NoError(t, db.Update(func(tx *bbolt.Tx) error {
+		for _, sb := range chain {
+			buf, err := network.Marshal(sb)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(db.bucketName).Put(sb.Hash, buf); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(db.bucketName).Put([]byte("corrupt"), []byte{1, 2, 3})
+	}))
+
+	// A block that cannot be decoded stops the conversion, and the blocks
+	// stay where they are.
+	require.Error(t, db.migrate())
+	flat, err := db.flatLayout()
+	require.NoError(t, err)
+	require.True(t, flat)
+	require.NoError(t, db.View(func(tx *bbolt.Tx) error {
+		for _, sb := range chain {
+			require.NotNil(t, tx.Bucket(db.bucketName).Get(sb.Hash))
+		}
+		return nil
+	}))
+}
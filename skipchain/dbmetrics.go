@@ -0,0 +1,274 @@
+package skipchain
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3/log"
+	bbolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the metrics of the storage and the propagation of blocks. The
+database measures how long storing and getting blocks takes, and the service
+how long the propagations take and how many nodes they reach. If the
+propagations are paced, the service also measures how long a node takes to
+send a block to all its children, and how long the pacing delayed the sends.
+The metrics are
+returned by Service.Metrics and GetMetrics, added to the status report of the
+database, and can be served in the Prometheus text format on the address
+given in the COTHORITY_SKIPCHAIN_METRICS environment variable. Every service
+serves its own metrics, until it is closed.
+*/
+
+// metricsEnv is the environment variable holding the address of the HTTP
+// endpoint of the metrics, for example ":9100". Without a host, the endpoint
+// is only served on localhost.
+const metricsEnv = "COTHORITY_SKIPCHAIN_METRICS"
+
+// Timing holds the number of runs of an operation and their latencies.
+type Timing struct {
+	Count int
+	// Total is the time spent in all runs, Max the longest run.
+	Total time.Duration
+	Max   time.Duration
+}
+
+func (t *Timing) record(latency time.Duration) {
+	t.Count++
+	t.Total += latency
+	if latency > t.Max {
+		t.Max = latency
+	}
+}
+
+// Average returns the average latency of the runs.
+func (t Timing) Average() time.Duration {
+	if t.Count == 0 {
+		return 0
+	}
+	return t.Total / time.Duration(t.Count)
+}
+
+// String returns the count and the average and maximum latencies.
+func (t Timing) String() string {
+	return fmt.Sprintf("count=%d avg=%s max=%s", t.Count, t.Average(), t.Max)
+}
+
+// DBMetrics holds the metrics of the storage and the propagation of blocks.
+type DBMetrics struct {
+	// Blocks is the number of stored blocks, Bytes the size they use in
+	// the database.
+	Blocks int
+	Bytes  int
+	Stores Timing
+	Gets   Timing
+	// Propagations is the timing of the propagations started by this
+	// node. FanOut is the number of nodes they were sent to, and Replies
+	// the number of nodes that answered.
+	Propagations Timing
+	FanOut       int
+	Replies      int
+	// FanOutTimes is the timing of sending the blocks to the children in
+	// the paced propagations, and PacingWaited the time the sends have been
+	// delayed by the pacing.
+	FanOutTimes  Timing
+	PacingWaited time.Duration
+}
+
+// dbTimings holds the timings of the database and of the propagations.
+type dbTimings struct {
+	sync.Mutex
+	stores       Timing
+	gets         Timing
+	propagations Timing
+	fanOut       int
+	replies      int
+}
+
+func (dt *dbTimings) recordStore(start time.Time) {
+	dt.Lock()
+	dt.stores.record(time.Since(start))
+	dt.Unlock()
+}
+
+func (dt *dbTimings) recordGet(start time.Time) {
+	dt.Lock()
+	dt.gets.record(time.Since(start))
+	dt.Unlock()
+}
+
+func (dt *dbTimings) recordPropagation(start time.Time, nodes, replies int) {
+	dt.Lock()
+	dt.propagations.record(time.Since(start))
+	dt.fanOut += nodes
+	dt.replies += replies
+	dt.Unlock()
+}
+
+// Metrics returns the metrics of the database.
+func (db *SkipBlockDB) Metrics() (DBMetrics, error) {
+	var m DBMetrics
+	err := db.View(func(tx *bbolt.Tx) error {
+		m.Blocks, m.Bytes = db.sizeTx(tx)
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+	db.timings.Lock()
+	defer db.timings.Unlock()
+	m.Stores = db.timings.stores
+	m.Gets = db.timings.gets
+	m.Propagations = db.timings.propagations
+	m.FanOut = db.timings.fanOut
+	m.Replies = db.timings.replies
+	return m, nil
+}
+
+// WritePrometheus writes the metrics in the Prometheus text format.
+func (m DBMetrics) WritePrometheus(w io.Writer) error {
+	gauge := func(name, help string, value int) error {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %d\n",
+			name, help, name, name, value)
+		return err
+	}
+	counter := func(name, help string, value int) error {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %d\n",
+			name, help, name, name, value)
+		return err
+	}
+	seconds := func(name, help string, value time.Duration) error {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n%s %g\n",
+			name, help, name, name, value.Seconds())
+		return err
+	}
+	summary := func(name, help string, t Timing) error {
+		_, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s summary\n"+
+			"%s_sum %g\n%s_count %d\n", name, help, name, name,
+			t.Total.Seconds(), name, t.Count)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "# HELP %s_max longest %s\n"+
+			"# TYPE %s_max gauge\n%s_max %g\n", name, help, name, name,
+			t.Max.Seconds())
+		return err
+	}
+	for _, err := range []error{
+		gauge("skipchain_blocks", "number of stored blocks", m.Blocks),
+		gauge("skipchain_db_bytes", "bytes used by the stored blocks", m.Bytes),
+		summary("skipchain_store_seconds", "latency of storing blocks", m.Stores),
+		summary("skipchain_get_seconds", "latency of getting a block", m.Gets),
+		summary("skipchain_propagation_seconds", "latency of propagations",
+			m.Propagations),
+		counter("skipchain_propagation_nodes_total",
+			"nodes the propagations were sent to", m.FanOut),
+		counter("skipchain_propagation_replies_total",
+			"nodes that answered the propagations", m.Replies),
+		summary("skipchain_propagation_fanout_seconds",
+			"time to send blocks to the children", m.FanOutTimes),
+		seconds("skipchain_propagation_paced_seconds_total",
+			"time sends have been delayed by the pacing", m.PacingWaited),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Metrics returns the metrics of the storage and the propagation of blocks.
+func (s *Service) Metrics() (DBMetrics, error) {
+	m, err := s.db.Metrics()
+	if err != nil {
+		return m, err
+	}
+	m.FanOutTimes, m.PacingWaited = s.pacingStats()
+	return m, nil
+}
+
+// GetMetrics returns the metrics of the storage and the propagation of
+// blocks of this conode.
+func (s *Service) GetMetrics(req *GetMetrics) (*GetMetricsReply, error) {
+	m, err := s.Metrics()
+	if err != nil {
+		return nil, err
+	}
+	return &GetMetricsReply{Metrics: m}, nil
+}
+
+// serveMetrics serves the metrics in the Prometheus text format.
+func (s *Service) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	m, err := s.Metrics()
+	if err != nil {
+		log.Errorf("couldn't get metrics: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if err := m.WritePrometheus(w); err != nil {
+		log.Errorf("couldn't write metrics: %v", err)
+	}
+}
+
+// metricsAddress returns the address of the metrics endpoint given in the
+// environment, with localhost as host if none is given. It returns an empty
+// string if no address is given.
+func metricsAddress() string {
+	addr := os.Getenv(metricsEnv)
+	if addr == "" {
+		return ""
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	return net.JoinHostPort("localhost", port)
+}
+
+// startMetricsEndpoint serves the metrics of this service on /metrics until
+// the service is closed. It returns the address it listens on.
+func (s *Service) startMetricsEndpoint(addr string) (net.Addr, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't listen for metrics: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", s.serveMetrics)
+	srv := &http.Server{Handler: mux}
+	log.Lvlf1("%s: serving skipchain metrics on %s", s.ServerIdentity(), l.Addr())
+	go func() {
+		if err := srv.Serve(l); err != nil && err != http.ErrServerClosed {
+			log.Errorf("couldn't serve metrics: %v", err)
+		}
+	}()
+	closing := s.closing
+	go func() {
+		<-closing
+		if err := srv.Close(); err != nil {
+			log.Errorf("couldn't stop serving metrics: %v", err)
+		}
+	}()
+	return l.Addr(), nil
+}
+
+// startMetrics starts the metrics endpoint if an address is given in the
+// environment.
+func (s *Service) startMetrics() {
+	if addr := metricsAddress(); addr != "" {
+		if _, err := s.startMetricsEndpoint(addr); err != nil {
+			log.Errorf("%s: %v", s.ServerIdentity(), err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package skipchain
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestDBMetrics_WritePrometheus(t *testing.T) {
+	m := DBMetrics{
+		Blocks: 3,
+		Bytes:  1024,
+		Stores: Timing{Count: 2, Total: time.Second, Max: 750 * time.Millisecond},
+		FanOut: 4,
+
+		PacingWaited: 1500 * time.Millisecond,
+	}
+	var buf bytes.Buffer
+	require.NoError(t, m.WritePrometheus(&buf))
+	out := buf.String()
+	require.Contains(t, out, "# TYPE skipchain_blocks gauge\nskipchain_blocks 3\n")
+	require.Contains(t, out, "skipchain_db_bytes 1024\n")
+	require.Contains(t, out, "skipchain_store_seconds_sum 1\n")
+	require.Contains(t, out, "skipchain_store_seconds_count 2\n")
+	require.Contains(t, out, "skipchain_store_seconds_max 0.75\n")
+	require.Contains(t, out, "skipchain_propagation_nodes_total 4\n")
+	require.Contains(t, out, "skipchain_propagation_paced_seconds_total 1.5\n")
+}
+
+func TestService_Metrics(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	servers, ro, _ := l.GenTree(3, true)
+	defer l.CloseAll()
+	s := l.GetServices(servers, skipchainSID)[0].(*Service)
+	c := newTestClient(l)
+
+	genesis, err := c.CreateGenesis(ro, 2, 3, VerificationStandard, nil)
+	require.NoError(t, err)
+	_, err = c.StoreSkipBlock(genesis, nil, []byte("data"))
+	require.NoError(t, err)
+
+	m, err := c.GetMetrics(ro.List[0])
+	require.NoError(t, err)
+	require.Equal(t, 2, m.Blocks)
+	require.True(t, m.Bytes > 0)
+	require.True(t, m.Stores.Count > 0)
+	require.True(t, m.Gets.Count > 0)
+	require.True(t, m.Stores.Max > 0)
+	require.True(t, m.Propagations.Count > 0)
+	require.True(t, m.FanOut >= m.Replies)
+	require.True(t, m.Replies > 0)
+
+	status := s.db.GetStatus().Field
+	require.True(t, strings.HasPrefix(status["Stores"], "count="))
+
+	rec := httptest.NewRecorder()
+	s.serveMetrics(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "skipchain_blocks 2\n")
+	rec = httptest.NewRecorder()
+	s.serveMetrics(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestMetricsAddress(t *testing.T) {
+	defer os.Setenv(metricsEnv, os.Getenv(metricsEnv))
+	os.Setenv(metricsEnv, "")
+	require.Equal(t, "", metricsAddress())
+	os.Setenv(metricsEnv, ":9100")
+	require.Equal(t, "localhost:9100", metricsAddress())
+	os.Setenv(metricsEnv, "0.0.0.0:9100")
+	require.Equal(t, "0.0.0.0:9100", metricsAddress())
+}
+
+func TestService_MetricsEndpoint(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	servers, ro, _ := l.GenTree(2, true)
+	defer l.CloseAll()
+	services := l.GetServices(servers, skipchainSID)
+	c := newTestClient(l)
+	_, err := c.CreateGenesis(ro, 2, 3, VerificationStandard, nil)
+	require.NoError(t, err)
+
+	// Every service serves its own metrics.
+	get := func(addr string) (string, error) {
+		resp, err := http.Get("http://" + addr + "/metrics")
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		buf, err := ioutil.ReadAll(resp.Body)
+		return string(buf), err
+	}
+	var addrs []string
+	for _, s := range services {
+		addr, err := s.(*Service).startMetricsEndpoint("127.0.0.1:0")
+		require.NoError(t, err)
+		addrs = append(addrs, addr.String())
+	}
+	for _, addr := range addrs {
+		out, err := get(addr)
+		require.NoError(t, err)
+		require.Contains(t, out, "skipchain_blocks 1\n")
+	}
+
+	// The endpoint stops with its service.
+	services[0].(*Service).TestClose()
+	require.Eventually(t, func() bool {
+		_, err := get(addrs[0])
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = get(addrs[1])
+	require.NoError(t, err)
+}
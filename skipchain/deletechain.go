@@ -0,0 +1,54 @@
+package skipchain
+
+import (
+	"golang.org/x/xerrors"
+)
+
+// DeleteChainLocal removes all blocks of the skipchain from the database of
+// this conode and refuses any further block of the chain, be it through
+// propagation, signature requests or reconciliation. The request must be
+// signed by one of the linked clients or by this conode.
+func (s *Service) DeleteChainLocal(req *DeleteChainLocal) (*EmptyReply, error) {
+	if !s.verifyAdminSigs(deleteChainMsg(req.SkipChainID), req.Signature) {
+		return nil, xerrors.New("wrong signature of unknown signer")
+	}
+	genesis := s.db.GetByID(req.SkipChainID)
+	if genesis == nil || genesis.Index != 0 {
+		return nil, xerrors.Errorf("unknown skipchain %x", req.SkipChainID)
+	}
+
+	// Mark the chain as deleted first, so that no new block is stored while
+	// the existing ones are removed.
+	s.storageMutex.Lock()
+	s.Storage.Deleted = append(s.Storage.Deleted, req.SkipChainID)
+	s.storageMutex.Unlock()
+	s.save()
+
+	s.chains.lock(req.SkipChainID)
+	defer s.chains.unlock(req.SkipChainID)
+	if err := s.db.RemoveSkipchain(req.SkipChainID); err != nil {
+		return nil, xerrors.Errorf("couldn't remove skipchain: %v", err)
+	}
+	if err := s.RegisterCheckpoint(req.SkipChainID, 0, nil); err != nil {
+		return nil, err
+	}
+	return &EmptyReply{}, nil
+}
+
+// chainIsDeleted returns true if the skipchain has been deleted locally.
+func (s *Service) chainIsDeleted(scID SkipBlockID) bool {
+	s.storageMutex.Lock()
+	defer s.storageMutex.Unlock()
+	for _, id := range s.Storage.Deleted {
+		if id.Equal(scID) {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteChainMsg returns the message to be signed by a linked client to
+// delete a skipchain locally.
+func deleteChainMsg(scID SkipBlockID) []byte {
+	return append([]byte("deletechain:"), scID...)
+}
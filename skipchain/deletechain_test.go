@@ -0,0 +1,72 @@
+package skipchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_DeleteChainLocal(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, ro, gs := l.MakeSRS(cothority.Suite, 4, skipchainSID)
+	service := gs.(*Service)
+	services := l.GetServices(servers, skipchainSID)
+	last := services[3].(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	other, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	store := func() error {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		return err
+	}
+	require.NoError(t, store())
+	require.NotNil(t, last.db.GetByID(genesis.Hash))
+
+	// Without linked clients, only the conode itself can delete a chain.
+	wrong := key.NewKeyPair(cothority.Suite)
+	require.Error(t, c.DeleteChainLocal(ro.List[3], wrong.Private, genesis.Hash))
+	require.NotNil(t, last.db.GetByID(genesis.Hash))
+	require.NoError(t, c.DeleteChainLocal(ro.List[3], l.GetPrivate(servers[3]),
+		other.Hash))
+	require.Nil(t, last.db.GetByID(other.Hash))
+
+	kp := key.NewKeyPair(cothority.Suite)
+	last.Storage.Clients = []kyber.Point{kp.Public}
+	require.Error(t, c.DeleteChainLocal(ro.List[3], wrong.Private, genesis.Hash))
+	require.Error(t, c.DeleteChainLocal(ro.List[3], kp.Private, SkipBlockID{1}))
+	require.NoError(t, c.DeleteChainLocal(ro.List[3], kp.Private, genesis.Hash))
+
+	summary, err := last.db.summary()
+	require.NoError(t, err)
+	require.Equal(t, 0, len(summary))
+	require.False(t, last.BlockIsFriendly(genesis))
+	require.False(t, last.BlockIsFriendly(other))
+	require.True(t, service.BlockIsFriendly(genesis))
+
+	// The other nodes still sign new blocks, but the deleted chain is not
+	// stored anymore on this node.
+	require.NoError(t, store())
+	require.Nil(t, last.db.GetByID(genesis.Hash))
+	latest, err := service.db.GetLatestByID(genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 2, latest.Index)
+
+	// Nor is it copied back when reconciling with another node.
+	_, err = c.ReconcileDB(ro.List[3], kp.Private, ro.List[0])
+	require.NoError(t, err)
+	require.Nil(t, last.db.GetByID(genesis.Hash))
+}
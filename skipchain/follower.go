@@ -0,0 +1,381 @@
+package skipchain
+
+import (
+	"sync"
+	"time"
+
+	"go.dedis.ch/kyber/v3/util/random"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the follower of many skipchains. Instead of opening one
+stream per skipchain, the follower opens one stream per conode, carrying the
+new blocks of all skipchains it follows on this conode. Following or
+unfollowing a skipchain changes the skipchains of the open stream. Every new
+block is verified with the roster of the previous block and given to the
+handler of its skipchain. The blocks of a skipchain are streamed from the
+leader of its latest block: if a stream fails, or if the roster of a
+skipchain doesn't hold the conode anymore, the skipchain moves to the stream
+of its current leader. Blocks that are missed in between are fetched with
+GetUpdateChain.
+*/
+
+// followerRetry is the time the follower waits before reopening a stream
+// that failed. Streams also fail if no block is sent for a while.
+var followerRetry = time.Second
+
+// Follower follows many skipchains and calls a handler for every new block.
+// The handlers of one skipchain are called one after the other, in the order
+// of the blocks.
+type Follower struct {
+	sync.Mutex
+	client  *Client
+	chains  map[string]*followedChain
+	streams map[network.ServerIdentityID]*followerStream
+	closed  bool
+}
+
+// followedChain holds the latest block of a skipchain given to the handler.
+// Its lock is held while giving blocks to the handler. The node streaming
+// its blocks is protected by the lock of the Follower.
+type followedChain struct {
+	sync.Mutex
+	scID    SkipBlockID
+	node    *network.ServerIdentity
+	latest  *SkipBlock
+	handler func(*SkipBlock)
+	stopped bool
+}
+
+// followerStream is the stream of one conode. Every stream has its own
+// client, so that it can be closed on its own, and a random ID, so that its
+// skipchains can be changed while it is open.
+type followerStream struct {
+	client *Client
+	id     []byte
+}
+
+// NewFollower returns a follower without any skipchain.
+func NewFollower() *Follower {
+	return &Follower{
+		client:  NewClient(),
+		chains:  make(map[string]*followedChain),
+		streams: make(map[network.ServerIdentityID]*followerStream),
+	}
+}
+
+// Follow calls handler for every block of the skipchain after latest. The
+// blocks are streamed from the leader of the roster of latest.
+func (f *Follower) Follow(latest *SkipBlock, handler func(*SkipBlock)) error {
+	if latest == nil || latest.Roster == nil || len(latest.Roster.List) == 0 {
+		return xerrors.New("need the latest block with its roster")
+	}
+	fc := &followedChain{
+		scID:    latest.SkipChainID(),
+		node:    latest.Roster.List[0],
+		latest:  latest,
+		handler: handler,
+	}
+	key := string(fc.scID)
+	f.Lock()
+	if f.closed {
+		f.Unlock()
+		return xerrors.New("follower is closed")
+	}
+	if _, ok := f.chains[key]; ok {
+		f.Unlock()
+		return xerrors.Errorf("already following skipchain %x", fc.scID)
+	}
+	f.chains[key] = fc
+	if err := f.subscribeLocked(fc.node, []SkipBlockID{fc.scID}); err != nil {
+		delete(f.chains, key)
+		f.Unlock()
+		return err
+	}
+	f.Unlock()
+
+	// Get the blocks added before the stream has been opened.
+	if err := fc.catchUp(f.client); err != nil {
+		log.Warnf("couldn't catch up with skipchain %x: %v", fc.scID, err)
+	}
+	return nil
+}
+
+// Unfollow stops calling the handler of the skipchain. It must not be
+// called from a handler.
+func (f *Follower) Unfollow(scID SkipBlockID) error {
+	f.Lock()
+	fc, ok := f.chains[string(scID)]
+	if !ok {
+		f.Unlock()
+		return xerrors.Errorf("not following skipchain %x", scID)
+	}
+	delete(f.chains, string(scID))
+	f.unsubscribeLocked(fc.node, scID)
+	f.Unlock()
+
+	fc.Lock()
+	fc.stopped = true
+	fc.Unlock()
+	return nil
+}
+
+// Latest returns the latest block of the skipchain given to the handler.
+func (f *Follower) Latest(scID SkipBlockID) *SkipBlock {
+	f.Lock()
+	fc, ok := f.chains[string(scID)]
+	f.Unlock()
+	if !ok {
+		return nil
+	}
+	fc.Lock()
+	defer fc.Unlock()
+	return fc.latest
+}
+
+// Close stops following all skipchains and closes the streams.
+func (f *Follower) Close() error {
+	f.Lock()
+	f.closed = true
+	streams := f.streams
+	f.streams = make(map[network.ServerIdentityID]*followerStream)
+	f.Unlock()
+	for _, fs := range streams {
+		if err := fs.client.Close(); err != nil {
+			log.Lvl2("couldn't close stream:", err)
+		}
+	}
+	return f.client.Close()
+}
+
+// follows returns true if the skipchain is followed.
+func (f *Follower) follows(scID SkipBlockID) bool {
+	f.Lock()
+	defer f.Unlock()
+	_, ok := f.chains[string(scID)]
+	return ok
+}
+
+// subscribeLocked adds the skipchains to the stream of the node, or opens
+// a new stream if there is none or if it cannot be changed. The nodes of
+// the skipchains must already be set to node.
+func (f *Follower) subscribeLocked(node *network.ServerIdentity, scIDs []SkipBlockID) error {
+	if f.closed {
+		return xerrors.New("follower is closed")
+	}
+	if fs := f.streams[node.ID]; fs != nil {
+		err := f.client.updateBlockStream(node, fs.id, scIDs, nil)
+		if err == nil {
+			return nil
+		}
+		log.Lvlf2("couldn't update stream of %s: %v", node, err)
+	}
+	return f.openStreamLocked(node)
+}
+
+// unsubscribeLocked removes the skipchain from the stream of the node, and
+// closes the stream if no other skipchain is followed on the node.
+func (f *Follower) unsubscribeLocked(node *network.ServerIdentity, scID SkipBlockID) {
+	fs := f.streams[node.ID]
+	if fs == nil {
+		return
+	}
+	for _, fc := range f.chains {
+		if fc.node.Equal(node) {
+			// The blocks of the skipchain are ignored if the stream
+			// cannot be changed.
+			err := f.client.updateBlockStream(node, fs.id, nil, []SkipBlockID{scID})
+			if err != nil {
+				log.Lvlf2("couldn't update stream of %s: %v", node, err)
+			}
+			return
+		}
+	}
+	delete(f.streams, node.ID)
+	go func() {
+		if err := fs.client.Close(); err != nil {
+			log.Lvl2("couldn't close stream:", err)
+		}
+	}()
+}
+
+// openStreamLocked opens a new stream to the node with all the skipchains
+// followed on it, and closes the previous one once the new one is open.
+func (f *Follower) openStreamLocked(node *network.ServerIdentity) error {
+	var scIDs []SkipBlockID
+	for _, fc := range f.chains {
+		if fc.node.Equal(node) {
+			scIDs = append(scIDs, fc.scID)
+		}
+	}
+	fs := &followerStream{client: NewClient(), id: make([]byte, 32)}
+	random.Bytes(fs.id, random.New())
+	conn, err := fs.client.openBlockStream(node, scIDs, fs.id)
+	if err != nil {
+		return err
+	}
+	old := f.streams[node.ID]
+	f.streams[node.ID] = fs
+	go f.readStream(fs, node, conn)
+	if old != nil {
+		go func() {
+			if err := old.client.Close(); err != nil {
+				log.Lvl2("couldn't close stream:", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// readStream gives the blocks of the stream to their skipchains. Once the
+// stream fails, its skipchains move to the stream of their current leader,
+// unless the stream has been replaced or closed.
+func (f *Follower) readStream(fs *followerStream, node *network.ServerIdentity,
+	conn onet.StreamingConn) {
+	readBlockStream(conn, f.follows, func(reply *StreamBlocksReply, err error) {
+		if err != nil {
+			log.Lvlf2("stream of %s: %v", node, err)
+			return
+		}
+		f.Lock()
+		fc := f.chains[string(reply.Block.SkipChainID())]
+		f.Unlock()
+		if fc != nil {
+			fc.newBlock(f.client, reply)
+			f.checkLeader(fc, node)
+		}
+	})
+
+	f.Lock()
+	if f.closed || f.streams[node.ID] != fs {
+		f.Unlock()
+		return
+	}
+	delete(f.streams, node.ID)
+	var pending []*followedChain
+	for _, fc := range f.chains {
+		if fc.node.Equal(node) {
+			pending = append(pending, fc)
+		}
+	}
+	f.Unlock()
+
+	for len(pending) > 0 {
+		time.Sleep(followerRetry)
+		var failed, moved []*followedChain
+		for _, fc := range pending {
+			leader := fc.leader()
+			f.Lock()
+			if f.closed {
+				f.Unlock()
+				return
+			}
+			if f.chains[string(fc.scID)] != fc {
+				f.Unlock()
+				continue
+			}
+			fc.node = leader
+			err := f.subscribeLocked(leader, []SkipBlockID{fc.scID})
+			f.Unlock()
+			if err != nil {
+				log.Warnf("couldn't reopen stream of %s: %v", leader, err)
+				failed = append(failed, fc)
+			} else {
+				moved = append(moved, fc)
+			}
+		}
+		for _, fc := range moved {
+			if err := fc.catchUp(f.client); err != nil {
+				log.Warnf("couldn't catch up with skipchain %x: %v", fc.scID, err)
+			}
+		}
+		pending = failed
+	}
+}
+
+// checkLeader moves the skipchain to the stream of its current leader if
+// the node streaming its blocks left its roster.
+func (f *Follower) checkLeader(fc *followedChain, node *network.ServerIdentity) {
+	fc.Lock()
+	i, _ := fc.latest.Roster.Search(node.ID)
+	fc.Unlock()
+	if i >= 0 {
+		return
+	}
+	leader := fc.leader()
+
+	f.Lock()
+	defer f.Unlock()
+	if f.closed || f.chains[string(fc.scID)] != fc || !fc.node.Equal(node) {
+		return
+	}
+	fc.node = leader
+	if err := f.subscribeLocked(leader, []SkipBlockID{fc.scID}); err != nil {
+		log.Warnf("couldn't move skipchain %x to %s: %v", fc.scID, leader, err)
+		fc.node = node
+		return
+	}
+	f.unsubscribeLocked(node, fc.scID)
+}
+
+// leader returns the leader of the latest block of the skipchain.
+func (fc *followedChain) leader() *network.ServerIdentity {
+	fc.Lock()
+	defer fc.Unlock()
+	return fc.latest.Roster.List[0]
+}
+
+// newBlock gives the block to the handler if it directly follows the latest
+// block and its forward-link is signed by the roster of the latest block.
+// Else the missing blocks are fetched.
+func (fc *followedChain) newBlock(c *Client, reply *StreamBlocksReply) {
+	fc.Lock()
+	defer fc.Unlock()
+	sb := reply.Block
+	if fc.stopped || sb.Index <= fc.latest.Index {
+		return
+	}
+	if sb.Index == fc.latest.Index+1 && reply.Link != nil &&
+		reply.Link.From.Equal(fc.latest.Hash) &&
+		reply.Link.VerifyWithThreshold(suite,
+			fc.latest.Roster.ServicePublics(ServiceName),
+			fc.latest.SignatureScheme, fc.latest.SignatureThreshold) == nil {
+		fc.deliver(sb)
+		return
+	}
+	if err := fc.catchUpLocked(c); err != nil {
+		log.Warnf("couldn't catch up with skipchain %x: %v", fc.scID, err)
+	}
+}
+
+// catchUp gives all blocks after the latest one to the handler.
+func (fc *followedChain) catchUp(c *Client) error {
+	fc.Lock()
+	defer fc.Unlock()
+	return fc.catchUpLocked(c)
+}
+
+func (fc *followedChain) catchUpLocked(c *Client) error {
+	if fc.stopped {
+		return nil
+	}
+	blocks, err := c.GetUpdateChainLevel(fc.latest.Roster, fc.latest.Hash, 1, -1)
+	if err != nil {
+		return xerrors.Errorf("couldn't get update: %v", err)
+	}
+	for _, sb := range blocks {
+		if sb.Index > fc.latest.Index {
+			fc.deliver(sb)
+		}
+	}
+	return nil
+}
+
+func (fc *followedChain) deliver(sb *SkipBlock) {
+	fc.latest = sb
+	fc.handler(sb)
+}
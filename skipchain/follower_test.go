@@ -0,0 +1,136 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestFollower(t *testing.T) {
+	defer func(d time.Duration) { followerRetry = d }(followerRetry)
+	followerRetry = 10 * time.Millisecond
+
+	l := onet.NewTCPTest(cothority.Suite)
+	servers, ro, _ := l.GenTree(3, true)
+	defer l.CloseAll()
+	leader := l.GetServices(servers, skipchainSID)[0].(*Service)
+	c := newTestClient(l)
+
+	gen1, err := c.CreateGenesis(ro, 2, 3, VerificationStandard, nil)
+	require.NoError(t, err)
+	gen2, err := c.CreateGenesis(ro, 2, 3, VerificationStandard, nil)
+	require.NoError(t, err)
+	// This block is added before following and must be caught up.
+	_, err = c.StoreSkipBlock(gen1, nil, []byte("before"))
+	require.NoError(t, err)
+
+	blocks1 := make(chan *SkipBlock, 10)
+	blocks2 := make(chan *SkipBlock, 10)
+	f := NewFollower()
+	defer f.Close()
+	require.NoError(t, f.Follow(gen1, func(sb *SkipBlock) { blocks1 <- sb }))
+	stream := func() *followerStream {
+		f.Lock()
+		defer f.Unlock()
+		return f.streams[ro.List[0].ID]
+	}
+	fs := stream()
+	require.NotNil(t, fs)
+	require.NoError(t, f.Follow(gen2, func(sb *SkipBlock) { blocks2 <- sb }))
+	require.Error(t, f.Follow(gen2, func(sb *SkipBlock) {}))
+	// The stream is changed, not reopened.
+	require.True(t, fs == stream())
+
+	expect := func(blocks chan *SkipBlock, index int) {
+		select {
+		case sb := <-blocks:
+			require.Equal(t, index, sb.Index)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("didn't get block %d", index)
+		}
+	}
+	expect(blocks1, 1)
+
+	// Both chains use the same stream.
+	leader.streams.Lock()
+	require.Equal(t, 1, len(leader.streams.chains))
+	leader.streams.Unlock()
+
+	_, err = c.StoreSkipBlock(gen1, nil, []byte("data"))
+	require.NoError(t, err)
+	_, err = c.StoreSkipBlock(gen2, nil, []byte("data"))
+	require.NoError(t, err)
+	expect(blocks1, 2)
+	expect(blocks2, 1)
+
+	// Blocks added while the stream fails are caught up.
+	require.NoError(t, fs.client.Close())
+	_, err = c.StoreSkipBlock(gen2, nil, []byte("data"))
+	require.NoError(t, err)
+	expect(blocks2, 2)
+	require.Equal(t, 2, f.Latest(gen2.Hash).Index)
+
+	require.Eventually(t, func() bool {
+		fs = stream()
+		return fs != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, f.Unfollow(gen1.Hash))
+	require.Error(t, f.Unfollow(gen1.Hash))
+	require.True(t, fs == stream())
+	_, err = c.StoreSkipBlock(gen1, nil, []byte("data"))
+	require.NoError(t, err)
+	_, err = c.StoreSkipBlock(gen2, nil, []byte("data"))
+	require.NoError(t, err)
+	expect(blocks2, 3)
+	require.Equal(t, 0, len(blocks1))
+}
+
+func TestFollower_RosterChange(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	servers, all, _ := l.GenTree(4, true)
+	defer l.CloseAll()
+	services := l.GetServices(servers, skipchainSID)
+	c := newTestClient(l)
+
+	ro := onet.NewRoster(all.List[:3])
+	genesis, err := c.CreateGenesis(ro, 2, 3, VerificationRosterChange, nil)
+	require.NoError(t, err)
+
+	blocks := make(chan *SkipBlock, 10)
+	f := NewFollower()
+	defer f.Close()
+	require.NoError(t, f.Follow(genesis, func(sb *SkipBlock) { blocks <- sb }))
+	expect := func(index int) {
+		select {
+		case sb := <-blocks:
+			require.Equal(t, index, sb.Index)
+		case <-time.After(10 * time.Second):
+			t.Fatalf("didn't get block %d", index)
+		}
+	}
+
+	// The leader leaves the roster, and the follower moves to the new one.
+	newRoster := onet.NewRoster(all.List[1:])
+	reply, err := c.ChangeRoster(genesis.Hash, newRoster, all.List[1].GetPrivate())
+	require.NoError(t, err)
+	expect(1)
+	require.Eventually(t, func() bool {
+		f.Lock()
+		defer f.Unlock()
+		_, old := f.streams[all.List[0].ID]
+		_, current := f.streams[all.List[1].ID]
+		return !old && current
+	}, 5*time.Second, 10*time.Millisecond)
+
+	sb := NewSkipBlock()
+	sb.Roster = reply.Latest.Roster
+	_, err = services[1].(*Service).StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+	expect(2)
+}
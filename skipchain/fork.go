@@ -0,0 +1,171 @@
+package skipchain
+
+import (
+	"time"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"go.dedis.ch/protobuf"
+	bbolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the detection of forks. A fork happens if the roster of a
+block signs two forward-links at the same height to different blocks, so
+that two blocks with the same index are valid. When the database is asked
+to store a known block with such a conflicting forward-link, it refuses the
+blocks and records both forward-links as evidence. From then on, no block of
+the chain is stored until the evidence is removed by an operator.
+*/
+
+// ErrorForkedChain is returned when storing blocks of a chain for which a
+// fork has been detected.
+var ErrorForkedChain = xerrors.New("fork detected on the skipchain")
+
+// ForkEvidence holds two valid forward-links of the same block and height,
+// pointing to different blocks.
+type ForkEvidence struct {
+	SkipChainID SkipBlockID
+	// Index of the block both forward-links start from.
+	Index       int
+	Height      int
+	Known       *ForwardLink
+	Conflicting *ForwardLink
+	// Timestamp of the detection in nanoseconds since the epoch.
+	Timestamp int64
+}
+
+// forkBucket returns the name of the bucket of the fork evidences.
+func (db *SkipBlockDB) forkBucket() []byte {
+	return append(append([]byte{}, db.bucketName...), []byte("_forks")...)
+}
+
+// findFork returns the evidence of a fork if sb holds a valid forward-link
+// at the same height as the known block, but to another block.
+func findFork(known, sb *SkipBlock) *ForkEvidence {
+	publics := known.Roster.ServicePublics(ServiceName)
+	knownFLs := known.forwardLinks()
+	for h, fl := range sb.forwardLinks() {
+		if h >= len(knownFLs) {
+			break
+		}
+		kfl := knownFLs[h]
+		if fl.IsEmpty() || kfl.IsEmpty() || fl.To.Equal(kfl.To) ||
+			!fl.From.Equal(known.Hash) {
+			continue
+		}
+		if fl.VerifyWithThreshold(suite, publics, known.SignatureScheme,
+			known.SignatureThreshold) != nil {
+			continue
+		}
+		return &ForkEvidence{
+			SkipChainID: known.SkipChainID(),
+			Index:       known.Index,
+			Height:      h,
+			Known:       kfl.Copy(),
+			Conflicting: fl.Copy(),
+			Timestamp:   time.Now().UnixNano(),
+		}
+	}
+	return nil
+}
+
+// hasForkTx returns true if there is an evidence of a fork on the chain.
+func (db *SkipBlockDB) hasForkTx(tx *bbolt.Tx, scID SkipBlockID) bool {
+	b := tx.Bucket(db.forkBucket())
+	return b != nil && b.Get(scID) != nil
+}
+
+// storeFork records the evidence, unless the chain already has one.
+func (db *SkipBlockDB) storeFork(fe *ForkEvidence) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(db.forkBucket())
+		if err != nil {
+			return err
+		}
+		if b.Get(fe.SkipChainID) != nil {
+			return nil
+		}
+		buf, err := protobuf.Encode(fe)
+		if err != nil {
+			return err
+		}
+		return b.Put(fe.SkipChainID, buf)
+	})
+}
+
+// GetForks returns the evidence of the fork of the chain, or of all chains
+// if scID is nil.
+func (db *SkipBlockDB) GetForks(scID SkipBlockID) ([]ForkEvidence, error) {
+	var forks []ForkEvidence
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(db.forkBucket())
+		if b == nil {
+			return nil
+		}
+		decode := func(buf []byte) error {
+			var fe ForkEvidence
+			err := protobuf.DecodeWithConstructors(buf, &fe,
+				network.DefaultConstructors(cothority.Suite))
+			if err != nil {
+				return err
+			}
+			forks = append(forks, fe)
+			return nil
+		}
+		if scID != nil {
+			if buf := b.Get(scID); buf != nil {
+				return decode(buf)
+			}
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			return decode(v)
+		})
+	})
+	return forks, err
+}
+
+// ResolveFork removes the evidence of the fork of the chain, so that its
+// blocks are stored again.
+func (db *SkipBlockDB) ResolveFork(scID SkipBlockID) error {
+	return db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(db.forkBucket())
+		if b == nil || b.Get(scID) == nil {
+			return xerrors.Errorf("no fork on skipchain %x", scID)
+		}
+		return b.Delete(scID)
+	})
+}
+
+// GetForkEvidence returns the evidences of forks detected by this conode.
+func (s *Service) GetForkEvidence(req *GetForkEvidence) (*GetForkEvidenceReply, error) {
+	forks, err := s.db.GetForks(req.SkipChainID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get forks: %v", err)
+	}
+	return &GetForkEvidenceReply{Forks: forks}, nil
+}
+
+// ResolveFork removes the evidence of the fork of a chain, once the operator
+// took care of it, for example by deleting the chain with DeleteChainLocal.
+// The request must be signed by a linked client or by this conode.
+func (s *Service) ResolveFork(req *ResolveFork) (*EmptyReply, error) {
+	if !s.verifyAdminSigs(resolveForkMsg(req.SkipChainID), req.Signature) {
+		return nil, xerrors.New("wrong signature of unknown signer")
+	}
+	if err := s.db.ResolveFork(req.SkipChainID); err != nil {
+		return nil, err
+	}
+	log.Lvlf1("%s: fork on skipchain %x resolved", s.ServerIdentity(),
+		req.SkipChainID)
+	return &EmptyReply{}, nil
+}
+
+// resolveForkMsg returns the message to be signed by a linked client to
+// resolve the fork of a chain.
+func resolveForkMsg(scID SkipBlockID) []byte {
+	return append([]byte("resolvefork:"), scID...)
+}
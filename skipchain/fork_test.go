@@ -0,0 +1,95 @@
+package skipchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestService_ForkDetection(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer l.CloseAll()
+	servers, ro, _ := l.MakeSRS(cothority.Suite, 2, skipchainSID)
+	s := l.GetServices(servers, skipchainSID)[0].(*Service)
+	c := newTestClient(l)
+
+	root := NewSkipBlock()
+	root.Roster = ro
+	root.Height = 1
+	root.BaseHeight = 2
+	root.updateHash()
+	newBlock := func(data string) *SkipBlock {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Index = 1
+		sb.Height = 1
+		sb.BaseHeight = 2
+		sb.GenesisID = root.Hash
+		sb.BackLinkIDs = []SkipBlockID{root.Hash}
+		sb.Data = []byte(data)
+		sb.updateHash()
+		return sb
+	}
+	sb1 := newBlock("one")
+	root.ForwardLink = []*ForwardLink{{From: root.Hash, To: sb1.Hash}}
+	require.NoError(t, root.ForwardLink[0].sign(ro))
+	_, err := s.db.StoreBlocks([]*SkipBlock{root, sb1})
+	require.NoError(t, err)
+
+	// A forward-link to another block with a wrong signature is no evidence.
+	sb2 := newBlock("two")
+	fork := root.Copy()
+	fork.ForwardLink = []*ForwardLink{{From: root.Hash, To: sb2.Hash}}
+	require.NoError(t, fork.ForwardLink[0].signBy(ro, 1))
+	_, err = s.db.StoreBlocks([]*SkipBlock{fork})
+	require.NoError(t, err)
+	forks, err := c.GetForkEvidence(ro.List[0], nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(forks))
+
+	// A valid one is a fork.
+	require.NoError(t, fork.ForwardLink[0].sign(ro))
+	_, err = s.db.StoreBlocks([]*SkipBlock{fork, sb2})
+	require.Equal(t, ErrorForkedChain, err)
+	require.Nil(t, s.db.GetByID(sb2.Hash))
+
+	forks, err = c.GetForkEvidence(ro.List[0], root.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(forks))
+	require.True(t, forks[0].SkipChainID.Equal(root.Hash))
+	require.Equal(t, 0, forks[0].Index)
+	require.Equal(t, 0, forks[0].Height)
+	require.True(t, forks[0].Known.To.Equal(sb1.Hash))
+	require.True(t, forks[0].Conflicting.To.Equal(sb2.Hash))
+	forks, err = c.GetForkEvidence(ro.List[1], nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(forks))
+
+	// No more blocks of the chain are stored.
+	_, err = s.db.StoreBlocks([]*SkipBlock{root})
+	require.Equal(t, ErrorForkedChain, err)
+
+	// Without linked clients, only the conode itself can resolve the fork.
+	other := key.NewKeyPair(cothority.Suite)
+	require.Error(t, c.ResolveFork(ro.List[0], other.Private, root.Hash))
+	_, err = s.ResolveFork(&ResolveFork{SkipChainID: root.Hash})
+	require.Error(t, err)
+	forks, err = c.GetForkEvidence(ro.List[0], root.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(forks))
+
+	kp := key.NewKeyPair(cothority.Suite)
+	s.Storage.Clients = []kyber.Point{kp.Public}
+	require.Error(t, c.ResolveFork(ro.List[0], other.Private, root.Hash))
+	require.NoError(t, c.ResolveFork(ro.List[0], kp.Private, root.Hash))
+	require.Error(t, c.ResolveFork(ro.List[0], kp.Private, root.Hash))
+	forks, err = c.GetForkEvidence(ro.List[0], nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(forks))
+	_, err = s.db.StoreBlocks([]*SkipBlock{root})
+	require.NoError(t, err)
+}
@@ -0,0 +1,90 @@
+package skipchain
+
+import (
+	"go.dedis.ch/onet/v3"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the proofs for light clients. Instead of fetching all blocks
+from the genesis block to a target block, a client holding the genesis block
+gets the shortest list of forward-links leading to the target, and verifies
+only their signatures. With the default base height, this needs a number of
+signatures logarithmic in the index of the target block.
+*/
+
+// GetProof returns the forward-links from the genesis block to the target
+// block, or to the latest block if no target is given.
+func (s *Service) GetProof(req *GetProof) (*GetProofReply, error) {
+	target := req.Target
+	if len(target) == 0 {
+		latest, err := s.db.GetLatestByID(req.Genesis)
+		if err != nil {
+			return nil, xerrors.Errorf("couldn't get latest block: %v", err)
+		}
+		target = latest.Hash
+	}
+	sb := s.db.GetByID(target)
+	if sb == nil {
+		return nil, xerrors.Errorf("unknown block %x", target)
+	}
+	if !sb.SkipChainID().Equal(req.Genesis) {
+		return nil, xerrors.New("block is not part of the skipchain")
+	}
+	pr, err := s.db.GetProofFromIndex(req.Genesis, sb.Index)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get path to block: %v", err)
+	}
+	links, err := pr.GetForwardLinks()
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get forward-links: %v", err)
+	}
+	return &GetProofReply{Links: links, Block: sb}, nil
+}
+
+// VerifyProof checks that the forward-links lead from the genesis block to
+// the target block. The first link only points to the genesis block, as
+// returned by Proof.GetForwardLinks, and its signature is not checked: the
+// genesis block has to be trusted by the caller, and its roster is used to
+// verify the next link.
+func VerifyProof(genesis *SkipBlock, links []*ForwardLink, target *SkipBlock) error {
+	if genesis == nil || target == nil {
+		return xerrors.New("missing block")
+	}
+	if genesis.Index != 0 || !genesis.CalculateHash().Equal(genesis.Hash) {
+		return xerrors.New("invalid genesis block")
+	}
+	if len(links) == 0 {
+		return xerrors.New("missing forward-links")
+	}
+	if !links[0].To.Equal(genesis.Hash) {
+		return xerrors.New("first link doesn't point to the genesis block")
+	}
+
+	id := genesis.Hash
+	publics := genesis.Roster.ServicePublics(ServiceName)
+	for i, fl := range links[1:] {
+		if !fl.From.Equal(id) {
+			return xerrors.Errorf("link %d doesn't follow the previous one", i+1)
+		}
+		err := fl.VerifyWithThreshold(suite, publics, genesis.SignatureScheme,
+			genesis.SignatureThreshold)
+		if err != nil {
+			return xerrors.Errorf("invalid signature of link %d: %v", i+1, err)
+		}
+		id = fl.To
+		if fl.NewRoster != nil {
+			// Only the ID of the roster is signed.
+			ro := onet.NewRoster(fl.NewRoster.List)
+			if ro == nil || !ro.ID.Equal(fl.NewRoster.ID) {
+				return xerrors.Errorf("roster of link %d doesn't match its ID", i+1)
+			}
+			publics = fl.NewRoster.ServicePublics(ServiceName)
+		}
+	}
+
+	if !target.CalculateHash().Equal(id) {
+		return xerrors.New("last link doesn't point to the target block")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package skipchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_GetProof(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	_, all, _ := l.GenTree(4, true)
+	defer l.CloseAll()
+	c := newTestClient(l)
+
+	ro := onet.NewRoster(all.List[:3])
+	nbrBlocks := 10
+	blocks := make([]*SkipBlock, nbrBlocks)
+	var err error
+	blocks[0], err = c.CreateGenesis(ro, 2, 4, VerificationNone, nil)
+	require.NoError(t, err)
+	for i := 1; i < nbrBlocks; i++ {
+		// The roster changes in the middle of the chain.
+		if i == 5 {
+			ro = all
+		}
+		reply, err := c.StoreSkipBlock(blocks[i-1], ro, nil)
+		require.NoError(t, err)
+		blocks[i] = reply.Latest
+	}
+
+	for i, sb := range blocks {
+		reply, err := c.GetProof(ro, blocks[0], sb.Hash)
+		require.NoError(t, err)
+		require.True(t, reply.Block.Hash.Equal(sb.Hash))
+		// The genesis link and at most one link per level.
+		require.True(t, len(reply.Links) <= 5, "block %d", i)
+	}
+	reply, err := c.GetProof(ro, blocks[0], nil)
+	require.NoError(t, err)
+	require.Equal(t, nbrBlocks-1, reply.Block.Index)
+	require.Equal(t, 3, len(reply.Links))
+
+	require.Error(t, VerifyProof(blocks[0], reply.Links, blocks[nbrBlocks-2]))
+	require.Error(t, VerifyProof(blocks[1], reply.Links, reply.Block))
+	require.Error(t, VerifyProof(blocks[0], reply.Links[:2], reply.Block))
+	require.Error(t, VerifyProof(blocks[0], nil, reply.Block))
+
+	copyLinks := func() []*ForwardLink {
+		links := make([]*ForwardLink, len(reply.Links))
+		for i, fl := range reply.Links {
+			links[i] = fl.Copy()
+		}
+		return links
+	}
+	// The link to block 8 holds the new roster, but only its ID is signed.
+	links := copyLinks()
+	require.NotNil(t, links[1].NewRoster)
+	links[1].NewRoster.List = all.List[:3]
+	require.Error(t, VerifyProof(blocks[0], links, reply.Block))
+
+	links = copyLinks()
+	links[2].Signature.Sig[0] ^= 1
+	require.Error(t, VerifyProof(blocks[0], links, reply.Block))
+
+	_, err = c.GetProof(ro, blocks[0], blocks[0].Roster.ID[:])
+	require.Error(t, err)
+}
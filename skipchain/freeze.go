@@ -0,0 +1,194 @@
+package skipchain
+
+import (
+	"encoding/binary"
+	"sync"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the freezing of chains. The genesis block of a chain using
+the VerifyFreezable verifier holds a FreezeAdmins with the keys of the
+administrators. A control block with a FreezeControl signed by one of them
+freezes or unfreezes the chain. As long as the chain is frozen, only control
+blocks and blocks changing the roster are accepted, so that the chain can
+still be migrated. A roster change on a frozen chain must also be signed by
+one of the administrators.
+*/
+
+func init() {
+	network.RegisterMessages(&FreezeAdmins{}, &FreezeControl{})
+}
+
+// FreezeAdmins is stored in the data of the genesis block and holds the
+// keys allowed to freeze and unfreeze the chain.
+type FreezeAdmins struct {
+	Keys []kyber.Point
+}
+
+// FreezeControl is stored in the data of a control block. The signature is
+// done by one of the administrators on the message returned by freezeMsg.
+type FreezeControl struct {
+	Frozen    bool
+	Signature []byte
+}
+
+// freezeMsg returns the message an administrator signs to freeze or
+// unfreeze the chain with the block at the given index. The index makes
+// sure a control block cannot be replayed.
+func freezeMsg(scID SkipBlockID, index int, frozen bool) []byte {
+	msg := []byte("unfreeze:")
+	if frozen {
+		msg = []byte("freeze:")
+	}
+	msg = append(msg, scID...)
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(index))
+	return append(msg, buf...)
+}
+
+// newFreezeControl returns the control block data to freeze or unfreeze the
+// chain with the block following latest.
+func newFreezeControl(latest *SkipBlock, frozen bool, priv kyber.Scalar) (*FreezeControl, error) {
+	sig, err := schnorr.Sign(cothority.Suite, priv,
+		freezeMsg(latest.SkipChainID(), latest.Index+1, frozen))
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't sign message: %v", err)
+	}
+	return &FreezeControl{Frozen: frozen, Signature: sig}, nil
+}
+
+// decodeBlockData returns the message stored in the data of the block, or
+// nil if it holds none of the registered messages.
+func decodeBlockData(sb *SkipBlock) network.Message {
+	if len(sb.Data) == 0 {
+		return nil
+	}
+	_, msg, err := network.Unmarshal(sb.Data, cothority.Suite)
+	if err != nil {
+		return nil
+	}
+	return msg
+}
+
+// freezeState is the state of a chain after the block with the given index.
+type freezeState struct {
+	index  int
+	frozen bool
+}
+
+// freezeStates caches the state of the chains, so that the blocks are only
+// read once.
+type freezeStates struct {
+	sync.Mutex
+	chains map[string]freezeState
+}
+
+// isFrozen returns whether the chain is frozen after the block sb. It goes
+// back to the last control block, or to the last block of the cache.
+func (s *Service) isFrozen(sb *SkipBlock) (bool, error) {
+	scID := sb.SkipChainID()
+	s.freezes.Lock()
+	cached, ok := s.freezes.chains[string(scID)]
+	s.freezes.Unlock()
+
+	frozen := false
+	for pointer := sb; ; {
+		if ok && pointer.Index == cached.index {
+			frozen = cached.frozen
+			break
+		}
+		if fc, isControl := decodeBlockData(pointer).(*FreezeControl); isControl {
+			frozen = fc.Frozen
+			break
+		}
+		if pointer.Index == 0 || len(pointer.BackLinkIDs) == 0 {
+			break
+		}
+		pointer = s.db.GetByID(pointer.BackLinkIDs[0])
+		if pointer == nil {
+			return false, xerrors.New("missing block before the latest")
+		}
+	}
+
+	s.freezes.Lock()
+	defer s.freezes.Unlock()
+	if s.freezes.chains == nil {
+		s.freezes.chains = make(map[string]freezeState)
+	}
+	s.freezes.chains[string(scID)] = freezeState{sb.Index, frozen}
+	return frozen, nil
+}
+
+// verifyFuncFreezable refuses control blocks not signed by an administrator,
+// and blocks other than control blocks or roster changes signed by an
+// administrator while the chain is frozen.
+func (s *Service) verifyFuncFreezable(newID []byte, newSB *SkipBlock) bool {
+	if len(newSB.BackLinkIDs) == 0 {
+		return false
+	}
+	prev := s.db.GetByID(newSB.BackLinkIDs[0])
+	if prev == nil {
+		return false
+	}
+
+	data := decodeBlockData(newSB)
+	if fc, ok := data.(*FreezeControl); ok {
+		msg := freezeMsg(newSB.SkipChainID(), newSB.Index, fc.Frozen)
+		if !s.signedByFreezeAdmin(newSB.SkipChainID(), msg, fc.Signature) {
+			log.Lvl2("control block is not signed by an administrator")
+			return false
+		}
+		return true
+	}
+
+	frozen, err := s.isFrozen(prev)
+	if err != nil {
+		log.Lvl2(err)
+		return false
+	}
+	if !frozen {
+		return true
+	}
+	if rc, ok := data.(*RosterChange); ok {
+		if err := rc.verify(prev.Roster, newSB.Roster); err != nil {
+			log.Lvl2(err)
+			return false
+		}
+		msg := frozenRosterMsg(newSB.SkipChainID(), newSB.Index, newSB.Roster)
+		if !s.signedByFreezeAdmin(newSB.SkipChainID(), msg, rc.Signature) {
+			log.Lvl2("roster change is not signed by an administrator")
+			return false
+		}
+		return true
+	}
+	log.Lvlf2("%s: chain %x is frozen", s.ServerIdentity(),
+		newSB.SkipChainID())
+	return false
+}
+
+// signedByFreezeAdmin returns true if sig is the signature of msg by one of
+// the FreezeAdmins of the chain.
+func (s *Service) signedByFreezeAdmin(scID SkipBlockID, msg, sig []byte) bool {
+	genesis := s.db.GetByID(scID)
+	if genesis == nil {
+		return false
+	}
+	admins, ok := decodeBlockData(genesis).(*FreezeAdmins)
+	if !ok {
+		log.Lvl2("chain has no freeze administrators")
+		return false
+	}
+	for _, key := range admins.Keys {
+		if schnorr.Verify(cothority.Suite, key, msg, sig) == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package skipchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_SetFrozen(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	_, all, _ := l.GenTree(4, true)
+	defer l.CloseAll()
+	c := newTestClient(l)
+
+	admin := key.NewKeyPair(cothority.Suite)
+	ro := onet.NewRoster(all.List[:3])
+	genesis, err := c.CreateGenesis(ro, 2, 3,
+		[]VerifierID{VerifyBase, VerifyFreezable, VerifyRosterChange},
+		&FreezeAdmins{Keys: []kyber.Point{admin.Public}})
+	require.NoError(t, err)
+	reply, err := c.StoreSkipBlock(genesis, nil, []byte("data"))
+	require.NoError(t, err)
+	latest := reply.Latest
+
+	other := key.NewKeyPair(cothority.Suite)
+	_, err = c.SetFrozen(latest, true, other.Private)
+	require.Error(t, err)
+	reply, err = c.SetFrozen(latest, true, admin.Private)
+	require.NoError(t, err)
+	latest = reply.Latest
+
+	_, err = c.StoreSkipBlock(genesis, nil, []byte("data"))
+	require.Error(t, err)
+	// A fake roster change doesn't pass.
+	_, err = c.StoreSkipBlock(genesis, nil, &RosterChange{})
+	require.Error(t, err)
+	// Roster changes must be signed by an administrator and match the new
+	// roster.
+	_, err = c.StoreSkipBlock(genesis, all, &RosterChange{
+		Added: all.List[3:]})
+	require.Error(t, err)
+	sig, err := schnorr.Sign(cothority.Suite, admin.Private,
+		frozenRosterMsg(genesis.Hash, latest.Index+1, all))
+	require.NoError(t, err)
+	_, err = c.StoreSkipBlock(genesis, all, &RosterChange{Signature: sig})
+	require.Error(t, err)
+
+	// The roster can still change.
+	conode := all.List[0].GetPrivate()
+	_, err = c.ChangeFrozenRoster(latest, all, conode, other.Private)
+	require.Error(t, err)
+	reply, err = c.ChangeFrozenRoster(latest, all, conode, admin.Private)
+	require.NoError(t, err)
+	latest = reply.Latest
+
+	// A control block cannot be replayed.
+	fc, err := newFreezeControl(latest, false, admin.Private)
+	require.NoError(t, err)
+	reply, err = c.StoreSkipBlock(latest, nil, fc)
+	require.NoError(t, err)
+	_, err = c.StoreSkipBlock(latest, nil, fc)
+	require.Error(t, err)
+
+	reply, err = c.StoreSkipBlock(latest, nil, []byte("data"))
+	require.NoError(t, err)
+	require.Equal(t, 5, reply.Latest.Index)
+
+	// Chains without administrators cannot be frozen.
+	genesis, err = c.CreateGenesis(ro, 2, 3,
+		[]VerifierID{VerifyBase, VerifyFreezable}, nil)
+	require.NoError(t, err)
+	_, err = c.SetFrozen(genesis, true, admin.Private)
+	require.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package skipchain
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the proofs of freshness (PoF). A PoF is a signed head of a
+skipchain: the roster of the head co-signed that the block is the head of the
+chain at a given time. A conode can be asked to renew the PoF of a chain
+periodically, and keeps the latest one, so that web servers can fetch and
+serve a fresh PoF next to their content without starting a new signature for
+every request. Clients verify it with VerifyPoF.
+*/
+
+// PoF is a proof of freshness of the head of a skipchain.
+type PoF = GetSignedHeadReply
+
+// VerifyPoF checks that the proof of freshness holds the head of the
+// skipchain, reached by the forward-links from the genesis block, and that
+// it is not older than maxAge. A maxAge of 0 accepts proofs of any age.
+func VerifyPoF(pof *PoF, scID SkipBlockID, maxAge time.Duration) error {
+	if pof == nil {
+		return xerrors.New("missing proof of freshness")
+	}
+	return pof.Verify(scID, maxAge)
+}
+
+// freshnessSigners holds the chains whose PoF is renewed by this conode.
+type freshnessSigners struct {
+	sync.Mutex
+	chains map[string]*freshnessSigner
+}
+
+// freshnessSigner renews the PoF of one chain.
+type freshnessSigner struct {
+	stop      chan bool
+	latest    *PoF
+	renewals  int
+	lastError string
+}
+
+// GetStatus implements onet.StatusReporter.
+func (fs *freshnessSigners) GetStatus() *onet.Status {
+	fs.Lock()
+	defer fs.Unlock()
+	renewals := 0
+	for _, c := range fs.chains {
+		renewals += c.renewals
+	}
+	return &onet.Status{Field: map[string]string{
+		"Chains":   strconv.Itoa(len(fs.chains)),
+		"Renewals": strconv.Itoa(renewals),
+	}}
+}
+
+// SetFreshness starts renewing the PoF of the skipchain every interval. This
+// conode must be in the roster of the head of the chain. An interval of 0
+// stops the renewal and forgets the latest PoF.
+func (s *Service) SetFreshness(scID SkipBlockID, interval time.Duration) error {
+	if interval < 0 {
+		return xerrors.New("interval must be positive")
+	}
+	s.freshness.Lock()
+	defer s.freshness.Unlock()
+	if s.freshness.chains == nil {
+		s.freshness.chains = make(map[string]*freshnessSigner)
+	}
+	if fs := s.freshness.chains[string(scID)]; fs != nil {
+		close(fs.stop)
+		delete(s.freshness.chains, string(scID))
+	}
+	if interval == 0 {
+		return nil
+	}
+	if _, err := s.db.GetLatestByID(scID); err != nil {
+		return xerrors.Errorf("couldn't find skipchain: %v", err)
+	}
+	if err := s.incrementWorking(); err != nil {
+		return err
+	}
+	fs := &freshnessSigner{stop: make(chan bool)}
+	s.freshness.chains[string(scID)] = fs
+	go func() {
+		defer s.decrementWorking()
+		for {
+			s.renewPoF(scID, fs)
+			select {
+			case <-time.After(interval):
+			case <-fs.stop:
+				return
+			case <-s.closing:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// renewPoF gets the head of the chain signed and keeps it as the latest PoF.
+func (s *Service) renewPoF(scID SkipBlockID, fs *freshnessSigner) {
+	pof, err := s.GetSignedHead(&GetSignedHead{SkipChainID: scID})
+
+	s.freshness.Lock()
+	defer s.freshness.Unlock()
+	if err != nil {
+		log.Warnf("%s: couldn't renew proof of freshness of %x: %v",
+			s.ServerIdentity(), scID, err)
+		fs.lastError = err.Error()
+		return
+	}
+	fs.latest = pof
+	fs.renewals++
+	fs.lastError = ""
+}
+
+// GetPoF returns the latest proof of freshness of a skipchain whose PoF is
+// renewed by this conode.
+func (s *Service) GetPoF(req *GetPoF) (*GetPoFReply, error) {
+	s.freshness.Lock()
+	defer s.freshness.Unlock()
+	fs := s.freshness.chains[string(req.SkipChainID)]
+	if fs == nil {
+		return nil, xerrors.New("this conode doesn't renew the proof of " +
+			"freshness of this chain")
+	}
+	if fs.latest == nil {
+		if fs.lastError != "" {
+			return nil, xerrors.Errorf("no proof of freshness yet: %s",
+				fs.lastError)
+		}
+		return nil, xerrors.New("no proof of freshness yet")
+	}
+	return &GetPoFReply{PoF: fs.latest}, nil
+}
@@ -0,0 +1,55 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_GetPoF(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 4, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+	first := onet.NewRoster(ro.List[:1])
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	_, err = c.GetPoF(first, genesis.Hash, 0)
+	require.Error(t, err)
+	require.Error(t, service.SetFreshness(genesis.Hash, -time.Second))
+	require.Error(t, service.SetFreshness(SkipBlockID("unknown"), time.Second))
+
+	require.NoError(t, service.SetFreshness(genesis.Hash, 100*time.Millisecond))
+	require.Eventually(t, func() bool {
+		_, err := c.GetPoF(first, genesis.Hash, time.Minute)
+		return err == nil
+	}, 10*time.Second, 50*time.Millisecond)
+
+	// A new block is covered by the next renewal.
+	reply, err := service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          &SkipBlock{SkipBlockFix: &SkipBlockFix{Roster: ro}},
+	})
+	require.NoError(t, err)
+	var pof *PoF
+	require.Eventually(t, func() bool {
+		pof, err = c.GetPoF(first, genesis.Hash, time.Minute)
+		return err == nil && pof.Head.Hash.Equal(reply.Latest.Hash)
+	}, 10*time.Second, 50*time.Millisecond)
+
+	require.NoError(t, VerifyPoF(pof, genesis.Hash, time.Minute))
+	require.Error(t, VerifyPoF(pof, reply.Latest.Hash, time.Minute))
+	require.Error(t, VerifyPoF(pof, genesis.Hash, time.Nanosecond))
+	require.Error(t, VerifyPoF(nil, genesis.Hash, 0))
+
+	require.NoError(t, service.SetFreshness(genesis.Hash, 0))
+	_, err = c.GetPoF(first, genesis.Hash, 0)
+	require.Error(t, err)
+}
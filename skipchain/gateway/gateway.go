@@ -0,0 +1,359 @@
+// Package gateway offers an HTTP/JSON API in front of the skipchain service,
+// so that clients that don't speak the onet protocol can read blocks and add
+// new ones. The gateway talks to the conodes of a roster with the skipchain
+// client, verifies the hashes and forward-links of all blocks it gets, and
+// translates them into the JSON schema described in the README.
+package gateway
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
+
+// maxBodySize is the maximum size of the body of a request.
+const maxBodySize = 1 << 20
+
+// Block is the JSON representation of a skipblock. IDs are hex encoded,
+// byte slices are base64 encoded.
+type Block struct {
+	Hash               string         `json:"hash"`
+	Index              int            `json:"index"`
+	Height             int            `json:"height"`
+	MaximumHeight      int            `json:"maximum_height"`
+	BaseHeight         int            `json:"base_height"`
+	BackLinks          []string       `json:"backlinks"`
+	Verifiers          []string       `json:"verifiers"`
+	GenesisID          string         `json:"genesis_id"`
+	SkipChainID        string         `json:"skipchain_id"`
+	Data               []byte         `json:"data"`
+	Payload            []byte         `json:"payload,omitempty"`
+	Roster             []Node         `json:"roster"`
+	ForwardLinks       []*ForwardLink `json:"forward_links"`
+	SignatureScheme    uint32         `json:"signature_scheme"`
+	SignatureThreshold int            `json:"signature_threshold"`
+	Proposers          []string       `json:"proposers,omitempty"`
+	ProposerSignature  []byte         `json:"proposer_signature,omitempty"`
+}
+
+// Node is the JSON representation of a member of a roster. The public keys
+// are hex encoded. SkipchainPublic is the key signing the forward-links.
+type Node struct {
+	Address         string `json:"address"`
+	Public          string `json:"public"`
+	SkipchainPublic string `json:"skipchain_public"`
+	Description     string `json:"description,omitempty"`
+}
+
+// ForwardLink is the JSON representation of a forward-link. Empty
+// forward-links, which only pad the higher levels, are null.
+type ForwardLink struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	NewRoster []Node `json:"new_roster,omitempty"`
+	// Msg is the message signed by the roster, Signature the aggregated
+	// signature.
+	Msg       []byte `json:"msg"`
+	Signature []byte `json:"signature"`
+	// Batch is only set if Msg is the merkle root of a batch of blocks.
+	Batch *BatchProof `json:"batch,omitempty"`
+}
+
+// BatchProof is the JSON representation of the proof that a forward-link is
+// part of the merkle root signed for a batch of blocks. The hashes of the
+// path are hex encoded.
+type BatchProof struct {
+	Index int      `json:"index"`
+	Count int      `json:"count"`
+	Path  []string `json:"path"`
+}
+
+// StoreRequest is the body of a request to add a block to a chain.
+type StoreRequest struct {
+	Data []byte `json:"data"`
+}
+
+// StoreReply holds the previous and the new block of the chain.
+type StoreReply struct {
+	Previous *Block `json:"previous"`
+	Latest   *Block `json:"latest"`
+}
+
+// UpdateReply holds the blocks going from a known block to the latest one.
+type UpdateReply struct {
+	Blocks []*Block `json:"blocks"`
+}
+
+type errorReply struct {
+	Error string `json:"error"`
+}
+
+// Gateway is an http.Handler that serves the following requests:
+//   - GET /blocks/<id> returns the block
+//   - GET /chains/<skipchain-id>/blocks/<index> returns the block of the
+//     chain at the index
+//   - GET /chains/<id>/update returns the blocks from the given one to the
+//     latest block of its chain
+//   - POST /chains/<skipchain-id>/blocks with a StoreRequest adds a block
+//     to the chain
+type Gateway struct {
+	client *skipchain.Client
+	roster *onet.Roster
+}
+
+// NewGateway returns a gateway that asks the nodes of the roster.
+func NewGateway(roster *onet.Roster) *Gateway {
+	return &Gateway{
+		client: skipchain.NewClient(),
+		roster: roster,
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var reply interface{}
+	var err error
+	switch {
+	case len(parts) == 2 && parts[0] == "blocks" && r.Method == http.MethodGet:
+		reply, err = g.getBlock(parts[1])
+	case len(parts) == 4 && parts[0] == "chains" && parts[2] == "blocks" &&
+		r.Method == http.MethodGet:
+		reply, err = g.getBlockByIndex(parts[1], parts[3])
+	case len(parts) == 3 && parts[0] == "chains" && parts[2] == "update" &&
+		r.Method == http.MethodGet:
+		reply, err = g.getUpdateChain(parts[1])
+	case len(parts) == 3 && parts[0] == "chains" && parts[2] == "blocks" &&
+		r.Method == http.MethodPost:
+		reply, err = g.storeBlock(parts[1], r.Body)
+	default:
+		writeJSON(w, http.StatusNotFound, errorReply{"unknown request"})
+		return
+	}
+	if err != nil {
+		status := http.StatusBadGateway
+		var reqErr requestError
+		if xerrors.As(err, &reqErr) {
+			status = http.StatusBadRequest
+		}
+		writeJSON(w, status, errorReply{err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, reply)
+}
+
+// requestError is returned for requests that can't be served, as opposed
+// to errors of the conodes.
+type requestError struct {
+	error
+}
+
+func (g *Gateway) getBlock(idStr string) (*Block, error) {
+	id, err := parseID(idStr)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := g.client.GetSingleBlock(g.roster, id)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get block: %v", err)
+	}
+	return newCheckedBlock(sb)
+}
+
+func (g *Gateway) getBlockByIndex(idStr, indexStr string) (*Block, error) {
+	id, err := parseID(idStr)
+	if err != nil {
+		return nil, err
+	}
+	index, err := strconv.Atoi(indexStr)
+	if err != nil || index < 0 {
+		return nil, requestError{xerrors.Errorf("invalid index %q", indexStr)}
+	}
+	reply, err := g.client.GetSingleBlockByIndex(g.roster, id, index)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get block: %v", err)
+	}
+	return newCheckedBlock(reply.SkipBlock)
+}
+
+func (g *Gateway) getUpdateChain(idStr string) (*UpdateReply, error) {
+	id, err := parseID(idStr)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := g.client.GetUpdateChain(g.roster, id)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get update chain: %v", err)
+	}
+	update := &UpdateReply{Blocks: []*Block{}}
+	for _, sb := range reply.Update {
+		if err := sb.VerifyForwardSignatures(); err != nil {
+			return nil, xerrors.Errorf("invalid block %x: %v", sb.Hash, err)
+		}
+		b, err := newCheckedBlock(sb)
+		if err != nil {
+			return nil, err
+		}
+		update.Blocks = append(update.Blocks, b)
+	}
+	return update, nil
+}
+
+func (g *Gateway) storeBlock(idStr string, body io.Reader) (*StoreReply, error) {
+	id, err := parseID(idStr)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := ioutil.ReadAll(io.LimitReader(body, maxBodySize+1))
+	if err != nil {
+		return nil, requestError{xerrors.Errorf("couldn't read body: %v", err)}
+	}
+	if len(buf) > maxBodySize {
+		return nil, requestError{xerrors.New("body is too big")}
+	}
+	var req StoreRequest
+	if err := json.Unmarshal(buf, &req); err != nil {
+		return nil, requestError{xerrors.Errorf("invalid body: %v", err)}
+	}
+
+	genesis, err := g.client.GetSingleBlock(g.roster, id)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get genesis block: %v", err)
+	}
+	if genesis.Index != 0 {
+		return nil, requestError{xerrors.New("not the ID of a skipchain")}
+	}
+	data := req.Data
+	if data == nil {
+		data = []byte{}
+	}
+	reply, err := g.client.StoreSkipBlock(genesis, nil, data)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't store block: %v", err)
+	}
+	if reply.Latest == nil {
+		return nil, xerrors.New("got an empty reply")
+	}
+	sr := &StoreReply{}
+	if sr.Latest, err = newCheckedBlock(reply.Latest); err != nil {
+		return nil, err
+	}
+	if reply.Previous != nil {
+		if sr.Previous, err = newCheckedBlock(reply.Previous); err != nil {
+			return nil, err
+		}
+	}
+	return sr, nil
+}
+
+// newCheckedBlock returns the JSON representation of the skipblock, or an
+// error if its hash doesn't match its content.
+func newCheckedBlock(sb *skipchain.SkipBlock) (*Block, error) {
+	if sb == nil {
+		return nil, xerrors.New("got an empty block")
+	}
+	if !sb.CalculateHash().Equal(sb.Hash) {
+		return nil, xerrors.Errorf("invalid hash of block %x", sb.Hash)
+	}
+	return NewBlock(sb), nil
+}
+
+// NewBlock returns the JSON representation of the skipblock. It doesn't
+// check the block.
+func NewBlock(sb *skipchain.SkipBlock) *Block {
+	b := &Block{
+		Hash:               hex.EncodeToString(sb.Hash),
+		Index:              sb.Index,
+		Height:             sb.Height,
+		MaximumHeight:      sb.MaximumHeight,
+		BaseHeight:         sb.BaseHeight,
+		BackLinks:          []string{},
+		Verifiers:          []string{},
+		GenesisID:          hex.EncodeToString(sb.GenesisID),
+		SkipChainID:        hex.EncodeToString(sb.SkipChainID()),
+		Data:               sb.Data,
+		Payload:            sb.Payload,
+		Roster:             newNodes(sb.Roster),
+		ForwardLinks:       []*ForwardLink{},
+		SignatureScheme:    sb.SignatureScheme,
+		SignatureThreshold: sb.SignatureThreshold,
+		ProposerSignature:  sb.ProposerSignature,
+	}
+	for _, bl := range sb.BackLinkIDs {
+		b.BackLinks = append(b.BackLinks, hex.EncodeToString(bl))
+	}
+	for _, v := range sb.VerifierIDs {
+		b.Verifiers = append(b.Verifiers, v.String())
+	}
+	for _, p := range sb.Proposers {
+		b.Proposers = append(b.Proposers, p.String())
+	}
+	for _, fl := range sb.GetForwardLinks() {
+		if fl.IsEmpty() {
+			b.ForwardLinks = append(b.ForwardLinks, nil)
+			continue
+		}
+		jfl := &ForwardLink{
+			From:      hex.EncodeToString(fl.From),
+			To:        hex.EncodeToString(fl.To),
+			NewRoster: newNodes(fl.NewRoster),
+			Msg:       fl.Signature.Msg,
+			Signature: fl.Signature.Sig,
+		}
+		if fl.Batch != nil {
+			jfl.Batch = &BatchProof{
+				Index: fl.Batch.Index,
+				Count: fl.Batch.Count,
+				Path:  []string{},
+			}
+			for _, p := range fl.Batch.Path {
+				jfl.Batch.Path = append(jfl.Batch.Path, hex.EncodeToString(p))
+			}
+		}
+		b.ForwardLinks = append(b.ForwardLinks, jfl)
+	}
+	return b
+}
+
+func newNodes(ro *onet.Roster) []Node {
+	if ro == nil {
+		return nil
+	}
+	nodes := make([]Node, len(ro.List))
+	for i, si := range ro.List {
+		nodes[i] = Node{
+			Address:     si.Address.String(),
+			Public:      si.Public.String(),
+			Description: si.Description,
+		}
+		if pub := si.ServicePublic(skipchain.ServiceName); pub != nil {
+			nodes[i].SkipchainPublic = pub.String()
+		}
+	}
+	return nodes
+}
+
+func parseID(s string) (skipchain.SkipBlockID, error) {
+	id, err := hex.DecodeString(s)
+	if err != nil || len(id) != 32 {
+		return nil, requestError{xerrors.Errorf("invalid ID %q", s)}
+	}
+	return skipchain.SkipBlockID(id), nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Warnf("couldn't write reply: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+)
+
+func TestMain(m *testing.M) {
+	log.MainTest(m)
+}
+
+func TestGateway(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer l.CloseAll()
+	_, ro, _ := l.GenTree(3, true)
+	genesis, err := skipchain.NewClient().CreateGenesis(ro, 1, 1,
+		skipchain.VerificationNone, nil)
+	require.NoError(t, err)
+	gid := hex.EncodeToString(genesis.Hash)
+
+	server := httptest.NewServer(NewGateway(ro))
+	defer server.Close()
+	get := func(path string, status int, v interface{}) {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode)
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+	}
+
+	buf, err := json.Marshal(&StoreRequest{Data: []byte("new block")})
+	require.NoError(t, err)
+	resp, err := http.Post(server.URL+"/chains/"+gid+"/blocks",
+		"application/json", bytes.NewReader(buf))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var sr StoreReply
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&sr))
+	resp.Body.Close()
+	require.Equal(t, 1, sr.Latest.Index)
+	require.Equal(t, gid, sr.Latest.SkipChainID)
+	require.Equal(t, []byte("new block"), sr.Latest.Data)
+	require.Equal(t, gid, sr.Previous.Hash)
+
+	var b Block
+	get("/blocks/"+gid, http.StatusOK, &b)
+	require.Equal(t, 0, b.Index)
+	require.Equal(t, 3, len(b.Roster))
+	require.NotEqual(t, "", b.Roster[0].SkipchainPublic)
+	require.Equal(t, 1, len(b.ForwardLinks))
+	require.Equal(t, gid, b.ForwardLinks[0].From)
+	require.Equal(t, sr.Latest.Hash, b.ForwardLinks[0].To)
+	require.NotEmpty(t, b.ForwardLinks[0].Signature)
+	require.Nil(t, b.ForwardLinks[0].Batch)
+
+	get("/chains/"+gid+"/blocks/1", http.StatusOK, &b)
+	require.Equal(t, sr.Latest.Hash, b.Hash)
+	require.Equal(t, []string{gid}, b.BackLinks)
+
+	var ur UpdateReply
+	get("/chains/"+gid+"/update", http.StatusOK, &ur)
+	require.Equal(t, 2, len(ur.Blocks))
+	require.Equal(t, sr.Latest.Hash, ur.Blocks[1].Hash)
+
+	var er errorReply
+	get("/blocks/1234", http.StatusBadRequest, &er)
+	require.Contains(t, er.Error, "invalid ID")
+	get("/chains/"+gid+"/blocks/abc", http.StatusBadRequest, &er)
+	get("/blocks/"+hex.EncodeToString(make([]byte, 32)), http.StatusBadGateway, &er)
+	get("/unknown", http.StatusNotFound, &er)
+
+	// Only the genesis block can be used as the target.
+	resp, err = http.Post(server.URL+"/chains/"+sr.Latest.Hash+"/blocks",
+		"application/json", bytes.NewReader(buf))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	// The forward-links of a batch hold the proof of the signed root.
+	sb := genesis.Copy()
+	require.NoError(t, sb.AddForwardLink(&skipchain.ForwardLink{
+		From: genesis.Hash, To: genesis.Hash,
+		Batch: &skipchain.BatchProof{Index: 1, Count: 2,
+			Path: [][]byte{genesis.Hash}}}, 0))
+	require.Equal(t, &BatchProof{Index: 1, Count: 2, Path: []string{gid}},
+		NewBlock(sb).ForwardLinks[0].Batch)
+
+	// All hashed fields are returned, and only blocks with a correct hash.
+	sb = genesis.Copy()
+	sb.Proposers = []kyber.Point{cothority.Suite.Point().Base()}
+	_, err = newCheckedBlock(sb)
+	require.Error(t, err)
+	sb.Hash = sb.CalculateHash()
+	jb, err := newCheckedBlock(sb)
+	require.NoError(t, err)
+	require.Equal(t, []string{sb.Proposers[0].String()}, jb.Proposers)
+}
@@ -0,0 +1,96 @@
+package skipchain
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+)
+
+// maxIdempotencyKey is the maximum length of an idempotency key.
+const maxIdempotencyKey = 64
+
+// defaultIdempotencyWindow is how long an idempotency key is remembered.
+var defaultIdempotencyWindow = 10 * time.Minute
+
+// idempotencyEntry is the block that has been created for a given key.
+type idempotencyEntry struct {
+	block   SkipBlockID
+	expires time.Time
+}
+
+// idempotencyKeys remembers the blocks created with an idempotency key, so
+// that a client retrying a StoreSkipBlock gets the same block back instead
+// of appending the same data twice. The map is indexed by the skipchain-ID
+// followed by the key.
+type idempotencyKeys struct {
+	sync.Mutex
+	entries map[string]idempotencyEntry
+}
+
+func idempotencyIndex(scID SkipBlockID, key []byte) string {
+	return string(scID) + string(key)
+}
+
+// lookup returns the ID of the block created with this key on the chain, or
+// nil if the key is unknown or expired.
+func (ik *idempotencyKeys) lookup(scID SkipBlockID, key []byte) SkipBlockID {
+	ik.Lock()
+	defer ik.Unlock()
+	e, ok := ik.entries[idempotencyIndex(scID, key)]
+	if !ok || time.Now().After(e.expires) {
+		return nil
+	}
+	return e.block
+}
+
+// remember stores the block created with this key and removes the expired
+// entries.
+func (ik *idempotencyKeys) remember(scID SkipBlockID, key []byte, block SkipBlockID, window time.Duration) {
+	ik.Lock()
+	defer ik.Unlock()
+	if ik.entries == nil {
+		ik.entries = make(map[string]idempotencyEntry)
+	}
+	now := time.Now()
+	for i, e := range ik.entries {
+		if now.After(e.expires) {
+			delete(ik.entries, i)
+		}
+	}
+	ik.entries[idempotencyIndex(scID, key)] = idempotencyEntry{
+		block:   block,
+		expires: now.Add(window),
+	}
+}
+
+// idempotentReply returns the reply of the block previously created with
+// the same idempotency key, or nil if there is none. An error is returned if
+// the key has been used for a block with different data or roster.
+func (s *Service) idempotentReply(scID SkipBlockID, key []byte, prop *SkipBlock) (*StoreSkipBlockReply, error) {
+	id := s.idempotency.lookup(scID, key)
+	if id == nil {
+		return nil, nil
+	}
+	sb := s.db.GetByID(id)
+	if sb == nil {
+		return nil, nil
+	}
+	if !bytes.Equal(sb.Data, prop.Data) || !sb.Roster.ID.Equal(prop.Roster.ID) {
+		return nil, xerrors.New("idempotency key already used for a different block")
+	}
+	if len(sb.BackLinkIDs) == 0 {
+		return nil, xerrors.New("stored block has no back-link")
+	}
+	return &StoreSkipBlockReply{
+		Previous: s.db.GetByID(sb.BackLinkIDs[0]),
+		Latest:   sb,
+	}, nil
+}
+
+// SetIdempotencyWindow sets how long idempotency keys of StoreSkipBlock are
+// remembered.
+func (s *Service) SetIdempotencyWindow(d time.Duration) {
+	s.idempotencyWindow = d
+}
@@ -0,0 +1,74 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestService_IdempotencyKey(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	_, ro, genService := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := genService.(*Service)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	store := func(data []byte, key []byte) (*StoreSkipBlockReply, error) {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Data = data
+		return service.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: genesis.Hash,
+			NewBlock: sb, IdempotencyKey: key})
+	}
+
+	_, err = store([]byte{1}, make([]byte, maxIdempotencyKey+1))
+	require.Error(t, err)
+
+	// A retry returns the same block.
+	key := []byte("retry")
+	reply1, err := store([]byte{1}, key)
+	require.NoError(t, err)
+	reply2, err := store([]byte{1}, key)
+	require.NoError(t, err)
+	require.Equal(t, reply1.Latest.Hash, reply2.Latest.Hash)
+	require.Equal(t, genesis.Hash, reply2.Previous.Hash)
+	latest, err := service.db.GetLatestByID(genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 1, latest.Index)
+
+	// The same key with a different block is refused.
+	_, err = store([]byte{2}, key)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "different block")
+
+	// Without a key, the same data is appended again.
+	reply3, err := store([]byte{1}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, reply3.Latest.Index)
+
+	// Keys are bound to their chain.
+	genesis2, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	sb.Data = []byte{1}
+	reply4, err := service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis2.Hash, NewBlock: sb, IdempotencyKey: key})
+	require.NoError(t, err)
+	require.Equal(t, genesis2.Hash, reply4.Latest.GenesisID)
+
+	// Once the key expired, a new block is added.
+	service.SetIdempotencyWindow(time.Millisecond)
+	reply5, err := store([]byte{3}, []byte("expire"))
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+	reply6, err := store([]byte{3}, []byte("expire"))
+	require.NoError(t, err)
+	require.Equal(t, reply5.Latest.Index+1, reply6.Latest.Index)
+}
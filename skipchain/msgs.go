@@ -44,14 +44,80 @@ func init() {
 		&ListFollow{},
 		// Returns the genesis-blocks of all skipchains we follow
 		&ListFollowReply{},
+		// Anchoring of external digests
+		&AnchorData{},
+		&AnchorDataReply{},
+		// Naming registry
+		&RegisterName{},
+		&RegisterNameReply{},
+		&ResolveName{},
+		&ResolveNameReply{},
+		// Repairing the database from another conode
+		&GetDBSummary{},
+		&GetDBSummaryReply{},
+		&ReconcileDB{},
+		&ReconcileDBReply{},
+		// Local annotations of blocks
+		&SetAnnotation{},
+		&GetAnnotation{},
+		&GetAnnotationReply{},
+		// Head of a chain signed by its roster
+		&GetSignedHead{},
+		&GetSignedHeadReply{},
+		// Checkpoints of chains
+		&GetCheckpoint{},
+		&GetCheckpointReply{},
+		// Redaction of payloads
+		&Redact{},
+		&GetRedaction{},
+		&GetRedactionReply{},
+		// Local deletion of a skipchain
+		&DeleteChainLocal{},
+		// Streaming of new blocks
+		&StreamBlocks{},
+		&StreamBlocksReply{},
+		&UpdateStream{},
+		&UpdateStreamReply{},
+		// Archival of pruned blocks
+		&ArchiveExport{},
+		&ArchiveExportReply{},
+		&ArchiveImport{},
+		&ArchiveImportReply{},
+		// Paginated traversal of chains
+		&GetBlockRange{},
+		&GetBlockRangeReply{},
+		// Explicit change of the roster of a chain
+		&ChangeRoster{},
+		// Forward-link proofs for light clients
+		&GetProof{},
+		&GetProofReply{},
+		// Fork detection
+		&GetForkEvidence{},
+		&GetForkEvidenceReply{},
+		&ResolveFork{},
+		// Metrics of the storage and the propagation
+		&GetMetrics{},
+		&GetMetricsReply{},
+		// Proofs of freshness
+		&GetPoF{},
+		&GetPoFReply{},
+		// Batched appends of blocks
+		&StoreSkipBlocks{},
+		&StoreSkipBlocksReply{},
 		// - Internal calls
 		// Propagation
 		&PropagateGenesis{},
 		&PropagateForwardLink{},
 		&PropagateProof{},
+		&PropagateHandover{},
 		// Request forward-signature
 		&ForwardSignature{},
 		&ForwardSignatureReply{},
+		&ForwardSignatureBatch{},
+		// Subscription to new blocks
+		&HeadSubscription{},
+		&HeadSubscriptionReply{},
+		&HeadUpdate{},
 		// - Data structures
 		&SkipBlockFix{},
 		&SkipBlock{},
@@ -79,6 +145,15 @@ type StoreSkipBlock struct {
 	TargetSkipChainID SkipBlockID
 	NewBlock          *SkipBlock
 	Signature         *[]byte
+	// IdempotencyKey is an optional key chosen by the client. If a block
+	// has already been added to the chain with the same key, this block is
+	// returned instead of adding a new one.
+	IdempotencyKey []byte `protobuf:"opt"`
+	// ProposerSignature is needed if the chain restricts its Proposers.
+	// It is done by one of them on the following message, with the index
+	// of the new block as a little-endian uint64:
+	// "proposal:" + latest block ID + Index + sha256(NewBlock.Data)
+	ProposerSignature []byte `protobuf:"opt"`
 }
 
 // StoreSkipBlockReply - returns the signed SkipBlock with updated backlinks
@@ -88,6 +163,20 @@ type StoreSkipBlockReply struct {
 	Latest   *SkipBlock
 }
 
+// StoreSkipBlocks asks the leader to append all blocks to the skipchain in
+// order, with a single signature of the roster.
+type StoreSkipBlocks struct {
+	TargetSkipChainID SkipBlockID
+	NewBlocks         []*SkipBlock
+}
+
+// StoreSkipBlocksReply returns the block that was the latest before the
+// batch, and the new blocks with their forward-links.
+type StoreSkipBlocksReply struct {
+	Previous *SkipBlock
+	Latest   []*SkipBlock
+}
+
 // OptimizeProofRequest is request to create missing forward links.
 // If the ID is the skipchain-ID,
 // the proofs from the genesis block to the latest block are optimized.
@@ -148,6 +237,29 @@ type GetAllSkipChainIDsReply struct {
 
 // Internal calls
 
+// HeadSubscription is sent by a conode to the nodes of a roster to subscribe
+// to, or unsubscribe from, the new blocks of a skipchain.
+type HeadSubscription struct {
+	SkipChainID SkipBlockID
+	Unsubscribe bool
+	// Nonce is returned in the reply.
+	Nonce uint64
+}
+
+// HeadSubscriptionReply tells the subscriber whether the subscription has
+// been accepted. Error is empty if it has.
+type HeadSubscriptionReply struct {
+	Nonce uint64
+	Error string
+}
+
+// HeadUpdate is sent to the subscribers of a skipchain once a new block has
+// been added. The forward-link is signed by the roster of the previous block.
+type HeadUpdate struct {
+	Link  *ForwardLink
+	Block *SkipBlock
+}
+
 // PropagateGenesis sends the genesis block of a newly created SkipChain to all members of
 // the Cothority
 type PropagateGenesis struct {
@@ -167,6 +279,13 @@ type PropagateProof struct {
 	Proof Proof
 }
 
+// PropagateHandover sends consecutive blocks of a chain to the conodes that
+// joined its roster. The first block is either the genesis block or the last
+// block of the previous PropagateHandover.
+type PropagateHandover struct {
+	Blocks []*SkipBlock
+}
+
 // ForwardSignature is called once a new skipblock has been accepted by
 // signing the forward-link, and then the older skipblocks need to
 // update their forward-links. Each cothority needs to get the necessary
@@ -184,6 +303,15 @@ type ForwardSignature struct {
 	Links []*ForwardLink
 }
 
+// ForwardSignatureBatch asks the roster to sign the level-0 forward-links of
+// a batch of blocks.
+type ForwardSignatureBatch struct {
+	// Previous is the latest block before the batch.
+	Previous SkipBlockID
+	// Newest are the blocks of the batch, in order.
+	Newest []*SkipBlock
+}
+
 // ForwardSignatureReply returns the new forward-link
 type ForwardSignatureReply struct {
 	Link *ForwardLink
@@ -368,3 +496,302 @@ type ListFollowReply struct {
 	Follow    *[]FollowChainType
 	FollowIDs *[]SkipBlockID
 }
+
+// AnchorData asks the leader of a skipchain to include the digest in the
+// next block. All anchors received during one epoch are stored together.
+type AnchorData struct {
+	SkipChainID SkipBlockID
+	Digest      []byte
+	Metadata    []byte
+}
+
+// AnchorDataReply returns the block holding the anchor and the proof that
+// the anchor is part of the merkle root stored in the block. Links are the
+// forward-links from the genesis block to the block, as in GetProofReply.
+type AnchorDataReply struct {
+	Block *SkipBlock
+	Links []*ForwardLink
+	Proof AnchorProof
+}
+
+// RegisterName asks the leader of a naming registry to map the name to the
+// skipchain. If the leader has linked clients, the signature must be from
+// one of them on NameRegistrationHash.
+type RegisterName struct {
+	Registry    SkipBlockID
+	Name        string
+	SkipChainID SkipBlockID
+	Signature   *[]byte
+}
+
+// RegisterNameReply returns the block of the registry holding the name.
+type RegisterNameReply struct {
+	Block *SkipBlock
+}
+
+// ResolveName asks for the block of the registry holding the name.
+type ResolveName struct {
+	Registry SkipBlockID
+	Name     string
+}
+
+// ResolveNameReply returns the oldest block holding the name, with the
+// genesis block of the registry and the forward-links from the genesis block
+// to the block.
+type ResolveNameReply struct {
+	Genesis *SkipBlock
+	Block   *SkipBlock
+	Links   []*ForwardLink
+}
+
+// GetDBSummary asks a conode for the summary of all skipchains it stores.
+type GetDBSummary struct {
+}
+
+// GetDBSummaryReply holds one summary for each skipchain.
+type GetDBSummaryReply struct {
+	Chains []ChainSummary
+}
+
+// ReconcileDB asks the conode to fetch from the peer all blocks it is
+// missing. The signature is from a linked client or from the conode, and
+// has to be on the following message:
+// "reconciledb:" + the ID of the peer
+type ReconcileDB struct {
+	Peer      *network.ServerIdentity
+	Signature []byte
+}
+
+// ReconcileDBReply returns the number of skipchains that have been updated
+// or failed, and the number of blocks added to the database.
+type ReconcileDBReply struct {
+	Chains int
+	Failed int
+	Blocks int
+}
+
+// SetAnnotation stores a local annotation for a block. An empty annotation
+// removes the existing one. The signature is from a linked client or from
+// the conode, and has to be on the following message:
+// "annotation:" + BlockID + Annotation
+type SetAnnotation struct {
+	BlockID    SkipBlockID
+	Annotation string
+	Signature  []byte
+}
+
+// GetAnnotation asks for the local annotation of a block.
+type GetAnnotation struct {
+	BlockID SkipBlockID
+}
+
+// GetAnnotationReply returns the annotation, which is empty if the block
+// has none.
+type GetAnnotationReply struct {
+	Annotation string
+}
+
+// GetSignedHead asks for the latest block of the skipchain, together with a
+// statement that it is the head, co-signed by the roster of this block.
+type GetSignedHead struct {
+	SkipChainID SkipBlockID
+}
+
+// GetSignedHeadReply returns the latest block of the skipchain, the statement
+// and the BDN signature of the statement by the roster of the block. The
+// genesis block and the forward-links from it prove that the head is part of
+// the skipchain.
+type GetSignedHeadReply struct {
+	Genesis   *SkipBlock
+	Links     []*ForwardLink
+	Head      *SkipBlock
+	Statement HeadStatement
+	Signature []byte
+}
+
+// GetCheckpoint asks for the latest checkpoint block of the skipchain.
+type GetCheckpoint struct {
+	SkipChainID SkipBlockID
+}
+
+// GetCheckpointReply returns the index of the latest checkpoint block.
+type GetCheckpointReply struct {
+	Index int
+}
+
+// Redact deletes the payload of a redactable block. The signature is from a
+// linked client or from the conode, and has to be on the following message:
+// "redact:" + BlockID + Reason
+type Redact struct {
+	BlockID   SkipBlockID
+	Reason    string
+	Signature []byte
+}
+
+// GetRedaction asks for the redaction of a block.
+type GetRedaction struct {
+	BlockID SkipBlockID
+}
+
+// GetRedactionReply returns the redaction of the block, or nil if it has not
+// been redacted.
+type GetRedactionReply struct {
+	Redaction *Redaction `protobuf:"opt"`
+}
+
+// DeleteChainLocal removes a skipchain from the database of the conode and
+// refuses its future blocks. The signature is from a linked client or from
+// the conode, and has to be on the following message:
+// "deletechain:" + SkipChainID
+type DeleteChainLocal struct {
+	SkipChainID SkipBlockID
+	Signature   []byte
+}
+
+// StreamBlocks opens a stream of the new blocks of a skipchain. The blocks
+// of the skipchains in Others are sent over the same stream.
+type StreamBlocks struct {
+	SkipChainID SkipBlockID
+	Others      []SkipBlockID `protobuf:"opt"`
+	// StreamID is chosen by the client to change the skipchains of the
+	// stream with UpdateStream. It must be random, as anybody knowing it
+	// can change the skipchains of the stream.
+	StreamID []byte `protobuf:"opt"`
+}
+
+// UpdateStream adds and removes skipchains of the open stream with the
+// StreamID given in StreamBlocks.
+type UpdateStream struct {
+	StreamID []byte
+	Add      []SkipBlockID `protobuf:"opt"`
+	Remove   []SkipBlockID `protobuf:"opt"`
+}
+
+// UpdateStreamReply is returned once the skipchains of the stream changed.
+type UpdateStreamReply struct {
+}
+
+// StreamBlocksReply holds a new block of the skipchain, and the forward-link
+// pointing to it, if the conode knows it.
+type StreamBlocksReply struct {
+	Block *SkipBlock
+	Link  *ForwardLink `protobuf:"opt"`
+}
+
+// ArchiveExport asks for the stored blocks of a skipchain with an index
+// between From and To, both included.
+type ArchiveExport struct {
+	SkipChainID SkipBlockID
+	From        int
+	To          int
+}
+
+// ArchiveExportReply returns the blocks in an archive.
+type ArchiveExportReply struct {
+	Archive *Archive
+}
+
+// ArchiveImport restores the pruned payloads of the blocks of the archive.
+// The signature is from a linked client or from the conode, and has to be on
+// the following message: "archiveimport:" +
+// sha256(SkipChainID + for every block: Hash + len(Payload) + Payload)
+type ArchiveImport struct {
+	Archive   *Archive
+	Signature []byte
+}
+
+// ArchiveImportReply returns the number of restored blocks.
+type ArchiveImportReply struct {
+	Restored int
+}
+
+// GetBlockRange asks for consecutive blocks of a chain. Start is the first
+// block, unless Cursor is given, in which case the traversal continues where
+// the previous reply stopped. MaxBlocks defaults to and is limited by the
+// conode.
+type GetBlockRange struct {
+	Start     SkipBlockID
+	Cursor    []byte    `protobuf:"opt"`
+	MaxBlocks int       `protobuf:"opt"`
+	Direction Direction `protobuf:"opt"`
+}
+
+// GetBlockRangeReply returns the blocks in the order of the traversal.
+// NextCursor is empty if there are no more blocks in this direction.
+type GetBlockRangeReply struct {
+	Blocks     []*SkipBlock
+	NextCursor []byte `protobuf:"opt"`
+}
+
+// ChangeRoster asks the leader of the new roster to add a block changing the
+// roster of the chain. The new roster can also hold the same nodes in
+// another order. The signature has to be on the following message, by a
+// linked client or by the conode, where the ID of NewRoster is computed from
+// its nodes, also in the second message:
+// "changeroster:" + SkipChainID + NewRoster.ID
+// If the chain is frozen, AdminSignature has to be done by a freeze
+// administrator on the following message, with the index of the new block
+// as a little-endian uint64:
+// "frozenroster:" + SkipChainID + Index + NewRoster.ID
+type ChangeRoster struct {
+	SkipChainID    SkipBlockID
+	NewRoster      *onet.Roster
+	Signature      []byte
+	AdminSignature []byte `protobuf:"opt"`
+}
+
+// GetProof asks for the forward-links from the genesis block to the Target
+// block. If Target is empty, the links lead to the latest block.
+type GetProof struct {
+	Genesis SkipBlockID
+	Target  SkipBlockID `protobuf:"opt"`
+}
+
+// GetProofReply returns the forward-links and the target block. The first
+// link only points to the genesis block, and can be verified with
+// VerifyProof.
+type GetProofReply struct {
+	Links []*ForwardLink
+	Block *SkipBlock
+}
+
+// GetForkEvidence asks for the evidence of the fork of a chain, or of all
+// chains if SkipChainID is empty.
+type GetForkEvidence struct {
+	SkipChainID SkipBlockID `protobuf:"opt"`
+}
+
+// GetForkEvidenceReply returns the evidences of the forks detected by the
+// conode.
+type GetForkEvidenceReply struct {
+	Forks []ForkEvidence
+}
+
+// ResolveFork removes the evidence of the fork of a chain, so that the
+// conode stores its blocks again. The signature is from a linked client or
+// from the conode, and has to be on the following message:
+// "resolvefork:" + SkipChainID
+type ResolveFork struct {
+	SkipChainID SkipBlockID
+	Signature   []byte
+}
+
+// GetMetrics asks a conode for the metrics of the storage and the
+// propagation of blocks.
+type GetMetrics struct {
+}
+
+// GetMetricsReply holds the metrics of the conode.
+type GetMetricsReply struct {
+	Metrics DBMetrics
+}
+
+// GetPoF asks a conode for the latest proof of freshness of a skipchain.
+type GetPoF struct {
+	SkipChainID SkipBlockID
+}
+
+// GetPoFReply returns the latest proof of freshness of the skipchain.
+type GetPoFReply struct {
+	PoF *PoF
+}
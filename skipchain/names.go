@@ -0,0 +1,313 @@
+package skipchain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sync"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the naming registry. A registry is a skipchain where every
+block after the genesis block holds a NameEntry that maps a name to the ID
+of a skipchain. Names are given on a first-come basis: once a name is in the
+registry, it cannot be registered again. If the leader of the registry has
+linked clients, only those clients can register names.
+
+The genesis block of a registry holds the VerifyNameRegistry verifier, so that
+all nodes refuse a block with an invalid or an already registered name. A
+client resolving a name can then trust the oldest block holding the name once
+it verified the forward-links from the genesis block to it.
+*/
+
+// maxNameLength is the maximum length of a name. It is shorter than an
+// hex-encoded skipchain-ID, so that a name is never taken for an ID.
+const maxNameLength = 63
+
+var nameRegexp = regexp.MustCompile("^[a-z][a-z0-9._-]*$")
+
+func init() {
+	network.RegisterMessages(&NameEntry{})
+}
+
+// NameEntry is the data of a block of a naming registry.
+type NameEntry struct {
+	Name        string
+	SkipChainID SkipBlockID
+}
+
+// checkName returns an error if the name cannot be used in a registry.
+func checkName(name string) error {
+	if len(name) == 0 || len(name) > maxNameLength {
+		return xerrors.Errorf("name must be between 1 and %d characters",
+			maxNameLength)
+	}
+	if !nameRegexp.MatchString(name) {
+		return xerrors.New("name must start with a lowercase letter and " +
+			"hold only lowercase letters, digits, '.', '_' and '-'")
+	}
+	return nil
+}
+
+// NameRegistrationHash returns the hash to be signed by a linked client to
+// register a name.
+func NameRegistrationHash(registry SkipBlockID, name string, scID SkipBlockID) []byte {
+	h := sha256.New()
+	h.Write(registry)
+	h.Write([]byte(name))
+	h.Write(scID)
+	return h.Sum(nil)
+}
+
+// nameEntryFromBlock returns the name entry of the block, or nil if the
+// block doesn't hold one.
+func nameEntryFromBlock(sb *SkipBlock) *NameEntry {
+	if sb.Index == 0 || len(sb.Data) == 0 {
+		return nil
+	}
+	_, msg, err := network.Unmarshal(sb.Data, cothority.Suite)
+	if err != nil {
+		return nil
+	}
+	entry, ok := msg.(*NameEntry)
+	if !ok {
+		return nil
+	}
+	return entry
+}
+
+// nameIndexes holds the index of the names of every registry, so that a name
+// is found without going through all blocks of the registry.
+type nameIndexes struct {
+	sync.Mutex
+	registries map[string]*nameIndex
+}
+
+// nameIndex maps the names of a registry to the ID of the oldest block
+// holding them. Only the blocks up to latest are indexed.
+type nameIndex struct {
+	latest SkipBlockID
+	names  map[string]SkipBlockID
+}
+
+// hasNameRegistry returns true if the block is from a naming registry.
+func hasNameRegistry(sb *SkipBlock) bool {
+	for _, v := range sb.VerifierIDs {
+		if v.Equal(VerifyNameRegistry) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupName returns the oldest block of the registry holding the name, or
+// nil if the name is not registered. The blocks added since the previous
+// call are indexed first.
+func (s *Service) lookupName(registry SkipBlockID, name string) (*SkipBlock, error) {
+	latest, err := s.db.GetLatestByID(registry)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't find registry: %v", err)
+	}
+
+	s.nameIndexes.Lock()
+	defer s.nameIndexes.Unlock()
+	if s.nameIndexes.registries == nil {
+		s.nameIndexes.registries = make(map[string]*nameIndex)
+	}
+	idx := s.nameIndexes.registries[string(registry)]
+	if idx == nil || s.db.GetByID(idx.latest) == nil {
+		// The registry is new or has been deleted since.
+		idx = &nameIndex{names: make(map[string]SkipBlockID)}
+	}
+
+	var entries []*NameEntry
+	var ids []SkipBlockID
+	for sb := latest; sb.Index > 0 && !sb.Hash.Equal(idx.latest); {
+		if entry := nameEntryFromBlock(sb); entry != nil {
+			entries = append(entries, entry)
+			ids = append(ids, sb.Hash)
+		}
+		prev := s.db.GetByID(sb.BackLinkIDs[0])
+		if prev == nil {
+			return nil, xerrors.Errorf("missing block %x in registry",
+				sb.BackLinkIDs[0])
+		}
+		sb = prev
+	}
+	// Add the oldest blocks first, so that the first registration wins.
+	for i := len(entries) - 1; i >= 0; i-- {
+		if _, ok := idx.names[entries[i].Name]; !ok {
+			idx.names[entries[i].Name] = ids[i]
+		}
+	}
+	idx.latest = latest.Hash
+	s.nameIndexes.registries[string(registry)] = idx
+
+	id, ok := idx.names[name]
+	if !ok {
+		return nil, nil
+	}
+	sb := s.db.GetByID(id)
+	if sb == nil {
+		return nil, xerrors.Errorf("missing block %x in registry", id)
+	}
+	return sb, nil
+}
+
+// verifyFuncNameRegistry refuses the blocks of a registry that don't hold a
+// valid name, or that hold a name already registered in an older block.
+func (s *Service) verifyFuncNameRegistry(newID []byte, newSB *SkipBlock) bool {
+	if newSB.Index == 0 {
+		return true
+	}
+	entry := nameEntryFromBlock(newSB)
+	if entry == nil {
+		log.Lvl2("registry block without a name entry")
+		return false
+	}
+	if err := checkName(entry.Name); err != nil {
+		log.Lvl2(err)
+		return false
+	}
+	if entry.SkipChainID.IsNull() {
+		log.Lvl2("name entry without a skipchain-ID")
+		return false
+	}
+	existing, err := s.lookupName(newSB.SkipChainID(), entry.Name)
+	if err != nil {
+		log.Lvl2(err)
+		return false
+	}
+	// The block itself is found when it is verified again once stored.
+	if existing != nil && existing.Index != newSB.Index {
+		log.Lvlf2("name %s is already registered", entry.Name)
+		return false
+	}
+	return true
+}
+
+// RegisterName adds a new name to the registry. It must be sent to the
+// leader of the registry.
+func (s *Service) RegisterName(req *RegisterName) (*RegisterNameReply, error) {
+	if err := checkName(req.Name); err != nil {
+		return nil, err
+	}
+	if req.SkipChainID.IsNull() {
+		return nil, xerrors.New("missing skipchain-ID")
+	}
+	s.storageMutex.Lock()
+	needAuth := len(s.Storage.Clients) > 0
+	s.storageMutex.Unlock()
+	if needAuth {
+		if req.Signature == nil {
+			return nil, xerrors.New("cannot register a name without authentication")
+		}
+		msg := NameRegistrationHash(req.Registry, req.Name, req.SkipChainID)
+		if !s.authenticate(msg, *req.Signature) {
+			return nil, xerrors.New("wrong signature for this name")
+		}
+	}
+
+	latest, err := s.db.GetLatestByID(req.Registry)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't find registry: %v", err)
+	}
+	if !latest.SkipChainID().Equal(req.Registry) {
+		return nil, xerrors.New("need the ID of the genesis block")
+	}
+	if !hasNameRegistry(latest) {
+		return nil, xerrors.New("skipchain is not a naming registry")
+	}
+
+	s.names.Lock()
+	defer s.names.Unlock()
+	existing, err := s.lookupName(req.Registry, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, xerrors.Errorf("name %s is already registered", req.Name)
+	}
+
+	data, err := network.Marshal(&NameEntry{Name: req.Name,
+		SkipChainID: req.SkipChainID})
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't marshal entry: %v", err)
+	}
+	sb := NewSkipBlock()
+	sb.Roster = latest.Roster
+	sb.Data = data
+	reply, err := s.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: req.Registry,
+		NewBlock:          sb,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't store name: %v", err)
+	}
+	log.Lvlf2("%s: registered name %s in block %d", s.ServerIdentity(),
+		req.Name, reply.Latest.Index)
+	return &RegisterNameReply{Block: reply.Latest}, nil
+}
+
+// ResolveName returns the oldest block of the registry holding the name,
+// with the forward-links from the genesis block to it.
+func (s *Service) ResolveName(req *ResolveName) (*ResolveNameReply, error) {
+	if err := checkName(req.Name); err != nil {
+		return nil, err
+	}
+	sb, err := s.lookupName(req.Registry, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil {
+		return nil, xerrors.Errorf("name %s is not registered", req.Name)
+	}
+	genesis := s.db.GetByID(req.Registry)
+	if genesis == nil {
+		return nil, xerrors.New("couldn't find genesis block of registry")
+	}
+	proof, err := s.GetProof(&GetProof{Genesis: req.Registry, Target: sb.Hash})
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get proof of name: %v", err)
+	}
+	return &ResolveNameReply{Genesis: genesis, Block: sb, Links: proof.Links}, nil
+}
+
+// Verify checks that the reply holds the name in a block of the registry,
+// reached by the forward-links from the genesis block of the registry.
+// As the registry refuses duplicate names, this is the only block holding
+// the name. It returns the ID of the skipchain registered under the name.
+func (r *ResolveNameReply) Verify(registry SkipBlockID, name string) (SkipBlockID, error) {
+	if r.Genesis == nil || !r.Genesis.Hash.Equal(registry) {
+		return nil, xerrors.New("got the wrong genesis block")
+	}
+	if !hasNameRegistry(r.Genesis) {
+		return nil, xerrors.New("skipchain is not a naming registry")
+	}
+	if err := VerifyProof(r.Genesis, r.Links, r.Block); err != nil {
+		return nil, xerrors.Errorf("invalid proof of name: %v", err)
+	}
+	entry := nameEntryFromBlock(r.Block)
+	if entry == nil || entry.Name != name {
+		return nil, xerrors.New("block doesn't hold the name")
+	}
+	return entry.SkipChainID, nil
+}
+
+// ParseSkipChainID returns the skipchain-ID if nameOrID is an hex-encoded
+// ID. Else it returns nil.
+func ParseSkipChainID(nameOrID string) SkipBlockID {
+	if len(nameOrID) != 2*sha256.Size {
+		return nil
+	}
+	id, err := hex.DecodeString(nameOrID)
+	if err != nil {
+		return nil
+	}
+	return id
+}
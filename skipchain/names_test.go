@@ -0,0 +1,132 @@
+package skipchain
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+)
+
+func TestCheckName(t *testing.T) {
+	for _, name := range []string{"a", "mychain", "my-chain.v2_1",
+		strings.Repeat("a", maxNameLength)} {
+		require.NoError(t, checkName(name), name)
+	}
+	for _, name := range []string{"", "1chain", "MyChain", "my chain", "-a",
+		strings.Repeat("a", maxNameLength+1)} {
+		require.Error(t, checkName(name), name)
+	}
+
+	id := SkipBlockID(make([]byte, 32))
+	id[0] = 0xab
+	require.Equal(t, id, ParseSkipChainID(hex.EncodeToString(id)))
+	require.Nil(t, ParseSkipChainID("ab"))
+	require.Nil(t, ParseSkipChainID(strings.Repeat("z", 64)))
+}
+
+func TestService_RegisterName(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	_, ro, genService := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := genService.(*Service)
+
+	registry, err := makeGenesisRosterArgs(service, ro, nil,
+		VerificationNameRegistry, 1, 1)
+	require.NoError(t, err)
+	chain1, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	chain2, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	// Only registries accept names.
+	_, err = service.RegisterName(&RegisterName{Registry: chain2.Hash,
+		Name: "one", SkipChainID: chain1.Hash})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not a naming registry")
+
+	_, err = service.RegisterName(&RegisterName{Registry: registry.Hash,
+		Name: "Invalid", SkipChainID: chain1.Hash})
+	require.Error(t, err)
+	_, err = service.ResolveName(&ResolveName{Registry: registry.Hash, Name: "one"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not registered")
+
+	reply, err := service.RegisterName(&RegisterName{Registry: registry.Hash,
+		Name: "one", SkipChainID: chain1.Hash})
+	require.NoError(t, err)
+	require.Equal(t, 1, reply.Block.Index)
+	_, err = service.RegisterName(&RegisterName{Registry: registry.Hash,
+		Name: "two", SkipChainID: chain2.Hash})
+	require.NoError(t, err)
+
+	// First come, first served.
+	_, err = service.RegisterName(&RegisterName{Registry: registry.Hash,
+		Name: "one", SkipChainID: chain2.Hash})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "already registered")
+
+	// The registry refuses duplicate names from any source.
+	data, err := network.Marshal(&NameEntry{Name: "one", SkipChainID: chain2.Hash})
+	require.NoError(t, err)
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	sb.Data = data
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: registry.Hash, NewBlock: sb})
+	require.Error(t, err)
+	sb = NewSkipBlock()
+	sb.Roster = ro
+	sb.Data = []byte("not a name")
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: registry.Hash, NewBlock: sb})
+	require.Error(t, err)
+
+	res, err := service.ResolveName(&ResolveName{Registry: registry.Hash, Name: "one"})
+	require.NoError(t, err)
+	require.Equal(t, 1, res.Block.Index)
+	id, err := res.Verify(registry.Hash, "one")
+	require.NoError(t, err)
+	require.Equal(t, chain1.Hash, id)
+	_, err = res.Verify(registry.Hash, "two")
+	require.Error(t, err)
+	_, err = res.Verify(chain1.Hash, "one")
+	require.Error(t, err)
+	res, err = service.ResolveName(&ResolveName{Registry: registry.Hash, Name: "two"})
+	require.NoError(t, err)
+	require.Equal(t, 2, res.Block.Index)
+
+	// A block that isn't linked from the genesis block is refused.
+	forged := res.Block.Copy()
+	forged.Data, err = network.Marshal(&NameEntry{Name: "two",
+		SkipChainID: chain1.Hash})
+	require.NoError(t, err)
+	forged.Hash = forged.CalculateHash()
+	res.Block = forged
+	_, err = res.Verify(registry.Hash, "two")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid proof")
+
+	// With linked clients, only they can register names.
+	kp := key.NewKeyPair(cothority.Suite)
+	service.Storage.Clients = []kyber.Point{kp.Public}
+	_, err = service.RegisterName(&RegisterName{Registry: registry.Hash,
+		Name: "three", SkipChainID: chain2.Hash})
+	require.Error(t, err)
+	sig, err := schnorr.Sign(cothority.Suite, kp.Private,
+		NameRegistrationHash(registry.Hash, "three", chain1.Hash))
+	require.NoError(t, err)
+	_, err = service.RegisterName(&RegisterName{Registry: registry.Hash,
+		Name: "three", SkipChainID: chain2.Hash, Signature: &sig})
+	require.Error(t, err)
+	_, err = service.RegisterName(&RegisterName{Registry: registry.Hash,
+		Name: "three", SkipChainID: chain1.Hash, Signature: &sig})
+	require.NoError(t, err)
+}
@@ -0,0 +1,132 @@
+package skipchain
+
+import (
+	"reflect"
+	"sync"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+)
+
+/*
+This file holds the notification of new blocks to external systems. A
+BlockNotifier, for example a producer of a Kafka or NATS client, is
+registered at the service and gets a BlockNotification for every block that
+is newly stored by this conode, so that indexers don't need to poll the
+service. The notifications are queued and delivered in order by a single
+go-routine, so a slow message bus never blocks the storage of blocks.
+*/
+
+// maxNotificationQueue is the maximum number of notifications waiting to be
+// delivered. Further notifications are dropped.
+const maxNotificationQueue = 1024
+
+// BlockNotification describes a block newly stored by the conode.
+type BlockNotification struct {
+	SkipChainID SkipBlockID
+	Index       int
+	Hash        SkipBlockID
+	// DataType is the name of the type of the data of the block, if it
+	// holds a registered network message, else it is empty.
+	DataType string
+}
+
+// BlockNotifier publishes the new blocks to an external system.
+type BlockNotifier interface {
+	Notify(n BlockNotification) error
+}
+
+// blockNotifiers holds the registered notifiers and the notifications not
+// yet delivered.
+type blockNotifiers struct {
+	sync.Mutex
+	notifiers map[string]BlockNotifier
+	queue     []BlockNotification
+	running   bool
+}
+
+// RegisterBlockNotifier adds a notifier that will get all blocks stored
+// from now on. A notifier registered with the same name is replaced.
+func (s *Service) RegisterBlockNotifier(name string, n BlockNotifier) {
+	s.notifiers.Lock()
+	defer s.notifiers.Unlock()
+	if s.notifiers.notifiers == nil {
+		s.notifiers.notifiers = make(map[string]BlockNotifier)
+	}
+	s.notifiers.notifiers[name] = n
+}
+
+// UnregisterBlockNotifier removes the notifier with the given name.
+func (s *Service) UnregisterBlockNotifier(name string) {
+	s.notifiers.Lock()
+	defer s.notifiers.Unlock()
+	delete(s.notifiers.notifiers, name)
+}
+
+// notifyNewBlocks queues the notifications of newly stored blocks.
+func (s *Service) notifyNewBlocks(sbs []*SkipBlock) {
+	s.notifiers.Lock()
+	defer s.notifiers.Unlock()
+	if len(s.notifiers.notifiers) == 0 {
+		return
+	}
+	for _, sb := range sbs {
+		if len(s.notifiers.queue) >= maxNotificationQueue {
+			log.Warnf("%s: notification queue is full, dropping block %x",
+				s.ServerIdentity(), sb.Hash)
+			continue
+		}
+		s.notifiers.queue = append(s.notifiers.queue,
+			newBlockNotification(sb))
+	}
+	if s.notifiers.running {
+		return
+	}
+	// The caller might hold the closedMutex, so the go-routine is not
+	// added to the working group.
+	s.notifiers.running = true
+	go s.deliverNotifications()
+}
+
+// deliverNotifications sends the queued notifications to all notifiers and
+// returns once the queue is empty.
+func (s *Service) deliverNotifications() {
+	for {
+		s.notifiers.Lock()
+		if len(s.notifiers.queue) == 0 {
+			s.notifiers.running = false
+			s.notifiers.Unlock()
+			return
+		}
+		n := s.notifiers.queue[0]
+		s.notifiers.queue = s.notifiers.queue[1:]
+		notifiers := make(map[string]BlockNotifier)
+		for name, nf := range s.notifiers.notifiers {
+			notifiers[name] = nf
+		}
+		s.notifiers.Unlock()
+
+		for name, nf := range notifiers {
+			if err := nf.Notify(n); err != nil {
+				log.Warnf("%s: notifier %s failed for block %x: %v",
+					s.ServerIdentity(), name, n.Hash, err)
+			}
+		}
+	}
+}
+
+func newBlockNotification(sb *SkipBlock) BlockNotification {
+	n := BlockNotification{
+		SkipChainID: sb.SkipChainID(),
+		Index:       sb.Index,
+		Hash:        sb.Hash,
+	}
+	if len(sb.Data) > 0 {
+		_, msg, err := network.Unmarshal(sb.Data, cothority.Suite)
+		if err == nil && msg != nil {
+			n.DataType = reflect.Indirect(reflect.ValueOf(msg)).Type().String()
+		}
+	}
+	return n
+}
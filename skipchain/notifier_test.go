@@ -0,0 +1,82 @@
+package skipchain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+type chanNotifier chan BlockNotification
+
+func (c chanNotifier) Notify(n BlockNotification) error {
+	c <- n
+	return nil
+}
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(n BlockNotification) error {
+	return errors.New("bus is down")
+}
+
+func TestService_BlockNotifier(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	servers, ro, genService := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := genService.(*Service)
+	follower := local.GetServices(servers, skipchainSID)[2].(*Service)
+
+	leaderCh := make(chanNotifier, 10)
+	followerCh := make(chanNotifier, 10)
+	service.RegisterBlockNotifier("test", leaderCh)
+	service.RegisterBlockNotifier("failing", failingNotifier{})
+	follower.RegisterBlockNotifier("test", followerCh)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	require.NoError(t, sb.SetRedactablePayload([]byte("data")))
+	reply, err := service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+
+	// The forward-link added to the genesis block doesn't trigger a second
+	// notification.
+	for _, ch := range []chanNotifier{leaderCh, followerCh} {
+		n := <-ch
+		require.Equal(t, genesis.Hash, n.SkipChainID)
+		require.Equal(t, genesis.Hash, n.Hash)
+		require.Equal(t, 0, n.Index)
+		require.Equal(t, "", n.DataType)
+		n = <-ch
+		require.Equal(t, genesis.Hash, n.SkipChainID)
+		require.Equal(t, reply.Latest.Hash, n.Hash)
+		require.Equal(t, 1, n.Index)
+		require.Equal(t, "skipchain.RedactableData", n.DataType)
+	}
+
+	service.UnregisterBlockNotifier("test")
+	sb = NewSkipBlock()
+	sb.Roster = ro
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+	n := <-followerCh
+	require.Equal(t, 2, n.Index)
+	select {
+	case n := <-leaderCh:
+		t.Fatalf("got notification %+v after unregistering", n)
+	case <-time.After(100 * time.Millisecond):
+	}
+	require.Equal(t, 0, len(followerCh))
+}
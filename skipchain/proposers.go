@@ -0,0 +1,85 @@
+package skipchain
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the access control on the proposers of new blocks. If the
+genesis block holds Proposers, the leader only appends a new block to the
+chain if the request is signed by one of the keys, as done by
+Client.StoreSkipBlockProposal. This is also true for the blocks added by
+the services, like anchors, roster changes and checkpoints, so that they are
+refused on such chains unless they are signed. The signature is on the latest
+block, the index and the data of the new block, so that it cannot be
+replayed.
+
+The leader stores the signature in the ProposerSignature of the new block.
+Chains with Proposers must use VerifyProposers, so that every node of the
+roster checks the signature before signing the forward-link.
+*/
+
+// proposalMsg returns the message a proposer signs to append a block with
+// the given data after the latest block.
+func proposalMsg(latest SkipBlockID, index int, data []byte) []byte {
+	msg := append([]byte("proposal:"), latest...)
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(index))
+	msg = append(msg, buf...)
+	h := sha256.Sum256(data)
+	return append(msg, h[:]...)
+}
+
+// signProposal returns the signature of the proposer to append a block with
+// the given data after the latest block.
+func signProposal(latest *SkipBlock, data []byte, priv kyber.Scalar) ([]byte, error) {
+	return schnorr.Sign(cothority.Suite, priv,
+		proposalMsg(latest.Hash, latest.Index+1, data))
+}
+
+// checkProposer returns an error if the chain restricts its proposers and
+// the ProposerSignature of the new block following prev is not by one of
+// them.
+func (s *Service) checkProposer(prev, prop *SkipBlock) error {
+	genesis := s.db.GetByID(prev.SkipChainID())
+	if genesis == nil {
+		return xerrors.New("unknown genesis block")
+	}
+	if len(genesis.Proposers) == 0 {
+		return nil
+	}
+	if len(prop.ProposerSignature) == 0 {
+		return xerrors.New("the chain only accepts signed proposals")
+	}
+	msg := proposalMsg(prev.Hash, prev.Index+1, prop.Data)
+	for _, key := range genesis.Proposers {
+		if schnorr.Verify(cothority.Suite, key, msg, prop.ProposerSignature) == nil {
+			return nil
+		}
+	}
+	return xerrors.New("proposal is not signed by an authorized proposer")
+}
+
+// verifyFuncProposers refuses blocks of chains with Proposers that are not
+// signed by one of them.
+func (s *Service) verifyFuncProposers(newID []byte, newSB *SkipBlock) bool {
+	if newSB.Index == 0 {
+		return true
+	}
+	prev := s.db.GetByID(newSB.BackLinkIDs[0])
+	if prev == nil {
+		return false
+	}
+	if err := s.checkProposer(prev, newSB); err != nil {
+		log.Lvl2(err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,95 @@
+package skipchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestService_StoreSkipBlockProposers(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	servers, ro, _ := l.GenTree(3, true)
+	defer l.CloseAll()
+	service := l.GetServices(servers, skipchainSID)[0].(*Service)
+	c := newTestClient(l)
+
+	proposer := key.NewKeyPair(cothority.Suite)
+	ct := TemplateStandard
+	ct.Proposers = []kyber.Point{proposer.Public}
+	_, err := c.CreateChainFromTemplate(ro, ct, nil)
+	require.Error(t, err)
+	ct.VerifierIDs = VerificationProposers
+	genesis, err := c.CreateChainFromTemplate(ro, ct, nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(genesis.Proposers))
+
+	_, err = c.StoreSkipBlock(genesis, nil, []byte("data"))
+	require.Error(t, err)
+	other := key.NewKeyPair(cothority.Suite)
+	_, err = c.StoreSkipBlockProposal(genesis, nil, []byte("data"),
+		other.Private)
+	require.Error(t, err)
+	reply, err := c.StoreSkipBlockProposal(genesis, nil, []byte("data"),
+		proposer.Private)
+	require.NoError(t, err)
+	require.Equal(t, 1, reply.Latest.Index)
+	require.Nil(t, reply.Latest.Proposers)
+	require.NotEmpty(t, reply.Latest.ProposerSignature)
+	latest := reply.Latest
+
+	// Every node of the roster checks the signature, not only the leader.
+	forged := latest.Copy()
+	forged.Index++
+	forged.BackLinkIDs = []SkipBlockID{latest.Hash}
+	forged.Data = []byte("forged")
+	forged.ProposerSignature = nil
+	forged.updateHash()
+	follower := l.GetServices(servers, skipchainSID)[1].(*Service)
+	require.False(t, follower.verifyFuncProposers(forged.Hash, forged))
+	forged.ProposerSignature = latest.ProposerSignature
+	forged.updateHash()
+	require.False(t, follower.verifyFuncProposers(forged.Hash, forged))
+	sig, err := signProposal(latest, forged.Data, proposer.Private)
+	require.NoError(t, err)
+	forged.ProposerSignature = sig
+	forged.updateHash()
+	require.True(t, follower.verifyFuncProposers(forged.Hash, forged))
+
+	// A proposal cannot be replayed, nor used for other data.
+	_, err = c.StoreSkipBlockProposal(genesis, nil, []byte("data"),
+		proposer.Private)
+	require.Error(t, err)
+	sig, err = signProposal(latest, []byte("data"), proposer.Private)
+	require.NoError(t, err)
+	store := func(data string) error {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Data = []byte(data)
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash, NewBlock: sb,
+			ProposerSignature: sig})
+		return err
+	}
+	require.Error(t, store("other data"))
+	require.NoError(t, store("data"))
+	require.Error(t, store("data"))
+
+	// The services on the conode need to be signed, too.
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	_, err = service.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash, NewBlock: sb})
+	require.Error(t, err)
+	_, err = c.AnchorData(ro, genesis, make([]byte, AnchorDigestSize), nil)
+	require.Error(t, err)
+
+	// Chains without proposers accept unsigned blocks.
+	genesis, err = c.CreateGenesis(ro, 2, 3, VerificationStandard, nil)
+	require.NoError(t, err)
+	_, err = c.StoreSkipBlock(genesis, nil, []byte("data"))
+	require.NoError(t, err)
+}
@@ -275,15 +275,16 @@ func (p *GetBlocks) HandleGetBlocks(msg ProtoStructGetBlocks) error {
 		n--
 
 		// Find the next one (or exit if we are at the latest)
-		if len(s.ForwardLink) == 0 {
+		fls := s.forwardLinks()
+		if len(fls) == 0 {
 			break
 		}
 
 		linkNum := 0
 		if msg.Skipping {
-			linkNum = len(s.ForwardLink) - 1
+			linkNum = len(fls) - 1
 		}
-		next = s.ForwardLink[linkNum].To
+		next = fls[linkNum].To
 	}
 	log.Lvlf2("%v: GetBlocks replies to %s: %v blocks, last index %v",
 		p.ServerIdentity(), msg.ServerIdentity, len(result), lastIdx)
@@ -0,0 +1,456 @@
+package skipchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"go.dedis.ch/protobuf"
+	bbolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the pruning and the archival of blocks. The Data of a block
+is part of its hash and must be kept to verify the chain, but the Payload is
+not, so it can be dropped without breaking the hashes, the links or the
+signatures. Pruning is enabled per chain, and only for chains whose verifiers
+don't need the payloads. A background job then drops the payloads of all
+blocks of these chains older than the horizon, and records the digest of
+every dropped payload. Full blocks can be exported to an Archive before, and later be
+imported again: the payloads of pruned blocks are only restored if they match
+the recorded digest.
+*/
+
+// maxArchiveBlocks is the maximum number of blocks in an archive.
+const maxArchiveBlocks = 1000
+
+// pruneBatchSize is the maximum number of blocks pruned in one transaction.
+// It is not a constant so that the tests can change it.
+var pruneBatchSize = 100
+
+// prunableVerifiers are the verifiers that don't need the payloads of the
+// blocks. Other verifiers, like the one of byzcoin, replay the payloads
+// during catch-up, so their chains must not be pruned.
+var prunableVerifiers = []VerifierID{VerifyBase, VerifyRedactable}
+
+func init() {
+	network.RegisterMessages(&Pruned{}, &Archive{})
+}
+
+// Pruned records the deletion of the payload of an old block.
+type Pruned struct {
+	// Digest is the sha256 of the payload.
+	Digest []byte
+	// Timestamp in nanoseconds since the epoch.
+	Timestamp int64
+}
+
+// Archive holds full blocks of a skipchain, sorted by index, to be kept in
+// cold storage.
+type Archive struct {
+	SkipChainID SkipBlockID
+	Blocks      []*SkipBlock
+}
+
+// skipBlockPayloadShort is used to find the blocks of a chain and their
+// payloads without decoding all of them.
+type skipBlockPayloadShort struct {
+	Index     int    `protobuf:"1"`
+	GenesisID []byte `protobuf:"7"`
+	Hash      []byte `protobuf:"10"`
+	Payload   []byte `protobuf:"12,opt"`
+}
+
+// chainID returns the ID of the skipchain of the block.
+func (sbs *skipBlockPayloadShort) chainID() SkipBlockID {
+	if sbs.Index == 0 {
+		return sbs.Hash
+	}
+	return sbs.GenesisID
+}
+
+// prunedBucket returns the name of the bucket of the pruned blocks.
+func (db *SkipBlockDB) prunedBucket() []byte {
+	return append(append([]byte{}, db.bucketName...), []byte("_pruned")...)
+}
+
+// Prune drops the payloads of all blocks of the skipchain except the latest
+// horizon blocks, and returns the number of pruned blocks. The blocks are
+// pruned in transactions of at most pruneBatchSize blocks, so that the
+// database is not blocked while pruning a long chain.
+func (db *SkipBlockDB) Prune(scID SkipBlockID, horizon int) (int, error) {
+	if horizon < 1 {
+		return 0, xerrors.New("horizon must be at least 1")
+	}
+	latest := -1
+	var candidates []skipBlockPayloadShort
+	err := db.View(func(tx *bbolt.Tx) error {
+		return db.forEachChainBlockTx(tx, scID, func(k, v []byte) error {
+			var sbs skipBlockPayloadShort
+			if err := protobuf.Decode(v[16:], &sbs); err != nil {
+				return err
+			}
+			if sbs.Index > latest {
+				latest = sbs.Index
+			}
+			if len(sbs.Payload) > 0 {
+				// Only keep what is needed, as the decoded slices
+				// point to the memory of the transaction.
+				candidates = append(candidates, skipBlockPayloadShort{
+					Index: sbs.Index,
+					Hash:  append([]byte{}, sbs.Hash...),
+				})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+	var ids []SkipBlockID
+	for _, sbs := range candidates {
+		if sbs.Index <= latest-horizon {
+			ids = append(ids, sbs.Hash)
+		}
+	}
+
+	pruned := 0
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > pruneBatchSize {
+			batch = batch[:pruneBatchSize]
+		}
+		ids = ids[len(batch):]
+		err := db.Update(func(tx *bbolt.Tx) error {
+			b, err := tx.CreateBucketIfNotExists(db.prunedBucket())
+			if err != nil {
+				return err
+			}
+			now := time.Now().UnixNano()
+			for _, id := range batch {
+				sb, err := db.getFromTx(tx, id)
+				if err != nil {
+					return err
+				}
+				// The block might have been removed or pruned since it
+				// was selected.
+				if sb == nil || len(sb.Payload) == 0 {
+					continue
+				}
+				digest := sha256.Sum256(sb.Payload)
+				buf, err := protobuf.Encode(&Pruned{Digest: digest[:],
+					Timestamp: now})
+				if err != nil {
+					return err
+				}
+				if err := b.Put(sb.Hash, buf); err != nil {
+					return err
+				}
+				sb.Payload = nil
+				if err := db.storeToTx(tx, sb); err != nil {
+					return err
+				}
+				pruned++
+			}
+			return nil
+		})
+		if err != nil {
+			return pruned, err
+		}
+	}
+	return pruned, nil
+}
+
+// GetPruned returns the record of the pruning of the block, or nil if its
+// payload has not been pruned.
+func (db *SkipBlockDB) GetPruned(id SkipBlockID) (*Pruned, error) {
+	var p *Pruned
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(db.prunedBucket())
+		if b == nil {
+			return nil
+		}
+		buf := b.Get(id)
+		if buf == nil {
+			return nil
+		}
+		p = &Pruned{}
+		return protobuf.Decode(buf, p)
+	})
+	return p, err
+}
+
+// deletePrunedTx removes the record of the pruning of the block, if any.
+func (db *SkipBlockDB) deletePrunedTx(tx *bbolt.Tx, id SkipBlockID) error {
+	b := tx.Bucket(db.prunedBucket())
+	if b == nil {
+		return nil
+	}
+	return b.Delete(id)
+}
+
+// ArchiveExport returns the stored blocks of the skipchain with an index
+// between from and to, both included. Blocks whose payload has already been
+// pruned are exported without it.
+func (db *SkipBlockDB) ArchiveExport(scID SkipBlockID, from, to int) (*Archive, error) {
+	if from < 0 || to < from {
+		return nil, xerrors.Errorf("invalid range %d-%d", from, to)
+	}
+	if to-from >= maxArchiveBlocks {
+		return nil, xerrors.Errorf("cannot export more than %d blocks",
+			maxArchiveBlocks)
+	}
+	a := &Archive{SkipChainID: scID}
+	err := db.View(func(tx *bbolt.Tx) error {
+		return db.forEachChainBlockTx(tx, scID, func(k, v []byte) error {
+			var sbs skipBlockPayloadShort
+			if err := protobuf.Decode(v[16:], &sbs); err != nil {
+				return err
+			}
+			if sbs.Index < from || sbs.Index > to {
+				return nil
+			}
+			sb, err := db.getFromTx(tx, sbs.Hash)
+			if err != nil {
+				return err
+			}
+			a.Blocks = append(a.Blocks, sb)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(a.Blocks, func(i, j int) bool {
+		return a.Blocks[i].Index < a.Blocks[j].Index
+	})
+	return a, nil
+}
+
+// ArchiveImport restores the payloads of the pruned blocks found in the
+// archive and returns the number of restored blocks. Every block of the
+// archive must match its hash, and the payloads must match the digests
+// recorded when pruning. Blocks that are unknown or have not been pruned
+// are ignored.
+func (db *SkipBlockDB) ArchiveImport(a *Archive) (int, error) {
+	for _, sb := range a.Blocks {
+		if !sb.CalculateHash().Equal(sb.Hash) {
+			return 0, xerrors.Errorf("block %x doesn't match its hash", sb.Hash)
+		}
+		if !sb.SkipChainID().Equal(a.SkipChainID) {
+			return 0, xerrors.Errorf("block %x is not part of the skipchain",
+				sb.Hash)
+		}
+	}
+	restored := 0
+	err := db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(db.prunedBucket())
+		if b == nil {
+			return nil
+		}
+		for _, sb := range a.Blocks {
+			buf := b.Get(sb.Hash)
+			if buf == nil || len(sb.Payload) == 0 {
+				continue
+			}
+			var p Pruned
+			if err := protobuf.Decode(buf, &p); err != nil {
+				return err
+			}
+			digest := sha256.Sum256(sb.Payload)
+			if !bytes.Equal(p.Digest, digest[:]) {
+				return xerrors.Errorf("payload of block %x doesn't match "+
+					"the pruned one", sb.Hash)
+			}
+			stored, err := db.getFromTx(tx, sb.Hash)
+			if err != nil {
+				return err
+			}
+			if stored == nil {
+				continue
+			}
+			stored.Payload = sb.Payload
+			if err := db.storeToTx(tx, stored); err != nil {
+				return err
+			}
+			if err := b.Delete(sb.Hash); err != nil {
+				return err
+			}
+			restored++
+		}
+		return nil
+	})
+	return restored, err
+}
+
+// pruner holds the state of the background pruning.
+type pruner struct {
+	sync.Mutex
+	stop      chan bool
+	chains    []SkipBlockID
+	horizon   int
+	passes    int
+	pruned    int
+	lastError string
+	lastPass  time.Time
+}
+
+// GetStatus implements onet.StatusReporter.
+func (p *pruner) GetStatus() *onet.Status {
+	p.Lock()
+	defer p.Unlock()
+	out := map[string]string{
+		"Running":   strconv.FormatBool(p.stop != nil),
+		"Chains":    strconv.Itoa(len(p.chains)),
+		"Horizon":   strconv.Itoa(p.horizon),
+		"Passes":    strconv.Itoa(p.passes),
+		"Pruned":    strconv.Itoa(p.pruned),
+		"LastError": p.lastError,
+	}
+	if !p.lastPass.IsZero() {
+		out["LastPass"] = p.lastPass.Format(time.RFC3339)
+	}
+	return &onet.Status{Field: out}
+}
+
+// SetPruning starts the background pruning of the payloads of the given
+// chains, keeping the payloads of the latest horizon blocks of every chain.
+// The chains must only use the verifiers in prunableVerifiers. A pass runs
+// every interval. A horizon of 0 stops it.
+func (s *Service) SetPruning(chains []SkipBlockID, horizon int, interval time.Duration) error {
+	if horizon > 0 && interval <= 0 {
+		return xerrors.New("interval must be positive")
+	}
+	if horizon > 0 {
+		for _, scID := range chains {
+			if err := s.checkPrunable(scID); err != nil {
+				return xerrors.Errorf("cannot prune %x: %v", scID, err)
+			}
+		}
+	}
+	s.pruning.Lock()
+	defer s.pruning.Unlock()
+	if s.pruning.stop != nil {
+		close(s.pruning.stop)
+		s.pruning.stop = nil
+	}
+	s.pruning.horizon = horizon
+	s.pruning.chains = nil
+	if horizon <= 0 {
+		return nil
+	}
+	chains = append([]SkipBlockID{}, chains...)
+	s.pruning.chains = chains
+	if err := s.incrementWorking(); err != nil {
+		return err
+	}
+	stop := make(chan bool)
+	s.pruning.stop = stop
+	go func() {
+		defer s.decrementWorking()
+		for {
+			s.prunePass(chains, horizon)
+			select {
+			case <-time.After(interval):
+			case <-stop:
+				return
+			case <-s.closing:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// checkPrunable returns an error if the payloads of the skipchain must be
+// kept.
+func (s *Service) checkPrunable(scID SkipBlockID) error {
+	genesis := s.db.GetByID(scID)
+	if genesis == nil || genesis.Index != 0 {
+		return xerrors.New("unknown skipchain")
+	}
+	for _, ver := range genesis.VerifierIDs {
+		found := false
+		for _, pv := range prunableVerifiers {
+			if ver.Equal(pv) {
+				found = true
+			}
+		}
+		if !found {
+			return xerrors.Errorf("verifier %s needs the payloads", ver)
+		}
+	}
+	return nil
+}
+
+// prunePass prunes the chains once.
+func (s *Service) prunePass(chains []SkipBlockID, horizon int) {
+	var err error
+	pruned := 0
+	for _, scID := range chains {
+		var n int
+		n, err = s.db.Prune(scID, horizon)
+		pruned += n
+		if err != nil {
+			break
+		}
+	}
+
+	s.pruning.Lock()
+	defer s.pruning.Unlock()
+	s.pruning.passes++
+	s.pruning.pruned += pruned
+	s.pruning.lastPass = time.Now()
+	s.pruning.lastError = ""
+	if err != nil {
+		log.Errorf("%s: couldn't prune blocks: %v", s.ServerIdentity(), err)
+		s.pruning.lastError = err.Error()
+	}
+}
+
+// ArchiveExport returns the full blocks of a skipchain, so that they can be
+// kept in cold storage before their payloads are pruned.
+func (s *Service) ArchiveExport(req *ArchiveExport) (*ArchiveExportReply, error) {
+	a, err := s.db.ArchiveExport(req.SkipChainID, req.From, req.To)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't export blocks: %v", err)
+	}
+	return &ArchiveExportReply{Archive: a}, nil
+}
+
+// ArchiveImport restores the pruned payloads from an archive. The request
+// must be signed by a linked client or by the conode.
+func (s *Service) ArchiveImport(req *ArchiveImport) (*ArchiveImportReply, error) {
+	if req.Archive == nil {
+		return nil, xerrors.New("missing archive")
+	}
+	if !s.verifyAdminSigs(archiveImportMsg(req.Archive), req.Signature) {
+		return nil, xerrors.New("wrong signature of unknown signer")
+	}
+	n, err := s.db.ArchiveImport(req.Archive)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't import archive: %v", err)
+	}
+	return &ArchiveImportReply{Restored: n}, nil
+}
+
+// archiveImportMsg returns the message to be signed by a linked client to
+// import an archive.
+func archiveImportMsg(a *Archive) []byte {
+	h := sha256.New()
+	h.Write(a.SkipChainID)
+	for _, sb := range a.Blocks {
+		h.Write(sb.Hash)
+		binary.Write(h, binary.LittleEndian, uint64(len(sb.Payload)))
+		h.Write(sb.Payload)
+	}
+	return append([]byte("archiveimport:"), h.Sum(nil)...)
+}
@@ -0,0 +1,148 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_PruneArchive(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil,
+		[]VerifierID{VerifyBase, VerifyRedactable}, 1, 1)
+	require.NoError(t, err)
+	var ids []SkipBlockID
+	for i := 0; i < 4; i++ {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		require.NoError(t, sb.SetRedactablePayload([]byte{byte(i + 1)}))
+		reply, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+		ids = append(ids, reply.Latest.Hash)
+	}
+
+	archive, err := c.ArchiveExport(ro.List[0], genesis.Hash, 1, 4)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(archive.Blocks))
+	require.Equal(t, []byte{1}, archive.Blocks[0].RedactablePayload())
+	_, err = c.ArchiveExport(ro.List[0], genesis.Hash, 0, maxArchiveBlocks)
+	require.Error(t, err)
+
+	// Only the payloads of the blocks 1 and 2 are pruned, one per
+	// transaction.
+	defer func(size int) { pruneBatchSize = size }(pruneBatchSize)
+	pruneBatchSize = 1
+	_, err = service.db.Prune(genesis.Hash, 0)
+	require.Error(t, err)
+	n, err := service.db.Prune(genesis.Hash, 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	n, err = service.db.Prune(genesis.Hash, 2)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	for i, id := range ids {
+		sb := service.db.GetByID(id)
+		p, err := service.db.GetPruned(id)
+		require.NoError(t, err)
+		if i < 2 {
+			require.Equal(t, 0, len(sb.Payload))
+			require.NotNil(t, p)
+		} else {
+			require.Equal(t, 1, len(sb.RedactablePayload()))
+			require.Nil(t, p)
+		}
+	}
+	sbs, err := c.GetUpdateChain(ro, genesis.Hash)
+	require.NoError(t, err)
+	for _, sb := range sbs.Update {
+		require.NoError(t, sb.VerifyForwardSignatures())
+	}
+
+	kp := key.NewKeyPair(cothority.Suite)
+	for _, s := range l.GetServices(servers, skipchainSID) {
+		s.(*Service).Storage.Clients = []kyber.Point{kp.Public}
+	}
+	other := key.NewKeyPair(cothority.Suite)
+	_, err = c.ArchiveImport(ro.List[0], other.Private, archive)
+	require.Error(t, err)
+
+	// A payload that doesn't match the pruned one is refused.
+	wrong := &Archive{SkipChainID: genesis.Hash,
+		Blocks: []*SkipBlock{archive.Blocks[0].Copy()}}
+	wrong.Blocks[0].Payload = []byte("other data")
+	_, err = c.ArchiveImport(ro.List[0], kp.Private, wrong)
+	require.Error(t, err)
+
+	n, err = c.ArchiveImport(ro.List[0], kp.Private, archive)
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.Equal(t, []byte{1}, service.db.GetByID(ids[0]).RedactablePayload())
+	p, err := service.db.GetPruned(ids[0])
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	// A redacted payload is not restored.
+	_, err = service.db.Prune(genesis.Hash, 2)
+	require.NoError(t, err)
+	require.NoError(t, service.db.Redact(ids[0], "request of the owner"))
+	n, err = c.ArchiveImport(ro.List[0], kp.Private, archive)
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.Equal(t, 0, len(service.db.GetByID(ids[0]).Payload))
+}
+
+func TestService_SetPruning(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 2, skipchainSID)
+	service := gs.(*Service)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	sb.Payload = []byte("old payload")
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+	sb = NewSkipBlock()
+	sb.Roster = ro
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+
+	// Chains whose verifiers need the payloads are not pruned.
+	registry, err := makeGenesisRosterArgs(service, ro, nil,
+		VerificationNameRegistry, 1, 1)
+	require.NoError(t, err)
+	chains := []SkipBlockID{genesis.Hash}
+	require.Error(t, service.SetPruning(append(chains, registry.Hash), 1,
+		10*time.Millisecond))
+	require.Error(t, service.SetPruning(chains, 1, 0))
+
+	require.NoError(t, service.SetPruning(chains, 1, 10*time.Millisecond))
+	require.Eventually(t, func() bool {
+		return service.pruning.GetStatus().Field["Pruned"] == "1"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, service.SetPruning(nil, 0, 0))
+	require.Equal(t, "false", service.pruning.GetStatus().Field["Running"])
+}
@@ -0,0 +1,152 @@
+package skipchain
+
+import (
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+// reconcileBatch is the number of blocks fetched at once while reconciling.
+const reconcileBatch = 100
+
+// GetDBSummary returns the summary of all skipchains stored on this conode.
+func (s *Service) GetDBSummary(req *GetDBSummary) (*GetDBSummaryReply, error) {
+	chains, err := s.db.summary()
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get summary: %v", err)
+	}
+	return &GetDBSummaryReply{Chains: chains}, nil
+}
+
+// ReconcileDB compares the database of this conode with the one of the peer
+// and fetches the blocks this conode is missing. Only chains where the peer
+// has a higher index or more blocks are fetched, starting at the first block
+// missing locally. New chains are only fetched if this conode follows them,
+// as the blocks of an unknown chain can only be checked against each other,
+// and the peer could make up a chain. All blocks are verified before they
+// are stored.
+// The request must be signed by a linked client or by this conode.
+func (s *Service) ReconcileDB(req *ReconcileDB) (*ReconcileDBReply, error) {
+	if req.Peer == nil {
+		return nil, xerrors.New("missing peer")
+	}
+	if !s.verifyAdminSigs(reconcileDBMsg(req.Peer), req.Signature) {
+		return nil, xerrors.New("wrong signature of unknown signer")
+	}
+	if req.Peer.Equal(s.ServerIdentity()) {
+		return nil, xerrors.New("cannot reconcile with itself")
+	}
+	if err := s.incrementWorking(); err != nil {
+		return nil, err
+	}
+	defer s.decrementWorking()
+
+	remote := &GetDBSummaryReply{}
+	cl := NewClient()
+	defer cl.Close()
+	if err := cl.SendProtobuf(req.Peer, &GetDBSummary{}, remote); err != nil {
+		return nil, xerrors.Errorf("couldn't get summary of peer: %v", err)
+	}
+	local, err := s.db.summary()
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get summary: %v", err)
+	}
+	known := make(map[string]ChainSummary)
+	for _, cs := range local {
+		known[string(cs.SkipChainID)] = cs
+	}
+
+	roster := onet.NewRoster([]*network.ServerIdentity{req.Peer})
+	before := s.db.Length()
+	reply := &ReconcileDBReply{}
+	for _, rcs := range remote.Chains {
+		if s.chainIsDeleted(rcs.SkipChainID) {
+			continue
+		}
+		lcs, ok := known[string(rcs.SkipChainID)]
+		if ok && lcs.Index >= rcs.Index && lcs.Blocks >= rcs.Blocks {
+			continue
+		}
+		if err := s.reconcileChain(roster, rcs.SkipChainID, ok); err != nil {
+			log.Warnf("%s: couldn't reconcile chain %x: %v",
+				s.ServerIdentity(), rcs.SkipChainID, err)
+			reply.Failed++
+			continue
+		}
+		reply.Chains++
+	}
+	reply.Blocks = s.db.Length() - before
+	log.Lvlf2("%s: reconciled %d chains with %s", s.ServerIdentity(),
+		reply.Chains, req.Peer)
+	return reply, nil
+}
+
+// reconcileChain fetches the blocks of the chain from the first one that
+// is missing locally up to the latest block of the roster. If the chain is
+// not known yet, it is only fetched if this conode follows it.
+func (s *Service) reconcileChain(roster *onet.Roster, scID SkipBlockID, known bool) error {
+	if !known && !s.followsChain(scID) {
+		return xerrors.New("chain is not known and not followed")
+	}
+	from := s.firstMissing(scID)
+	for {
+		blocks, err := s.getBlocksSkipping(roster, from, reconcileBatch, false)
+		if err != nil {
+			return err
+		}
+		if len(blocks) == 0 {
+			return xerrors.New("peer didn't return any block")
+		}
+		if _, err := s.db.StoreBlocks(blocks); err != nil {
+			return xerrors.Errorf("couldn't store blocks: %v", err)
+		}
+		last := blocks[len(blocks)-1]
+		if len(blocks) == 1 || last.GetForwardLen() == 0 {
+			return nil
+		}
+		from = last.Hash
+	}
+}
+
+// followsChain returns true if the chain is in the chains followed by this
+// conode.
+func (s *Service) followsChain(scID SkipBlockID) bool {
+	s.storageMutex.Lock()
+	defer s.storageMutex.Unlock()
+	for _, id := range s.Storage.FollowIDs {
+		if id.Equal(scID) {
+			return true
+		}
+	}
+	for _, fct := range s.Storage.Follow {
+		if fct.Block.SkipChainID().Equal(scID) {
+			return true
+		}
+	}
+	return false
+}
+
+// firstMissing follows the level-0 forward-links from the genesis block and
+// returns the ID of the last block before a missing one. If the genesis block
+// is missing, the skipchain-ID is returned.
+func (s *Service) firstMissing(scID SkipBlockID) SkipBlockID {
+	sb := s.db.GetByID(scID)
+	if sb == nil {
+		return scID
+	}
+	for fl := sb.GetForward(0); fl != nil; fl = sb.GetForward(0) {
+		next := s.db.GetByID(fl.To)
+		if next == nil {
+			break
+		}
+		sb = next
+	}
+	return sb.Hash
+}
+
+// reconcileDBMsg returns the message to be signed by a linked client to
+// reconcile the database with the peer.
+func reconcileDBMsg(peer *network.ServerIdentity) []byte {
+	return append([]byte("reconciledb:"), peer.ID[:]...)
+}
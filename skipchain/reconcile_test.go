@@ -0,0 +1,116 @@
+package skipchain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/sign/schnorr"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestService_ReconcileDB(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	servers, ro, genService := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	leader := genService.(*Service)
+	follower := local.GetServices(servers, skipchainSID)[2].(*Service)
+
+	chain1, err := makeGenesisRosterArgs(leader, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	blocks := []*SkipBlock{chain1}
+	for i := 0; i < 4; i++ {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Data = []byte{byte(i)}
+		reply, err := leader.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: chain1.Hash, NewBlock: sb})
+		require.NoError(t, err)
+		blocks = append(blocks, reply.Latest)
+	}
+	chain2, err := makeGenesisRosterArgs(leader, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	summary, err := leader.GetDBSummary(&GetDBSummary{})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(summary.Chains))
+	for _, cs := range summary.Chains {
+		if cs.SkipChainID.Equal(chain1.Hash) {
+			require.Equal(t, 4, cs.Index)
+			require.Equal(t, 5, cs.Blocks)
+			require.Equal(t, blocks[4].Hash, cs.Latest)
+		}
+	}
+
+	// Make holes in the database of the follower and remove one chain.
+	require.NoError(t, follower.db.RemoveBlock(blocks[2].Hash))
+	require.NoError(t, follower.db.RemoveBlock(blocks[4].Hash))
+	require.NoError(t, follower.db.RemoveSkipchain(chain2.Hash))
+	require.Nil(t, follower.db.GetByID(chain2.Hash))
+
+	// Without linked clients, only the conode itself can reconcile.
+	_, err = follower.ReconcileDB(&ReconcileDB{Peer: leader.ServerIdentity()})
+	require.Error(t, err)
+	ownSig, err := schnorr.Sign(cothority.Suite, local.GetPrivate(servers[2]),
+		reconcileDBMsg(leader.ServerIdentity()))
+	require.NoError(t, err)
+	req := &ReconcileDB{Peer: leader.ServerIdentity(), Signature: ownSig}
+	reply, err := follower.ReconcileDB(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, reply.Chains)
+	require.Equal(t, 1, reply.Failed)
+	require.Equal(t, 2, reply.Blocks)
+	for _, sb := range blocks {
+		require.NotNil(t, follower.db.GetByID(sb.Hash))
+	}
+
+	// Unknown chains are not fetched, even if this conode is in their
+	// roster, unless they are followed.
+	require.Nil(t, follower.db.GetByID(chain2.Hash))
+	follower.Storage.FollowIDs = []SkipBlockID{chain2.Hash}
+	reply, err = follower.ReconcileDB(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, reply.Chains)
+	require.Equal(t, 0, reply.Failed)
+	require.Equal(t, 1, reply.Blocks)
+	require.NotNil(t, follower.db.GetByID(chain2.Hash))
+
+	// Nothing to do the second time.
+	reply, err = follower.ReconcileDB(req)
+	require.NoError(t, err)
+	require.Equal(t, 0, reply.Chains)
+	require.Equal(t, 0, reply.Blocks)
+
+	_, err = follower.ReconcileDB(&ReconcileDB{Peer: follower.ServerIdentity()})
+	require.Error(t, err)
+
+	// Chains without this conode are also fetched if they are followed.
+	others, err := makeGenesisRosterArgs(leader, onet.NewRoster(ro.List[:2]),
+		nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	reply, err = follower.ReconcileDB(req)
+	require.NoError(t, err)
+	require.Equal(t, 0, reply.Chains)
+	require.Equal(t, 1, reply.Failed)
+	require.Nil(t, follower.db.GetByID(others.Hash))
+	follower.Storage.FollowIDs = append(follower.Storage.FollowIDs, others.Hash)
+	reply, err = follower.ReconcileDB(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, reply.Chains)
+	require.NotNil(t, follower.db.GetByID(others.Hash))
+
+	// With linked clients, the request must be signed.
+	kp := key.NewKeyPair(cothority.Suite)
+	follower.Storage.Clients = []kyber.Point{kp.Public}
+	_, err = follower.ReconcileDB(&ReconcileDB{Peer: leader.ServerIdentity()})
+	require.Error(t, err)
+	sig, err := schnorr.Sign(cothority.Suite, kp.Private,
+		reconcileDBMsg(leader.ServerIdentity()))
+	require.NoError(t, err)
+	_, err = follower.ReconcileDB(&ReconcileDB{Peer: leader.ServerIdentity(),
+		Signature: sig})
+	require.NoError(t, err)
+}
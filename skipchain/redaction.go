@@ -0,0 +1,219 @@
+package skipchain
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"time"
+
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3/network"
+	"go.dedis.ch/protobuf"
+	bbolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the redaction of blocks. A redactable block only commits to
+the hash of its salted payload: the Data of the block holds a RedactableData
+with the digest of the salt and the payload, and both are stored in the
+Payload of the block, which is not part of the hash. The random salt makes
+sure that a payload with little entropy cannot be found back from the digest
+once it has been redacted. On request of a linked client, a conode deletes
+the salt and the payload and records the redaction, while the hash, the
+links and the signatures of the block still verify.
+*/
+
+// maxRedactionReason is the maximum size of the reason of a redaction.
+const maxRedactionReason = 1024
+
+// redactionSaltSize is the size of the salt stored before the payload.
+const redactionSaltSize = 32
+
+func init() {
+	network.RegisterMessages(&RedactableData{}, &Redaction{})
+}
+
+// RedactableData is stored in the data of a redactable block.
+type RedactableData struct {
+	// Digest is the sha256 of the salt followed by the payload.
+	Digest []byte
+}
+
+// Redaction records the deletion of the payload of a block.
+type Redaction struct {
+	Reason string
+	// Timestamp in nanoseconds since the epoch.
+	Timestamp int64
+}
+
+// SetRedactablePayload stores a new salt and the payload in the block and
+// their digest in the data, so that the payload can be redacted later.
+func (sb *SkipBlock) SetRedactablePayload(payload []byte) error {
+	salted := make([]byte, redactionSaltSize, redactionSaltSize+len(payload))
+	if _, err := rand.Read(salted); err != nil {
+		return xerrors.Errorf("couldn't create salt: %v", err)
+	}
+	salted = append(salted, payload...)
+	digest := sha256.Sum256(salted)
+	data, err := network.Marshal(&RedactableData{Digest: digest[:]})
+	if err != nil {
+		return xerrors.Errorf("couldn't marshal data: %v", err)
+	}
+	sb.Data = data
+	sb.Payload = salted
+	return nil
+}
+
+// RedactablePayload returns the payload of a redactable block without its
+// salt, or nil if the block is not redactable or the payload is missing.
+func (sb *SkipBlock) RedactablePayload() []byte {
+	if RedactableDataFromBlock(sb) == nil || len(sb.Payload) < redactionSaltSize {
+		return nil
+	}
+	return sb.Payload[redactionSaltSize:]
+}
+
+// RedactableDataFromBlock returns the data of a redactable block, or nil if
+// the block is not redactable.
+func RedactableDataFromBlock(sb *SkipBlock) *RedactableData {
+	if len(sb.Data) == 0 {
+		return nil
+	}
+	_, msg, err := network.Unmarshal(sb.Data, cothority.Suite)
+	if err != nil {
+		return nil
+	}
+	rd, ok := msg.(*RedactableData)
+	if !ok {
+		return nil
+	}
+	return rd
+}
+
+// VerifyPayload returns an error if the block is redactable and its salted
+// payload doesn't match the digest. An empty payload is accepted, as it might
+// have been redacted.
+func (sb *SkipBlock) VerifyPayload() error {
+	rd := RedactableDataFromBlock(sb)
+	if rd == nil || len(sb.Payload) == 0 {
+		return nil
+	}
+	digest := sha256.Sum256(sb.Payload)
+	if !bytes.Equal(rd.Digest, digest[:]) {
+		return xerrors.New("payload doesn't match the digest")
+	}
+	return nil
+}
+
+// verifyFuncRedactable refuses new redactable blocks without a salt or with
+// a payload that doesn't match the digest.
+func (s *Service) verifyFuncRedactable(newID []byte, newSB *SkipBlock) bool {
+	if RedactableDataFromBlock(newSB) == nil {
+		return true
+	}
+	return len(newSB.Payload) > redactionSaltSize && newSB.VerifyPayload() == nil
+}
+
+// redactionBucket returns the name of the bucket of the redactions.
+func (db *SkipBlockDB) redactionBucket() []byte {
+	return append(append([]byte{}, db.bucketName...), []byte("_redactions")...)
+}
+
+// Redact deletes the salt and the payload of a redactable block and records
+// the redaction. Redacting a block twice keeps the first record.
+func (db *SkipBlockDB) Redact(id SkipBlockID, reason string) error {
+	if len(reason) > maxRedactionReason {
+		return xerrors.Errorf("reason is longer than %d bytes",
+			maxRedactionReason)
+	}
+	return db.Update(func(tx *bbolt.Tx) error {
+		sb, err := db.getFromTx(tx, id)
+		if err != nil {
+			return err
+		}
+		if sb == nil {
+			return xerrors.Errorf("unknown block %x", id)
+		}
+		if RedactableDataFromBlock(sb) == nil {
+			return xerrors.Errorf("block %x is not redactable", id)
+		}
+		b, err := tx.CreateBucketIfNotExists(db.redactionBucket())
+		if err != nil {
+			return err
+		}
+		if b.Get(id) != nil {
+			return nil
+		}
+		buf, err := protobuf.Encode(&Redaction{Reason: reason,
+			Timestamp: time.Now().UnixNano()})
+		if err != nil {
+			return err
+		}
+		if err := b.Put(id, buf); err != nil {
+			return err
+		}
+		// A redacted payload must not be restored from an archive.
+		if err := db.deletePrunedTx(tx, id); err != nil {
+			return err
+		}
+		sb.Payload = nil
+		return db.storeToTx(tx, sb)
+	})
+}
+
+// GetRedaction returns the redaction of the block, or nil if it has not
+// been redacted.
+func (db *SkipBlockDB) GetRedaction(id SkipBlockID) (*Redaction, error) {
+	var r *Redaction
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(db.redactionBucket())
+		if b == nil {
+			return nil
+		}
+		buf := b.Get(id)
+		if buf == nil {
+			return nil
+		}
+		r = &Redaction{}
+		return protobuf.Decode(buf, r)
+	})
+	return r, err
+}
+
+// deleteRedactionTx removes the redaction of the block, if any.
+func (db *SkipBlockDB) deleteRedactionTx(tx *bbolt.Tx, id SkipBlockID) error {
+	b := tx.Bucket(db.redactionBucket())
+	if b == nil {
+		return nil
+	}
+	return b.Delete(id)
+}
+
+// Redact deletes the payload of a redactable block. The request must be
+// signed by one of the linked clients or by this conode.
+func (s *Service) Redact(req *Redact) (*EmptyReply, error) {
+	if !s.verifyAdminSigs(redactMsg(req.BlockID, req.Reason), req.Signature) {
+		return nil, xerrors.New("wrong signature of unknown signer")
+	}
+	if err := s.db.Redact(req.BlockID, req.Reason); err != nil {
+		return nil, xerrors.Errorf("couldn't redact block: %v", err)
+	}
+	return &EmptyReply{}, nil
+}
+
+// GetRedaction returns the redaction of a block.
+func (s *Service) GetRedaction(req *GetRedaction) (*GetRedactionReply, error) {
+	r, err := s.db.GetRedaction(req.BlockID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get redaction: %v", err)
+	}
+	return &GetRedactionReply{Redaction: r}, nil
+}
+
+// redactMsg returns the message to be signed by a linked client to redact
+// a block.
+func redactMsg(id SkipBlockID, reason string) []byte {
+	msg := append([]byte("redact:"), id...)
+	return append(msg, []byte(reason)...)
+}
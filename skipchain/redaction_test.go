@@ -0,0 +1,160 @@
+package skipchain
+
+import (
+	"crypto/sha256"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+)
+
+func TestSkipBlockDB_Redact(t *testing.T) {
+	l := onet.NewLocalTest(suite)
+	_, roster, _ := l.GenTree(1, false)
+	defer l.CloseAll()
+
+	db, fname := setupSkipBlockDB(t)
+	defer db.Close()
+	defer os.Remove(fname)
+
+	sb := NewSkipBlock()
+	sb.Roster = roster
+	sb.BackLinkIDs = []SkipBlockID{{1, 2, 3}}
+	sb.updateHash()
+	db.Store(sb)
+	require.Error(t, db.Redact(sb.Hash, "not redactable"))
+
+	sb = NewSkipBlock()
+	sb.Roster = roster
+	sb.BackLinkIDs = []SkipBlockID{{1, 2, 3}}
+	require.NoError(t, sb.SetRedactablePayload([]byte("personal data")))
+	sb.updateHash()
+	hash := sb.Hash
+	db.Store(sb)
+	require.NoError(t, db.GetByID(hash).VerifyPayload())
+	require.Equal(t, []byte("personal data"), db.GetByID(hash).RedactablePayload())
+	r, err := db.GetRedaction(hash)
+	require.NoError(t, err)
+	require.Nil(t, r)
+
+	require.Error(t, db.Redact(SkipBlockID{1}, "unknown block"))
+	require.Error(t, db.Redact(hash, strings.Repeat("a", maxRedactionReason+1)))
+	require.NoError(t, db.Redact(hash, "request of the owner"))
+	redacted := db.GetByID(hash)
+	require.Equal(t, 0, len(redacted.Payload))
+	require.Nil(t, redacted.RedactablePayload())
+	require.Equal(t, hash, redacted.CalculateHash())
+	require.NoError(t, redacted.VerifyPayload())
+	r, err = db.GetRedaction(hash)
+	require.NoError(t, err)
+	require.Equal(t, "request of the owner", r.Reason)
+
+	// The first redaction is kept.
+	require.NoError(t, db.Redact(hash, "again"))
+	r, err = db.GetRedaction(hash)
+	require.NoError(t, err)
+	require.Equal(t, "request of the owner", r.Reason)
+
+	redacted.Payload = []byte("other data")
+	require.Error(t, redacted.VerifyPayload())
+
+	require.NoError(t, db.RemoveBlock(hash))
+	r, err = db.GetRedaction(hash)
+	require.NoError(t, err)
+	require.Nil(t, r)
+
+	// The same payload gets another digest, thanks to the salt.
+	other := NewSkipBlock()
+	require.NoError(t, other.SetRedactablePayload([]byte("personal data")))
+	require.NotEqual(t, RedactableDataFromBlock(sb).Digest,
+		RedactableDataFromBlock(other).Digest)
+}
+
+func TestClient_Redact(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil,
+		[]VerifierID{VerifyBase, VerifyRedactable}, 1, 1)
+	require.NoError(t, err)
+
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	require.NoError(t, sb.SetRedactablePayload([]byte("personal data")))
+	reply, err := service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+	id := reply.Latest.Hash
+
+	// A payload that doesn't match the digest is refused.
+	bad := NewSkipBlock()
+	bad.Roster = ro
+	require.NoError(t, bad.SetRedactablePayload([]byte("personal data")))
+	bad.Payload = []byte("other data")
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          bad,
+	})
+	require.Error(t, err)
+
+	// So is a payload without a salt.
+	unsalted := NewSkipBlock()
+	unsalted.Roster = ro
+	digest := sha256.Sum256([]byte("yes"))
+	unsalted.Data, err = network.Marshal(&RedactableData{Digest: digest[:]})
+	require.NoError(t, err)
+	unsalted.Payload = []byte("yes")
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          unsalted,
+	})
+	require.Error(t, err)
+
+	// Without linked clients, anonymous requests are refused.
+	anonymous := key.NewKeyPair(cothority.Suite)
+	require.Error(t, c.Redact(ro, anonymous.Private, id, "anonymous"))
+
+	// The last node doesn't know the client, but the other nodes still
+	// redact the block.
+	kp := key.NewKeyPair(cothority.Suite)
+	services := l.GetServices(servers, skipchainSID)
+	for _, s := range services[:2] {
+		s.(*Service).Storage.Clients = []kyber.Point{kp.Public}
+	}
+	other := key.NewKeyPair(cothority.Suite)
+	require.Error(t, c.Redact(ro, other.Private, id, "request of the owner"))
+	err = c.Redact(ro, kp.Private, id, "request of the owner")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "1 of 3 nodes")
+
+	for i, si := range ro.List {
+		r, err := c.GetRedaction(si, id)
+		require.NoError(t, err)
+		if i == 2 {
+			require.Nil(t, r)
+			continue
+		}
+		require.NotNil(t, r)
+		require.Equal(t, "request of the owner", r.Reason)
+	}
+	services[2].(*Service).Storage.Clients = []kyber.Point{kp.Public}
+	require.NoError(t, c.Redact(ro, kp.Private, id, "request of the owner"))
+	sbs, err := c.GetUpdateChain(ro, genesis.Hash)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(sbs.Update))
+	require.Equal(t, id, sbs.Update[1].Hash)
+	require.Equal(t, 0, len(sbs.Update[1].Payload))
+	require.NoError(t, sbs.Update[1].VerifyForwardSignatures())
+}
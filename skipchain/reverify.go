@@ -0,0 +1,170 @@
+package skipchain
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the re-verification of the stored chains. When enabled, a
+background job walks all chains of the database from the genesis block,
+checking the hash, the links and the signatures of every block. It waits
+between two blocks so that it doesn't slow down the conode. The
+inconsistencies are logged and reported by the status service.
+*/
+
+// reverifier holds the state of the background re-verification.
+type reverifier struct {
+	sync.Mutex
+	stop            chan bool
+	passes          int
+	checked         int
+	inconsistencies int
+	lastError       string
+	lastPass        time.Time
+}
+
+// GetStatus implements onet.StatusReporter.
+func (r *reverifier) GetStatus() *onet.Status {
+	r.Lock()
+	defer r.Unlock()
+	out := map[string]string{
+		"Running":         strconv.FormatBool(r.stop != nil),
+		"Passes":          strconv.Itoa(r.passes),
+		"Checked":         strconv.Itoa(r.checked),
+		"Inconsistencies": strconv.Itoa(r.inconsistencies),
+		"LastError":       r.lastError,
+	}
+	if !r.lastPass.IsZero() {
+		out["LastPass"] = r.lastPass.Format(time.RFC3339)
+	}
+	return &onet.Status{Field: out}
+}
+
+// SetReverifyRate starts the background re-verification of the stored
+// chains, checking one block every rate. A rate of 0 stops it.
+func (s *Service) SetReverifyRate(rate time.Duration) error {
+	s.reverify.Lock()
+	defer s.reverify.Unlock()
+	if s.reverify.stop != nil {
+		close(s.reverify.stop)
+		s.reverify.stop = nil
+	}
+	if rate <= 0 {
+		return nil
+	}
+	if err := s.incrementWorking(); err != nil {
+		return err
+	}
+	stop := make(chan bool)
+	s.reverify.stop = stop
+	go func() {
+		defer s.decrementWorking()
+		pause := func() bool {
+			select {
+			case <-time.After(rate):
+				return true
+			case <-stop:
+			case <-s.closing:
+			}
+			return false
+		}
+		for {
+			if !s.reverifyPass(pause) || !pause() {
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// reverifyPass checks all blocks of all chains once and returns false if it
+// was interrupted. Pause is called after every block and the pass stops if
+// it returns false.
+func (s *Service) reverifyPass(pause func() bool) bool {
+	summaries, err := s.db.summary()
+	if err != nil {
+		s.reverifyFailed(xerrors.Errorf("couldn't list chains: %v", err))
+		return pause()
+	}
+	for _, cs := range summaries {
+		if !s.reverifyChain(cs.SkipChainID, pause) {
+			return false
+		}
+	}
+	s.reverify.Lock()
+	s.reverify.passes++
+	s.reverify.lastPass = time.Now()
+	s.reverify.Unlock()
+	return true
+}
+
+// reverifyChain follows the level-0 forward links from the genesis block and
+// checks every block on the way.
+func (s *Service) reverifyChain(scID SkipBlockID, pause func() bool) bool {
+	var prev *SkipBlock
+	id := scID
+	for id != nil {
+		sb := s.db.GetByID(id)
+		if sb == nil {
+			// Nodes that only follow a chain can have gaps.
+			return true
+		}
+		err := reverifyBlock(id, prev, sb)
+		s.reverify.Lock()
+		s.reverify.checked++
+		s.reverify.Unlock()
+		if err != nil {
+			s.reverifyFailed(xerrors.Errorf("chain %x: %v", scID, err))
+			return pause()
+		}
+		if !pause() {
+			return false
+		}
+		prev = sb
+		id = nil
+		if fl := sb.GetForward(0); fl != nil {
+			id = fl.To
+		}
+	}
+	return true
+}
+
+// reverifyBlock checks that the block is stored under its hash, that it
+// links back to the previous block and that its forward links are signed.
+func reverifyBlock(id SkipBlockID, prev, sb *SkipBlock) error {
+	if !id.Equal(sb.Hash) || !sb.Hash.Equal(sb.CalculateHash()) {
+		return xerrors.Errorf("block %x has a wrong hash", id)
+	}
+	if prev != nil {
+		if sb.Index != prev.Index+1 || len(sb.BackLinkIDs) == 0 ||
+			!sb.BackLinkIDs[0].Equal(prev.Hash) {
+			return xerrors.Errorf("block %x doesn't link back to block %d",
+				id, prev.Index)
+		}
+	}
+	for i, fl := range sb.forwardLinks() {
+		if !fl.IsEmpty() && !fl.From.Equal(sb.Hash) {
+			return xerrors.Errorf("forward link %d of block %x starts at %x",
+				i, id, fl.From)
+		}
+	}
+	if err := sb.VerifyForwardSignatures(); err != nil {
+		return xerrors.Errorf("block %d: %v", sb.Index, err)
+	}
+	return nil
+}
+
+// reverifyFailed records an inconsistency found by the re-verification.
+func (s *Service) reverifyFailed(err error) {
+	log.Errorf("%s: re-verification failed: %v", s.ServerIdentity(), err)
+	s.reverify.Lock()
+	s.reverify.inconsistencies++
+	s.reverify.lastError = err.Error()
+	s.reverify.Unlock()
+}
@@ -0,0 +1,67 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+	bbolt "go.etcd.io/bbolt"
+)
+
+func TestService_Reverify(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Data = []byte{byte(i)}
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+	}
+
+	next := func() bool { return true }
+	require.True(t, service.reverifyPass(next))
+	status := service.reverify.GetStatus().Field
+	require.Equal(t, "4", status["Checked"])
+	require.Equal(t, "0", status["Inconsistencies"])
+	require.Equal(t, "1", status["Passes"])
+
+	// The background job stops when asked to.
+	require.NoError(t, service.SetReverifyRate(time.Millisecond))
+	for i := 0; i < 100 && service.reverify.GetStatus().Field["Passes"] == "1"; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.NoError(t, service.SetReverifyRate(0))
+	status = service.reverify.GetStatus().Field
+	require.NotEqual(t, "1", status["Passes"])
+	require.Equal(t, "false", status["Running"])
+	require.Equal(t, "0", status["Inconsistencies"])
+
+	// Corrupt the data of a block without changing its key.
+	sb := service.db.GetByID(service.db.GetByID(genesis.Hash).GetForward(0).To)
+	require.NotNil(t, sb)
+	sb.Data = []byte("corrupted")
+	require.NoError(t, service.db.Update(func(tx *bbolt.Tx) error {
+		return service.db.storeToTx(tx, sb)
+	}))
+	require.True(t, service.reverifyPass(next))
+	status = service.reverify.GetStatus().Field
+	require.Equal(t, "1", status["Inconsistencies"])
+	require.Contains(t, status["LastError"], "wrong hash")
+
+	// An interrupted pass is not counted.
+	passes := status["Passes"]
+	require.False(t, service.reverifyPass(func() bool { return false }))
+	require.Equal(t, passes, service.reverify.GetStatus().Field["Passes"])
+}
@@ -0,0 +1,359 @@
+package skipchain
+
+import (
+	"encoding/binary"
+
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the explicit change of the roster of a chain. A new block
+with the new roster is added, after checking that at most a third of the
+nodes are replaced, so that the previous roster keeps control of the chain.
+The chain must use VerifyRosterChange, so that every node of the previous
+roster checks the change before signing it. Once the block is stored, the
+joining conodes get all blocks of the chain, not only the shortest path to
+the latest block. The departing conodes get the new block too, even if they
+missed its signature, and stop the work they did as members of the roster:
+renewing proofs of freshness, adding checkpoints and serving subscribers.
+*/
+
+func init() {
+	network.RegisterMessages(&RosterChange{})
+}
+
+// RosterChange is stored in the data of the block changing the roster.
+type RosterChange struct {
+	Added   []*network.ServerIdentity
+	Removed []*network.ServerIdentity
+	// Signature is done by a freeze administrator on the message returned
+	// by frozenRosterMsg. It is only needed while the chain is frozen.
+	Signature []byte `protobuf:"opt"`
+}
+
+// checkRosterChange returns the nodes added and removed by the new roster,
+// or an error if more than a third of the nodes of the current roster would
+// be added or removed.
+func checkRosterChange(current, next *onet.Roster) (*RosterChange, error) {
+	if next == nil || len(next.List) == 0 {
+		return nil, xerrors.New("empty roster")
+	}
+	seen := make(map[network.ServerIdentityID]bool)
+	for _, si := range next.List {
+		if seen[si.ID] {
+			return nil, xerrors.Errorf("node %s is twice in the roster", si)
+		}
+		seen[si.ID] = true
+	}
+	rc := &RosterChange{}
+	for _, si := range next.List {
+		if i, _ := current.Search(si.ID); i < 0 {
+			rc.Added = append(rc.Added, si)
+		}
+	}
+	for _, si := range current.List {
+		if i, _ := next.Search(si.ID); i < 0 {
+			rc.Removed = append(rc.Removed, si)
+		}
+	}
+	// A new order of the same nodes, for example to change the leader, is
+	// a change without added or removed nodes.
+	if len(rc.Added) == 0 && len(rc.Removed) == 0 &&
+		sameNodes(current.List, next.List) {
+		return nil, xerrors.New("the roster doesn't change")
+	}
+	if len(rc.Added)*3 > len(current.List) {
+		return nil, xerrors.Errorf("cannot add more than a third of %d nodes",
+			len(current.List))
+	}
+	if len(rc.Removed)*3 > len(current.List) {
+		return nil, xerrors.Errorf("cannot remove more than a third of %d nodes",
+			len(current.List))
+	}
+	return rc, nil
+}
+
+// sameNodes returns true if both lists hold the same nodes in the same
+// order.
+func sameNodes(a, b []*network.ServerIdentity) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameMembers returns true if both lists hold the same nodes, in any order.
+func sameMembers(a, b []*network.ServerIdentity) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[network.ServerIdentityID]int)
+	for _, si := range a {
+		count[si.ID]++
+	}
+	for _, si := range b {
+		if count[si.ID] == 0 {
+			return false
+		}
+		count[si.ID]--
+	}
+	return true
+}
+
+// verify returns an error if the change from the current to the next
+// roster is not allowed, or doesn't match the nodes of the RosterChange.
+func (rc *RosterChange) verify(current, next *onet.Roster) error {
+	expected, err := checkRosterChange(current, next)
+	if err != nil {
+		return err
+	}
+	if !sameNodes(rc.Added, expected.Added) ||
+		!sameNodes(rc.Removed, expected.Removed) {
+		return xerrors.New("roster change doesn't match the new roster")
+	}
+	return nil
+}
+
+// verifyFuncRosterChange refuses blocks changing more than a third of the
+// roster, blocks changing the nodes of the roster without a RosterChange
+// describing the change, and blocks with a RosterChange that don't change
+// the roster. The nodes of the rosters are compared, as the ID of the roster
+// is given by the proposer of the block. A new order of the same nodes
+// doesn't need a RosterChange.
+func (s *Service) verifyFuncRosterChange(newID []byte, newSB *SkipBlock) bool {
+	if newSB.Index == 0 {
+		return true
+	}
+	prev := s.db.GetByID(newSB.BackLinkIDs[0])
+	if prev == nil || newSB.Roster == nil {
+		return false
+	}
+	rc, isChange := decodeBlockData(newSB).(*RosterChange)
+	if isChange {
+		if err := rc.verify(prev.Roster, newSB.Roster); err != nil {
+			log.Lvl2(err)
+			return false
+		}
+		return true
+	}
+	if !sameMembers(prev.Roster.List, newSB.Roster.List) {
+		log.Lvl2("roster changed without a roster change block")
+		return false
+	}
+	return true
+}
+
+// ChangeRoster adds a block with the new roster to the chain and hands the
+// chain over to the joining conodes. It must be sent to the leader of the
+// new roster, which must be part of the current roster. The chain must use
+// VerifyRosterChange, and the request must be signed by a linked client or
+// by the conode. If the chain is frozen, the request must also hold the
+// signature of a freeze administrator.
+func (s *Service) ChangeRoster(req *ChangeRoster) (*StoreSkipBlockReply, error) {
+	if req.NewRoster == nil {
+		return nil, xerrors.New("missing roster")
+	}
+	if !s.verifyAdminSigs(changeRosterMsg(req.SkipChainID, req.NewRoster),
+		req.Signature) {
+		return nil, xerrors.New("wrong signature of unknown signer")
+	}
+	genesis := s.db.GetByID(req.SkipChainID)
+	if genesis == nil || genesis.Index != 0 {
+		return nil, xerrors.Errorf("unknown skipchain %x", req.SkipChainID)
+	}
+	found := false
+	for _, ver := range genesis.VerifierIDs {
+		if ver.Equal(VerifyRosterChange) {
+			found = true
+		}
+	}
+	if !found {
+		return nil, xerrors.New("the chain doesn't verify roster changes")
+	}
+	latest, err := s.db.GetLatestByID(req.SkipChainID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get latest block: %v", err)
+	}
+	rc, err := checkRosterChange(latest.Roster, req.NewRoster)
+	if err != nil {
+		return nil, err
+	}
+	rc.Signature = req.AdminSignature
+	data, err := network.Marshal(rc)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't marshal data: %v", err)
+	}
+
+	sb := NewSkipBlock()
+	sb.Roster = req.NewRoster
+	sb.Data = data
+	// The request has already been authenticated.
+	reply, err := s.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: req.SkipChainID,
+		NewBlock:          sb,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't store block: %v", err)
+	}
+	if err := s.checkpointIfDue(reply.Latest); err != nil {
+		log.Errorf("%s: couldn't add checkpoint: %v", s.ServerIdentity(), err)
+	}
+
+	if len(rc.Added) > 0 {
+		added := onet.NewRoster(append(append([]*network.ServerIdentity{},
+			rc.Added...), s.ServerIdentity()))
+		if err := s.handOver(added, req.SkipChainID); err != nil {
+			// The roster changed anyway, and the joining conodes can
+			// still catch up by themselves.
+			log.Errorf("%s: couldn't hand over chain: %v",
+				s.ServerIdentity(), err)
+		}
+	}
+	if len(rc.Removed) > 0 {
+		// The departing conodes leave the chain once they stored the new
+		// block.
+		departed := onet.NewRoster(append(append([]*network.ServerIdentity{},
+			rc.Removed...), s.ServerIdentity()))
+		err := s.startPropagation(s.propagateHandover, departed,
+			&PropagateHandover{Blocks: []*SkipBlock{reply.Previous, reply.Latest}})
+		if err != nil {
+			log.Errorf("%s: couldn't send new roster to departing conodes: %v",
+				s.ServerIdentity(), err)
+		}
+	}
+	return reply, nil
+}
+
+// leaveChains lets this conode leave the skipchains of the new blocks if
+// it was in the roster of the previous block, but is not in the roster of
+// the new block.
+func (s *Service) leaveChains(sbs []*SkipBlock) {
+	for _, sb := range sbs {
+		if sb.Index == 0 || sb.Roster == nil {
+			continue
+		}
+		if i, _ := sb.Roster.Search(s.ServerIdentity().ID); i >= 0 {
+			continue
+		}
+		prev := s.db.GetByID(sb.BackLinkIDs[0])
+		if prev == nil {
+			continue
+		}
+		if i, _ := prev.Roster.Search(s.ServerIdentity().ID); i >= 0 {
+			s.leaveChain(sb.SkipChainID())
+		}
+	}
+}
+
+// leaveChain stops the work this conode does for the skipchain as a member
+// of its roster. The blocks are kept, so that they can still be served.
+func (s *Service) leaveChain(scID SkipBlockID) {
+	log.Lvlf2("%s: left the roster of %x", s.ServerIdentity(), scID)
+	if err := s.SetFreshness(scID, 0); err != nil {
+		log.Error(err)
+	}
+
+	s.checkpoints.Lock()
+	delete(s.checkpoints.chains, string(scID))
+	s.checkpoints.Unlock()
+
+	s.heads.Lock()
+	defer s.heads.Unlock()
+	for _, si := range s.heads.subscribers[string(scID)] {
+		s.heads.count[si.ID]--
+		if s.heads.count[si.ID] == 0 {
+			delete(s.heads.count, si.ID)
+		}
+	}
+	delete(s.heads.subscribers, string(scID))
+}
+
+// handOver sends all blocks of the chain to the conodes of the roster, in
+// pages of consecutive blocks. Every page starts with the last block of the
+// previous one, so that it can be verified from a known block.
+func (s *Service) handOver(roster *onet.Roster, scID SkipBlockID) error {
+	sb := s.db.GetByID(scID)
+	for sb != nil {
+		page := []*SkipBlock{sb}
+		for len(page) < maxBlockRange && sb.GetForwardLen() > 0 {
+			sb = s.db.GetByID(sb.GetForward(0).To)
+			if sb == nil {
+				return xerrors.Errorf("missing block after index %d",
+					page[len(page)-1].Index)
+			}
+			page = append(page, sb)
+		}
+		log.Lvlf2("%s: handing over blocks %d to %d to %v", s.ServerIdentity(),
+			page[0].Index, sb.Index, roster.List)
+		err := s.startPropagation(s.propagateHandover, roster,
+			&PropagateHandover{Blocks: page})
+		if err != nil {
+			return err
+		}
+		if sb.GetForwardLen() == 0 {
+			return nil
+		}
+	}
+	return xerrors.Errorf("unknown skipchain %x", scID)
+}
+
+// propagateHandoverHandler stores a page of blocks handed over by the
+// leader of a chain this conode joined.
+func (s *Service) propagateHandoverHandler(msg network.Message) error {
+	ph, ok := msg.(*PropagateHandover)
+	if !ok {
+		return xerrors.Errorf("got unexpected type %T", msg)
+	}
+	if len(ph.Blocks) == 0 {
+		return xerrors.New("empty list of blocks")
+	}
+	first := ph.Blocks[0]
+	if !s.BlockIsFriendly(first) {
+		return xerrors.New("block is not friendly")
+	}
+	if first.Index > 0 && s.db.GetByID(first.Hash) == nil {
+		return xerrors.Errorf("unknown first block %x", first.Hash)
+	}
+	if err := Proof(ph.Blocks).VerifyFromID(first.Hash); err != nil {
+		return xerrors.Errorf("invalid blocks: %v", err)
+	}
+	if _, err := s.db.StoreBlocks(ph.Blocks); err != nil {
+		return xerrors.Errorf("couldn't store blocks: %v", err)
+	}
+	return nil
+}
+
+// frozenRosterMsg returns the message a freeze administrator signs to
+// change the roster of a frozen chain with the block at the given index.
+func frozenRosterMsg(scID SkipBlockID, index int, roster *onet.Roster) []byte {
+	msg := append([]byte("frozenroster:"), scID...)
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, uint64(index))
+	msg = append(msg, buf...)
+	return append(msg, rosterID(roster)...)
+}
+
+// rosterID returns the ID of the roster computed from its nodes, as the ID
+// sent with a roster is not checked.
+func rosterID(ro *onet.Roster) []byte {
+	computed := onet.NewRoster(ro.List)
+	if computed == nil {
+		return nil
+	}
+	return computed.ID[:]
+}
+
+// changeRosterMsg returns the message to be signed by a linked client to
+// change the roster of a chain.
+func changeRosterMsg(scID SkipBlockID, roster *onet.Roster) []byte {
+	msg := append([]byte("changeroster:"), scID...)
+	return append(msg, rosterID(roster)...)
+}
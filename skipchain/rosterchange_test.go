@@ -0,0 +1,150 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/kyber/v3/util/key"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+)
+
+func TestClient_ChangeRoster(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, all, gs := l.MakeSRS(cothority.Suite, 5, skipchainSID)
+	service := gs.(*Service)
+	services := l.GetServices(servers, skipchainSID)
+	c := newTestClient(l)
+
+	ro := onet.NewRoster(all.List[:3])
+	standard, err := makeGenesisRosterArgs(service, ro, nil, VerificationStandard, 2, 3)
+	require.NoError(t, err)
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationRosterChange, 2, 3)
+	require.NoError(t, err)
+	for i := 0; i < 4; i++ {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+	}
+
+	kp := key.NewKeyPair(cothority.Suite)
+	for _, s := range services {
+		s.(*Service).Storage.Clients = []kyber.Point{kp.Public}
+	}
+
+	// Only chains verifying the roster changes accept them.
+	_, err = c.ChangeRoster(standard.Hash, onet.NewRoster([]*network.ServerIdentity{
+		all.List[0], all.List[1], all.List[3]}), kp.Private)
+	require.Error(t, err)
+
+	// The roster can't change without a RosterChange.
+	sb := NewSkipBlock()
+	sb.Roster = all
+	_, err = service.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.Error(t, err)
+
+	departed := services[2].(*Service)
+	require.NoError(t, departed.SetFreshness(genesis.Hash, time.Hour))
+
+	change := func(list ...*network.ServerIdentity) (*StoreSkipBlockReply, error) {
+		return c.ChangeRoster(genesis.Hash, onet.NewRoster(list), kp.Private)
+	}
+
+	_, err = change(all.List[:3]...)
+	require.Error(t, err)
+	_, err = change(all.List[0], all.List[3], all.List[4])
+	require.Error(t, err)
+	_, err = change(all.List[3], all.List[0], all.List[1])
+	require.Error(t, err)
+	other := key.NewKeyPair(cothority.Suite)
+	_, err = c.ChangeRoster(genesis.Hash, onet.NewRoster([]*network.ServerIdentity{
+		all.List[0], all.List[1], all.List[3]}), other.Private)
+	require.Error(t, err)
+
+	reply, err := change(all.List[0], all.List[1], all.List[3])
+	require.NoError(t, err)
+	require.Equal(t, 5, reply.Latest.Index)
+	_, msg, err := network.Unmarshal(reply.Latest.Data, cothority.Suite)
+	require.NoError(t, err)
+	rc := msg.(*RosterChange)
+	require.Equal(t, 1, len(rc.Added))
+	require.True(t, rc.Added[0].Equal(all.List[3]))
+	require.Equal(t, 1, len(rc.Removed))
+	require.True(t, rc.Removed[0].Equal(all.List[2]))
+
+	// The joining conode got all blocks, not only the shortest path.
+	joined := services[3].(*Service)
+	for i := 0; i <= 5; i++ {
+		sb, err := joined.db.GetProofFromIndex(genesis.Hash, i)
+		require.NoError(t, err)
+		require.Equal(t, i, sb[len(sb)-1].Index)
+	}
+	summary, err := joined.db.summary()
+	require.NoError(t, err)
+	require.Equal(t, 6, summary[0].Blocks)
+
+	// The departing conode knows about the new roster and left the chain.
+	require.Eventually(t, func() bool {
+		latest, err := departed.db.GetLatestByID(genesis.Hash)
+		return err == nil && latest.Index == 5
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, err := departed.GetPoF(&GetPoF{SkipChainID: genesis.Hash})
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The new roster adds blocks.
+	sb = NewSkipBlock()
+	sb.Roster = reply.Latest.Roster
+	_, err = service.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		latest, err := joined.db.GetLatestByID(genesis.Hash)
+		return err == nil && latest.Index == 6
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// A roster with new nodes but the ID of the current roster is refused.
+	latest, err := service.db.GetLatestByID(genesis.Hash)
+	require.NoError(t, err)
+	forged := latest.Copy()
+	forged.Index++
+	forged.BackLinkIDs = []SkipBlockID{latest.Hash}
+	forged.Roster = &onet.Roster{ID: latest.Roster.ID,
+		List: []*network.ServerIdentity{all.List[2], all.List[4], all.List[3]}}
+	forged.Hash = forged.CalculateHash()
+	require.False(t, service.verifyFuncRosterChange(forged.Hash, forged))
+
+	// The same nodes in a new order change the leader, with or without a
+	// RosterChange.
+	reply, err = change(all.List[1], all.List[0], all.List[3])
+	require.NoError(t, err)
+	require.Equal(t, 7, reply.Latest.Index)
+	_, msg, err = network.Unmarshal(reply.Latest.Data, cothority.Suite)
+	require.NoError(t, err)
+	rc = msg.(*RosterChange)
+	require.Equal(t, 0, len(rc.Added))
+	require.Equal(t, 0, len(rc.Removed))
+	sb = NewSkipBlock()
+	sb.Roster = onet.NewRoster([]*network.ServerIdentity{all.List[0],
+		all.List[1], all.List[3]})
+	_, err = service.StoreSkipBlockInternal(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+}
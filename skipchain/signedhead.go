@@ -0,0 +1,210 @@
+package skipchain
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"go.dedis.ch/cothority/v3/blscosi/bdnproto"
+	"go.dedis.ch/cothority/v3/blscosi/protocol"
+	"go.dedis.ch/kyber/v3/sign"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/protobuf"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the signed heads. The blocks returned by a conode are linked
+by their forward-links, but nothing prevents a malicious conode from omitting
+the newest blocks. A signed head is a statement "the head of chain C is block
+B at time T", co-signed by the roster of the head. Every node only signs if
+its own head of the chain is B, so a client holding the statement knows that
+the head is not stale. The statement comes with the forward-links from the
+genesis block to the head, so that the client knows that the roster signing
+the statement is the one of the chain, and not one chosen by the conode.
+*/
+
+const signedHeadProtocol = "SkipchainSignedHead"
+const signedHeadSubProtocol = "SkipchainSignedHeadSub"
+
+// maxHeadClockSkew is the maximum difference between the time of a head
+// statement and the local clock for a node to sign it.
+var maxHeadClockSkew = time.Minute
+
+// HeadStatement states that the block Head with the given Index is the latest
+// block of the skipchain at the time Timestamp, in nanoseconds since the
+// epoch.
+type HeadStatement struct {
+	SkipChainID SkipBlockID
+	Head        SkipBlockID
+	Index       int
+	Timestamp   int64
+}
+
+// Hash returns the message signed by the roster for the statement.
+func (hs *HeadStatement) Hash() []byte {
+	h := sha256.New()
+	h.Write(hs.SkipChainID)
+	h.Write(hs.Head)
+	buf := make([]byte, 16)
+	binary.LittleEndian.PutUint64(buf, uint64(hs.Index))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(hs.Timestamp))
+	h.Write(buf)
+	return h.Sum(nil)
+}
+
+// Verify checks that the reply holds the latest block of the skipchain and
+// that the statement is signed by the roster of this block. The head must be
+// reached by the forward-links from the genesis block, whose hash is the
+// skipchain-ID. If maxAge is bigger than 0, the statement must not be older
+// than maxAge.
+func (r *GetSignedHeadReply) Verify(scID SkipBlockID, maxAge time.Duration) error {
+	if r.Head == nil || r.Head.Roster == nil {
+		return xerrors.New("missing head")
+	}
+	if r.Genesis == nil || !r.Genesis.Hash.Equal(scID) {
+		return xerrors.New("got the wrong genesis block")
+	}
+	if err := VerifyProof(r.Genesis, r.Links, r.Head); err != nil {
+		return xerrors.Errorf("invalid proof of head: %v", err)
+	}
+	hs := r.Statement
+	if !hs.SkipChainID.Equal(scID) || !hs.Head.Equal(r.Head.Hash) ||
+		hs.Index != r.Head.Index {
+		return xerrors.New("statement doesn't match the head")
+	}
+	if maxAge > 0 && time.Since(time.Unix(0, hs.Timestamp)) > maxAge {
+		return xerrors.New("statement is too old")
+	}
+	publics := r.Head.Roster.ServicePublics(ServiceName)
+	policy := sign.NewThresholdPolicy(protocol.DefaultThreshold(len(publics)))
+	err := bdnproto.BdnSignature(r.Signature).VerifyWithPolicy(suite,
+		hs.Hash(), publics, policy)
+	if err != nil {
+		return xerrors.Errorf("wrong signature of head: %v", err)
+	}
+	return nil
+}
+
+// registerSignedHead registers the co-signing protocols of the statements.
+func (s *Service) registerSignedHead() error {
+	_, err := s.ProtocolRegister(signedHeadSubProtocol, func(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+		return bdnproto.NewSubBdnCosi(n, s.verifyHeadStatement, suite)
+	})
+	if err != nil {
+		return xerrors.Errorf("registering protocol: %v", err)
+	}
+	_, err = s.ProtocolRegister(signedHeadProtocol, func(n *onet.TreeNodeInstance) (onet.ProtocolInstance, error) {
+		return bdnproto.NewBdnCosi(n, s.verifyHeadStatement, signedHeadSubProtocol, suite)
+	})
+	if err != nil {
+		return xerrors.Errorf("registering protocol: %v", err)
+	}
+	return nil
+}
+
+// GetSignedHead returns the latest block of the skipchain together with a
+// statement that it is the head, co-signed by the roster of the block.
+func (s *Service) GetSignedHead(req *GetSignedHead) (*GetSignedHeadReply, error) {
+	latest, err := s.db.GetLatestByID(req.SkipChainID)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't find skipchain: %v", err)
+	}
+	if !latest.SkipChainID().Equal(req.SkipChainID) {
+		return nil, xerrors.New("need the ID of the genesis block")
+	}
+	genesis := s.db.GetByID(req.SkipChainID)
+	if genesis == nil {
+		return nil, xerrors.New("couldn't find genesis block")
+	}
+	proof, err := s.GetProof(&GetProof{Genesis: req.SkipChainID,
+		Target: latest.Hash})
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't get proof of head: %v", err)
+	}
+	hs := HeadStatement{
+		SkipChainID: req.SkipChainID,
+		Head:        latest.Hash,
+		Index:       latest.Index,
+		Timestamp:   time.Now().UnixNano(),
+	}
+	data, err := protobuf.Encode(&hs)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't encode statement: %v", err)
+	}
+
+	bf := 2
+	if len(latest.Roster.List)-1 > 2 {
+		bf = len(latest.Roster.List) - 1
+	}
+	tree := latest.Roster.GenerateNaryTreeWithRoot(bf, s.ServerIdentity())
+	if tree == nil {
+		return nil, xerrors.New("this conode is not in the roster of the head")
+	}
+	pi, err := s.CreateProtocol(signedHeadProtocol, tree)
+	if err != nil {
+		return nil, xerrors.Errorf("couldn't create protocol: %v", err)
+	}
+	p := pi.(*protocol.BlsCosi)
+	p.CreateProtocol = s.CreateProtocol
+	p.Msg = hs.Hash()
+	p.Data = data
+	p.Timeout = s.propTimeout
+	p.Threshold = protocol.DefaultThreshold(len(tree.List()))
+	if err := p.SetNbrSubTree(protocol.DefaultSubLeaders(len(tree.List()))); err != nil {
+		return nil, xerrors.Errorf("couldn't set subtrees: %v", err)
+	}
+	if err := p.Start(); err != nil {
+		return nil, xerrors.Errorf("couldn't start protocol: %v", err)
+	}
+
+	select {
+	case sig := <-p.FinalSignature:
+		if sig == nil {
+			return nil, xerrors.New("couldn't sign the head")
+		}
+		return &GetSignedHeadReply{
+			Genesis:   genesis,
+			Links:     proof.Links,
+			Head:      latest,
+			Statement: hs,
+			Signature: sig,
+		}, nil
+	case <-time.After(p.Timeout * 2):
+		return nil, xerrors.New("timed out while waiting for signature")
+	case <-s.closing:
+		return nil, xerrors.New("closing down")
+	}
+}
+
+// verifyHeadStatement only accepts statements about the head this node
+// knows, with a timestamp close to the local clock.
+func (s *Service) verifyHeadStatement(msg, data []byte) bool {
+	var hs HeadStatement
+	if err := protobuf.Decode(data, &hs); err != nil {
+		log.Error(s.ServerIdentity(), "couldn't decode head statement:", err)
+		return false
+	}
+	if !bytes.Equal(hs.Hash(), msg) {
+		log.Lvl2(s.ServerIdentity(), "hash of head statement doesn't match")
+		return false
+	}
+	skew := time.Since(time.Unix(0, hs.Timestamp))
+	if skew > maxHeadClockSkew || skew < -maxHeadClockSkew {
+		log.Lvl2(s.ServerIdentity(), "time of head statement is off by", skew)
+		return false
+	}
+	latest, err := s.db.GetLatestByID(hs.SkipChainID)
+	if err != nil {
+		log.Lvl2(s.ServerIdentity(), "unknown skipchain in head statement")
+		return false
+	}
+	if !latest.Hash.Equal(hs.Head) {
+		log.Lvlf2("%s: refusing head %d, the latest block is %d",
+			s.ServerIdentity(), hs.Index, latest.Index)
+		return false
+	}
+	return true
+}
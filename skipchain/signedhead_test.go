@@ -0,0 +1,71 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestClient_GetSignedHead(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, ro, gs := l.MakeSRS(cothority.Suite, 4, skipchainSID)
+	service := gs.(*Service)
+	c := newTestClient(l)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	reply, err := service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          &SkipBlock{SkipBlockFix: &SkipBlockFix{Roster: ro}},
+	})
+	require.NoError(t, err)
+	head := reply.Latest
+
+	sh, err := c.GetSignedHead(ro, genesis.Hash, time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, head.Hash, sh.Head.Hash)
+	require.Equal(t, head.Hash, sh.Statement.Head)
+
+	// A statement not matching the head or the chain is refused.
+	require.Error(t, sh.Verify(head.Hash, 0))
+	sh.Statement.Index++
+	require.Error(t, sh.Verify(genesis.Hash, 0))
+	sh.Statement.Index--
+	require.NoError(t, sh.Verify(genesis.Hash, 0))
+	require.Error(t, sh.Verify(genesis.Hash, time.Nanosecond))
+	sh.Statement.Timestamp++
+	require.Error(t, sh.Verify(genesis.Hash, 0))
+	sh.Statement.Timestamp--
+
+	// A head with another roster than the one of the chain is refused, even
+	// if that roster signed the statement.
+	forged := sh.Head.Copy()
+	forged.Roster = onet.NewRoster(ro.List[1:])
+	forged.Hash = forged.CalculateHash()
+	forgedReply := *sh
+	forgedReply.Head = forged
+	forgedReply.Statement.Head = forged.Hash
+	err = forgedReply.Verify(genesis.Hash, 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid proof")
+	forgedReply = *sh
+	forgedReply.Genesis = head
+	require.Error(t, forgedReply.Verify(genesis.Hash, 0))
+
+	// A conode missing the latest block cannot get a stale head signed.
+	require.NoError(t, service.db.RemoveBlock(head.Hash))
+	_, err = service.GetSignedHead(&GetSignedHead{SkipChainID: genesis.Hash})
+	require.Error(t, err)
+
+	// The other conodes still sign the real head.
+	other := l.Services[servers[1].ServerIdentity.ID][skipchainSID].(*Service)
+	sh2, err := other.GetSignedHead(&GetSignedHead{SkipChainID: genesis.Hash})
+	require.NoError(t, err)
+	require.NoError(t, sh2.Verify(genesis.Hash, time.Minute))
+	require.Equal(t, head.Hash, sh2.Head.Hash)
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/BurntSushi/toml"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/simul/monitor"
+	bbolt "go.etcd.io/bbolt"
+	"golang.org/x/xerrors"
+)
+
+/*
+The growth simulation appends blocks to a new skipchain until it holds Blocks
+blocks. Every MeasureEvery blocks, it records the following measures, with
+the length of the chain as a suffix:
+  - store_<n>: the time to store the block
+  - update_<n>: the time to get the update chain from the genesis block
+  - update_blocks_<n>: the number of blocks in the update chain
+  - db_size_<n>: the size of the database of the root node in bytes
+This allows to check that the latencies stay flat when the chain grows.
+*/
+
+func init() {
+	onet.SimulationRegister("SkipchainGrowth", NewSimulationGrowth)
+}
+
+// SimulationGrowth holds the state of the simulation.
+type SimulationGrowth struct {
+	onet.SimulationBFTree
+	Blocks        int
+	MeasureEvery  int
+	BaseHeight    int
+	MaximumHeight int
+}
+
+// NewSimulationGrowth returns the new simulation, where all fields are
+// initialised using the config-file
+func NewSimulationGrowth(config string) (onet.Simulation, error) {
+	es := &SimulationGrowth{}
+	_, err := toml.Decode(config, es)
+	if err != nil {
+		return nil, err
+	}
+	return es, nil
+}
+
+// Setup creates the tree used for that simulation
+func (s *SimulationGrowth) Setup(dir string, hosts []string) (
+	*onet.SimulationConfig, error) {
+	sc := &onet.SimulationConfig{}
+	s.CreateRoster(sc, hosts, 2000)
+	err := s.CreateTree(sc)
+	if err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+// Node can be used to initialize each node before it will be run
+// by the server. Here we call the 'Node'-method of the
+// SimulationBFTree structure which will load the roster- and the
+// tree-structure to speed up the first round.
+func (s *SimulationGrowth) Node(config *onet.SimulationConfig) error {
+	index, _ := config.Roster.Search(config.Server.ServerIdentity.ID)
+	if index < 0 {
+		log.Fatal("Didn't find this node in roster")
+	}
+	log.Lvl3("Initializing node-index", index)
+	return s.SimulationBFTree.Node(config)
+}
+
+// Run grows a new chain in every round.
+func (s *SimulationGrowth) Run(config *onet.SimulationConfig) error {
+	if s.MeasureEvery <= 0 {
+		return xerrors.New("MeasureEvery must be bigger than 0")
+	}
+	log.Lvl2("Size is:", config.Tree.Size(), "rounds:", s.Rounds,
+		"blocks:", s.Blocks)
+	client := skipchain.NewClient()
+	service := config.GetService(skipchain.ServiceName).(*skipchain.Service)
+
+	for round := 0; round < s.Rounds; round++ {
+		log.Lvl1("Starting round", round)
+		genesis, err := client.CreateGenesis(config.Roster, s.BaseHeight,
+			s.MaximumHeight, skipchain.VerificationNone, nil)
+		if err != nil {
+			return xerrors.Errorf("couldn't create genesis: %v", err)
+		}
+
+		data := make([]byte, 8)
+		for i := 1; i < s.Blocks; i++ {
+			binary.LittleEndian.PutUint64(data, uint64(i))
+			measure := (i+1)%s.MeasureEvery == 0
+			var store *monitor.TimeMeasure
+			if measure {
+				store = monitor.NewTimeMeasure(fmt.Sprintf("store_%d", i+1))
+			}
+			_, err := client.StoreSkipBlock(genesis, nil, data)
+			if err != nil {
+				return xerrors.Errorf("couldn't store block %d: %v", i, err)
+			}
+			if !measure {
+				continue
+			}
+			store.Record()
+			if err := s.measure(client, service, config.Roster, genesis,
+				i+1); err != nil {
+				return err
+			}
+			log.Lvl1("Chain holds", i+1, "blocks")
+		}
+	}
+	return nil
+}
+
+// measure records the latency of the update chain and the size of the db
+// for a chain of the given length.
+func (s *SimulationGrowth) measure(client *skipchain.Client,
+	service *skipchain.Service, roster *onet.Roster,
+	genesis *skipchain.SkipBlock, length int) error {
+	update := monitor.NewTimeMeasure(fmt.Sprintf("update_%d", length))
+	reply, err := client.GetUpdateChain(roster, genesis.Hash)
+	if err != nil {
+		return xerrors.Errorf("couldn't get update chain: %v", err)
+	}
+	update.Record()
+	if last := reply.Update[len(reply.Update)-1]; last.Index != length-1 {
+		return xerrors.Errorf("update chain ends at %d instead of %d",
+			last.Index, length-1)
+	}
+	monitor.RecordSingleMeasure(fmt.Sprintf("update_blocks_%d", length),
+		float64(len(reply.Update)))
+
+	var size int64
+	err = service.GetDB().View(func(tx *bbolt.Tx) error {
+		size = tx.Size()
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("couldn't get db size: %v", err)
+	}
+	monitor.RecordSingleMeasure(fmt.Sprintf("db_size_%d", length),
+		float64(size))
+	return nil
+}
@@ -0,0 +1,14 @@
+// This package contains the skipchain simulation configuration and the code
+// needed to run it.
+//
+// Please see the README.md in this directory for instructions on how to run
+// the simulation.
+package main
+
+import (
+	"go.dedis.ch/onet/v3/simul"
+)
+
+func main() {
+	simul.Start()
+}
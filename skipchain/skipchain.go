@@ -16,8 +16,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"reflect"
-	"runtime"
 	"strconv"
 	"sync"
 	"time"
@@ -63,7 +61,9 @@ type Service struct {
 	propagateGenesis        messaging.PropagationFunc
 	propagateForwardLink    messaging.PropagationFunc
 	propagateProof          messaging.PropagationFunc
+	propagateHandover       messaging.PropagationFunc
 	verifiers               map[VerifierID]SkipBlockVerifier
+	plugins                 map[VerifierID]*verifierPlugin
 	storageMutex            sync.Mutex
 	Storage                 *Storage
 	bftTimeout              time.Duration
@@ -71,10 +71,44 @@ type Service struct {
 	chains                  chainLocker
 	verifyNewBlockBuffer    sync.Map
 	verifyFollowBlockBuffer sync.Map
+	verifierErrors          sync.Map
+	verifierTimeout         time.Duration
+	verifierTimeouts        map[VerifierID]time.Duration
+	verifierTimeoutsLock    sync.Mutex
+	verifierStats           verifierStats
+	verifiersRunning        runningVerifiers
 	closed                  bool
 	closedMutex             sync.Mutex
 	working                 sync.WaitGroup
 	closing                 chan bool
+	anchors                 anchorBatches
+	heads                   headSubscriptions
+	idempotency             idempotencyKeys
+	idempotencyWindow       time.Duration
+	// names makes sure that the check for an existing name and the
+	// registration of the new name are done atomically.
+	names       sync.Mutex
+	nameIndexes nameIndexes
+	checkpoints checkpoints
+	reverify    reverifier
+	pruning     pruner
+	freshness   freshnessSigners
+	notifiers   blockNotifiers
+	streams     blockStreams
+	catchUps    catchUps
+	freezes     freezeStates
+	// propFanOut, if bigger than 0, is the number of nodes every node
+	// sends the propagated blocks to in rosters of at least
+	// propFanOutMinNodes nodes.
+	propFanOut         int
+	propFanOutMinNodes int
+	// propMaxSends and propBytesPerSecond, if bigger than 0, limit the
+	// concurrent sends and the bandwidth used to propagate the blocks of
+	// one skipchain. pacers holds the messaging.Pacer of every skipchain.
+	propMaxSends       int
+	propBytesPerSecond int
+	pacersLock         sync.Mutex
+	pacers             map[string]*messaging.Pacer
 
 	// disableForwardLink is useful in testing mode
 	disableForwardLink bool
@@ -153,6 +187,9 @@ type Storage struct {
 	// to this service. Once a client is linked to a service, only blocks signed
 	// by this client will be allowed.
 	Clients []kyber.Point
+	// Deleted is a list of skipchains that have been deleted locally and
+	// whose blocks are refused.
+	Deleted []SkipBlockID
 }
 
 // StoreSkipBlock stores a new skipblock in the system. This can be either a
@@ -180,14 +217,21 @@ func (s *Service) StoreSkipBlock(psbd *StoreSkipBlock) (*StoreSkipBlockReply, er
 				"wrong signature for this skipchain")
 		}
 	}
-	return s.StoreSkipBlockInternal(psbd)
+	reply, err := s.StoreSkipBlockInternal(psbd)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkpointIfDue(reply.Latest); err != nil {
+		log.Errorf("%s: couldn't add checkpoint: %v", s.ServerIdentity(), err)
+	}
+	return reply, nil
 }
 
 // StoreSkipBlockInternal bypasses the authentification performed in StoreSkipBlock.
 // This method must be used in the case the service (like byzcoin) is running on the
 // same host as the Skipchain one. If the Skipchain service is linked to a client,
 // its behavior is to reject any new foreign resquests, even it it comes from a local
-// service, like Byzcoin.
+// service, like Byzcoin. The proposers of the chain are still checked.
 func (s *Service) StoreSkipBlockInternal(psbd *StoreSkipBlock) (*StoreSkipBlockReply, error) {
 	err := s.incrementWorking()
 	if err != nil {
@@ -200,6 +244,9 @@ func (s *Service) StoreSkipBlockInternal(psbd *StoreSkipBlock) (*StoreSkipBlockR
 	if len(prop.Roster.List) == 0 {
 		return nil, errors.New("empty roster")
 	}
+	if len(psbd.IdempotencyKey) > maxIdempotencyKey {
+		return nil, errors.New("idempotency key too long")
+	}
 
 	if !s.ServerIdentity().Equal(prop.Roster.Get(0)) {
 		return nil, errors.New(
@@ -212,7 +259,7 @@ func (s *Service) StoreSkipBlockInternal(psbd *StoreSkipBlock) (*StoreSkipBlockR
 		// A new chain is created
 		log.Lvl2("Creating new skipchain with roster", psbd.NewBlock.Roster.List)
 		prop.Height = prop.MaximumHeight
-		prop.ForwardLink = make([]*ForwardLink, 0)
+		prop.setForwardLinks(make([]*ForwardLink, 0))
 		// genesis block has a random back-link, so that two
 		// identical genesis blocks have a different ID.
 		var bl [32]byte
@@ -279,6 +326,18 @@ func (s *Service) StoreSkipBlockInternal(psbd *StoreSkipBlock) (*StoreSkipBlockR
 		s.chains.lock(scID)
 		defer s.chains.unlock(scID)
 
+		if len(psbd.IdempotencyKey) > 0 {
+			reply, err := s.idempotentReply(scID, psbd.IdempotencyKey, prop)
+			if err != nil {
+				return nil, err
+			}
+			if reply != nil {
+				log.Lvlf2("%s: returning block %x of idempotency key",
+					s.ServerIdentity(), reply.Latest.Hash)
+				return reply, nil
+			}
+		}
+
 		var err error
 		prev, err = s.db.GetLatestByID(scID)
 		if err != nil {
@@ -292,56 +351,19 @@ func (s *Service) StoreSkipBlockInternal(psbd *StoreSkipBlock) (*StoreSkipBlockR
 		}
 
 		// Check if the previous block already has a forward link.
-		if len(prev.ForwardLink) > 0 {
+		if prev.GetForwardLen() > 0 {
 			return nil, errors.New(
 				"the latest block already has a follower")
 		}
 
-		// Copy the block-header to a new block.
-		prop.MaximumHeight = prev.MaximumHeight
-		prop.BaseHeight = prev.BaseHeight
-		prop.VerifierIDs = prev.VerifierIDs
-		prop.Index = prev.Index + 1
-		prop.GenesisID = scID
-		prop.ForwardLink = []*ForwardLink{}
-		prop.SignatureScheme = prev.SignatureScheme
-		// And calculate the height of that block.
-		index := prop.Index
-		for prop.Height = 1; index%prop.BaseHeight == 0; prop.Height++ {
-			index /= prop.BaseHeight
-			if prop.Height >= prop.MaximumHeight {
-				break
-			}
+		prop.ProposerSignature = psbd.ProposerSignature
+		if err := s.checkProposer(prev, prop); err != nil {
+			return nil, err
 		}
-		log.Lvl4("Found height", prop.Height, "for index", prop.Index,
-			"and maxHeight", prop.MaximumHeight, "and base", prop.BaseHeight)
-
-		// Add backlinks to the block.
-		prop.BackLinkIDs = make([]SkipBlockID, prop.Height)
-		pointer := prev
-		for h := range prop.BackLinkIDs {
-			// For every height, we pass the skiplist backwards at the lower height,
-			// till we find a block with the desired height.
-			for pointer.Height <= h {
-				prevPointer := s.db.GetByID(pointer.BackLinkIDs[h-1])
-				if prevPointer == nil {
-					pp, err := s.getBlocks(pointer.Roster, pointer.BackLinkIDs[h-1], 1)
-					if err != nil {
-						return nil, errors.New("couldn't fetch missing block: " + err.Error())
-					}
-					if len(pp) == 0 {
-						return nil, errors.New(
-							"Didn't find convenient SkipBlock for height " +
-								strconv.Itoa(h))
-					}
-					s.db.Store(pp[0])
-					prevPointer = pp[0]
-				}
-				pointer = prevPointer
-			}
-			prop.BackLinkIDs[h] = pointer.Hash
+
+		if err := s.newBlockHeader(scID, prev, prop, nil); err != nil {
+			return nil, err
 		}
-		prop.updateHash()
 
 		// Only check changing roster, or if this is the block after the genesis-block,
 		// as we don't verify the roster for the genesis-block.
@@ -367,32 +389,15 @@ func (s *Service) StoreSkipBlockInternal(psbd *StoreSkipBlock) (*StoreSkipBlockR
 			return nil, errors.New(
 				"Couldn't get forward signature on block: " + err.Error())
 		}
+		s.notifyHeadSubscribers(prev, prop)
+		if len(psbd.IdempotencyKey) > 0 {
+			s.idempotency.remember(scID, psbd.IdempotencyKey, prop.Hash,
+				s.idempotencyWindow)
+		}
 
 		if !s.disableForwardLink {
-			// Now create all further forward links. Again, after creation of each
-			// forward-link, it will propagate them to all nodes.
-			log.Lvl3("Asking forward-links from all linked blocks")
-			for i, bl := range prop.BackLinkIDs[1:] {
-				back := s.db.GetByID(bl)
-				if back == nil {
-					return nil, errors.New(
-						"Didn't get skipblock in back-link")
-
-				}
-				// Requesting creation of secondary forward link.
-				log.Lvlf2("%s: sending request for height %d to %s", s.ServerIdentity(),
-					i+1, back.Roster.List[0])
-				// Deprecated: it should be replaced by the handler so that it can be checked
-				// that the link has been created and try another node otherwise.
-				err := s.SendRaw(back.Roster.List[0], &ForwardSignature{
-					TargetHeight: i + 1,
-					Previous:     back.Hash,
-					Newest:       prop.Copy(),
-				})
-
-				if err != nil {
-					log.Warn(err)
-				}
+			if err := s.requestForwardLinks(prop); err != nil {
+				return nil, err
 			}
 		}
 	}
@@ -404,6 +409,99 @@ func (s *Service) StoreSkipBlockInternal(psbd *StoreSkipBlock) (*StoreSkipBlockR
 	return reply, nil
 }
 
+// newBlockHeader copies the header of prev to prop, the block following it
+// in the skipchain scID, and adds the index, the height and the back-links.
+// Blocks that are not stored yet, because they are appended in the same
+// batch, are looked up in pending.
+func (s *Service) newBlockHeader(scID SkipBlockID, prev, prop *SkipBlock, pending []*SkipBlock) error {
+	prop.MaximumHeight = prev.MaximumHeight
+	prop.BaseHeight = prev.BaseHeight
+	prop.VerifierIDs = prev.VerifierIDs
+	prop.Index = prev.Index + 1
+	prop.GenesisID = scID
+	prop.setForwardLinks([]*ForwardLink{})
+	prop.SignatureScheme = prev.SignatureScheme
+	prop.SignatureThreshold = prev.SignatureThreshold
+	prop.Proposers = nil
+	if prop.SignatureThreshold > len(prop.Roster.List) {
+		return errors.New("signature threshold is bigger than the roster")
+	}
+	// And calculate the height of that block.
+	index := prop.Index
+	for prop.Height = 1; index%prop.BaseHeight == 0; prop.Height++ {
+		index /= prop.BaseHeight
+		if prop.Height >= prop.MaximumHeight {
+			break
+		}
+	}
+	log.Lvl4("Found height", prop.Height, "for index", prop.Index,
+		"and maxHeight", prop.MaximumHeight, "and base", prop.BaseHeight)
+
+	// Add backlinks to the block.
+	prop.BackLinkIDs = make([]SkipBlockID, prop.Height)
+	pointer := prev
+	for h := range prop.BackLinkIDs {
+		// For every height, we pass the skiplist backwards at the lower height,
+		// till we find a block with the desired height.
+		for pointer.Height <= h {
+			id := pointer.BackLinkIDs[h-1]
+			prevPointer := s.db.GetByID(id)
+			for _, sb := range pending {
+				if prevPointer == nil && sb.Hash.Equal(id) {
+					prevPointer = sb
+				}
+			}
+			if prevPointer == nil {
+				pp, err := s.getBlocks(pointer.Roster, id, 1)
+				if err != nil {
+					return errors.New("couldn't fetch missing block: " + err.Error())
+				}
+				if len(pp) == 0 {
+					return errors.New(
+						"Didn't find convenient SkipBlock for height " +
+							strconv.Itoa(h))
+				}
+				s.db.Store(pp[0])
+				prevPointer = pp[0]
+			}
+			pointer = prevPointer
+		}
+		prop.BackLinkIDs[h] = pointer.Hash
+	}
+	prop.updateHash()
+	return nil
+}
+
+// requestForwardLinks asks the rosters of the back-links of the new block
+// to create the forward-links of height 1 and more. Again, after creation of
+// each forward-link, it will propagate them to all nodes.
+func (s *Service) requestForwardLinks(prop *SkipBlock) error {
+	log.Lvl3("Asking forward-links from all linked blocks")
+	for i, bl := range prop.BackLinkIDs[1:] {
+		back := s.db.GetByID(bl)
+		if back == nil {
+			return errors.New(
+				"Didn't get skipblock in back-link")
+
+		}
+		// Requesting creation of secondary forward link.
+		log.Lvlf2("%s: sending request for height %d to %s", s.ServerIdentity(),
+			i+1, back.Roster.List[0])
+		// Deprecated: it should be replaced by the handler so that it can be checked
+		// that the link has been created and try another node otherwise.
+		err := s.SendRaw(back.Roster.List[0], &ForwardSignature{
+			TargetHeight: i + 1,
+			Previous:     back.Hash,
+			Newest:       prop.Copy(),
+		})
+
+		if err != nil {
+			log.Warn(err)
+		}
+	}
+	return nil
+}
+
 // sendForwardLinkRequest sends requests to conodes in the given roster until either the forward-link is
 // created or there's not enough online nodes to get a valid signature.
 func sendForwardLinkRequest(ro *onet.Roster, req *ForwardSignature, reply *ForwardSignatureReply) (err error) {
@@ -573,7 +671,7 @@ func (s *Service) optimizeCheck(pr Proof, current, target int) (index int,
 	}
 	var h int
 	h, index = sb.pathForIndex(target)
-	if h > 0 && len(sb.ForwardLink) <= h {
+	if h > 0 && sb.GetForwardLen() <= h {
 		to := pr.Search(index)
 		if to == nil {
 			return -1, false, xerrors.Errorf(
@@ -599,8 +697,8 @@ func (s *Service) optimizeSend(newProof Proof) error {
 		for i, pr := range newProof[1:] {
 			roster = roster.Concat(pr.Roster.List...)
 
-			if pr.GetForwardLen() > 0 {
-				to := pr.ForwardLink[pr.GetForwardLen()-1].To
+			if fls := pr.forwardLinks(); len(fls) > 0 {
+				to := fls[len(fls)-1].To
 				log.Lvlf3("%d: Block %d / %x with fl-len %d pointing to %x",
 					i, pr.Index, pr.Hash[:], pr.GetForwardLen(), to[:])
 			}
@@ -638,10 +736,10 @@ func (s *Service) GetUpdateChain(guc *GetUpdateChain) (*GetUpdateChainReply, err
 	for block.GetForwardLen() > 0 &&
 		(maxBlocks <= 0 || len(blocks) < maxBlocks) {
 		var link *ForwardLink
-		if block.GetForwardLen() < maxHeight {
-			link = block.ForwardLink[block.GetForwardLen()-1]
+		if fls := block.forwardLinks(); len(fls) < maxHeight {
+			link = fls[len(fls)-1]
 		} else {
-			link = block.ForwardLink[maxHeight-1]
+			link = fls[maxHeight-1]
 		}
 		next := s.db.GetByID(link.To)
 		if next == nil {
@@ -682,6 +780,16 @@ func (s *Service) RegisterStoreSkipblockCallback(f func(SkipBlockID) error) {
 	s.db.callback = f
 }
 
+// newBlocksStored is called by the db with the blocks that were not yet
+// stored, and passes them to the verifier plugins, the streams and the
+// notifiers. It also lets this conode leave the chains whose roster it left.
+func (s *Service) newBlocksStored(sbs []*SkipBlock) {
+	s.commitNewBlocks(sbs)
+	s.streamNewBlocks(sbs)
+	s.notifyNewBlocks(sbs)
+	s.leaveChains(sbs)
+}
+
 // SyncChain communicates with conodes in the Roster via getBlocks
 // in order traverse the chain and save the blocks locally. It starts with
 // the given 'latest' skipblockid and fetches all blocks up to the latest block.
@@ -716,7 +824,7 @@ func (s *Service) SyncChain(roster *onet.Roster, latest SkipBlockID) error {
 		}
 
 		lBlock := blocks[len(blocks)-1]
-		if len(lBlock.ForwardLink) == 0 {
+		if lBlock.GetForwardLen() == 0 {
 			break
 		}
 		latest = lBlock.Hash
@@ -729,6 +837,12 @@ func (s *Service) SyncChain(roster *onet.Roster, latest SkipBlockID) error {
 // in the roster, in order to find an answer, even in the case that a few
 // nodes in the network are down.
 func (s *Service) getBlocks(roster *onet.Roster, id SkipBlockID, n int) ([]*SkipBlock, error) {
+	return s.getBlocksSkipping(roster, id, n, true)
+}
+
+// getBlocksSkipping works like getBlocks, but if skipping is false, it
+// returns direct neighbors instead of following the highest forward-links.
+func (s *Service) getBlocksSkipping(roster *onet.Roster, id SkipBlockID, n int, skipping bool) ([]*SkipBlock, error) {
 	subCount := len(roster.List)
 	if subCount > 10 {
 		// Only take half of the nodes to not spam the whole network.
@@ -745,7 +859,7 @@ func (s *Service) getBlocks(roster *onet.Roster, id SkipBlockID, n int) ([]*Skip
 	pisc.GetBlocks = &ProtoGetBlocks{
 		SBID:     id,
 		Count:    n,
-		Skipping: true,
+		Skipping: skipping,
 	}
 	if err := pi.Start(); err != nil {
 		return nil, err
@@ -787,7 +901,7 @@ func (s *Service) getLastBlock(roster *onet.Roster, latest SkipBlockID) (*SkipBl
 		// last block of this batch
 		lb := blocks[len(blocks)-1]
 
-		if len(lb.ForwardLink) == 0 {
+		if lb.GetForwardLen() == 0 {
 			return lb, nil
 		}
 		latest = lb.Hash
@@ -1149,6 +1263,94 @@ func (s *Service) SetPropTimeout(t time.Duration) {
 	s.propTimeout = t
 }
 
+// SetPropagationFanOut makes the propagation of blocks in rosters of at
+// least minNodes nodes go along a tree where every node sends the blocks to
+// at most fanOut nodes, instead of having the leader send them to all nodes.
+// A fanOut of 0 makes the leader contact all nodes, which is the default.
+func (s *Service) SetPropagationFanOut(minNodes, fanOut int) {
+	s.propFanOutMinNodes = minNodes
+	s.propFanOut = fanOut
+}
+
+// propagationTree returns the tree used to propagate blocks to the given
+// roster, depending on its size.
+func (s *Service) propagationTree(rooted *onet.Roster) *onet.Tree {
+	if s.propFanOut > 0 && len(rooted.List) >= s.propFanOutMinNodes {
+		return messaging.FanOutTree(s.propFanOut)(rooted)
+	}
+	return messaging.StarTree(rooted)
+}
+
+// SetPropagationPacing makes every node send the propagated blocks of a
+// skipchain to at most maxSends nodes at once, using at most bytesPerSecond
+// bytes per second. A value of 0 removes the corresponding limit, which is
+// the default.
+func (s *Service) SetPropagationPacing(maxSends, bytesPerSecond int) {
+	s.pacersLock.Lock()
+	defer s.pacersLock.Unlock()
+	s.propMaxSends = maxSends
+	s.propBytesPerSecond = bytesPerSecond
+	s.pacers = make(map[string]*messaging.Pacer)
+}
+
+// propagationPacer returns the pacer of the skipchain of the propagated
+// message, or nil if the propagations are not paced.
+func (s *Service) propagationPacer(msg network.Message) *messaging.Pacer {
+	s.pacersLock.Lock()
+	paced := s.propMaxSends > 0 || s.propBytesPerSecond > 0
+	s.pacersLock.Unlock()
+	if !paced {
+		return nil
+	}
+
+	var scID SkipBlockID
+	switch m := msg.(type) {
+	case *PropagateGenesis:
+		if m.Genesis != nil {
+			scID = m.Genesis.SkipChainID()
+		}
+	case *PropagateForwardLink:
+		if m.ForwardLink != nil {
+			if sb := s.db.GetByID(m.ForwardLink.From); sb != nil {
+				scID = sb.SkipChainID()
+			}
+		}
+	case *PropagateProof:
+		if len(m.Proof) > 0 {
+			scID = m.Proof[0].SkipChainID()
+		}
+	case *PropagateHandover:
+		if len(m.Blocks) > 0 {
+			scID = m.Blocks[0].SkipChainID()
+		}
+	}
+
+	s.pacersLock.Lock()
+	defer s.pacersLock.Unlock()
+	p, ok := s.pacers[string(scID)]
+	if !ok {
+		p = messaging.NewPacer(s.propMaxSends, s.propBytesPerSecond)
+		s.pacers[string(scID)] = p
+	}
+	return p
+}
+
+// pacingStats returns the sum of the fan-out times of all pacers.
+func (s *Service) pacingStats() (fanOuts Timing, waited time.Duration) {
+	s.pacersLock.Lock()
+	defer s.pacersLock.Unlock()
+	for _, p := range s.pacers {
+		st := p.Stats()
+		fanOuts.Count += st.FanOuts
+		fanOuts.Total += st.Total
+		if st.Max > fanOuts.Max {
+			fanOuts.Max = st.Max
+		}
+		waited += st.Waited
+	}
+	return
+}
+
 // TestClose is called by Server.Close in case we're in testing. It
 // makes sure that skipchain is not processing requests and will avoid
 // further requests that might be queued up.
@@ -1159,6 +1361,7 @@ func (s *Service) TestClose() {
 		for _, fct := range s.Storage.Follow {
 			fct.Shutdown()
 		}
+		s.streams.stopAll()
 		close(s.closing)
 		s.closedMutex.Unlock()
 		s.working.Wait()
@@ -1173,15 +1376,23 @@ func (s *Service) TestRestart() error {
 	s.TestClose()
 	db, bucket := s.GetAdditionalBucket([]byte("skipblocks"))
 	s.db = NewSkipBlockDB(db, bucket)
+	if err := s.db.migrate(); err != nil {
+		return xerrors.Errorf("couldn't convert the database: %v", err)
+	}
+	s.db.newBlocks = s.newBlocksStored
 	s.Storage = &Storage{}
 	// Don't reset the verifiers, keep them
 	//s.verifiers = map[VerifierID]SkipBlockVerifier{}
+	for _, vp := range s.plugins {
+		vp.reset()
+	}
 	s.propTimeout = defaultPropagateTimeout
 	s.blockBuffer = newSkipBlockBuffer()
 	s.closedMutex.Lock()
 	s.closed = false
 	s.closing = make(chan bool)
 	s.closedMutex.Unlock()
+	s.startMetrics()
 	return s.tryLoad()
 }
 
@@ -1199,6 +1410,23 @@ func (s *Service) verifySigs(msg, sig []byte) bool {
 	return false
 }
 
+// verifyAdminSigs is used instead of verifySigs for the operations that
+// change or delete the data of this conode. It fails closed: without linked
+// clients, only a signature from the private key of this conode is accepted.
+func (s *Service) verifyAdminSigs(msg, sig []byte) bool {
+	if schnorr.Verify(cothority.Suite, s.ServerIdentity().Public, msg, sig) == nil {
+		return true
+	}
+	s.storageMutex.Lock()
+	defer s.storageMutex.Unlock()
+	for _, cl := range s.Storage.Clients {
+		if schnorr.Verify(cothority.Suite, cl, msg, sig) == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // forwardLinkLevel0 is used to add a new block to the skipchain.
 // It verifies if the new block is valid. If it is not valid, it
 // returns with an error.
@@ -1234,22 +1462,29 @@ func (s *Service) forwardLinkLevel0(src, dst *SkipBlock) error {
 	}
 	fwd := NewForwardLink(src, dst)
 	protoName, _ := src.SignatureProtocol()
-	sig, err := s.startBFT(protoName, roster, dst.Roster, fwd.Hash(), data)
+	sig, err := s.startBFT(protoName, roster, dst.Roster, fwd.Hash(), data,
+		src.SignatureThreshold)
+	verr, refused := s.verifierErrors.LoadAndDelete(sliceToArr(fwd.Hash()))
 	if err != nil {
+		if refused {
+			err = xerrors.Errorf("%v: %v", err, verr)
+		}
 		log.Error(s.ServerIdentity().Address, "startBFT failed with", err)
 		return err
 	}
 
 	fwd.Signature = *sig
 
-	fwl := s.db.GetByID(src.Hash).ForwardLink
+	fwl := s.db.GetByID(src.Hash).forwardLinks()
 	log.Lvlf3("%s adds forward-link to %s: %d->%d - fwlinks:%v", s.ServerIdentity(),
 		roster.List, src.Index, dst.Index, fwl)
 	if len(fwl) > 0 {
 		return errors.New("forward-link got signed during our signing")
 	}
 
-	src.ForwardLink = []*ForwardLink{fwd}
+	if err := src.AddForwardLink(fwd, 0); err != nil {
+		return xerrors.Errorf("couldn't add forward-link: %v", err)
+	}
 	if err = src.VerifyForwardSignatures(); err != nil {
 		return errors.New("Wrong BFT-signature: " + err.Error())
 	}
@@ -1306,16 +1541,31 @@ func (s *Service) bftForwardLinkLevel0(msg, data []byte) bool {
 		return false
 	}
 
+	scID := fs.Newest.SkipChainID()
+	if !s.catchUps.wait(scID, s.propTimeout) {
+		log.Lvlf2("%s: still catching up with %x", s.ServerIdentity(), scID)
+		return false
+	}
 	prevSB := s.db.GetByID(fs.Previous)
 	if prevSB == nil {
 		if !s.BlockIsFriendly(fs.Newest) {
 			log.Lvlf2("%s: block is not friendly: %x", s.ServerIdentity(), fs.Newest.Hash)
 			return false
 		}
-		log.Lvl2(s.ServerIdentity(), "Didn't find src-skipblock, trying to sync")
-		if err := s.SyncChain(fs.Newest.Roster, fs.Previous); err != nil {
-			log.Error("failed to sync skipchain:", err)
-			return false
+		if s.db.GetByID(scID) == nil {
+			// A conode joining the roster gets the whole chain before
+			// taking part in the signature.
+			log.Lvl2(s.ServerIdentity(), "Didn't find skipchain, catching up")
+			if err := s.catchUp(fs.Newest.Roster, scID); err != nil {
+				log.Error("failed to catch up with skipchain:", err)
+				return false
+			}
+		} else {
+			log.Lvl2(s.ServerIdentity(), "Didn't find src-skipblock, trying to sync")
+			if err := s.SyncChain(fs.Newest.Roster, fs.Previous); err != nil {
+				log.Error("failed to sync skipchain:", err)
+				return false
+			}
 		}
 		prevSB = s.db.GetByID(fs.Previous)
 		if prevSB == nil {
@@ -1334,7 +1584,7 @@ func (s *Service) bftForwardLinkLevel0(msg, data []byte) bool {
 		log.Lvl2("Backlink does not point to previous block:", prevSB.Index, fs.Newest.Index)
 		return false
 	}
-	if len(prevSB.ForwardLink) > 0 {
+	if prevSB.GetForwardLen() > 0 {
 		log.Lvl2("previous block already has forward-link")
 		return false
 	}
@@ -1350,47 +1600,38 @@ func (s *Service) bftForwardLinkLevel0(msg, data []byte) bool {
 	// has succeeded
 	s.blockBuffer.add(fs.Newest)
 
-	ok = func() bool {
+	err = func() error {
 		for i, verifier := range prevSB.VerifierIDs {
 			if !verifier.Equal(fs.Newest.VerifierIDs[i]) {
-				log.Lvlf2("Verifier IDs in the forward signature is wrong: %s != %s", verifier.String(), fs.Newest.VerifierIDs[i].String())
-				return false
+				return xerrors.Errorf("verifier IDs in the forward signature are wrong: %s != %s",
+					verifier.String(), fs.Newest.VerifierIDs[i].String())
 			}
 		}
 		for _, ver := range fs.Newest.VerifierIDs {
 			f, exists := s.verifiers[ver]
 			if !exists {
-				log.Lvlf2("Found no user verification for %s", ver)
-				return false
-			}
-			// Now we call the verification function. Wrap up f() inside of
-			// g(), so that we can recover panics from f().
-			g := func(to []byte, newest *SkipBlock) (out bool) {
-				defer func() {
-					if re := recover(); re != nil {
-						log.Errorf("Verification function panic: %v", re)
-						out = false
-					}
-				}()
-				out = f(to, newest)
-				return
+				return xerrors.Errorf("found no user verification for %s", ver)
 			}
-
-			if !g(fl.To, fs.Newest) {
-				fname := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
-				log.Lvlf2("Verification function failed: %v %s", fname, ver)
-				return false
+			if err := s.runVerifier(ver, f, fl.To, fs.Newest); err != nil {
+				return err
 			}
 		}
-		return true
+		return nil
 	}()
-	if !ok {
+	if err != nil {
+		log.Lvlf2("%s: %v", s.ServerIdentity(), err)
+		// The leader keeps the reason, so that it can be returned to the
+		// client proposing the block.
+		if s.ServerIdentity().Equal(fs.Newest.Roster.Get(0)) {
+			s.verifierErrors.Store(sliceToArr(msg), err)
+		}
 		// So the fast storage was too fast, and the cached blocks need to
 		// be removed.
 		s.verifyNewBlockBuffer.Delete(sliceToArr(msg))
 		s.blockBuffer.clear(fs.Newest.Hash)
+		return false
 	}
-	return ok
+	return true
 }
 
 func (s *Service) bftForwardLinkLevel0Ack(msg []byte, data []byte) bool {
@@ -1459,18 +1700,19 @@ func (s *Service) ForwardLinkHandler(req *ForwardSignature) (*ForwardSignatureRe
 		// Add links to prove the newest block is valid.
 		pointer := from
 		for !pointer.Hash.Equal(fs.Newest.Hash) {
-			if len(pointer.ForwardLink) == 0 {
+			if pointer.GetForwardLen() == 0 {
 				err := s.SyncChain(pointer.Roster, pointer.Hash)
 				if err != nil {
 					return nil, err
 				}
 
 				pointer = s.db.GetByID(pointer.Hash)
-				if pointer == nil || len(pointer.ForwardLink) == 0 {
+				if pointer == nil || pointer.GetForwardLen() == 0 {
 					return nil, errors.New("Couldn't reach the proposed block from the backlink")
 				}
 			}
-			highest := pointer.ForwardLink[len(pointer.ForwardLink)-1]
+			fls := pointer.forwardLinks()
+			highest := fls[len(fls)-1]
 			fs.Links = append(fs.Links, highest)
 			next := s.db.GetByID(highest.To)
 			if next == nil {
@@ -1488,7 +1730,8 @@ func (s *Service) ForwardLinkHandler(req *ForwardSignature) (*ForwardSignatureRe
 		}
 		fl := NewForwardLink(from, fs.Newest)
 		_, protoName := from.SignatureProtocol()
-		sig, err := s.startBFT(protoName, from.Roster, fs.Newest.Roster, fl.Hash(), data)
+		sig, err := s.startBFT(protoName, from.Roster, fs.Newest.Roster, fl.Hash(), data,
+			from.SignatureThreshold)
 		if err != nil {
 			return nil, errors.New("Couldn't get signature: " + err.Error())
 		}
@@ -1564,7 +1807,8 @@ func (s *Service) bftForwardLink(msg, data []byte) bool {
 		for i, fl := range fs.Links {
 			publics := newRoster.ServicePublics(ServiceName)
 
-			if err := fl.VerifyWithScheme(suite, publics, src.SignatureScheme); err != nil {
+			if err := fl.VerifyWithThreshold(suite, publics, src.SignatureScheme,
+				src.SignatureThreshold); err != nil {
 				return errors.New("verification failed: " + err.Error())
 			}
 			if fl.NewRoster != nil {
@@ -1619,7 +1863,8 @@ func (s *Service) bftForwardLinkAck(msg, data []byte) bool {
 // the same. This is an optimisation because the newer roster might have an
 // order that is more likely to give us non-failing subleaders in the byzcoinx
 // protocol.
-func (s *Service) startBFT(proto string, origRoster, newRoster *onet.Roster, msg, data []byte) (*byzcoinx.FinalSignature, error) {
+func (s *Service) startBFT(proto string, origRoster, newRoster *onet.Roster, msg, data []byte,
+	threshold int) (*byzcoinx.FinalSignature, error) {
 	// Before BDN signatures, the new roster was used when it was a rotation so
 	// that subleaders were more likely to be alive. It doesn't work anymore with
 	// BDN signatures because the way coefficients are computed.
@@ -1654,6 +1899,9 @@ func (s *Service) startBFT(proto string, origRoster, newRoster *onet.Roster, msg
 	root.FinalSignatureChan = make(chan byzcoinx.FinalSignature, 1)
 	root.Timeout = s.propTimeout
 	root.Threshold = byzcoinx.Threshold(len(tree.List()))
+	if threshold > 0 {
+		root.Threshold = threshold
+	}
 	if s.bftTimeout != 0 {
 		root.Timeout = s.bftTimeout
 	}
@@ -1791,6 +2039,7 @@ func (s *Service) propagateProofHandler(msg network.Message) error {
 	}
 
 	log.Lvlf3("Proof has been propagated to %v", s.ServerIdentity())
+	s.catchUpIfJoined(pc.Proof)
 	return nil
 }
 
@@ -1801,6 +2050,18 @@ func (s *Service) registerVerification(v VerifierID, f SkipBlockVerifier) error
 	return nil
 }
 
+// registerVerificationTimeout stores the verification like
+// registerVerification, and how long it may run before the block is refused.
+func (s *Service) registerVerificationTimeout(v VerifierID, f SkipBlockVerifier, timeout time.Duration) error {
+	if timeout <= 0 {
+		return xerrors.New("the timeout of the verifier must be positive")
+	}
+	s.verifierTimeoutsLock.Lock()
+	s.verifierTimeouts[v] = timeout
+	s.verifierTimeoutsLock.Unlock()
+	return s.registerVerification(v, f)
+}
+
 // verifyBlock makes sure the basic parameters of a block are correct and returns
 // an error if something fails.
 func (s *Service) verifyBlock(sb *SkipBlock) error {
@@ -1828,6 +2089,15 @@ func (s *Service) verifyBlock(sb *SkipBlock) error {
 	if sb.Roster == nil {
 		return errors.New("Need a roster")
 	}
+	if sb.SignatureThreshold < 0 {
+		return errors.New("Can't have a signature threshold < 0")
+	}
+	if sb.SignatureThreshold > len(sb.Roster.List) {
+		return errors.New("Signature threshold is bigger than the roster")
+	}
+	if len(sb.Proposers) > 0 && !VerifierIDs(sb.VerifierIDs).Contains(VerifyProposers) {
+		return errors.New("Proposers need the VerifyProposers verifier")
+	}
 	return nil
 }
 
@@ -1838,7 +2108,9 @@ func (s *Service) startPropagation(propagate messaging.PropagationFunc, ro *onet
 	}
 	defer s.decrementWorking()
 
+	start := time.Now()
 	replies, err := propagate(ro, msg, s.propTimeout)
+	s.db.timings.recordPropagation(start, len(ro.List), replies)
 	if err != nil {
 		return err
 	}
@@ -1906,6 +2178,9 @@ func (s *Service) ChainIsFriendly(scID SkipBlockID) bool {
 // BlockIsFriendly searches if all members of the new block are followed
 // by this node.
 func (s *Service) BlockIsFriendly(sb *SkipBlock) bool {
+	if s.chainIsDeleted(sb.SkipChainID()) {
+		return false
+	}
 	if s.ChainIsFriendly(sb.SkipChainID()) {
 		return true
 	}
@@ -1992,13 +2267,22 @@ func sliceToArr(msg []byte) [32]byte {
 func newSkipchainService(c *onet.Context) (onet.Service, error) {
 	db, bucket := c.GetAdditionalBucket([]byte("skipblocks"))
 	s := &Service{
-		ServiceProcessor: onet.NewServiceProcessor(c),
-		db:               NewSkipBlockDB(db, bucket),
-		Storage:          &Storage{},
-		verifiers:        map[VerifierID]SkipBlockVerifier{},
-		propTimeout:      defaultPropagateTimeout,
-		closing:          make(chan bool),
-		blockBuffer:      newSkipBlockBuffer(),
+		ServiceProcessor:  onet.NewServiceProcessor(c),
+		db:                NewSkipBlockDB(db, bucket),
+		Storage:           &Storage{},
+		verifiers:         map[VerifierID]SkipBlockVerifier{},
+		plugins:           map[VerifierID]*verifierPlugin{},
+		propTimeout:       defaultPropagateTimeout,
+		closing:           make(chan bool),
+		blockBuffer:       newSkipBlockBuffer(),
+		anchors:           anchorBatches{epoch: defaultAnchorEpoch},
+		verifierTimeout:   defaultVerifierTimeout,
+		verifierTimeouts:  map[VerifierID]time.Duration{},
+		idempotencyWindow: defaultIdempotencyWindow,
+	}
+	s.db.newBlocks = s.newBlocksStored
+	if err := s.db.migrate(); err != nil {
+		return nil, xerrors.Errorf("couldn't convert the database: %v", err)
 	}
 
 	if err := s.tryLoad(); err != nil {
@@ -2008,25 +2292,68 @@ func newSkipchainService(c *onet.Context) (onet.Service, error) {
 		s.GetSingleBlock, s.GetSingleBlockByIndex, s.GetAllSkipchains,
 		s.GetAllSkipChainIDs, s.OptimizeProof,
 		s.CreateLinkPrivate, s.Unlink, s.AddFollow, s.ListFollow,
-		s.DelFollow, s.Listlink, s.ForwardLinkHandler))
+		s.DelFollow, s.Listlink, s.ForwardLinkHandler, s.AnchorData,
+		s.RegisterName, s.ResolveName, s.GetDBSummary, s.ReconcileDB,
+		s.SetAnnotation, s.GetAnnotation, s.GetSignedHead, s.GetCheckpoint, s.Redact, s.GetRedaction,
+		s.DeleteChainLocal, s.ArchiveExport, s.ArchiveImport, s.GetBlockRange, s.ChangeRoster,
+		s.GetProof, s.GetForkEvidence, s.ResolveFork, s.GetMetrics, s.GetPoF, s.StoreSkipBlocks, s.UpdateStream))
+	log.ErrFatal(s.RegisterStreamingHandlers(s.StreamBlocks))
 	s.ServiceProcessor.RegisterStatusReporter("Skipblock", s.db)
+	s.ServiceProcessor.RegisterStatusReporter("SkipblockReverify", &s.reverify)
+	s.ServiceProcessor.RegisterStatusReporter("SkipblockPruning", &s.pruning)
+	s.ServiceProcessor.RegisterStatusReporter("SkipblockFreshness", &s.freshness)
+	s.ServiceProcessor.RegisterStatusReporter("SkipblockVerifiers", &s.verifierStats)
 	// Deprecated: the handler should be used instead
 	s.RegisterProcessorFunc(network.RegisterMessage(&ForwardSignature{}), s.forwardLink)
+	s.RegisterProcessorFunc(network.RegisterMessage(&HeadSubscription{}), s.handleHeadSubscription)
+	s.RegisterProcessorFunc(network.RegisterMessage(&HeadSubscriptionReply{}), s.handleHeadSubscriptionReply)
+	s.RegisterProcessorFunc(network.RegisterMessage(&HeadUpdate{}), s.handleHeadUpdate)
 
 	if err := s.registerVerification(VerifyBase, s.verifyFuncBase); err != nil {
 		return nil, err
 	}
+	if err := s.registerVerification(VerifyRedactable, s.verifyFuncRedactable); err != nil {
+		return nil, err
+	}
+	if err := s.registerVerification(VerifyFreezable, s.verifyFuncFreezable); err != nil {
+		return nil, err
+	}
+	if err := s.registerVerification(VerifyNameRegistry, s.verifyFuncNameRegistry); err != nil {
+		return nil, err
+	}
+	if err := s.registerVerification(VerifyProposers, s.verifyFuncProposers); err != nil {
+		return nil, err
+	}
+	if err := s.registerVerification(VerifyRosterChange, s.verifyFuncRosterChange); err != nil {
+		return nil, err
+	}
+	if err := s.registerSignedHead(); err != nil {
+		return nil, err
+	}
+	s.startMetrics()
 
 	var err error
-	s.propagateGenesis, err = messaging.NewPropagationFunc(c, "SkipchainPropagate", s.propagateGenesisHandler, -1)
+	s.propagateGenesis, err = messaging.NewPropagationFuncPaced(c, "SkipchainPropagate",
+		s.propagateGenesisHandler, -1, s.propagationTree,
+		s.propagationPacer)
+	if err != nil {
+		return nil, err
+	}
+	s.propagateForwardLink, err = messaging.NewPropagationFuncPaced(c, "SkipchainPropagateFL",
+		s.propagateForwardLinkHandler, -1, s.propagationTree,
+		s.propagationPacer)
 	if err != nil {
 		return nil, err
 	}
-	s.propagateForwardLink, err = messaging.NewPropagationFunc(c, "SkipchainPropagateFL", s.propagateForwardLinkHandler, -1)
+	s.propagateProof, err = messaging.NewPropagationFuncPaced(c, "SkipchainPropagateProof",
+		s.propagateProofHandler, -1, s.propagationTree,
+		s.propagationPacer)
 	if err != nil {
 		return nil, err
 	}
-	s.propagateProof, err = messaging.NewPropagationFunc(c, "SkipchainPropagateProof", s.propagateProofHandler, -1)
+	s.propagateHandover, err = messaging.NewPropagationFuncPaced(c, "SkipchainPropagateHandover",
+		s.propagateHandoverHandler, -1, s.propagationTree,
+		s.propagationPacer)
 	if err != nil {
 		return nil, err
 	}
@@ -2052,6 +2379,17 @@ func newSkipchainService(c *onet.Context) (onet.Service, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Register ByzCoinX protocols for batches of blocks
+	err = byzcoinx.InitBFTCoSiProtocol(suite, s.Context,
+		s.bftForwardLinkBatch, s.bftForwardLinkBatchAck, bftNewBatch)
+	if err != nil {
+		return nil, err
+	}
+	err = byzcoinx.InitBDNCoSiProtocol(suite, s.Context,
+		s.bftForwardLinkBatch, s.bftForwardLinkBatchAck, bdnNewBatch)
+	if err != nil {
+		return nil, err
+	}
 
 	return s, nil
 }
@@ -386,6 +386,119 @@ func TestService_ProtocolVerificationPanic(t *testing.T) {
 
 	// We expect a failure here, because the verification function is panicing.
 	require.Contains(t, err.Error(), "couldn't sign forward-link")
+	require.Contains(t, err.Error(), "panicked: nope")
+}
+
+func TestService_ProtocolVerificationTimeout(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	_, el, s := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	s1 := s.(*Service)
+	stall := make(chan struct{})
+	defer close(stall)
+	verifyFunc := func(newID []byte, newSB *SkipBlock) bool {
+		<-stall
+		return true
+	}
+	verifyID := VerifierID(uuid.NewV1())
+	for _, s := range local.Services {
+		service := s[skipchainSID].(*Service)
+		service.registerVerification(verifyID, verifyFunc)
+		service.SetVerifierTimeout(100 * time.Millisecond)
+	}
+
+	sbRoot, err := makeGenesisRosterArgs(s1, el, nil, []VerifierID{verifyID}, 1, 1)
+	require.NoError(t, err)
+	sbNext := sbRoot.Copy()
+	sbNext.BackLinkIDs = []SkipBlockID{sbRoot.Hash}
+	_, err = s1.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: sbRoot.Hash, NewBlock: sbNext})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "couldn't sign forward-link")
+	require.Contains(t, err.Error(), "timed out after 100ms")
+}
+
+func TestService_ProtocolVerificationOwnTimeout(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	_, el, s := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	s1 := s.(*Service)
+	verifyFunc := func(newID []byte, newSB *SkipBlock) bool {
+		time.Sleep(300 * time.Millisecond)
+		return true
+	}
+	slowID := VerifierID(uuid.NewV1())
+	for _, s := range local.Services {
+		service := s[skipchainSID].(*Service)
+		require.Error(t, service.registerVerificationTimeout(slowID, verifyFunc, 0))
+		require.NoError(t, service.registerVerificationTimeout(slowID, verifyFunc, 10*time.Second))
+		service.SetVerifierTimeout(100 * time.Millisecond)
+	}
+
+	// The verifier runs longer than the timeout of the service, but not
+	// longer than its own timeout.
+	sbRoot, err := makeGenesisRosterArgs(s1, el, nil, []VerifierID{slowID}, 1, 1)
+	require.NoError(t, err)
+	sbNext := sbRoot.Copy()
+	sbNext.BackLinkIDs = []SkipBlockID{sbRoot.Hash}
+	_, err = s1.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: sbRoot.Hash, NewBlock: sbNext})
+	require.NoError(t, err)
+	stat := s1.GetVerifierStats(sbRoot.Hash, slowID)
+	require.Equal(t, 1, stat.Accepted)
+	require.Equal(t, 0, stat.Timeouts)
+}
+
+func TestService_ProtocolVerificationRunning(t *testing.T) {
+	defer func(max int) { maxRunningVerifiers = max }(maxRunningVerifiers)
+	maxRunningVerifiers = 1
+
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	_, el, s := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	s1 := s.(*Service)
+	stall := make(chan struct{})
+	verifyFunc := func(newID []byte, newSB *SkipBlock) bool {
+		<-stall
+		return true
+	}
+	verifyID := VerifierID(uuid.NewV1())
+	var services []*Service
+	for _, s := range local.Services {
+		service := s[skipchainSID].(*Service)
+		service.registerVerification(verifyID, verifyFunc)
+		service.SetVerifierTimeout(100 * time.Millisecond)
+		services = append(services, service)
+	}
+
+	sbRoot, err := makeGenesisRosterArgs(s1, el, nil, []VerifierID{verifyID}, 1, 1)
+	require.NoError(t, err)
+	sbNext := sbRoot.Copy()
+	sbNext.BackLinkIDs = []SkipBlockID{sbRoot.Hash}
+	_, err = s1.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: sbRoot.Hash, NewBlock: sbNext})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "timed out after 100ms")
+
+	// The stalled call is still running, so the verifier is not called again.
+	_, err = s1.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: sbRoot.Hash, NewBlock: sbNext})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "calls still running")
+
+	close(stall)
+	require.Eventually(t, func() bool {
+		for _, service := range services {
+			service.verifiersRunning.Lock()
+			running := len(service.verifiersRunning.count)
+			service.verifiersRunning.Unlock()
+			if running > 0 {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 10*time.Millisecond)
+	_, err = s1.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: sbRoot.Hash, NewBlock: sbNext})
+	require.NoError(t, err)
 }
 
 func TestService_RegisterVerification(t *testing.T) {
@@ -1277,6 +1390,84 @@ func makeHELS(local *onet.LocalTest, nbr int) ([]*onet.Server, *onet.Roster, *Se
 	return hosts, el, local.Services[hosts[0].ServerIdentity.ID][skipchainSID].(*Service)
 }
 
+func TestService_PropagationFanOut(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	servers, ro, genService := local.MakeSRS(cothority.Suite, 10, skipchainSID)
+	service := genService.(*Service)
+	var services []*Service
+	for _, s := range local.GetServices(servers, skipchainSID) {
+		s.(*Service).SetPropagationFanOut(5, 3)
+		services = append(services, s.(*Service))
+	}
+
+	tree := service.propagationTree(ro)
+	require.Equal(t, 3, len(tree.Root.Children))
+	tree = service.propagationTree(onet.NewRoster(ro.List[:4]))
+	require.Equal(t, 3, len(tree.Root.Children))
+	require.True(t, tree.Root.Children[0].IsLeaf())
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+	}
+
+	// All nodes converge, even the ones that only got the blocks from
+	// other nodes than the leader.
+	for _, s := range services {
+		latest, err := s.db.GetLatestByID(genesis.Hash)
+		require.NoError(t, err)
+		require.Equal(t, 2, latest.Index)
+		sb := s.db.GetByID(genesis.Hash)
+		require.Equal(t, 1, len(sb.ForwardLink))
+		require.NoError(t, sb.VerifyForwardSignatures())
+	}
+}
+
+func TestService_PropagationPacing(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	servers, ro, genService := local.MakeSRS(cothority.Suite, 5, skipchainSID)
+	service := genService.(*Service)
+	require.Nil(t, service.propagationPacer(&PropagateGenesis{}))
+	for _, s := range local.GetServices(servers, skipchainSID) {
+		s.(*Service).SetPropagationPacing(2, 1e6)
+	}
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	_, err = service.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash,
+		NewBlock:          sb,
+	})
+	require.NoError(t, err)
+
+	// All propagations of a skipchain share the same pacer.
+	pacer := service.propagationPacer(&PropagateGenesis{genesis})
+	require.NotNil(t, pacer)
+	require.True(t, pacer == service.propagationPacer(&PropagateForwardLink{
+		ForwardLink: &ForwardLink{From: genesis.Hash}}))
+	other := NewSkipBlock()
+	other.Hash = other.CalculateHash()
+	require.True(t, pacer != service.propagationPacer(&PropagateGenesis{other}))
+
+	m, err := service.Metrics()
+	require.NoError(t, err)
+	require.True(t, m.FanOutTimes.Count > 0)
+	require.True(t, m.FanOutTimes.Max > 0)
+}
+
 func waitPropagationFinished(t *testing.T, local *onet.LocalTest) {
 	var servers []*onet.Server
 	for _, s := range local.Servers {
@@ -1368,7 +1559,7 @@ func nukeBlocksFrom(t *testing.T, db *SkipBlockDB, where SkipBlockID) {
 		// nuke it
 		log.Lvl2("nuking block", sb.Index)
 		err := db.Update(func(tx *bbolt.Tx) error {
-			err := tx.Bucket([]byte(db.bucketName)).Delete(where)
+			err := db.deleteBlockTx(tx, where)
 			if err != nil {
 				log.Fatal("delete error", err)
 			}
@@ -0,0 +1,234 @@
+package skipchain
+
+import (
+	"sync"
+
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the streaming of new blocks to clients. A client opens a
+stream with StreamBlocks for one or more skipchains, and gets every block
+that is newly stored by the conode, together with the forward-link pointing
+to it. The client unsubscribes by closing the connection. A client that
+doesn't read fast enough gets disconnected and needs to catch up with
+GetUpdateChain. If the client gives an ID to the stream, it can add and
+remove skipchains of the open stream with UpdateStream.
+*/
+
+// streamBuffer is the number of blocks waiting to be sent to a client
+// before it is disconnected.
+const streamBuffer = 64
+
+// blockStreams holds the listeners of every skipchain, indexed by the
+// skipchain-ID. A listener can be registered for many skipchains.
+type blockStreams struct {
+	sync.Mutex
+	listeners map[string][]chan *StreamBlocksReply
+	// chains holds the skipchains of every listener.
+	chains map[chan *StreamBlocksReply][]string
+	// byID holds the listeners with an ID given by the client, and ids the
+	// ID of these listeners.
+	byID map[string]chan *StreamBlocksReply
+	ids  map[chan *StreamBlocksReply]string
+}
+
+func (bs *blockStreams) newListener(id []byte, scIDs ...string) (chan *StreamBlocksReply, error) {
+	bs.Lock()
+	defer bs.Unlock()
+	if bs.listeners == nil {
+		bs.listeners = make(map[string][]chan *StreamBlocksReply)
+		bs.chains = make(map[chan *StreamBlocksReply][]string)
+		bs.byID = make(map[string]chan *StreamBlocksReply)
+		bs.ids = make(map[chan *StreamBlocksReply]string)
+	}
+	if _, ok := bs.byID[string(id)]; ok && len(id) > 0 {
+		return nil, xerrors.New("stream ID is already used")
+	}
+	c := make(chan *StreamBlocksReply, streamBuffer)
+	for _, scID := range scIDs {
+		bs.subscribe(c, scID)
+	}
+	if len(id) > 0 {
+		bs.byID[string(id)] = c
+		bs.ids[c] = string(id)
+	}
+	return c, nil
+}
+
+// update adds and removes skipchains of the listener with the given ID.
+func (bs *blockStreams) update(id []byte, add, remove []string) error {
+	bs.Lock()
+	defer bs.Unlock()
+	c, ok := bs.byID[string(id)]
+	if !ok || len(id) == 0 {
+		return xerrors.New("unknown stream")
+	}
+	for _, scID := range add {
+		bs.subscribe(c, scID)
+	}
+	for _, scID := range remove {
+		bs.unsubscribe(c, scID)
+	}
+	return nil
+}
+
+// subscribe adds the skipchain to the listener, if it is not yet there.
+func (bs *blockStreams) subscribe(c chan *StreamBlocksReply, scID string) {
+	for _, known := range bs.chains[c] {
+		if known == scID {
+			return
+		}
+	}
+	bs.listeners[scID] = append(bs.listeners[scID], c)
+	bs.chains[c] = append(bs.chains[c], scID)
+}
+
+// unsubscribe removes the skipchain from the listener.
+func (bs *blockStreams) unsubscribe(c chan *StreamBlocksReply, scID string) {
+	ls := bs.listeners[scID]
+	for i, l := range ls {
+		if l == c {
+			ls = append(ls[:i:i], ls[i+1:]...)
+			break
+		}
+	}
+	if len(ls) == 0 {
+		delete(bs.listeners, scID)
+	} else {
+		bs.listeners[scID] = ls
+	}
+	chains := bs.chains[c]
+	for i, known := range chains {
+		if known == scID {
+			bs.chains[c] = append(chains[:i:i], chains[i+1:]...)
+			break
+		}
+	}
+}
+
+// stopListener closes the channel, which makes onet close the connection,
+// if it is still registered.
+func (bs *blockStreams) stopListener(c chan *StreamBlocksReply) {
+	bs.Lock()
+	defer bs.Unlock()
+	bs.removeListener(c)
+}
+
+func (bs *blockStreams) removeListener(c chan *StreamBlocksReply) {
+	scIDs, ok := bs.chains[c]
+	if !ok {
+		return
+	}
+	for _, scID := range append([]string{}, scIDs...) {
+		bs.unsubscribe(c, scID)
+	}
+	delete(bs.chains, c)
+	if id, ok := bs.ids[c]; ok {
+		delete(bs.byID, id)
+		delete(bs.ids, c)
+	}
+	close(c)
+}
+
+// notify sends the reply to all listeners of the skipchain. Listeners
+// that are too slow are removed.
+func (bs *blockStreams) notify(scID string, reply *StreamBlocksReply) {
+	bs.Lock()
+	defer bs.Unlock()
+	for _, c := range append([]chan *StreamBlocksReply{}, bs.listeners[scID]...) {
+		select {
+		case c <- reply:
+		default:
+			log.Warnf("stream of skipchain %x is too slow, closing it",
+				[]byte(scID))
+			bs.removeListener(c)
+		}
+	}
+}
+
+func (bs *blockStreams) stopAll() {
+	bs.Lock()
+	defer bs.Unlock()
+	for c := range bs.chains {
+		close(c)
+	}
+	bs.listeners = nil
+	bs.chains = nil
+	bs.byID = nil
+	bs.ids = nil
+}
+
+// streamNewBlocks sends the new blocks to the listeners of their skipchain.
+func (s *Service) streamNewBlocks(sbs []*SkipBlock) {
+	for _, sb := range sbs {
+		scID := sb.SkipChainID()
+		s.streams.Lock()
+		listening := len(s.streams.listeners[string(scID)]) > 0
+		s.streams.Unlock()
+		if !listening {
+			continue
+		}
+		reply := &StreamBlocksReply{Block: sb}
+		if len(sb.BackLinkIDs) > 0 {
+			prev := s.db.GetByID(sb.BackLinkIDs[0])
+			if prev != nil {
+				if fl := prev.GetForward(0); fl != nil && fl.To.Equal(sb.Hash) {
+					reply.Link = fl
+				}
+			}
+		}
+		s.streams.notify(string(scID), reply)
+	}
+}
+
+// StreamBlocks sends the new blocks of the skipchains to the client until
+// the client closes the connection.
+func (s *Service) StreamBlocks(req *StreamBlocks) (chan *StreamBlocksReply, chan bool, error) {
+	keys, err := s.streamKeys(append([]SkipBlockID{req.SkipChainID}, req.Others...))
+	if err != nil {
+		return nil, nil, err
+	}
+	outChan, err := s.streams.newListener(req.StreamID, keys...)
+	if err != nil {
+		return nil, nil, err
+	}
+	stopChan := make(chan bool)
+	go func() {
+		// The connection is closed by the client, or the service is
+		// closing.
+		<-stopChan
+		s.streams.stopListener(outChan)
+	}()
+	return outChan, stopChan, nil
+}
+
+// UpdateStream adds and removes skipchains of an open stream of new blocks.
+func (s *Service) UpdateStream(req *UpdateStream) (*UpdateStreamReply, error) {
+	add, err := s.streamKeys(req.Add)
+	if err != nil {
+		return nil, err
+	}
+	var remove []string
+	for _, scID := range req.Remove {
+		remove = append(remove, string(scID))
+	}
+	if err := s.streams.update(req.StreamID, add, remove); err != nil {
+		return nil, err
+	}
+	return &UpdateStreamReply{}, nil
+}
+
+// streamKeys returns the keys of the listeners of the skipchains, or an
+// error if one of them is unknown.
+func (s *Service) streamKeys(scIDs []SkipBlockID) ([]string, error) {
+	var keys []string
+	for _, scID := range scIDs {
+		if s.db.GetByID(scID) == nil {
+			return nil, xerrors.Errorf("unknown skipchain %x", scID)
+		}
+		keys = append(keys, string(scID))
+	}
+	return keys, nil
+}
@@ -0,0 +1,116 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestBlockStreams_SlowListener(t *testing.T) {
+	var bs blockStreams
+	c, err := bs.newListener([]byte("id"), "chain")
+	require.NoError(t, err)
+	for i := 0; i < streamBuffer; i++ {
+		bs.notify("chain", &StreamBlocksReply{})
+	}
+	require.Equal(t, 1, len(bs.listeners["chain"]))
+	bs.notify("chain", &StreamBlocksReply{})
+	require.Equal(t, 0, len(bs.listeners["chain"]))
+	for range c {
+	}
+	// The ID is free again.
+	_, err = bs.newListener([]byte("id"), "chain")
+	require.NoError(t, err)
+}
+
+func TestBlockStreams_Update(t *testing.T) {
+	var bs blockStreams
+	c, err := bs.newListener([]byte("id"), "a")
+	require.NoError(t, err)
+	_, err = bs.newListener([]byte("id"), "a")
+	require.Error(t, err)
+	require.Error(t, bs.update([]byte("other"), []string{"b"}, nil))
+	require.Error(t, bs.update(nil, []string{"b"}, nil))
+
+	require.NoError(t, bs.update([]byte("id"), []string{"b", "b"}, []string{"a"}))
+	require.Equal(t, []string{"b"}, bs.chains[c])
+	require.Equal(t, 0, len(bs.listeners["a"]))
+	require.Equal(t, 1, len(bs.listeners["b"]))
+	bs.notify("b", &StreamBlocksReply{})
+	require.Equal(t, 1, len(c))
+
+	bs.stopListener(c)
+	require.Equal(t, 0, len(bs.listeners["b"]))
+	require.Error(t, bs.update([]byte("id"), []string{"a"}, nil))
+}
+
+func TestClient_StreamBlocks(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	_, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil, VerificationNone, 1, 1)
+	require.NoError(t, err)
+	_, _, err = service.StreamBlocks(&StreamBlocks{SkipChainID: SkipBlockID{1}})
+	require.Error(t, err)
+
+	c := NewClient()
+	replies := make(chan *StreamBlocksReply, 10)
+	errs := make(chan error, 10)
+	done := make(chan error)
+	go func() {
+		done <- c.StreamBlocks(ro.List[1], genesis.Hash,
+			func(reply *StreamBlocksReply, err error) {
+				if err != nil {
+					errs <- err
+					return
+				}
+				replies <- reply
+			})
+	}()
+	// Wait for the stream to be registered.
+	other := l.Services[ro.List[1].ID][skipchainSID].(*Service)
+	for {
+		other.streams.Lock()
+		n := len(other.streams.listeners[string(genesis.Hash)])
+		other.streams.Unlock()
+		if n == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	prev := genesis
+	for i := 1; i <= 2; i++ {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		require.NoError(t, err)
+		select {
+		case reply := <-replies:
+			require.Equal(t, i, reply.Block.Index)
+			require.NotNil(t, reply.Link)
+			require.Equal(t, prev.Hash, reply.Link.From)
+			require.NoError(t, reply.Link.VerifyWithThreshold(suite,
+				ro.ServicePublics(ServiceName), prev.SignatureScheme,
+				prev.SignatureThreshold))
+			prev = reply.Block
+		case err := <-errs:
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("didn't get the new block")
+		}
+	}
+
+	require.NoError(t, c.Close())
+	require.NoError(t, <-done)
+	require.Error(t, <-errs)
+}
@@ -12,12 +12,14 @@ import (
 	"strconv"
 	"sync"
 	"time"
+	"unsafe"
 
 	"go.dedis.ch/cothority/v3/blscosi/bdnproto"
 	"go.dedis.ch/cothority/v3/blscosi/protocol"
 	"go.dedis.ch/cothority/v3/byzcoinx"
 	"go.dedis.ch/kyber/v3"
 	"go.dedis.ch/kyber/v3/pairing"
+	"go.dedis.ch/kyber/v3/sign"
 	"go.dedis.ch/onet/v3"
 	"go.dedis.ch/onet/v3/log"
 	"go.dedis.ch/onet/v3/network"
@@ -94,6 +96,16 @@ func (vids VerifierIDs) Equal(others []VerifierID) bool {
 	return true
 }
 
+// Contains returns true if the verifier is in the array
+func (vids VerifierIDs) Contains(vid VerifierID) bool {
+	for _, v := range vids {
+		if v.Equal(vid) {
+			return true
+		}
+	}
+	return false
+}
+
 // SkipBlockVerifier is function that should return whether this skipblock is
 // accepted or not. This function is used during a BFTCosi round, but wrapped
 // around so it accepts a block.
@@ -236,11 +248,38 @@ func RegisterVerification(s GetService, v VerifierID, f SkipBlockVerifier) error
 	return scs.(*Service).registerVerification(v, f)
 }
 
+// RegisterVerificationTimeout stores the verification like
+// RegisterVerification, with its own timeout instead of the one of the
+// service. It is used for verifiers that can take longer, like the ones
+// replaying the payload of the block.
+func RegisterVerificationTimeout(s GetService, v VerifierID, f SkipBlockVerifier, timeout time.Duration) error {
+	scs := s.Service(ServiceName)
+	if scs == nil {
+		return errors.New("Didn't find our service: " + ServiceName)
+	}
+	return scs.(*Service).registerVerificationTimeout(v, f, timeout)
+}
+
 var (
 	// VerifyBase checks that the base-parameters are correct, i.e.,
 	// the links are correctly set up, the height-parameters and the
 	// verification didn't change.
 	VerifyBase = VerifierID(uuid.NewV5(uuid.NamespaceURL, "Base"))
+	// VerifyRedactable checks that the payload of a redactable block matches
+	// the digest in its data.
+	VerifyRedactable = VerifierID(uuid.NewV5(uuid.NamespaceURL, "Redactable"))
+	// VerifyFreezable refuses new data blocks while the chain is frozen by
+	// one of the administrators given in the genesis block.
+	VerifyFreezable = VerifierID(uuid.NewV5(uuid.NamespaceURL, "Freezable"))
+	// VerifyNameRegistry refuses blocks of a naming registry with an
+	// invalid or an already registered name.
+	VerifyNameRegistry = VerifierID(uuid.NewV5(uuid.NamespaceURL, "NameRegistry"))
+	// VerifyRosterChange refuses blocks changing more than a third of the
+	// roster, or changing it without a RosterChange describing the change.
+	VerifyRosterChange = VerifierID(uuid.NewV5(uuid.NamespaceURL, "RosterChange"))
+	// VerifyProposers refuses blocks of chains with Proposers that are not
+	// signed by one of them. It is needed by all chains with Proposers.
+	VerifyProposers = VerifierID(uuid.NewV5(uuid.NamespaceURL, "Proposers"))
 )
 
 // VerificationStandard makes sure that all links are correct and that the
@@ -248,6 +287,17 @@ var (
 // blocks.
 var VerificationStandard = []VerifierID{VerifyBase}
 
+// VerificationNameRegistry is used for the naming registries.
+var VerificationNameRegistry = []VerifierID{VerifyBase, VerifyNameRegistry}
+
+// VerificationRosterChange is used for the chains whose roster is changed
+// with ChangeRoster.
+var VerificationRosterChange = []VerifierID{VerifyBase, VerifyRosterChange}
+
+// VerificationProposers is used for the chains restricting their
+// Proposers.
+var VerificationProposers = []VerifierID{VerifyBase, VerifyProposers}
+
 // VerificationNone is mostly used for test - it allows for nearly every new
 // block to be appended.
 var VerificationNone = []VerifierID{}
@@ -330,7 +380,9 @@ type SkipBlock struct {
 	Hash SkipBlockID
 
 	// ForwardLink will be calculated once future SkipBlocks are
-	// available
+	// available. It is exported for the encoding, but must only be used
+	// through GetForward, GetForwardLinks, AddForwardLink and
+	// RemoveForwardLinks.
 	ForwardLink []*ForwardLink
 
 	// Payload is additional data that needs to be hashed by the application
@@ -342,6 +394,39 @@ type SkipBlock struct {
 
 	// SignatureScheme holds the index of the scheme to use to verify the signature.
 	SignatureScheme uint32
+
+	// SignatureThreshold is the minimum number of nodes of the roster that
+	// need to sign a forward-link starting at this block. It is set in the
+	// genesis block and copied to all following blocks. If it is 0, the
+	// default threshold of the protocol is used.
+	SignatureThreshold int `protobuf:"opt"`
+
+	// Proposers holds the keys allowed to propose new blocks for the chain.
+	// It is only set in the genesis block. If it is empty, the chain
+	// accepts blocks from any proposer.
+	Proposers []kyber.Point `protobuf:"opt"`
+
+	// ProposerSignature is the signature of one of the Proposers of the
+	// genesis block on the proposal of this block. It is only set in
+	// chains with Proposers, and checked by VerifyProposers.
+	ProposerSignature []byte `protobuf:"opt"`
+}
+
+// forwardLinkLocks protect ForwardLink when it is accessed through the
+// methods of SkipBlock, as blocks are shared between goroutines during
+// propagation. Every block uses the lock given by its address, so that
+// goroutines working on different blocks rarely wait for each other, without
+// keeping a lock in the blocks, which are copied by value and created by
+// decoding. The slices are never modified in place but replaced by an
+// updated copy, so a slice read before an update stays consistent.
+var forwardLinkLocks [256]sync.RWMutex
+
+// fwdLock returns the lock of the forward-links of the block. As the address
+// of a block on the stack can change, the lock must be kept until it is
+// released.
+func (sb *SkipBlock) fwdLock() *sync.RWMutex {
+	addr := uintptr(unsafe.Pointer(sb))
+	return &forwardLinkLocks[(addr>>4)%uintptr(len(forwardLinkLocks))]
 }
 
 // NewSkipBlock pre-initialises the block so it can be sent over
@@ -369,13 +454,14 @@ func (sb *SkipBlock) VerifyForwardSignatures() error {
 
 	publics := sb.Roster.ServicePublics(ServiceName)
 
-	for _, fl := range sb.ForwardLink {
+	for _, fl := range sb.forwardLinks() {
 		if fl.IsEmpty() {
 			// This means it's an empty forward-link to correctly place a higher-order
 			// forward-link in place.
 			continue
 		}
-		if err := fl.VerifyWithScheme(suite, publics, sb.SignatureScheme); err != nil {
+		if err := fl.VerifyWithThreshold(suite, publics, sb.SignatureScheme,
+			sb.SignatureThreshold); err != nil {
 			return errors.New("Wrong signature in forward-link: " + err.Error())
 		}
 	}
@@ -392,16 +478,22 @@ func (sb *SkipBlock) Copy() *SkipBlock {
 	if sb == nil {
 		return nil
 	}
+	fls := sb.forwardLinks()
 	b := &SkipBlock{
 		SkipBlockFix:    sb.SkipBlockFix.Copy(),
 		Hash:            make([]byte, len(sb.Hash)),
 		Payload:         make([]byte, len(sb.Payload)),
-		ForwardLink:     make([]*ForwardLink, len(sb.ForwardLink)),
+		ForwardLink:     make([]*ForwardLink, len(fls)),
 		SignatureScheme: sb.SignatureScheme,
 	}
-	for i, fl := range sb.ForwardLink {
+	for i, fl := range fls {
 		b.ForwardLink[i] = fl.Copy()
 	}
+	b.SignatureThreshold = sb.SignatureThreshold
+	for _, p := range sb.Proposers {
+		b.Proposers = append(b.Proposers, p.Clone())
+	}
+	b.ProposerSignature = append([]byte(nil), sb.ProposerSignature...)
 	copy(b.Hash, sb.Hash)
 	copy(b.Payload, sb.Payload)
 	b.VerifierIDs = make([]VerifierID, len(sb.VerifierIDs))
@@ -442,7 +534,12 @@ func (sb *SkipBlock) SkipChainID() SkipBlockID {
 // DEPRECATION NOTICE: this method will disappear in onet.v3
 func (sb *SkipBlock) AddForward(fw *ForwardLink) {
 	log.Warn("this is deprecated, because it might create 'holes'")
-	sb.ForwardLink = append(sb.ForwardLink, fw)
+	l := sb.fwdLock()
+	l.Lock()
+	defer l.Unlock()
+	fls := make([]*ForwardLink, len(sb.ForwardLink), len(sb.ForwardLink)+1)
+	copy(fls, sb.ForwardLink)
+	sb.ForwardLink = append(fls, fw)
 }
 
 // AddForwardLink stores the forward-link at the indicated position. If the
@@ -455,25 +552,76 @@ func (sb *SkipBlock) AddForwardLink(fw *ForwardLink, pos int) error {
 		return errors.New("forward link doesn't start from this block")
 	}
 
-	for len(sb.ForwardLink) <= pos {
-		sb.ForwardLink = append(sb.ForwardLink, &ForwardLink{})
+	l := sb.fwdLock()
+	l.Lock()
+	defer l.Unlock()
+	size := len(sb.ForwardLink)
+	if size <= pos {
+		size = pos + 1
+	}
+	fls := make([]*ForwardLink, size)
+	copy(fls, sb.ForwardLink)
+	for i := len(sb.ForwardLink); i < pos; i++ {
+		fls[i] = &ForwardLink{}
 	}
-	sb.ForwardLink[pos] = fw
+	fls[pos] = fw
+	sb.ForwardLink = fls
 	return nil
 }
 
 // GetForward returns copy of the forward-link at position i. It returns nil if no link
 // at that level exists.
 func (sb *SkipBlock) GetForward(i int) *ForwardLink {
-	if len(sb.ForwardLink) <= i {
+	fls := sb.forwardLinks()
+	if len(fls) <= i {
 		return nil
 	}
-	return sb.ForwardLink[i].Copy()
+	return fls[i].Copy()
 }
 
 // GetForwardLen returns the number of ForwardLinks.
 func (sb *SkipBlock) GetForwardLen() int {
-	return len(sb.ForwardLink)
+	return len(sb.forwardLinks())
+}
+
+// GetForwardLinks returns a copy of all forward-links of the block.
+func (sb *SkipBlock) GetForwardLinks() []*ForwardLink {
+	fls := sb.forwardLinks()
+	out := make([]*ForwardLink, len(fls))
+	for i, fl := range fls {
+		out[i] = fl.Copy()
+	}
+	return out
+}
+
+// RemoveForwardLinks removes the forward-links from the given position on.
+func (sb *SkipBlock) RemoveForwardLinks(pos int) {
+	l := sb.fwdLock()
+	l.Lock()
+	defer l.Unlock()
+	if pos < len(sb.ForwardLink) {
+		fls := make([]*ForwardLink, pos)
+		copy(fls, sb.ForwardLink)
+		sb.ForwardLink = fls
+	}
+}
+
+// forwardLinks returns the current slice of forward-links. It must not be
+// modified, but stays valid even if forward-links are added to the block.
+func (sb *SkipBlock) forwardLinks() []*ForwardLink {
+	l := sb.fwdLock()
+	l.RLock()
+	defer l.RUnlock()
+	return sb.ForwardLink
+}
+
+// setForwardLinks replaces the slice of forward-links. The slice must not be
+// modified afterwards.
+func (sb *SkipBlock) setForwardLinks(fls []*ForwardLink) {
+	l := sb.fwdLock()
+	l.Lock()
+	defer l.Unlock()
+	sb.ForwardLink = fls
 }
 
 // pathForIndex computes the highest height that can be used to go
@@ -551,6 +699,21 @@ func (sb *SkipBlock) CalculateHash() SkipBlockID {
 			panic("error writing to hash: " + err.Error())
 		}
 	}
+	// Same for the signature threshold, which is only hashed when set.
+	if sb.SignatureThreshold > 0 {
+		err := binary.Write(hash, binary.LittleEndian, int32(sb.SignatureThreshold))
+		if err != nil {
+			panic("error writing to hash: " + err.Error())
+		}
+	}
+	// And the proposers, which are only set in some genesis blocks.
+	for _, p := range sb.Proposers {
+		_, err := p.MarshalTo(hash)
+		if err != nil {
+			panic("couldn't marshall point to hash: " + err.Error())
+		}
+	}
+	hash.Write(sb.ProposerSignature)
 
 	buf := hash.Sum(nil)
 	return buf
@@ -622,11 +785,15 @@ func (sbs Proof) GetForwardLinks() (links []*ForwardLink, err error) {
 	for i, sb := range sbs[:len(sbs)-1] {
 		logDist := math.Log(float64(sbs[i+1].Index - sb.Index))
 		// Using math.Round here to ignore rounding errors in math.Log
-		height := int(math.Round(logDist / logBH))
-		if len(sb.ForwardLink) <= height || sb.ForwardLink[height].IsEmpty() {
+		height := 0
+		if sbs[0].BaseHeight > 1 {
+			height = int(math.Round(logDist / logBH))
+		}
+		fls := sb.forwardLinks()
+		if len(fls) <= height || fls[height].IsEmpty() {
 			return nil, xerrors.New("missing forward-link in proof")
 		}
-		links[i+1] = sb.ForwardLink[height]
+		links[i+1] = fls[height]
 	}
 	return
 }
@@ -654,19 +821,21 @@ func (sbs Proof) verifyChain() error {
 		// not be the highest link,
 		// as the block to be optimized might be in nthe middle of the chain.
 		if i < len(sbs)-1 {
-			if len(sb.ForwardLink) == 0 {
+			fls := sb.forwardLinks()
+			if len(fls) == 0 {
 				return errors.New("Missing forward links")
 			}
 
 			flOK := false
-			for flIndex := len(sb.ForwardLink) - 1; flIndex >= 0; flIndex-- {
-				fl := sb.ForwardLink[flIndex]
+			for flIndex := len(fls) - 1; flIndex >= 0; flIndex-- {
+				fl := fls[flIndex]
 				if fl.IsEmpty() {
 					continue
 				}
 
-				if err := fl.VerifyWithScheme(suite,
-					sb.Roster.ServicePublics(ServiceName), sb.SignatureScheme); err != nil {
+				if err := fl.VerifyWithThreshold(suite,
+					sb.Roster.ServicePublics(ServiceName), sb.SignatureScheme,
+					sb.SignatureThreshold); err != nil {
 					return xerrors.Errorf("verify with scheme: %v", err)
 				}
 
@@ -712,6 +881,10 @@ type ForwardLink struct {
 	// In the case that NewRoster is nil, the signature is
 	// calculated on the sha256(From.Hash()|To.Hash())
 	Signature byzcoinx.FinalSignature
+	// Batch is only set if the signature is on the merkle root of the
+	// forward-links of a batch of blocks, see StoreSkipBlocks. It proves that
+	// the hash of this forward-link is part of the root.
+	Batch *BatchProof `protobuf:"opt"`
 }
 
 // NewForwardLink creates a new forwardlink structure with
@@ -759,6 +932,7 @@ func (fl *ForwardLink) Copy() *ForwardLink {
 		From:      append([]byte{}, fl.From...),
 		To:        append([]byte{}, fl.To...),
 		NewRoster: newRoster,
+		Batch:     fl.Batch.copy(),
 	}
 }
 
@@ -773,15 +947,30 @@ func (fl *ForwardLink) Verify(suite *pairing.SuiteBn256, pubs []kyber.Point) err
 // a given scheme. The list must correspond to the block roster to match the
 // signature. It returns nil if the signature is correct, or an error if not.
 func (fl *ForwardLink) VerifyWithScheme(suite *pairing.SuiteBn256, pubs []kyber.Point, scheme uint32) error {
-	if bytes.Compare(fl.Signature.Msg, fl.Hash()) != 0 {
+	return fl.VerifyWithThreshold(suite, pubs, scheme, 0)
+}
+
+// VerifyWithThreshold checks the signature like VerifyWithScheme, but accepts
+// it as soon as threshold nodes signed. A threshold of 0 uses the default
+// threshold of the protocol.
+func (fl *ForwardLink) VerifyWithThreshold(suite *pairing.SuiteBn256, pubs []kyber.Point, scheme uint32, threshold int) error {
+	if fl.Batch != nil {
+		if err := fl.Batch.verify(fl); err != nil {
+			return err
+		}
+	} else if bytes.Compare(fl.Signature.Msg, fl.Hash()) != 0 {
 		return errors.New("wrong hash of forward link")
 	}
+	if threshold <= 0 {
+		threshold = protocol.DefaultThreshold(len(pubs))
+	}
+	policy := sign.NewThresholdPolicy(threshold)
 
 	switch scheme {
 	case BlsSignatureSchemeIndex:
-		return protocol.BlsSignature(fl.Signature.Sig).Verify(suite, fl.Signature.Msg, pubs)
+		return protocol.BlsSignature(fl.Signature.Sig).VerifyWithPolicy(suite, fl.Signature.Msg, pubs, policy)
 	case BdnSignatureSchemeIndex:
-		return bdnproto.BdnSignature(fl.Signature.Sig).Verify(suite, fl.Signature.Msg, pubs)
+		return bdnproto.BdnSignature(fl.Signature.Sig).VerifyWithPolicy(suite, fl.Signature.Msg, pubs, policy)
 	default:
 		return errors.New("unknown signature scheme")
 	}
@@ -803,9 +992,15 @@ type SkipBlockDB struct {
 	latestBlocks map[string]SkipBlockID
 	latestMutex  sync.Mutex
 	callback     func(SkipBlockID) error
+	// newBlocks is called with the blocks that were not yet in the db
+	// after they have been stored.
+	newBlocks func([]*SkipBlock)
+	timings   dbTimings
 }
 
-// NewSkipBlockDB returns an initialized SkipBlockDB structure.
+// NewSkipBlockDB returns an initialized SkipBlockDB structure. Blocks
+// stored in the layout of older versions are not converted: tools must call
+// CheckLayout before using the database.
 func NewSkipBlockDB(db *bbolt.DB, bn []byte) *SkipBlockDB {
 	return &SkipBlockDB{
 		DB:           db,
@@ -818,18 +1013,22 @@ func NewSkipBlockDB(db *bbolt.DB, bn []byte) *SkipBlockDB {
 func (db *SkipBlockDB) GetStatus() *onet.Status {
 	out := make(map[string]string)
 	err := db.DB.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(db.bucketName))
-		s := b.Stats()
-		out["Blocks"] = strconv.Itoa(s.KeyN)
-
-		total := s.BranchInuse + s.LeafInuse
-		out["Bytes"] = strconv.Itoa(total)
+		blocks, bytes := db.sizeTx(tx)
+		out["Blocks"] = strconv.Itoa(blocks)
+		out["Bytes"] = strconv.Itoa(bytes)
 		return nil
 	})
 	if err != nil {
 		log.Error(err)
 		return nil
 	}
+	db.timings.Lock()
+	out["Stores"] = db.timings.stores.String()
+	out["Gets"] = db.timings.gets.String()
+	out["Propagations"] = db.timings.propagations.String()
+	out["FanOut"] = strconv.Itoa(db.timings.fanOut)
+	out["Replies"] = strconv.Itoa(db.timings.replies)
+	db.timings.Unlock()
 	return &onet.Status{Field: out}
 }
 
@@ -839,6 +1038,7 @@ func (db *SkipBlockDB) GetByID(sbID SkipBlockID) *SkipBlock {
 	if sbID == nil {
 		return nil
 	}
+	defer db.timings.recordGet(time.Now())
 	err := db.View(func(tx *bbolt.Tx) error {
 		sb, err := db.getFromTx(tx, sbID)
 		if err != nil {
@@ -857,28 +1057,40 @@ func (db *SkipBlockDB) GetByID(sbID SkipBlockID) *SkipBlock {
 // StoreBlocks stores the set of blocks in the boltdb in a transaction,
 // so that the db is consistent at every moment.
 func (db *SkipBlockDB) StoreBlocks(blocks []*SkipBlock) ([]SkipBlockID, error) {
+	defer db.timings.recordStore(time.Now())
 	var result []SkipBlockID
+	var added []*SkipBlock
+	var fork *ForkEvidence
 	err := db.Update(func(tx *bbolt.Tx) error {
 		for i, sb := range blocks {
 			log.Lvlf2("Storing skipblock %d / %x", sb.Index, sb.Hash)
+			if db.hasForkTx(tx, sb.SkipChainID()) {
+				return ErrorForkedChain
+			}
 			sbOld, err := db.getFromTx(tx, sb.Hash)
 			if err != nil {
 				return errors.New("failed to get skipblock with error: " + err.Error())
 			}
 			if sbOld != nil {
-				numFL := len(sbOld.ForwardLink)
+				if fork = findFork(sbOld, sb); fork != nil {
+					return ErrorForkedChain
+				}
+				numFL := sbOld.GetForwardLen()
 				// If this skipblock already exists, only copy forward-links and
 				// new children.
-				if len(sb.ForwardLink) > numFL {
-					for i, fl := range sb.ForwardLink[numFL:] {
+				if fls := sb.forwardLinks(); len(fls) > numFL {
+					for i, fl := range fls[numFL:] {
 						if fl.IsEmpty() {
 							// Ignore empty links.
 							continue
 						}
 
+						// The parameters of the stored block are used, so that
+						// the incoming copy can't choose them.
 						publics := sbOld.Roster.ServicePublics(ServiceName)
 
-						if err := fl.VerifyWithScheme(suite, publics, sb.SignatureScheme); err != nil {
+						if err := fl.VerifyWithThreshold(suite, publics, sbOld.SignatureScheme,
+							sbOld.SignatureThreshold); err != nil {
 							// Only keep a log of the failing forward links but keep trying others.
 							log.Error("Got a known block with wrong signature in forward-link with error: " + err.Error())
 							continue
@@ -912,7 +1124,7 @@ func (db *SkipBlockDB) StoreBlocks(blocks []*SkipBlock) ([]SkipBlockID, error) {
 				if !db.HasForwardLink(sb) {
 					found := false
 					for j := 0; j < i; j++ {
-						for _, fl := range blocks[j].ForwardLink {
+						for _, fl := range blocks[j].forwardLinks() {
 							if fl.To.Equal(sb.Hash) {
 								found = true
 							}
@@ -929,20 +1141,22 @@ func (db *SkipBlockDB) StoreBlocks(blocks []*SkipBlock) ([]SkipBlockID, error) {
 				// up in this path.
 				// Deprecated: This is a notice for v4 to add the target height in the
 				// forward-link so conodes can sign it.
-				if len(sb.ForwardLink) > sb.Height {
+				fls := sb.forwardLinks()
+				if len(fls) > sb.Height {
 					return fmt.Errorf("found %d forward-links for a height of %d",
-						len(sb.ForwardLink), sb.Height)
+						len(fls), sb.Height)
 				}
 
 				publics := sb.Roster.ServicePublics(ServiceName)
 
-				for _, fl := range sb.ForwardLink {
+				for _, fl := range fls {
 					if !fl.IsEmpty() {
 						if !fl.From.Equal(sb.Hash) {
 							return ErrorInconsistentForwardLink
 						}
 
-						if err := fl.VerifyWithScheme(suite, publics, sb.SignatureScheme); err != nil {
+						if err := fl.VerifyWithThreshold(suite, publics, sb.SignatureScheme,
+							sb.SignatureThreshold); err != nil {
 							return errors.New("invalid forward-link signature: " + err.Error())
 						}
 					}
@@ -953,11 +1167,20 @@ func (db *SkipBlockDB) StoreBlocks(blocks []*SkipBlock) ([]SkipBlockID, error) {
 					return err
 				}
 				db.latestUpdate(sb)
+				added = append(added, sb)
 			}
 			result = append(result, sb.Hash)
 		}
 		return nil
 	})
+	if fork != nil {
+		log.Errorf("fork detected on skipchain %x at block %d, height %d: "+
+			"%x and %x", fork.SkipChainID, fork.Index, fork.Height,
+			fork.Known.To, fork.Conflicting.To)
+		if err := db.storeFork(fork); err != nil {
+			log.Errorf("couldn't store evidence of the fork: %v", err)
+		}
+	}
 
 	// Run the callback if it exists, we have to do this outside of the
 	// boltdb transaction because the callback might also make updates to
@@ -969,6 +1192,9 @@ func (db *SkipBlockDB) StoreBlocks(blocks []*SkipBlock) ([]SkipBlockID, error) {
 			}
 		}
 	}
+	if err == nil && len(added) > 0 && db.newBlocks != nil {
+		db.newBlocks(added)
+	}
 
 	return result, err
 }
@@ -997,10 +1223,8 @@ func (db *SkipBlockDB) HasForwardLink(sb *SkipBlock) bool {
 	for i, bl := range sb.BackLinkIDs {
 		prev := db.GetByID(bl)
 		if prev != nil {
-			if len(prev.ForwardLink) > i {
-				if prev.ForwardLink[i].To.Equal(sb.Hash) {
-					return true
-				}
+			if fl := prev.GetForward(i); fl != nil && fl.To.Equal(sb.Hash) {
+				return true
 			}
 		}
 	}
@@ -1027,8 +1251,7 @@ func (db *SkipBlockDB) latestUpdate(sb *SkipBlock) {
 func (db *SkipBlockDB) Length() int {
 	var i int
 	_ = db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(db.bucketName))
-		i = b.Stats().KeyN
+		i, _ = db.sizeTx(tx)
 		return nil
 	})
 	return i
@@ -1132,7 +1355,7 @@ func (db *SkipBlockDB) GetLatest(sb *SkipBlock) (*SkipBlock, error) {
 			log.Warnf("DB has unknown forward-link - removing from scID=%x"+
 				" index=%d height=%d", latest.SkipChainID(), latest.Index,
 				height)
-			latest.ForwardLink = latest.ForwardLink[:height]
+			latest.setForwardLinks(latest.forwardLinks()[:height])
 			if err := db.RemoveBlock(latest.Hash); err != nil {
 				return nil, fmt.Errorf("couldn't clean up block: %v", err)
 			}
@@ -1168,27 +1391,30 @@ func (db *SkipBlockDB) GetFuzzy(id string) (*SkipBlock, error) {
 
 	var sb *SkipBlock
 	err = db.View(func(tx *bbolt.Tx) error {
-		c := tx.Bucket([]byte(db.bucketName)).Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			if bytes.HasPrefix(k, match) {
-				_, msg, err := network.Unmarshal(v, suite)
-				if err != nil {
-					return errors.New("Unmarshal failed with error: " + err.Error())
-				}
-				sb = msg.(*SkipBlock).Copy()
-				return nil
-			}
+		idx := tx.Bucket(db.indexBucket())
+		if idx == nil {
+			return nil
+		}
+		// The index is sorted by the hashes of the blocks, so the first
+		// block with the prefix is found directly.
+		c := idx.Cursor()
+		var id []byte
+		if k, _ := c.Seek(match); k != nil && bytes.HasPrefix(k, match) {
+			id = k
 		}
-		for k, v := c.First(); k != nil; k, v = c.Next() {
+		for k, _ := c.First(); k != nil && id == nil; k, _ = c.Next() {
 			if bytes.HasSuffix(k, match) {
-				_, msg, err := network.Unmarshal(v, suite)
-				if err != nil {
-					return errors.New("Unmarshal failed with error: " + err.Error())
-				}
-				sb = msg.(*SkipBlock).Copy()
-				return nil
+				id = k
 			}
 		}
+		if id == nil {
+			return nil
+		}
+		var err error
+		sb, err = db.getFromTx(tx, id)
+		if err != nil {
+			return errors.New("Unmarshal failed with error: " + err.Error())
+		}
 		return nil
 	})
 	return sb, err
@@ -1227,7 +1453,7 @@ func (db *SkipBlockDB) getPath(tx *bbolt.Tx, sb *SkipBlock,
 		return
 	}
 
-	for len(sb.ForwardLink) > 0 {
+	for sb.GetForwardLen() > 0 {
 		sb, err = db.getHighestJump(tx, sb, dest)
 		if err != nil {
 			return nil, xerrors.Errorf("while fetching next jump: %v", err)
@@ -1249,12 +1475,13 @@ func (db *SkipBlockDB) getPath(tx *bbolt.Tx, sb *SkipBlock,
 // dest < 0 indicates to chose the highest forward-link.
 func (db *SkipBlockDB) getHighestJump(tx *bbolt.Tx, start *SkipBlock,
 	dest int) (*SkipBlock, error) {
-	for i := len(start.ForwardLink) - 1; i >= 0; i-- {
+	fls := start.forwardLinks()
+	for i := len(fls) - 1; i >= 0; i-- {
 		// We can have holes in the forward links
-		if start.ForwardLink[i].IsEmpty() {
+		if fls[i].IsEmpty() {
 			continue
 		}
-		sb, err := db.getFromTx(tx, start.ForwardLink[i].To)
+		sb, err := db.getFromTx(tx, fls[i].To)
 		if err != nil {
 			return nil, xerrors.Errorf("while fetching block from db: %v",
 				err)
@@ -1299,51 +1526,55 @@ func (db *SkipBlockDB) GetSkipchains() (map[string]*SkipBlock, error) {
 	return db.getAllSkipchains()
 }
 
-// RemoveSkipchain removes all block from a given skipchain from the database.
-// If the skipchain is only partial, it can skip missing blocks, as long as the
-// forwardlinks are present.
+// Summary returns one ChainSummary for each skipchain in the database.
+func (db *SkipBlockDB) Summary() ([]ChainSummary, error) {
+	return db.summary()
+}
+
+// RemoveSkipchain removes all blocks of the skipchain from the database by
+// dropping the bucket of the skipchain. Nothing is done for an unknown
+// skipchain.
 func (db *SkipBlockDB) RemoveSkipchain(scid SkipBlockID) error {
-	return db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(db.bucketName))
-		sb, err := db.getFromTx(tx, scid)
+	err := db.Update(func(tx *bbolt.Tx) error {
+		ids, err := db.deleteChainTx(tx, scid)
 		if err != nil {
 			return err
 		}
-		if b == nil {
-			return xerrors.Errorf("cannot remove non-existent bucket %s",
-				string(db.bucketName))
-		}
-		for {
-			err := b.Delete(sb.Hash)
-			if err != nil {
+		for _, id := range ids {
+			if err := db.deleteAnnotationTx(tx, id); err != nil {
 				return err
 			}
-			if len(sb.ForwardLink) == 0 {
-				return nil
-			}
-
-			var next *SkipBlock
-			for _, fl := range sb.ForwardLink {
-				n, err := db.getFromTx(tx, fl.To)
-				if err == nil {
-					next = n
-					break
-				}
+			if err := db.deleteRedactionTx(tx, id); err != nil {
+				return err
 			}
-			if next == nil {
-				log.Error("didn't find next block")
-				return nil
+			if err := db.deletePrunedTx(tx, id); err != nil {
+				return err
 			}
-			sb = next
 		}
+		return nil
 	})
+	if err != nil {
+		return err
+	}
+	db.latestMutex.Lock()
+	delete(db.latestBlocks, string(scid))
+	db.latestMutex.Unlock()
+	return nil
 }
 
 // RemoveBlock removes the given block from the database.
 func (db *SkipBlockDB) RemoveBlock(blockID SkipBlockID) error {
 	return db.Update(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(db.bucketName))
-		return b.Delete(blockID)
+		if err := db.deleteBlockTx(tx, blockID); err != nil {
+			return err
+		}
+		if err := db.deleteAnnotationTx(tx, blockID); err != nil {
+			return err
+		}
+		if err := db.deleteRedactionTx(tx, blockID); err != nil {
+			return err
+		}
+		return db.deletePrunedTx(tx, blockID)
 	})
 }
 
@@ -1351,12 +1582,16 @@ func (db *SkipBlockDB) RemoveBlock(blockID SkipBlockID) error {
 // An error is returned on failure.
 // The caller must ensure that this function is called from within a valid transaction.
 func (db *SkipBlockDB) storeToTx(tx *bbolt.Tx, sb *SkipBlock) error {
-	key := sb.Hash
 	val, err := network.Marshal(sb)
 	if err != nil {
 		return err
 	}
-	return tx.Bucket([]byte(db.bucketName)).Put(key, val)
+	scID := sb.SkipChainID()
+	if len(scID) == 0 {
+		// A block without genesis-id is kept in a bucket of its own.
+		scID = sb.Hash
+	}
+	return db.putTx(tx, scID, sb.Hash, val)
 }
 
 // getFromTx returns the skipblock identified by sbID.
@@ -1368,7 +1603,11 @@ func (db *SkipBlockDB) getFromTx(tx *bbolt.Tx, sbID SkipBlockID) (*SkipBlock, er
 		return nil, xerrors.New("cannot look up skipblock with ID == nil")
 	}
 
-	val := tx.Bucket(db.bucketName).Get(sbID)
+	b := db.blockBucketTx(tx, sbID)
+	if b == nil {
+		return nil, nil
+	}
+	val := b.Get(sbID)
 	if val == nil {
 		return nil, nil
 	}
@@ -1392,8 +1631,7 @@ func (db *SkipBlockDB) getFromTx(tx *bbolt.Tx, sbID SkipBlockID) (*SkipBlock, er
 func (db *SkipBlockDB) getAll() (map[string]*SkipBlock, error) {
 	data := map[string]*SkipBlock{}
 	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(db.bucketName))
-		return b.ForEach(func(k, v []byte) error {
+		return db.forEachBlockTx(tx, func(k, v []byte) error {
 			_, sbMsg, err := network.Unmarshal(v, suite)
 			if err != nil {
 				return err
@@ -1433,8 +1671,7 @@ func (db *SkipBlockDB) getAllSkipchains() (map[string]*SkipBlock, error) {
 	// have not seen, remember it. If we see a higher Index than what
 	// we have, replace it.
 	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(db.bucketName))
-		return b.ForEach(func(k, v []byte) error {
+		return db.forEachBlockTx(tx, func(k, v []byte) error {
 			var sbs skipBlockShort
 			err := protobuf.Decode(v[16:], &sbs)
 			if err != nil {
@@ -1465,6 +1702,56 @@ func (db *SkipBlockDB) getAllSkipchains() (map[string]*SkipBlock, error) {
 	return gen, nil
 }
 
+// ChainSummary describes what a database knows about one skipchain.
+type ChainSummary struct {
+	SkipChainID SkipBlockID
+	// Latest is the ID of the block with the highest index.
+	Latest SkipBlockID
+	Index  int
+	// Blocks is the number of blocks of the skipchain in the database.
+	Blocks int
+}
+
+// summary returns one ChainSummary for each skipchain in the database. Like
+// getAllSkipchains, it only decodes the minimal information of every block.
+func (db *SkipBlockDB) summary() ([]ChainSummary, error) {
+	chains := make(map[string]*ChainSummary)
+	err := db.View(func(tx *bbolt.Tx) error {
+		return db.forEachBlockTx(tx, func(k, v []byte) error {
+			var sbs skipBlockShort
+			err := protobuf.Decode(v[16:], &sbs)
+			if err != nil {
+				return err
+			}
+			scID := sbs.GenesisID
+			if sbs.Index == 0 {
+				scID = sbs.Hash
+			}
+			cs, ok := chains[string(scID)]
+			if !ok {
+				cs = &ChainSummary{SkipChainID: scID, Latest: sbs.Hash,
+					Index: sbs.Index}
+				chains[string(scID)] = cs
+			}
+			if cs.Index < sbs.Index {
+				cs.Latest = sbs.Hash
+				cs.Index = sbs.Index
+			}
+			cs.Blocks++
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]ChainSummary, 0, len(chains))
+	for _, cs := range chains {
+		summaries = append(summaries, *cs)
+	}
+	return summaries, nil
+}
+
 // skipBlockBuffer will cache a proposed block when the conode has
 // verified it and it will later store it in the DB after the protocol
 // has succeeded.
@@ -6,6 +6,7 @@ import (
 	"go.dedis.ch/kyber/v3/util/random"
 	"io/ioutil"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -145,6 +146,75 @@ func TestSkipBlock_InvalidForwardLinks(t *testing.T) {
 	require.NoError(t, local.WaitDone(time.Second))
 }
 
+func TestSkipBlock_SignatureThreshold(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer local.CloseAll()
+	_, ro, _ := local.GenTree(4, false)
+
+	sb := NewSkipBlock()
+	sb.Height = 1
+	sb.Roster = ro
+	sb.updateHash()
+	fl := &ForwardLink{From: sb.Hash, To: SkipBlockID{1, 2, 3}}
+	require.NoError(t, fl.signBy(ro, 2))
+	publics := ro.ServicePublics(ServiceName)
+
+	// The default threshold for 4 nodes is 3.
+	require.Error(t, fl.VerifyWithScheme(suite, publics, sb.SignatureScheme))
+	require.NoError(t, fl.VerifyWithThreshold(suite, publics, sb.SignatureScheme, 2))
+	require.Error(t, fl.VerifyWithThreshold(suite, publics, sb.SignatureScheme, 3))
+
+	sb.ForwardLink = []*ForwardLink{fl}
+	require.Error(t, sb.VerifyForwardSignatures())
+
+	// The threshold is part of the hash, so the forward-link needs to be
+	// created again.
+	sb.ForwardLink = nil
+	sb.SignatureThreshold = 2
+	hash := sb.Hash
+	sb.updateHash()
+	require.False(t, hash.Equal(sb.Hash))
+	fl = &ForwardLink{From: sb.Hash, To: SkipBlockID{1, 2, 3}}
+	require.NoError(t, fl.signBy(ro, 2))
+	sb.ForwardLink = []*ForwardLink{fl}
+	require.NoError(t, sb.VerifyForwardSignatures())
+	require.Equal(t, 2, sb.Copy().SignatureThreshold)
+}
+
+// The forward-links added to a known block are verified with the threshold
+// of the stored block, not the one of the incoming copy.
+func TestSkipBlockDB_KnownBlockThreshold(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer local.CloseAll()
+	_, ro, _ := local.GenTree(4, false)
+	db, fname := setupSkipBlockDB(t)
+	defer os.Remove(fname)
+	defer db.Close()
+
+	root := NewSkipBlock()
+	root.Height = 1
+	root.BaseHeight = 2
+	root.Roster = ro
+	root.updateHash()
+	_, err := db.StoreBlocks([]*SkipBlock{root})
+	require.NoError(t, err)
+
+	// The default threshold for 4 nodes is 3.
+	fl := &ForwardLink{From: root.Hash, To: SkipBlockID{1, 2, 3}}
+	require.NoError(t, fl.signBy(ro, 2))
+	sb := root.Copy()
+	sb.SignatureThreshold = 2
+	sb.ForwardLink = []*ForwardLink{fl}
+	_, err = db.StoreBlocks([]*SkipBlock{sb})
+	require.NoError(t, err)
+	require.Equal(t, 0, db.GetByID(root.Hash).GetForwardLen())
+
+	require.NoError(t, fl.signBy(ro, 3))
+	_, err = db.StoreBlocks([]*SkipBlock{sb})
+	require.NoError(t, err)
+	require.Equal(t, 1, db.GetByID(root.Hash).GetForwardLen())
+}
+
 func TestSkipBlock_WrongSignatures(t *testing.T) {
 	fl := ForwardLink{
 		From:      SkipBlockID{},
@@ -168,6 +238,157 @@ func TestSkipBlock_WrongSignatures(t *testing.T) {
 	require.NotEqual(t, "unknown signature scheme", err.Error())
 }
 
+// Adding forward-links while other goroutines read them must be race-free
+// and never change a slice of forward-links already handed out.
+func TestSkipBlock_ConcurrentForwardLinks(t *testing.T) {
+	sb := NewSkipBlock()
+	sb.Height = 8
+	sb.Hash = SkipBlockID{1, 2, 3}
+	snapshot := sb.forwardLinks()
+
+	var wg sync.WaitGroup
+	for i := 0; i < sb.Height; i++ {
+		wg.Add(2)
+		go func(pos int) {
+			defer wg.Done()
+			fl := &ForwardLink{From: sb.Hash, To: SkipBlockID{byte(pos)}}
+			require.NoError(t, sb.AddForwardLink(fl, pos))
+		}(i)
+		go func() {
+			defer wg.Done()
+			cp := sb.Copy()
+			for i := 0; i < cp.GetForwardLen(); i++ {
+				cp.GetForward(i)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, 0, len(snapshot))
+	require.Equal(t, sb.Height, sb.GetForwardLen())
+	for i := 0; i < sb.Height; i++ {
+		require.Equal(t, SkipBlockID{byte(i)}, sb.GetForward(i).To)
+	}
+}
+
+func TestSkipBlock_ForwardLinkLock(t *testing.T) {
+	// Blocks created without NewSkipBlock and copied by value can be used
+	// by many goroutines.
+	sb1 := &SkipBlock{SkipBlockFix: &SkipBlockFix{Height: 4}, Hash: SkipBlockID{1}}
+	require.True(t, sb1.fwdLock() == sb1.fwdLock())
+	blocks := []*SkipBlock{sb1.Copy(), sb1.Copy()}
+	var wg sync.WaitGroup
+	for _, sb := range blocks {
+		for i := 0; i < sb.Height; i++ {
+			wg.Add(2)
+			go func(sb *SkipBlock, pos int) {
+				defer wg.Done()
+				fl := &ForwardLink{From: sb.Hash, To: SkipBlockID{byte(pos)}}
+				require.NoError(t, sb.AddForwardLink(fl, pos))
+			}(sb, i)
+			go func(sb *SkipBlock) {
+				defer wg.Done()
+				sb.GetForwardLinks()
+			}(sb)
+		}
+	}
+	wg.Wait()
+	for _, sb := range blocks {
+		require.Equal(t, sb.Height, sb.GetForwardLen())
+	}
+
+	for i := 0; i < sb1.Height; i++ {
+		fl := &ForwardLink{From: sb1.Hash, To: SkipBlockID{byte(i)}}
+		require.NoError(t, sb1.AddForwardLink(fl, i))
+	}
+	fls := sb1.GetForwardLinks()
+	require.Equal(t, 4, len(fls))
+	fls[0].To = SkipBlockID{9}
+	require.Equal(t, SkipBlockID{0}, sb1.GetForward(0).To)
+
+	sb1.RemoveForwardLinks(2)
+	require.Equal(t, 2, sb1.GetForwardLen())
+	require.Equal(t, 4, len(fls))
+	sb1.RemoveForwardLinks(3)
+	require.Equal(t, 2, sb1.GetForwardLen())
+	sb1.RemoveForwardLinks(0)
+	require.Equal(t, 0, sb1.GetForwardLen())
+}
+
+// Storing blocks while other goroutines add and read their forward-links
+// must be race-free. This is only checked when running with -race.
+func TestSkipBlockDB_ConcurrentForwardLinks(t *testing.T) {
+	l := onet.NewLocalTest(cothority.Suite)
+	defer l.CloseAll()
+	_, ro, _ := l.GenTree(2, false)
+	db, fname := setupSkipBlockDB(t)
+	defer os.Remove(fname)
+	defer db.Close()
+
+	n := 8
+	blocks := make([]*SkipBlock, n)
+	for i := range blocks {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Index = i
+		sb.Height = 1
+		sb.BaseHeight = 2
+		sb.MaximumHeight = 1
+		if i > 0 {
+			sb.GenesisID = blocks[0].Hash
+			sb.BackLinkIDs = []SkipBlockID{blocks[i-1].Hash}
+		}
+		sb.updateHash()
+		blocks[i] = sb
+		// Store the blocks without forward-links, so that the
+		// forward-links are added to known blocks.
+		require.NoError(t, storeRaw(db, sb.Copy()))
+	}
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < n-1; i++ {
+		sb := blocks[i]
+		fl := &ForwardLink{From: sb.Hash, To: blocks[i+1].Hash}
+		require.NoError(t, fl.sign(ro))
+		stored := make(chan struct{})
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			<-stored
+			require.NoError(t, sb.AddForwardLink(fl, 0))
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 10; j++ {
+				_, err := db.StoreBlocks([]*SkipBlock{sb})
+				require.NoError(t, err)
+				if j == 0 {
+					close(stored)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			for j := 0; j < 10; j++ {
+				sb.GetForward(0)
+				db.HasForwardLink(blocks[sb.Index+1])
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for i := 0; i < n-1; i++ {
+		_, err := db.StoreBlocks([]*SkipBlock{blocks[i]})
+		require.NoError(t, err)
+		stored := db.GetByID(blocks[i].Hash)
+		require.Equal(t, blocks[i+1].Hash, stored.GetForward(0).To)
+	}
+}
+
 func TestSkipBlock_Hash1(t *testing.T) {
 	// Needed for the roster.
 	s := suites.MustFind("ed25519")
@@ -438,7 +659,7 @@ func TestSkipBlockDB_GetProofFromIndex(t *testing.T) {
 	require.True(t, blocks[1].Hash.Equal(sb2.Hash))
 
 	err = db.Update(func(tx *bbolt.Tx) error {
-		return tx.Bucket(db.bucketName).Delete(sb2.Hash)
+		return db.deleteBlockTx(tx, sb2.Hash)
 	})
 	require.NoError(t, err)
 
@@ -520,9 +741,9 @@ func setupSkipchain(t *testing.T, nbrSkipBlocks int) (db *SkipBlockDB,
 	blIDs := []SkipBlockID{rand32, rand32}
 	fls := []*ForwardLink{
 		{rand32, rand32, roster,
-			byzcoinx.FinalSignature{Msg: rand32, Sig: rand64}},
+			byzcoinx.FinalSignature{Msg: rand32, Sig: rand64}, nil},
 		{rand32, rand32, roster,
-			byzcoinx.FinalSignature{Msg: rand32, Sig: rand64}},
+			byzcoinx.FinalSignature{Msg: rand32, Sig: rand64}, nil},
 	}
 	for sc := 0; sc < nbrSkipChains; sc++ {
 		log.Lvl2("Setting up skipchain", sc)
@@ -573,8 +794,7 @@ func getAllSkipchainsOld(db *SkipBlockDB) (map[string]*SkipBlock, error) {
 	// have not seen, remember it. If we see a higher Index than what
 	// we have, replace it.
 	err := db.View(func(tx *bbolt.Tx) error {
-		b := tx.Bucket([]byte(db.bucketName))
-		return b.ForEach(func(k, v []byte) error {
+		return db.forEachBlockTx(tx, func(k, v []byte) error {
 			_, sbMsg, err := network.Unmarshal(v, suite)
 			if err != nil {
 				return err
@@ -612,6 +832,29 @@ func TestProof_Verify(t *testing.T) {
 	require.NotNil(t, Proof{sb}.VerifyFromID(SkipBlockID{}))
 }
 
+// Chains with a base height of 1 only have forward-links of height 0.
+func TestProof_GetForwardLinksBaseHeightOne(t *testing.T) {
+	var blocks Proof
+	for i := 0; i < 3; i++ {
+		sb := NewSkipBlock()
+		sb.Index = i
+		sb.BaseHeight = 1
+		sb.MaximumHeight = 1
+		sb.Data = []byte{byte(i)}
+		sb.updateHash()
+		if i > 0 {
+			prev := blocks[i-1]
+			prev.ForwardLink = []*ForwardLink{{From: prev.Hash, To: sb.Hash}}
+		}
+		blocks = append(blocks, sb)
+	}
+
+	links, err := blocks.GetForwardLinks()
+	require.NoError(t, err)
+	require.Equal(t, 3, len(links))
+	require.True(t, links[2].To.Equal(blocks[2].Hash))
+}
+
 // setupSkipBlockDB initialises a database with a bucket called 'skipblock-test' inside.
 // The caller is responsible to close and remove the database file after using it.
 func setupSkipBlockDB(t *testing.T) (*SkipBlockDB, string) {
@@ -663,13 +906,18 @@ func TestBlockBuffer(t *testing.T) {
 }
 
 func (fl *ForwardLink) sign(ro *onet.Roster) error {
+	return fl.signBy(ro, len(ro.List))
+}
+
+// signBy signs the forward-link with the first n nodes of the roster.
+func (fl *ForwardLink) signBy(ro *onet.Roster, n int) error {
 	msg := fl.Hash()
 	mask, err := sign.NewMask(pairingSuite, ro.ServicePublics(ServiceName), nil)
 	if err != nil {
 		return err
 	}
-	sigs := make([][]byte, len(ro.List))
-	for i, si := range ro.List {
+	sigs := make([][]byte, n)
+	for i, si := range ro.List[:n] {
 		sig, err := bls.Sign(pairingSuite, si.ServicePrivate(ServiceName), msg)
 		if err != nil {
 			return err
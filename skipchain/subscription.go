@@ -0,0 +1,327 @@
+package skipchain
+
+import (
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the subscription to the heads of a skipchain. A conode
+following a skipchain registers at the nodes of the roster of that skipchain.
+Whenever the leader of the skipchain added a new block, it pushes the block
+together with the collectively signed forward-link to all subscribers, so
+they don't need to poll with GetUpdateChain.
+*/
+
+// maxHeadSubscriptions is the maximum number of skipchains one conode can
+// subscribe to at another conode. It is not a constant so that the tests
+// can change it.
+var maxHeadSubscriptions = 1024
+
+// How long a subscriber waits for the nodes to acknowledge its
+// subscription.
+var headSubscriptionTimeout = 10 * time.Second
+
+// headSubscriptions holds the subscribers of the skipchains of this conode,
+// and the callbacks for the skipchains this conode subscribed to. Both maps
+// are indexed by the skipchain-ID.
+type headSubscriptions struct {
+	sync.Mutex
+	subscribers map[string][]*network.ServerIdentity
+	// count is the number of skipchains every subscriber subscribed to.
+	count     map[network.ServerIdentityID]int
+	callbacks map[string]func(*SkipBlock)
+	// pending holds the channels waiting for the replies to the
+	// subscriptions sent by this conode, indexed by nonce.
+	pending map[uint64]chan error
+	nonce   uint64
+}
+
+// SubscribeHeads asks all nodes of the roster to send new blocks of the
+// skipchain to this conode. Every new block is verified, stored, and then
+// passed to the callback. Only one callback per skipchain is kept. It
+// returns once the nodes acknowledged the subscription.
+func (s *Service) SubscribeHeads(roster *onet.Roster, scID SkipBlockID, cb func(*SkipBlock)) error {
+	s.heads.Lock()
+	if s.heads.callbacks == nil {
+		s.heads.callbacks = make(map[string]func(*SkipBlock))
+	}
+	s.heads.callbacks[string(scID)] = cb
+	s.heads.Unlock()
+
+	return s.sendHeadSubscription(roster.List, scID, false)
+}
+
+// UnsubscribeHeads removes the callback for the skipchain and asks the nodes
+// of the roster to stop sending new blocks.
+func (s *Service) UnsubscribeHeads(roster *onet.Roster, scID SkipBlockID) error {
+	s.heads.Lock()
+	delete(s.heads.callbacks, string(scID))
+	s.heads.Unlock()
+
+	return s.sendHeadSubscription(roster.List, scID, true)
+}
+
+// sendHeadSubscription waits for the replies of the nodes, and returns an
+// error only if none of them accepted the request.
+func (s *Service) sendHeadSubscription(nodes []*network.ServerIdentity, scID SkipBlockID, unsubscribe bool) error {
+	replies := make(chan error, len(nodes))
+	s.heads.Lock()
+	if s.heads.pending == nil {
+		s.heads.pending = make(map[uint64]chan error)
+	}
+	s.heads.nonce++
+	nonce := s.heads.nonce
+	s.heads.pending[nonce] = replies
+	s.heads.Unlock()
+	defer func() {
+		s.heads.Lock()
+		delete(s.heads.pending, nonce)
+		s.heads.Unlock()
+	}()
+
+	sent := 0
+	var lastErr error
+	for _, si := range nodes {
+		err := s.SendRaw(si, &HeadSubscription{SkipChainID: scID,
+			Unsubscribe: unsubscribe, Nonce: nonce})
+		if err != nil {
+			log.Warnf("%s: couldn't send subscription to %s: %v",
+				s.ServerIdentity(), si, err)
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+
+	accepted := 0
+	timeout := time.After(headSubscriptionTimeout)
+	for i := 0; i < sent; i++ {
+		select {
+		case err := <-replies:
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			accepted++
+		case <-timeout:
+			lastErr = xerrors.New("timeout while waiting for replies")
+			i = sent
+		case <-s.closing:
+			return xerrors.New("closing down")
+		}
+	}
+	if accepted == 0 && lastErr != nil {
+		return xerrors.Errorf("no node accepted the subscription: %v", lastErr)
+	}
+	return nil
+}
+
+// handleHeadSubscription adds or removes the sender of the message from the
+// subscribers of the skipchain, and replies whether it succeeded.
+func (s *Service) handleHeadSubscription(env *network.Envelope) error {
+	req, ok := env.Msg.(*HeadSubscription)
+	if !ok {
+		return xerrors.New("didn't get a HeadSubscription message")
+	}
+	reply := &HeadSubscriptionReply{Nonce: req.Nonce}
+	if err := s.updateHeadSubscribers(env.ServerIdentity, req); err != nil {
+		reply.Error = err.Error()
+	}
+	return s.SendRaw(env.ServerIdentity, reply)
+}
+
+func (s *Service) updateHeadSubscribers(sender *network.ServerIdentity, req *HeadSubscription) error {
+	if s.db.GetByID(req.SkipChainID) == nil {
+		return xerrors.Errorf("unknown skipchain %x", req.SkipChainID)
+	}
+
+	s.heads.Lock()
+	defer s.heads.Unlock()
+	if s.heads.subscribers == nil {
+		s.heads.subscribers = make(map[string][]*network.ServerIdentity)
+		s.heads.count = make(map[network.ServerIdentityID]int)
+	}
+	key := string(req.SkipChainID)
+	var subs []*network.ServerIdentity
+	for _, si := range s.heads.subscribers[key] {
+		if !si.Equal(sender) {
+			subs = append(subs, si)
+		}
+	}
+	subscribed := len(subs) < len(s.heads.subscribers[key])
+	if !req.Unsubscribe {
+		if !subscribed && s.heads.count[sender.ID] >= maxHeadSubscriptions {
+			return xerrors.Errorf("too many subscriptions, maximum is %d",
+				maxHeadSubscriptions)
+		}
+		subs = append(subs, sender)
+	}
+	switch {
+	case subscribed && req.Unsubscribe:
+		s.heads.count[sender.ID]--
+		if s.heads.count[sender.ID] == 0 {
+			delete(s.heads.count, sender.ID)
+		}
+	case !subscribed && !req.Unsubscribe:
+		s.heads.count[sender.ID]++
+	}
+	if len(subs) == 0 {
+		delete(s.heads.subscribers, key)
+	} else {
+		s.heads.subscribers[key] = subs
+	}
+	return nil
+}
+
+// handleHeadSubscriptionReply passes the reply to the waiting
+// sendHeadSubscription.
+func (s *Service) handleHeadSubscriptionReply(env *network.Envelope) error {
+	reply, ok := env.Msg.(*HeadSubscriptionReply)
+	if !ok {
+		return xerrors.New("didn't get a HeadSubscriptionReply message")
+	}
+	var err error
+	if reply.Error != "" {
+		err = xerrors.Errorf("%s: %s", env.ServerIdentity, reply.Error)
+	}
+	s.heads.Lock()
+	replies := s.heads.pending[reply.Nonce]
+	s.heads.Unlock()
+	if replies == nil {
+		return xerrors.New("got a reply for an unknown subscription")
+	}
+	select {
+	case replies <- err:
+	default:
+		return xerrors.New("got too many replies for a subscription")
+	}
+	return nil
+}
+
+// notifyHeadSubscribers sends the new block and the forward-link pointing
+// to it to all subscribers of the skipchain.
+func (s *Service) notifyHeadSubscribers(prev, sb *SkipBlock) {
+	s.heads.Lock()
+	subs := append([]*network.ServerIdentity{},
+		s.heads.subscribers[string(sb.SkipChainID())]...)
+	s.heads.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	prev = s.db.GetByID(prev.Hash)
+	var link *ForwardLink
+	if prev != nil {
+		link = prev.GetForward(0)
+	}
+	if link == nil {
+		log.Errorf("%s: missing forward-link for block %x",
+			s.ServerIdentity(), sb.Hash)
+		return
+	}
+	update := &HeadUpdate{Link: link, Block: sb}
+
+	if err := s.incrementWorking(); err != nil {
+		return
+	}
+	go func() {
+		defer s.decrementWorking()
+		for _, si := range subs {
+			if err := s.SendRaw(si, update); err != nil {
+				log.Warnf("%s: couldn't send new head to %s: %v",
+					s.ServerIdentity(), si, err)
+			}
+		}
+	}()
+}
+
+// handleHeadUpdate verifies and stores a new block sent by one of the nodes
+// of a skipchain this conode subscribed to.
+func (s *Service) handleHeadUpdate(env *network.Envelope) error {
+	upd, ok := env.Msg.(*HeadUpdate)
+	if !ok {
+		return xerrors.New("didn't get a HeadUpdate message")
+	}
+	if upd.Block == nil || upd.Link == nil {
+		return xerrors.New("got an incomplete head update")
+	}
+	sb := upd.Block
+	scID := sb.SkipChainID()
+	s.heads.Lock()
+	cb := s.heads.callbacks[string(scID)]
+	s.heads.Unlock()
+	if cb == nil {
+		return xerrors.Errorf("not subscribed to skipchain %x", scID)
+	}
+
+	if !sb.CalculateHash().Equal(sb.Hash) {
+		return xerrors.New("wrong hash of new head")
+	}
+	if !upd.Link.To.Equal(sb.Hash) {
+		return xerrors.New("forward-link doesn't point to new head")
+	}
+
+	// The roster we knew before the new head.
+	known, _ := s.db.GetLatestByID(scID)
+	prev := s.db.GetByID(upd.Link.From)
+	if prev == nil {
+		// We're missing some blocks, so fetch them from the roster.
+		latest := scID
+		if known != nil {
+			latest = known.Hash
+		}
+		if err := s.SyncChain(sb.Roster, latest); err != nil {
+			return xerrors.Errorf("couldn't sync chain: %v", err)
+		}
+	} else {
+		publics := prev.Roster.ServicePublics(ServiceName)
+		err := upd.Link.VerifyWithThreshold(suite, publics, prev.SignatureScheme,
+			prev.SignatureThreshold)
+		if err != nil {
+			return xerrors.Errorf("invalid forward-link: %v", err)
+		}
+		prev = prev.Copy()
+		if prev.GetForwardLen() == 0 {
+			prev.setForwardLinks([]*ForwardLink{upd.Link})
+		}
+		if _, err := s.db.StoreBlocks([]*SkipBlock{prev, sb}); err != nil {
+			return xerrors.Errorf("couldn't store new head: %v", err)
+		}
+		known = prev
+	}
+	if s.db.GetByID(sb.Hash) == nil {
+		return xerrors.New("new head has not been stored")
+	}
+
+	// Make sure the new nodes of a roster change send us the next blocks,
+	// also if the roster changed in one of the blocks we just synced.
+	if known != nil && !known.Roster.ID.Equal(sb.Roster.ID) {
+		var newNodes []*network.ServerIdentity
+		for _, si := range sb.Roster.List {
+			if i, _ := known.Roster.Search(si.ID); i < 0 {
+				newNodes = append(newNodes, si)
+			}
+		}
+		if len(newNodes) > 0 && s.incrementWorking() == nil {
+			// Don't block the processing of the messages while waiting
+			// for the replies.
+			go func() {
+				defer s.decrementWorking()
+				err := s.sendHeadSubscription(newNodes, scID, false)
+				if err != nil {
+					log.Warnf("%s: couldn't subscribe to new nodes: %v",
+						s.ServerIdentity(), err)
+				}
+			}()
+		}
+	}
+
+	cb(sb)
+	return nil
+}
@@ -0,0 +1,143 @@
+package skipchain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestService_SubscribeHeads(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	servers, ro, _ := local.GenTree(4, true)
+	services := local.GetServices(servers, skipchainSID)
+	leader := services[0].(*Service)
+	follower := services[3].(*Service)
+
+	chainRoster := onet.NewRoster(ro.List[:3])
+	genesis, err := makeGenesisRosterArgs(leader, chainRoster, nil,
+		VerificationNone, 1, 1)
+	require.NoError(t, err)
+
+	heads := make(chan *SkipBlock, 10)
+	require.NoError(t, follower.SubscribeHeads(chainRoster, genesis.Hash,
+		func(sb *SkipBlock) {
+			heads <- sb
+		}))
+
+	for i := 1; i <= 2; i++ {
+		reply, err := leader.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash, NewBlock: newSkipBlockWith(chainRoster)})
+		require.NoError(t, err)
+		select {
+		case sb := <-heads:
+			require.True(t, sb.Hash.Equal(reply.Latest.Hash))
+		case <-time.After(5 * time.Second):
+			require.Fail(t, "didn't get new head")
+		}
+		stored := follower.db.GetByID(reply.Latest.Hash)
+		require.NotNil(t, stored)
+		require.Equal(t, i, stored.Index)
+	}
+
+	require.NoError(t, follower.UnsubscribeHeads(chainRoster, genesis.Hash))
+	_, err = leader.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash, NewBlock: newSkipBlockWith(chainRoster)})
+	require.NoError(t, err)
+	select {
+	case <-heads:
+		require.Fail(t, "got a head after unsubscribing")
+	case <-time.After(500 * time.Millisecond):
+	}
+}
+
+func TestService_SubscribeHeadsLimit(t *testing.T) {
+	defer func(max int) { maxHeadSubscriptions = max }(maxHeadSubscriptions)
+	maxHeadSubscriptions = 1
+
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	servers, ro, _ := local.GenTree(2, true)
+	services := local.GetServices(servers, skipchainSID)
+	leader := services[0].(*Service)
+	follower := services[1].(*Service)
+	chainRoster := onet.NewRoster(ro.List[:1])
+
+	var genesis []*SkipBlock
+	for i := 0; i < 2; i++ {
+		sb, err := makeGenesisRosterArgs(leader, chainRoster, nil,
+			VerificationNone, 1, 1)
+		require.NoError(t, err)
+		genesis = append(genesis, sb)
+	}
+	cb := func(*SkipBlock) {}
+	require.NoError(t, follower.SubscribeHeads(chainRoster, genesis[0].Hash, cb))
+	// Subscribing twice to the same chain doesn't count twice.
+	require.NoError(t, follower.SubscribeHeads(chainRoster, genesis[0].Hash, cb))
+	err := follower.SubscribeHeads(chainRoster, genesis[1].Hash, cb)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "too many subscriptions")
+
+	require.NoError(t, follower.UnsubscribeHeads(chainRoster, genesis[0].Hash))
+	require.NoError(t, follower.SubscribeHeads(chainRoster, genesis[1].Hash, cb))
+}
+
+// A follower missing some blocks syncs the chain, and subscribes to the
+// nodes added to the roster in one of the synced blocks.
+func TestService_SubscribeHeadsSync(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	servers, ro, _ := local.GenTree(5, true)
+	services := local.GetServices(servers, skipchainSID)
+	leader := services[0].(*Service)
+	follower := services[3].(*Service)
+	newNode := services[4].(*Service)
+
+	chainRoster := onet.NewRoster(ro.List[:3])
+	genesis, err := makeGenesisRosterArgs(leader, chainRoster, nil,
+		VerificationNone, 1, 1)
+	require.NoError(t, err)
+	_, err = follower.db.StoreBlocks([]*SkipBlock{genesis})
+	require.NoError(t, err)
+
+	// The follower misses the roster change.
+	newRoster := onet.NewRoster(append(chainRoster.List, ro.List[4]))
+	_, err = leader.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash, NewBlock: newSkipBlockWith(newRoster)})
+	require.NoError(t, err)
+
+	heads := make(chan *SkipBlock, 10)
+	require.NoError(t, follower.SubscribeHeads(chainRoster, genesis.Hash,
+		func(sb *SkipBlock) {
+			heads <- sb
+		}))
+	reply, err := leader.StoreSkipBlock(&StoreSkipBlock{
+		TargetSkipChainID: genesis.Hash, NewBlock: newSkipBlockWith(newRoster)})
+	require.NoError(t, err)
+	select {
+	case sb := <-heads:
+		require.True(t, sb.Hash.Equal(reply.Latest.Hash))
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "didn't get new head")
+	}
+	require.NotNil(t, follower.db.GetByID(reply.Latest.BackLinkIDs[0]))
+
+	require.Eventually(t, func() bool {
+		newNode.heads.Lock()
+		defer newNode.heads.Unlock()
+		subs := newNode.heads.subscribers[string(genesis.Hash)]
+		return len(subs) == 1 && subs[0].Equal(follower.ServerIdentity())
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func newSkipBlockWith(ro *onet.Roster) *SkipBlock {
+	sb := NewSkipBlock()
+	sb.Roster = ro
+	return sb
+}
@@ -0,0 +1,77 @@
+package skipchain
+
+import (
+	"go.dedis.ch/kyber/v3"
+	"go.dedis.ch/onet/v3"
+	"golang.org/x/xerrors"
+)
+
+/*
+This file holds the templates of new skipchains. A ChainTemplate bundles the
+parameters of a genesis block that cannot change during the life of the
+skipchain, so that all skipchains of the same kind are created with the same
+parameters, and so that wrong parameters are refused before contacting the
+roster.
+*/
+
+// ChainTemplate holds the parameters of the genesis block of new skipchains.
+type ChainTemplate struct {
+	// BaseHeight and MaximumHeight define the forward-links of the blocks,
+	// see SkipBlockFix.
+	BaseHeight    int
+	MaximumHeight int
+	VerifierIDs   []VerifierID
+	// SignatureThreshold is the number of nodes that need to sign the
+	// forward-links. 0 means the default threshold of the roster.
+	SignatureThreshold int
+	// Proposers are the keys allowed to propose new blocks. If it is
+	// empty, any client can propose new blocks. Else VerifierIDs must hold
+	// VerifyProposers.
+	Proposers []kyber.Point
+}
+
+// TemplateStandard is the template for skipchains with the standard
+// verification and forward-links over up to 4^31 blocks.
+var TemplateStandard = ChainTemplate{
+	BaseHeight:    4,
+	MaximumHeight: 32,
+	VerifierIDs:   VerificationStandard,
+}
+
+// Verify returns an error if a skipchain with the roster can't be created
+// from the template. It does the same checks as the conodes.
+func (ct ChainTemplate) Verify(ro *onet.Roster) error {
+	if ro == nil || len(ro.List) == 0 {
+		return xerrors.New("need a roster")
+	}
+	if ct.MaximumHeight <= 0 {
+		return xerrors.New("maximum height must be > 0")
+	}
+	if ct.BaseHeight <= 0 {
+		return xerrors.New("base height must be > 0")
+	}
+	if ct.BaseHeight == 1 && ct.MaximumHeight > 1 {
+		return xerrors.New("maximum height must be 1 when the base height is 1")
+	}
+	if ct.SignatureThreshold < 0 || ct.SignatureThreshold > len(ro.List) {
+		return xerrors.Errorf("signature threshold must be between 0 and %d",
+			len(ro.List))
+	}
+	if len(ct.Proposers) > 0 && !VerifierIDs(ct.VerifierIDs).Contains(VerifyProposers) {
+		return xerrors.New("proposers need the VerifyProposers verifier")
+	}
+	return nil
+}
+
+// NewGenesis returns a new genesis block for the roster with the parameters
+// of the template. The caller still needs to set the data.
+func (ct ChainTemplate) NewGenesis(ro *onet.Roster) *SkipBlock {
+	genesis := NewSkipBlock()
+	genesis.Roster = ro
+	genesis.BaseHeight = ct.BaseHeight
+	genesis.MaximumHeight = ct.MaximumHeight
+	genesis.VerifierIDs = append([]VerifierID(nil), ct.VerifierIDs...)
+	genesis.SignatureThreshold = ct.SignatureThreshold
+	genesis.Proposers = append([]kyber.Point(nil), ct.Proposers...)
+	return genesis
+}
@@ -1,11 +1,123 @@
 package skipchain
 
-import "go.dedis.ch/onet/v3/log"
+import (
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
 
 /*
 This file holds all verification-functions for the skipchain.
 */
 
+// defaultVerifierTimeout is how long a verifier registered without its own
+// timeout may run before the block is refused.
+const defaultVerifierTimeout = 10 * time.Second
+
+// maxRunningVerifiers is how many calls of the same verifier can run at the
+// same time, including the calls that timed out but didn't return yet. It is
+// not set to a constant because we'd like to change it in the test.
+var maxRunningVerifiers = 16
+
+// runningVerifiers counts the calls of every verifier that didn't return yet.
+type runningVerifiers struct {
+	sync.Mutex
+	count map[VerifierID]int
+}
+
+// start returns false if the maximum number of calls of the verifier are
+// already running.
+func (rv *runningVerifiers) start(ver VerifierID) bool {
+	rv.Lock()
+	defer rv.Unlock()
+	if rv.count == nil {
+		rv.count = make(map[VerifierID]int)
+	}
+	if rv.count[ver] >= maxRunningVerifiers {
+		return false
+	}
+	rv.count[ver]++
+	return true
+}
+
+func (rv *runningVerifiers) stop(ver VerifierID) {
+	rv.Lock()
+	defer rv.Unlock()
+	rv.count[ver]--
+	if rv.count[ver] == 0 {
+		delete(rv.count, ver)
+	}
+}
+
+// runVerifier calls the verifier and returns an error if it refuses the
+// block, panics, or doesn't return within the verifier timeout. A verifier
+// that timed out can't be stopped, so its result is ignored, and the block
+// is refused right away while too many calls of the verifier are still
+// running.
+func (s *Service) runVerifier(ver VerifierID, f SkipBlockVerifier, to []byte, newest *SkipBlock) error {
+	name := runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+	if !s.verifiersRunning.start(ver) {
+		return xerrors.Errorf("verifier %s (%s) has %d calls still running",
+			name, ver, maxRunningVerifiers)
+	}
+	scID := newest.SkipChainID()
+	timeout := s.verifierTimeoutOf(ver)
+	start := time.Now()
+	result := make(chan error, 1)
+	go func() {
+		defer s.verifiersRunning.stop(ver)
+		defer func() {
+			if re := recover(); re != nil {
+				result <- xerrors.Errorf("verifier %s (%s) panicked: %v",
+					name, ver, re)
+			}
+		}()
+		if f(to, newest) {
+			result <- nil
+		} else {
+			result <- xerrors.Errorf("verifier %s (%s) refused the block",
+				name, ver)
+		}
+	}()
+
+	select {
+	case err := <-result:
+		s.verifierStats.record(scID, ver, time.Since(start), err, false)
+		return err
+	case <-time.After(timeout):
+		s.verifierStats.record(scID, ver, time.Since(start), nil, true)
+		return xerrors.Errorf("verifier %s (%s) timed out after %s", name,
+			ver, timeout)
+	case <-s.closing:
+		// The result of the verifier is lost, so it counts as a timeout.
+		s.verifierStats.record(scID, ver, time.Since(start), nil, true)
+		return xerrors.New("closing down")
+	}
+}
+
+// verifierTimeoutOf returns how long the verifier may run: its own timeout
+// if it has been registered with one, else the timeout of the service.
+func (s *Service) verifierTimeoutOf(ver VerifierID) time.Duration {
+	s.verifierTimeoutsLock.Lock()
+	defer s.verifierTimeoutsLock.Unlock()
+	if t, ok := s.verifierTimeouts[ver]; ok {
+		return t
+	}
+	return s.verifierTimeout
+}
+
+// SetVerifierTimeout sets how long the verifiers of this service that have
+// no timeout of their own may run before the block is refused.
+func (s *Service) SetVerifierTimeout(t time.Duration) {
+	s.verifierTimeoutsLock.Lock()
+	defer s.verifierTimeoutsLock.Unlock()
+	s.verifierTimeout = t
+}
+
 // VerifyBase checks basic parameters between two skipblocks.
 func (s *Service) verifyFuncBase(newID []byte, newSB *SkipBlock) bool {
 	if !newSB.Hash.Equal(newID) {
@@ -21,7 +133,12 @@ func (s *Service) verifyFuncBase(newID []byte, newSB *SkipBlock) bool {
 	if prev == nil {
 		return false
 	}
+	return verifyBase(prev, newSB)
+}
 
+// verifyBase checks the parameters of newSB that must follow from the
+// previous block.
+func verifyBase(prev, newSB *SkipBlock) bool {
 	if !prev.SkipChainID().Equal(newSB.SkipChainID()) {
 		return false
 	}
@@ -34,6 +151,13 @@ func (s *Service) verifyFuncBase(newID []byte, newSB *SkipBlock) bool {
 	if prev.Index+1 != newSB.Index {
 		return false
 	}
+	if prev.SignatureThreshold != newSB.SignatureThreshold {
+		return false
+	}
+	if len(newSB.Proposers) > 0 {
+		// only the genesis block holds the proposers
+		return false
+	}
 	if prev.SignatureScheme > newSB.SignatureScheme {
 		// the signature scheme can only have an index higher than the previous blocks
 		// so that no one can downgrade the verification
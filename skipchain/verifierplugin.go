@@ -0,0 +1,122 @@
+package skipchain
+
+import (
+	"sync"
+
+	"go.dedis.ch/onet/v3/log"
+	"golang.org/x/xerrors"
+)
+
+// VerifierPlugin is a verifier that keeps state for every chain, like the
+// balances of accounts. Unlike a SkipBlockVerifier, it is told about the
+// blocks that are stored, so that it can update its state only with the
+// blocks that have been accepted.
+type VerifierPlugin interface {
+	// Init is called once for every chain using the verifier, with its
+	// genesis block, before the first call to VerifyBlock or Commit for
+	// this chain. It is called again after a restart of the conode, so the
+	// plugin has to rebuild its state from the stored blocks if needed. If
+	// it returns an error, the blocks of the chain are refused and Init is
+	// called again for the next block.
+	Init(genesis *SkipBlock) error
+	// VerifyBlock returns an error if the new block must be refused. It
+	// must not change the state of the chain, as the block might still be
+	// refused by other verifiers or other conodes.
+	VerifyBlock(newID []byte, newSB *SkipBlock) error
+	// Commit is called in order with every new block of the chain that is
+	// stored by this conode, be it verified by this conode or received from
+	// another one. It is called while the block is stored and must not call
+	// the skipchain service.
+	Commit(sb *SkipBlock)
+}
+
+// verifierPlugin adapts a VerifierPlugin to a SkipBlockVerifier and keeps
+// track of the chains it has been initialised for.
+type verifierPlugin struct {
+	sync.Mutex
+	plugin  VerifierPlugin
+	service *Service
+	chains  map[string]bool
+}
+
+// RegisterVerifierPlugin registers a stateful verifier. The ID can then be
+// used in the VerifierIDs of new chains like any other verifier.
+func RegisterVerifierPlugin(s GetService, v VerifierID, p VerifierPlugin) error {
+	scs := s.Service(ServiceName)
+	if scs == nil {
+		return xerrors.New("Didn't find our service: " + ServiceName)
+	}
+	return scs.(*Service).registerVerifierPlugin(v, p)
+}
+
+func (s *Service) registerVerifierPlugin(v VerifierID, p VerifierPlugin) error {
+	vp := &verifierPlugin{
+		plugin:  p,
+		service: s,
+		chains:  make(map[string]bool),
+	}
+	s.plugins[v] = vp
+	return s.registerVerification(v, vp.verify)
+}
+
+// init calls Init of the plugin if the chain of the block is new.
+func (vp *verifierPlugin) init(sb *SkipBlock) error {
+	scID := sb.SkipChainID()
+	vp.Lock()
+	defer vp.Unlock()
+	if vp.chains[string(scID)] {
+		return nil
+	}
+	genesis := sb
+	if sb.Index > 0 {
+		genesis = vp.service.db.GetByID(scID)
+		if genesis == nil {
+			return xerrors.Errorf("unknown genesis block %x", scID)
+		}
+	}
+	if err := vp.plugin.Init(genesis); err != nil {
+		return xerrors.Errorf("couldn't initialise chain: %v", err)
+	}
+	vp.chains[string(scID)] = true
+	return nil
+}
+
+// reset makes sure Init is called again for all chains.
+func (vp *verifierPlugin) reset() {
+	vp.Lock()
+	vp.chains = make(map[string]bool)
+	vp.Unlock()
+}
+
+// verify is the SkipBlockVerifier of the plugin.
+func (vp *verifierPlugin) verify(newID []byte, newSB *SkipBlock) bool {
+	if err := vp.init(newSB); err != nil {
+		log.Lvl2(err)
+		return false
+	}
+	if err := vp.plugin.VerifyBlock(newID, newSB); err != nil {
+		log.Lvlf2("block %x refused: %v", newID, err)
+		return false
+	}
+	return true
+}
+
+// commit passes the stored block to the plugin.
+func (vp *verifierPlugin) commit(sb *SkipBlock) {
+	if err := vp.init(sb); err != nil {
+		log.Errorf("couldn't commit block %x: %v", sb.Hash, err)
+		return
+	}
+	vp.plugin.Commit(sb)
+}
+
+// commitNewBlocks passes the new blocks to the plugins of their chains.
+func (s *Service) commitNewBlocks(sbs []*SkipBlock) {
+	for _, sb := range sbs {
+		for _, ver := range sb.VerifierIDs {
+			if vp, ok := s.plugins[ver]; ok {
+				vp.commit(sb)
+			}
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package skipchain
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+	"golang.org/x/xerrors"
+)
+
+// balancePlugin refuses blocks that would make the balance of the chain
+// negative. The data of a block is the signed change of the balance.
+type balancePlugin struct {
+	sync.Mutex
+	inits    int
+	balances map[string]int
+	commits  int
+}
+
+func (bp *balancePlugin) Init(genesis *SkipBlock) error {
+	bp.Lock()
+	defer bp.Unlock()
+	bp.inits++
+	bp.balances[string(genesis.Hash)] = 0
+	return nil
+}
+
+func (bp *balancePlugin) VerifyBlock(newID []byte, newSB *SkipBlock) error {
+	bp.Lock()
+	defer bp.Unlock()
+	if bp.balances[string(newSB.SkipChainID())]+int(int8(newSB.Data[0])) < 0 {
+		return xerrors.New("negative balance")
+	}
+	return nil
+}
+
+func (bp *balancePlugin) Commit(sb *SkipBlock) {
+	bp.Lock()
+	defer bp.Unlock()
+	bp.commits++
+	if sb.Index > 0 {
+		bp.balances[string(sb.SkipChainID())] += int(int8(sb.Data[0]))
+	}
+}
+
+func (bp *balancePlugin) state() (int, int, int) {
+	bp.Lock()
+	defer bp.Unlock()
+	for _, b := range bp.balances {
+		return bp.inits, bp.commits, b
+	}
+	return bp.inits, bp.commits, 0
+}
+
+func TestService_VerifierPlugin(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer waitPropagationFinished(t, l)
+	defer l.CloseAll()
+	servers, ro, gs := l.MakeSRS(cothority.Suite, 3, skipchainSID)
+	service := gs.(*Service)
+
+	verifyBalance := VerifierID(uuid.NewV5(uuid.NamespaceURL, "Balance"))
+	var plugins []*balancePlugin
+	for _, s := range servers {
+		bp := &balancePlugin{balances: make(map[string]int)}
+		require.NoError(t, RegisterVerifierPlugin(s, verifyBalance, bp))
+		plugins = append(plugins, bp)
+	}
+
+	genesis, err := makeGenesisRosterArgs(service, ro, nil,
+		[]VerifierID{VerifyBase, verifyBalance}, 1, 1)
+	require.NoError(t, err)
+	store := func(delta int8) error {
+		sb := NewSkipBlock()
+		sb.Roster = ro
+		sb.Data = []byte{byte(delta)}
+		_, err := service.StoreSkipBlock(&StoreSkipBlock{
+			TargetSkipChainID: genesis.Hash,
+			NewBlock:          sb,
+		})
+		return err
+	}
+	require.NoError(t, store(5))
+	require.NoError(t, store(-3))
+	err = store(-3)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "refused the block")
+
+	// All nodes committed the genesis block and the two accepted blocks.
+	for _, bp := range plugins {
+		require.Eventually(t, func() bool {
+			_, commits, _ := bp.state()
+			return commits == 3
+		}, 5*time.Second, 10*time.Millisecond)
+		inits, _, balance := bp.state()
+		require.Equal(t, 1, inits)
+		require.Equal(t, 2, balance)
+	}
+
+	// After a restart, Init is called again for the next block.
+	require.NoError(t, service.TestRestart())
+	require.Error(t, store(-3))
+	inits, _, _ := plugins[0].state()
+	require.Equal(t, 2, inits)
+}
@@ -0,0 +1,100 @@
+package skipchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.dedis.ch/onet/v3"
+)
+
+/*
+This file holds the metrics of the verifiers. For every chain and every
+verifier, the service counts how many blocks were accepted, refused or timed
+out, and how long the verifier took. They are reported by the status service,
+so that operators can find the verifier that refuses blocks or slows down the
+rounds.
+*/
+
+// VerifierStat holds the outcomes of one verifier on one chain.
+type VerifierStat struct {
+	Accepted int
+	Refused  int
+	Timeouts int
+	// Total is the time spent in the verifier, Max the longest run.
+	Total time.Duration
+	Max   time.Duration
+}
+
+// String returns the counts and the average and maximum latencies.
+func (vs VerifierStat) String() string {
+	var avg time.Duration
+	if runs := vs.Accepted + vs.Refused + vs.Timeouts; runs > 0 {
+		avg = vs.Total / time.Duration(runs)
+	}
+	return fmt.Sprintf("accepted=%d refused=%d timeouts=%d avg=%s max=%s",
+		vs.Accepted, vs.Refused, vs.Timeouts, avg, vs.Max)
+}
+
+type verifierStatKey struct {
+	chain    string
+	verifier VerifierID
+}
+
+// verifierStats holds the VerifierStat of every chain and verifier.
+type verifierStats struct {
+	sync.Mutex
+	stats map[verifierStatKey]*VerifierStat
+}
+
+// record adds one run of the verifier on a block of the chain.
+func (vs *verifierStats) record(scID SkipBlockID, ver VerifierID,
+	latency time.Duration, err error, timeout bool) {
+	vs.Lock()
+	defer vs.Unlock()
+	if vs.stats == nil {
+		vs.stats = make(map[verifierStatKey]*VerifierStat)
+	}
+	key := verifierStatKey{chain: string(scID), verifier: ver}
+	stat, ok := vs.stats[key]
+	if !ok {
+		stat = &VerifierStat{}
+		vs.stats[key] = stat
+	}
+	switch {
+	case timeout:
+		stat.Timeouts++
+	case err != nil:
+		stat.Refused++
+	default:
+		stat.Accepted++
+	}
+	stat.Total += latency
+	if latency > stat.Max {
+		stat.Max = latency
+	}
+}
+
+// GetStatus implements onet.StatusReporter. The keys are the short ID of
+// the chain and the ID of the verifier.
+func (vs *verifierStats) GetStatus() *onet.Status {
+	vs.Lock()
+	defer vs.Unlock()
+	out := make(map[string]string)
+	for key, stat := range vs.stats {
+		out[SkipBlockID(key.chain).Short()+"/"+key.verifier.String()] =
+			stat.String()
+	}
+	return &onet.Status{Field: out}
+}
+
+// GetVerifierStats returns the outcomes of the verifier on the chain.
+func (s *Service) GetVerifierStats(scID SkipBlockID, ver VerifierID) VerifierStat {
+	s.verifierStats.Lock()
+	defer s.verifierStats.Unlock()
+	if stat, ok := s.verifierStats.stats[verifierStatKey{chain: string(scID),
+		verifier: ver}]; ok {
+		return *stat
+	}
+	return VerifierStat{}
+}
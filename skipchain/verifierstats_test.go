@@ -0,0 +1,62 @@
+package skipchain
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/onet/v3"
+)
+
+func TestVerifierStats_Record(t *testing.T) {
+	var vs verifierStats
+	scID := SkipBlockID(make([]byte, 32))
+	other := SkipBlockID(append([]byte{1}, make([]byte, 31)...))
+	vs.record(scID, VerifyBase, time.Millisecond, nil, false)
+	vs.record(scID, VerifyBase, 3*time.Millisecond, errors.New("refused"), false)
+	vs.record(scID, VerifyBase, 5*time.Millisecond, nil, true)
+	vs.record(other, VerifyBase, time.Millisecond, nil, false)
+
+	status := vs.GetStatus().Field
+	require.Equal(t, 2, len(status))
+	require.Equal(t, "accepted=1 refused=1 timeouts=1 avg=3ms max=5ms",
+		status[scID.Short()+"/"+VerifyBase.String()])
+}
+
+func TestService_VerifierStats(t *testing.T) {
+	local := onet.NewLocalTest(cothority.Suite)
+	defer waitPropagationFinished(t, local)
+	defer local.CloseAll()
+	_, el, s := local.MakeSRS(cothority.Suite, 3, skipchainSID)
+	s1 := s.(*Service)
+	verifyFunc := func(newID []byte, newSB *SkipBlock) bool {
+		return string(newSB.Data) != "refuse"
+	}
+	verifyID := VerifierID(uuid.NewV1())
+	for _, s := range local.Services {
+		s[skipchainSID].(*Service).registerVerification(verifyID, verifyFunc)
+	}
+
+	sbRoot, err := makeGenesisRosterArgs(s1, el, nil, []VerifierID{verifyID}, 1, 1)
+	require.NoError(t, err)
+	store := func(data string) error {
+		sb := NewSkipBlock()
+		sb.Roster = el
+		sb.Data = []byte(data)
+		_, err := s1.StoreSkipBlock(&StoreSkipBlock{TargetSkipChainID: sbRoot.Hash,
+			NewBlock: sb})
+		return err
+	}
+	require.NoError(t, store("accept"))
+	require.Error(t, store("refuse"))
+
+	stat := s1.GetVerifierStats(sbRoot.Hash, verifyID)
+	require.Equal(t, 1, stat.Accepted)
+	require.Equal(t, 1, stat.Refused)
+	require.Equal(t, 0, stat.Timeouts)
+	require.True(t, stat.Max > 0)
+	require.Equal(t, VerifierStat{}, s1.GetVerifierStats(sbRoot.Hash, VerifyBase))
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cli "github.com/urfave/cli"
+	"go.dedis.ch/cothority/v3/skipchain"
+	status "go.dedis.ch/cothority/v3/status/service"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+)
+
+// health describes how a cell of the health matrix is colored.
+type health int
+
+const (
+	healthOK health = iota
+	healthWarn
+	healthFail
+)
+
+var healthColors = map[health]string{
+	healthOK:   "\x1b[32m",
+	healthWarn: "\x1b[33m",
+	healthFail: "\x1b[31m",
+}
+
+// nodeHealth holds what has been found out about one node of the roster.
+type nodeHealth struct {
+	Server  *network.ServerIdentity
+	Version string
+	// Skew is the difference between the clock of the node and the local
+	// clock, corrected by half of the round-trip time.
+	Skew   time.Duration
+	Blocks string
+	Bytes  string
+	// Heads holds the index and the start of the hash of the latest block
+	// known to the node, for each of the selected chains.
+	Heads []string
+	Err   error
+}
+
+// sweepHealth queries the status of all nodes and their latest block of the
+// chains, in parallel.
+func sweepHealth(list []*network.ServerIdentity, chains []skipchain.SkipBlockID) []*nodeHealth {
+	nodes := make([]*nodeHealth, len(list))
+	var wg sync.WaitGroup
+	for i, si := range list {
+		nodes[i] = &nodeHealth{Server: si, Heads: make([]string, len(chains))}
+		wg.Add(1)
+		go func(nh *nodeHealth) {
+			defer wg.Done()
+			nh.Err = nh.query(chains)
+		}(nodes[i])
+	}
+	wg.Wait()
+	return nodes
+}
+
+// query fills in the fields of the node.
+func (nh *nodeHealth) query(chains []skipchain.SkipBlockID) error {
+	start := time.Now()
+	sr, err := status.NewClient().Request(nh.Server)
+	if err != nil {
+		return err
+	}
+	rtt := time.Since(start)
+	field := func(section, key string) string {
+		if st, ok := sr.Status[section]; ok && st != nil {
+			return st.Field[key]
+		}
+		return ""
+	}
+	nh.Version = field("Conode", "version")
+	if t, err := strconv.ParseInt(field("Conode", "time"), 10, 64); err == nil {
+		nh.Skew = time.Unix(0, t).Sub(start.Add(rtt / 2))
+	}
+	nh.Blocks = field("Skipblock", "Blocks")
+	nh.Bytes = field("Skipblock", "Bytes")
+
+	cl := skipchain.NewClient()
+	defer cl.Close()
+	for i, id := range chains {
+		reply := &skipchain.GetUpdateChainReply{}
+		err := cl.SendProtobuf(nh.Server, &skipchain.GetUpdateChain{LatestID: id}, reply)
+		if err != nil || len(reply.Update) == 0 {
+			continue
+		}
+		head := reply.Update[len(reply.Update)-1]
+		nh.Heads[i] = fmt.Sprintf("%d:%x", head.Index, head.Hash[:4])
+	}
+	return nil
+}
+
+// majority returns the most common non-empty value.
+func majority(values []string) string {
+	count := make(map[string]int)
+	best := ""
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		count[v]++
+		if count[v] > count[best] {
+			best = v
+		}
+	}
+	return best
+}
+
+// healthMatrix returns the rows of the matrix with the health of every cell.
+// The first row is the header. A node fails if it cannot be reached, if its
+// clock is off by more than maxSkew, or if it doesn't agree with the
+// majority on the head of a chain. A version different from the majority
+// is a warning.
+func healthMatrix(nodes []*nodeHealth, chains []skipchain.SkipBlockID, maxSkew time.Duration) ([][]string, [][]health) {
+	header := []string{"Server", "Version", "Skew", "Blocks", "Bytes"}
+	for _, id := range chains {
+		header = append(header, fmt.Sprintf("%x", id[:4]))
+	}
+	rows := [][]string{header}
+	colors := [][]health{make([]health, len(header))}
+
+	var versions []string
+	heads := make([][]string, len(chains))
+	for _, nh := range nodes {
+		if nh.Err != nil {
+			continue
+		}
+		versions = append(versions, nh.Version)
+		for i, h := range nh.Heads {
+			heads[i] = append(heads[i], h)
+		}
+	}
+	version := majority(versions)
+	agreed := make([]string, len(chains))
+	for i := range chains {
+		agreed[i] = majority(heads[i])
+	}
+
+	for _, nh := range nodes {
+		row := []string{nh.Server.Address.String()}
+		color := []health{healthOK}
+		if nh.Err != nil {
+			for range header[1:] {
+				row = append(row, "-")
+				color = append(color, healthFail)
+			}
+			color[0] = healthFail
+			rows = append(rows, row)
+			colors = append(colors, color)
+			continue
+		}
+
+		row = append(row, nh.Version)
+		if nh.Version == version {
+			color = append(color, healthOK)
+		} else {
+			color = append(color, healthWarn)
+		}
+		row = append(row, nh.Skew.Round(time.Millisecond).String())
+		if nh.Skew > maxSkew || nh.Skew < -maxSkew {
+			color = append(color, healthFail)
+		} else {
+			color = append(color, healthOK)
+		}
+		row = append(row, nh.Blocks, nh.Bytes)
+		color = append(color, healthOK, healthOK)
+		for i, h := range nh.Heads {
+			if h == "" {
+				row = append(row, "-")
+			} else {
+				row = append(row, h)
+			}
+			if h == agreed[i] {
+				color = append(color, healthOK)
+			} else {
+				color = append(color, healthFail)
+			}
+		}
+		for _, c := range color[1:] {
+			if c > color[0] {
+				color[0] = c
+			}
+		}
+		rows = append(rows, row)
+		colors = append(colors, color)
+	}
+	return rows, colors
+}
+
+// printHealthMatrix writes the matrix as aligned columns. If useColors is
+// true, every cell is colored with its health.
+func printHealthMatrix(w io.Writer, rows [][]string, colors [][]health, useColors bool) {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	for r, row := range rows {
+		var line []string
+		for i, cell := range row {
+			cell = fmt.Sprintf("%-*s", widths[i], cell)
+			if useColors && r > 0 {
+				cell = healthColors[colors[r][i]] + cell + "\x1b[0m"
+			}
+			line = append(line, cell)
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(line, "  "), " "))
+	}
+}
+
+// healthSweep queries every node of the roster and prints a matrix with the
+// health of each of them.
+func healthSweep(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return errors.New("please give the group.toml file")
+	}
+	ro, err := readGroup(c.Args().First())
+	if err != nil {
+		return errors.New("couldn't read file: " + err.Error())
+	}
+	var chains []skipchain.SkipBlockID
+	for _, s := range c.StringSlice("chain") {
+		id := skipchain.ParseSkipChainID(s)
+		if id == nil {
+			return fmt.Errorf("invalid skipchain-ID %s", s)
+		}
+		chains = append(chains, id)
+	}
+	maxSkew, err := time.ParseDuration(c.String("maxskew"))
+	if err != nil {
+		return errors.New("duration parse error: " + err.Error())
+	}
+
+	log.Lvl2("Checking the health of", ro.List)
+	nodes := sweepHealth(ro.List, chains)
+	rows, colors := healthMatrix(nodes, chains, maxSkew)
+	printHealthMatrix(os.Stdout, rows, colors, !c.Bool("nocolor"))
+	for _, nh := range nodes {
+		if nh.Err != nil {
+			log.Errorf("could not get status from %v: %v", nh.Server, nh.Err)
+		}
+	}
+	for _, color := range colors[1:] {
+		if color[0] == healthFail {
+			return errors.New("some nodes are not healthy")
+		}
+	}
+	return nil
+}
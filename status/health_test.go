@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.dedis.ch/cothority/v3"
+	"go.dedis.ch/cothority/v3/skipchain"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/network"
+)
+
+func TestHealthMatrix(t *testing.T) {
+	chains := []skipchain.SkipBlockID{make([]byte, 32)}
+	si := func(i int) *network.ServerIdentity {
+		return network.NewServerIdentity(nil,
+			network.NewAddress(network.TLS, fmt.Sprintf("127.0.0.1:%d", 7000+2*i)))
+	}
+	nodes := []*nodeHealth{
+		{Server: si(0), Version: "v3", Heads: []string{"2:aa"}},
+		{Server: si(1), Version: "v3", Heads: []string{"2:aa"}},
+		{Server: si(2), Version: "v2", Heads: []string{"2:aa"}},
+		{Server: si(3), Version: "v3", Heads: []string{"1:bb"}},
+		{Server: si(4), Version: "v3", Heads: []string{"2:aa"}, Skew: time.Minute},
+		{Server: si(5), Err: errors.New("unreachable")},
+	}
+	rows, colors := healthMatrix(nodes, chains, time.Second)
+	require.Equal(t, len(nodes)+1, len(rows))
+	require.Equal(t, len(rows[0]), len(rows[1]))
+	require.Equal(t, healthOK, colors[1][0])
+	require.Equal(t, healthOK, colors[2][0])
+	require.Equal(t, healthWarn, colors[3][0])
+	require.Equal(t, healthWarn, colors[3][1])
+	require.Equal(t, healthFail, colors[4][0])
+	require.Equal(t, healthFail, colors[4][5])
+	require.Equal(t, healthFail, colors[5][0])
+	require.Equal(t, healthFail, colors[5][2])
+	require.Equal(t, healthFail, colors[6][0])
+
+	buf := &bytes.Buffer{}
+	printHealthMatrix(buf, rows, colors, false)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, len(rows), len(lines))
+	require.True(t, strings.HasPrefix(lines[0], "Server"))
+	require.NotContains(t, buf.String(), "\x1b[")
+}
+
+func TestSweepHealth(t *testing.T) {
+	l := onet.NewTCPTest(cothority.Suite)
+	defer l.CloseAll()
+	servers, ro, _ := l.GenTree(3, true)
+	services := l.GetServices(servers, onet.ServiceFactory.ServiceID(skipchain.ServiceName))
+	service := services[0].(*skipchain.Service)
+
+	genesis := skipchain.NewSkipBlock()
+	genesis.Roster = ro
+	genesis.MaximumHeight = 1
+	genesis.BaseHeight = 1
+	genesis.VerifierIDs = skipchain.VerificationNone
+	reply, err := service.StoreSkipBlock(&skipchain.StoreSkipBlock{NewBlock: genesis})
+	require.NoError(t, err)
+	chains := []skipchain.SkipBlockID{reply.Latest.Hash}
+
+	nodes := sweepHealth(ro.List, chains)
+	require.Equal(t, 3, len(nodes))
+	for _, nh := range nodes {
+		require.NoError(t, nh.Err)
+		require.NotEqual(t, "", nh.Blocks)
+		require.True(t, nh.Skew < time.Second && nh.Skew > -time.Second)
+	}
+	_, colors := healthMatrix(nodes, chains, time.Second)
+	for _, color := range colors[1:] {
+		require.Equal(t, healthOK, color[0])
+	}
+
+	// A node that is down fails the sweep.
+	list := append(ro.List[:2:2], network.NewServerIdentity(ro.List[2].Public,
+		network.NewAddress(network.PlainTCP, "127.0.0.1:2")))
+	nodes = sweepHealth(list, chains)
+	require.Error(t, nodes[2].Err)
+	_, colors = healthMatrix(nodes, chains, time.Second)
+	require.Equal(t, healthFail, colors[3][0])
+}
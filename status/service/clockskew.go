@@ -0,0 +1,263 @@
+package status
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.dedis.ch/kyber/v3/util/random"
+	"go.dedis.ch/onet/v3"
+	"go.dedis.ch/onet/v3/log"
+	"go.dedis.ch/onet/v3/network"
+)
+
+/*
+This file holds the measurement of the clock skews between conodes. A conode
+sends a probe to the other members of a roster, and computes the skew of every
+peer from the time in its reply and the round-trip time. Rosters given to
+WatchRoster are measured periodically. The skews are part of the status
+report, and RosterTime returns the local time corrected by the median skew of
+a roster.
+*/
+
+// skewInterval is the time between two measurements of the watched
+// rosters. RosterTime measures peers again if their skew is older than two
+// intervals.
+var skewInterval = time.Minute
+
+// skewTimeout is how long to wait for the reply of a peer.
+var skewTimeout = 5 * time.Second
+
+// clockProbe asks a peer for its time.
+type clockProbe struct {
+	Nonce uint64
+}
+
+// clockProbeReply holds the time of the peer in nanoseconds since the epoch.
+type clockProbeReply struct {
+	Nonce uint64
+	Time  int64
+}
+
+// PeerSkew is the last measurement of the clock of a peer.
+type PeerSkew struct {
+	// Skew is the difference between the clock of the peer and the local
+	// clock, corrected by half of the round-trip time.
+	Skew     time.Duration
+	RTT      time.Duration
+	Measured time.Time
+}
+
+// clockSkews holds the skews of the peers and the rosters to measure.
+type clockSkews struct {
+	sync.Mutex
+	peers     map[network.ServerIdentityID]PeerSkew
+	addresses map[network.ServerIdentityID]network.Address
+	// pending holds the channels waiting for the replies to the probes.
+	pending  map[uint64]chan int64
+	watched  map[string]*onet.Roster
+	watching bool
+	// closed is closed once the service stops.
+	closed chan bool
+}
+
+// GetStatus implements onet.StatusReporter. The keys are the addresses of
+// the peers.
+func (cs *clockSkews) GetStatus() *onet.Status {
+	cs.Lock()
+	defer cs.Unlock()
+	out := make(map[string]string)
+	for id, ps := range cs.peers {
+		out[cs.addresses[id].String()] = strings.Join([]string{
+			"skew=" + ps.Skew.Round(time.Millisecond).String(),
+			"rtt=" + ps.RTT.Round(time.Millisecond).String(),
+			"measured=" + ps.Measured.Format(time.RFC3339),
+		}, " ")
+	}
+	return &onet.Status{Field: out}
+}
+
+// handleClockProbe answers the probe with the local time.
+func (st *Stat) handleClockProbe(env *network.Envelope) error {
+	req, ok := env.Msg.(*clockProbe)
+	if !ok {
+		return errors.New("didn't get a clockProbe message")
+	}
+	return st.SendRaw(env.ServerIdentity, &clockProbeReply{
+		Nonce: req.Nonce,
+		Time:  st.clock().UnixNano(),
+	})
+}
+
+// handleClockProbeReply passes the time of the peer to the waiting probe.
+func (st *Stat) handleClockProbeReply(env *network.Envelope) error {
+	reply, ok := env.Msg.(*clockProbeReply)
+	if !ok {
+		return errors.New("didn't get a clockProbeReply message")
+	}
+	st.skews.Lock()
+	c, ok := st.skews.pending[reply.Nonce]
+	delete(st.skews.pending, reply.Nonce)
+	st.skews.Unlock()
+	if ok {
+		c <- reply.Time
+	}
+	return nil
+}
+
+// probe measures the skew of the peer.
+func (st *Stat) probe(si *network.ServerIdentity) (PeerSkew, error) {
+	nonce := binary.LittleEndian.Uint64(random.Bits(64, false, random.New()))
+	c := make(chan int64, 1)
+	st.skews.Lock()
+	if st.skews.pending == nil {
+		st.skews.pending = make(map[uint64]chan int64)
+	}
+	st.skews.pending[nonce] = c
+	st.skews.Unlock()
+	defer func() {
+		st.skews.Lock()
+		delete(st.skews.pending, nonce)
+		st.skews.Unlock()
+	}()
+
+	start := st.clock()
+	if err := st.SendRaw(si, &clockProbe{Nonce: nonce}); err != nil {
+		return PeerSkew{}, errors.New("couldn't send probe: " + err.Error())
+	}
+	select {
+	case t := <-c:
+		now := st.clock()
+		rtt := now.Sub(start)
+		return PeerSkew{
+			Skew:     time.Unix(0, t).Sub(start.Add(rtt / 2)),
+			RTT:      rtt,
+			Measured: now,
+		}, nil
+	case <-time.After(skewTimeout):
+		return PeerSkew{}, errors.New("timeout while waiting for the time of " +
+			si.Address.String())
+	case <-st.skews.closed:
+		return PeerSkew{}, errors.New("service is closed")
+	}
+}
+
+// MeasureSkews probes all other members of the roster in parallel and
+// records their skews. Peers that don't answer are logged and skipped.
+func (st *Stat) MeasureSkews(ro *onet.Roster) {
+	var wg sync.WaitGroup
+	for _, si := range ro.List {
+		if si.Equal(st.ServerIdentity()) {
+			continue
+		}
+		wg.Add(1)
+		go func(si *network.ServerIdentity) {
+			defer wg.Done()
+			ps, err := st.probe(si)
+			if err != nil {
+				log.Lvl2(st.ServerIdentity(), err)
+				return
+			}
+			st.skews.Lock()
+			if st.skews.peers == nil {
+				st.skews.peers = make(map[network.ServerIdentityID]PeerSkew)
+				st.skews.addresses = make(map[network.ServerIdentityID]network.Address)
+			}
+			st.skews.peers[si.ID] = ps
+			st.skews.addresses[si.ID] = si.Address
+			st.skews.Unlock()
+		}(si)
+	}
+	wg.Wait()
+}
+
+// PeerSkew returns the last measured skew of the peer.
+func (st *Stat) PeerSkew(si *network.ServerIdentity) (PeerSkew, bool) {
+	st.skews.Lock()
+	defer st.skews.Unlock()
+	ps, ok := st.skews.peers[si.ID]
+	return ps, ok
+}
+
+// RosterTime returns the local time corrected by the median of the skews
+// of the members of the roster, this conode having no skew. Peers that
+// have not been measured recently are measured first. The median is not
+// influenced by a minority of wrong clocks.
+func (st *Stat) RosterTime(ro *onet.Roster) time.Time {
+	var stale []*network.ServerIdentity
+	st.skews.Lock()
+	for _, si := range ro.List {
+		ps, ok := st.skews.peers[si.ID]
+		if !si.Equal(st.ServerIdentity()) &&
+			(!ok || st.clock().Sub(ps.Measured) > 2*skewInterval) {
+			stale = append(stale, si)
+		}
+	}
+	st.skews.Unlock()
+	if len(stale) > 0 {
+		st.MeasureSkews(onet.NewRoster(stale))
+	}
+
+	skews := []time.Duration{0}
+	st.skews.Lock()
+	for _, si := range ro.List {
+		if ps, ok := st.skews.peers[si.ID]; ok && !si.Equal(st.ServerIdentity()) {
+			skews = append(skews, ps.Skew)
+		}
+	}
+	st.skews.Unlock()
+	sort.Slice(skews, func(i, j int) bool { return skews[i] < skews[j] })
+	median := skews[len(skews)/2]
+	if len(skews)%2 == 0 {
+		median = (skews[len(skews)/2-1] + median) / 2
+	}
+	return st.clock().Add(median)
+}
+
+// WatchRoster adds the roster to the rosters whose skews are measured
+// every skewInterval.
+func (st *Stat) WatchRoster(ro *onet.Roster) {
+	st.skews.Lock()
+	defer st.skews.Unlock()
+	if st.skews.watched == nil {
+		st.skews.watched = make(map[string]*onet.Roster)
+	}
+	st.skews.watched[ro.ID.String()] = ro
+	if !st.skews.watching {
+		st.skews.watching = true
+		go st.watchSkews()
+	}
+}
+
+func (st *Stat) watchSkews() {
+	for {
+		select {
+		case <-st.skews.closed:
+			return
+		case <-time.After(skewInterval):
+		}
+		st.skews.Lock()
+		var rosters []*onet.Roster
+		for _, ro := range st.skews.watched {
+			rosters = append(rosters, ro)
+		}
+		st.skews.Unlock()
+		for _, ro := range rosters {
+			st.MeasureSkews(ro)
+		}
+	}
+}
+
+// TestClose stops the measurements.
+func (st *Stat) TestClose() {
+	st.skews.Lock()
+	defer st.skews.Unlock()
+	select {
+	case <-st.skews.closed:
+	default:
+		close(st.skews.closed)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"go.dedis.ch/onet/v3/log"
 	"go.dedis.ch/onet/v3/network"
 	"math"
+	"strconv"
 	"time"
 )
 
@@ -33,6 +34,9 @@ func init() {
 // on a server.
 type Stat struct {
 	*onet.ServiceProcessor
+	skews clockSkews
+	// clock returns the local time, it can be changed in tests.
+	clock func() time.Time
 }
 
 // Version will be set by the main() function before starting the server.
@@ -46,6 +50,9 @@ func (st *Stat) Request(req *Request) (network.Message, error) {
 	// support library and should never really have known it.
 	statuses["Conode"] = &onet.Status{Field: make(map[string]string)}
 	statuses["Conode"].Field["version"] = Version
+	// The local time in nanoseconds since the epoch, so that clients can
+	// detect clock skews.
+	statuses["Conode"].Field["time"] = strconv.FormatInt(st.clock().UnixNano(), 10)
 
 	log.Lvl4("Returning", statuses)
 	return &Response{
@@ -139,11 +146,16 @@ func (st *Stat) testNodes(nodes []*network.ServerIdentity, to time.Duration) err
 func newStatService(c *onet.Context) (onet.Service, error) {
 	s := &Stat{
 		ServiceProcessor: onet.NewServiceProcessor(c),
+		skews:            clockSkews{closed: make(chan bool)},
+		clock:            time.Now,
 	}
 	err := s.RegisterHandlers(s.Request, s.CheckConnectivity)
 	if err != nil {
 		return nil, errors.New("couldn't register handlers: " + err.Error())
 	}
+	s.RegisterProcessorFunc(network.RegisterMessage(&clockProbe{}), s.handleClockProbe)
+	s.RegisterProcessorFunc(network.RegisterMessage(&clockProbeReply{}), s.handleClockProbeReply)
+	s.RegisterStatusReporter("ClockSkew", &s.skews)
 
 	return s, nil
 }
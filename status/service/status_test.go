@@ -1,6 +1,7 @@
 package status
 
 import (
+	"errors"
 	"testing"
 	"time"
 
@@ -70,3 +71,56 @@ func TestStat_Request(t *testing.T) {
 	log.Lvl1(stat)
 	assert.NotEmpty(t, stat.Status["Generic"].Field["Available_Services"])
 }
+
+// Sets up three nodes, one of them with a clock one minute ahead, and checks
+// that the skews are measured and reported, and that the roster time is not
+// influenced by the single wrong clock.
+func TestStat_ClockSkew(t *testing.T) {
+	local := onet.NewTCPTest(tSuite)
+	servers, ro, _ := local.GenTree(3, false)
+	defer local.CloseAll()
+
+	stats := make([]*Stat, len(servers))
+	for i, s := range servers {
+		stats[i] = s.Service(ServiceName).(*Stat)
+	}
+	stats[1].clock = func() time.Time { return time.Now().Add(time.Minute) }
+
+	stats[0].MeasureSkews(ro)
+	ps, ok := stats[0].PeerSkew(ro.List[1])
+	require.True(t, ok)
+	require.InDelta(t, float64(time.Minute), float64(ps.Skew), float64(time.Second))
+	ps, ok = stats[0].PeerSkew(ro.List[2])
+	require.True(t, ok)
+	require.InDelta(t, 0, float64(ps.Skew), float64(time.Second))
+	_, ok = stats[0].PeerSkew(ro.List[0])
+	require.False(t, ok)
+
+	rt := stats[0].RosterTime(ro)
+	require.InDelta(t, 0, float64(time.Until(rt)), float64(time.Second))
+	rt = stats[1].RosterTime(ro)
+	require.InDelta(t, 0, float64(time.Until(rt)), float64(time.Second))
+
+	stat, err := NewTestClient(local).Request(ro.List[0])
+	require.NoError(t, err)
+	require.Contains(t, stat.Status["ClockSkew"].Field[ro.List[1].Address.String()], "skew=1m")
+
+	// The watched roster is measured again after every interval.
+	defer func(i time.Duration) { skewInterval = i }(skewInterval)
+	skewInterval = 100 * time.Millisecond
+	stats[2].WatchRoster(ro)
+	require.NoError(t, waitFor(func() bool {
+		_, ok := stats[2].PeerSkew(ro.List[0])
+		return ok
+	}))
+}
+
+func waitFor(f func() bool) error {
+	for i := 0; i < 50; i++ {
+		if f() {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return errors.New("timeout while waiting")
+}
@@ -128,6 +128,27 @@ func main() {
 			},
 			Action: connectivity,
 		},
+		{
+			Name:      "health",
+			Usage:     "checks the version, clock, database and chain heads of all nodes",
+			ArgsUsage: "group.toml",
+			Flags: []cli.Flag{
+				cli.StringSliceFlag{
+					Name:  "chain, c",
+					Usage: "hex-encoded skipchain-ID whose head is compared, can be repeated",
+				},
+				cli.StringFlag{
+					Name:  "maxskew, ms",
+					Usage: "maximum clock skew accepted between the nodes and this host",
+					Value: "5s",
+				},
+				cli.BoolFlag{
+					Name:  "nocolor",
+					Usage: "prints the matrix without colors",
+				},
+			},
+			Action: healthSweep,
+		},
 	}
 	app.Action = func(c *cli.Context) error {
 		log.SetUseColors(false)